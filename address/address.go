@@ -5,6 +5,7 @@ package address
 import (
 	"bytes"
 	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"slices"
@@ -17,10 +18,15 @@ import (
 	"seedhammer.com/bc/urtypes"
 )
 
+// Change returns the change address at index. Bare scripts, P2PK and
+// P2MS, have no address encoding and are returned as their output
+// script in hexadecimal.
 func Change(desc urtypes.OutputDescriptor, index uint32) (string, error) {
 	return address(desc, index, true)
 }
 
+// Receive returns the receive address at index, in the format of
+// Change.
 func Receive(desc urtypes.OutputDescriptor, index uint32) (string, error) {
 	return address(desc, index, false)
 }
@@ -36,7 +42,7 @@ func address(desc urtypes.OutputDescriptor, index uint32, change bool) (string,
 	var addr btcutil.Address
 	var network *chaincfg.Params
 	switch desc.Type {
-	case urtypes.SortedMulti:
+	case urtypes.SortedMulti, urtypes.Multi:
 		var keys []*btcutil.AddressPubKey
 		for _, k := range desc.Keys {
 			pub, err := derivePubKey(k, index, change)
@@ -53,9 +59,11 @@ func address(desc urtypes.OutputDescriptor, index uint32, change bool) (string,
 			}
 			keys = append(keys, addrPub)
 		}
-		slices.SortFunc(keys, func(addr1, addr2 *btcutil.AddressPubKey) int {
-			return bytes.Compare(addr1.PubKey().SerializeCompressed(), addr2.PubKey().SerializeCompressed())
-		})
+		if desc.Type == urtypes.SortedMulti {
+			slices.SortFunc(keys, func(addr1, addr2 *btcutil.AddressPubKey) int {
+				return bytes.Compare(addr1.PubKey().SerializeCompressed(), addr2.PubKey().SerializeCompressed())
+			})
+		}
 		script, err := txscript.MultiSigScript(keys, desc.Threshold)
 		if err != nil {
 			return "", fmt.Errorf("address: %w", err)
@@ -66,6 +74,8 @@ func address(desc urtypes.OutputDescriptor, index uint32, change bool) (string,
 		case urtypes.P2WSH, urtypes.P2SH_P2WSH:
 			hash := sha256.Sum256(script)
 			addr, err = btcutil.NewAddressWitnessScriptHash(hash[:], network)
+		case urtypes.P2MS:
+			return hex.EncodeToString(script), nil
 		default:
 			return "", fmt.Errorf("address: multisig script: %s: %w", desc.Script, errUnsupported)
 		}
@@ -89,6 +99,12 @@ func address(desc urtypes.OutputDescriptor, index uint32, change bool) (string,
 		case urtypes.P2TR:
 			tkey := txscript.ComputeTaprootKeyNoScript(pub)
 			addr, err = btcutil.NewAddressTaproot(schnorr.SerializePubKey(tkey), network)
+		case urtypes.P2PK:
+			script, err := txscript.NewScriptBuilder().AddData(pub.SerializeCompressed()).AddOp(txscript.OP_CHECKSIG).Script()
+			if err != nil {
+				return "", fmt.Errorf("address: %w", err)
+			}
+			return hex.EncodeToString(script), nil
 		default:
 			return "", fmt.Errorf("address: singlesig script: %s: %w", desc.Script, errUnsupported)
 		}
@@ -129,14 +145,24 @@ func derivePubKey(k urtypes.KeyDescriptor, index uint32, change bool) (*secp256k
 		)
 	}
 	xpub := k.ExtendedKey()
-	for _, c := range children {
+	for i, c := range children {
+		if c.Hardened {
+			return nil, fmt.Errorf("hardened path element: %w", errUnsupported)
+		}
 		var id uint32
 		switch c.Type {
 		case urtypes.ChildDerivation:
 			id = c.Index
+			// Descriptors from BIP45 and older BIP48 coordinators
+			// specify only the receive branch, such as .../0/*. Derive
+			// change addresses from the matching .../1/* branch.
+			last := i == len(children)-2 && children[i+1].Type == urtypes.WildcardDerivation
+			if change && last && c.Index == 0 {
+				id = 1
+			}
 		case urtypes.RangeDerivation:
 			if c.End != c.Index+1 {
-				return nil, errors.New("unsupported range path element")
+				return nil, fmt.Errorf("range path element: %w", errUnsupported)
 			}
 			id = c.Index
 			if change {
@@ -145,7 +171,7 @@ func derivePubKey(k urtypes.KeyDescriptor, index uint32, change bool) (*secp256k
 		case urtypes.WildcardDerivation:
 			id = index
 		default:
-			return nil, errors.New("unsupported path element")
+			return nil, fmt.Errorf("path element: %w", errUnsupported)
 		}
 		child, err := xpub.Derive(id)
 		if err != nil {
@@ -37,6 +37,29 @@ func TestAddresses(t *testing.T) {
 			[]string{"bc1ppeya86zv0hnpzrvh7czgqxkn5zjxxymxd6nqplhhx7fejxvhk0ysp7zekg", "bc1pqhh2d3sdktkfvneee95mlv99t0cddcy3vpk5fglz78jm3e55zydqj5wycf", "bc1px4k4y20vusff4v0xvpgwslda2s2fuajmn8eypt28ae4r73jlut7s8y5tq6"},
 			[]string{"bc1px5xqncrjm3823nervn3epj2al0adt79aaa56jvxpvzy29stvjn2q2jruge", "bc1p5u5rrr4lczraxkq3xwdjxh98fkl4sjuswwxgwj2uw3rdfwjp8uusp2ymfr", "bc1pvhsgwwmthv864r4kt2g65323jau4ge0y4k4qufqjzvfzsk4d60fq7pe6xx"},
 		},
+		{
+			// Bare scripts are returned as hex output scripts.
+			"pk(" + xpubs[0] + ")",
+			[]string{"21021924930d089304cced000a6de8af54a814c6de79f665f942ef20392e64769684ac", "210348ac643575943bfd56393c028c6b6fd14673110a856f2426487aa6289fcc5b97ac"},
+			[]string{"21035477cdae2d3e1e091bcbba7a7641046d2652b317cd4d05d65a299ae0394c8fbbac", "2102a53cbb719f3e94379b83863cf56e34df4150b5767bc4c2c6c915d5a851e2aef8ac"},
+		},
+		{
+			"multi(1," + xpubs[0] + ")",
+			[]string{"5121021924930d089304cced000a6de8af54a814c6de79f665f942ef20392e6476968451ae", "51210348ac643575943bfd56393c028c6b6fd14673110a856f2426487aa6289fcc5b9751ae"},
+			[]string{"5121035477cdae2d3e1e091bcbba7a7641046d2652b317cd4d05d65a299ae0394c8fbb51ae", "512102a53cbb719f3e94379b83863cf56e34df4150b5767bc4c2c6c915d5a851e2aef851ae"},
+		},
+		{
+			// Unsorted keys match sortedmulti where their order happens
+			// to be sorted.
+			"wsh(multi(2," + xpubs[0] + "," + xpubs[1] + "," + xpubs[2] + "))",
+			[]string{"bc1q4taqq6q6l8fvguva6ftvrz3qgdjy6p3w2s0ds0nl6qrjw7t0hfhqgrqcwd", "bc1qw3nhtat85lz6g3f8dh42067gf25hzquzn0tx9nk9nv2t6wtlx9lsfz7z0n"},
+			[]string{"bc1qqc570d30hws0x4dq39gp8nrq66g5422hpke5kxszm2mta23gmegq3tqrqh", "bc1qj8j9drgj956zzkkv7w3nk9a2wng7hl5e4mxp4kuqwr6fjvjp37gs9wn5kf"},
+		},
+		{
+			"wsh(sortedmulti(2," + xpubs[0] + "," + xpubs[1] + "," + xpubs[2] + "))",
+			[]string{"bc1q4taqq6q6l8fvguva6ftvrz3qgdjy6p3w2s0ds0nl6qrjw7t0hfhqgrqcwd", "bc1q3m9z4nhe7y376urgftnwl3js3mvyladmuzmgejwuhmdg6v47pwtqetcra8"},
+			[]string{"bc1q2gvjkydqvgcgk03wc0jf2um007lhecjsrq9k22gl37jpezj0ywvqq904eh", "bc1qc3vhae2ugyy4c7phx0mw29pxyt8t6ggpr0dm52cga2netweu2qasqv3lre"},
+		},
 		{
 			"wsh(sortedmulti(1," + xpubs[0] + "))",
 			[]string{"bc1qm78sug9d6g4jwlk9qulgtcp9ghepn2xjfz8xdhpa8g3q3hzcl8nsfez8at", "bc1q6uk7f77v7lspm803kjgvfpmreumdnjgaksfq3mvuhzc0zwvcy83qedrjvj", "bc1qntv6z9lyzxedfp63qgr7pm2gk9uzfjjzhhzm5j8599u6m89h2q6q3fzhu6"},
@@ -57,6 +80,23 @@ func TestAddresses(t *testing.T) {
 			[]string{"3DwWNBMDdsP5Tf9wYyGT7qMkCEe5mTC3U3", "334QzbkBDRWfBWuE8Qhj5dXigYZpt7tpcT"},
 			[]string{"39DByP7DcYyQHLhwYewbnN92e2T9Nz4n81", "3DwUtJerhAjkm2UALCkQkNFnrPgFmMZ9hT"},
 		},
+		{
+			"wsh(sortedmulti(1," + xpubs[0] + "/<0;1>/*))",
+			[]string{"bc1qm78sug9d6g4jwlk9qulgtcp9ghepn2xjfz8xdhpa8g3q3hzcl8nsfez8at", "bc1q6uk7f77v7lspm803kjgvfpmreumdnjgaksfq3mvuhzc0zwvcy83qedrjvj", "bc1qntv6z9lyzxedfp63qgr7pm2gk9uzfjjzhhzm5j8599u6m89h2q6q3fzhu6"},
+			[]string{"bc1qe3x073dtr0vy8xd342ctnsdzfz5ule53ul933jutx5yesxj3032qzmp8pj", "bc1q4yx84f5t2zgk24dcn87azhhvuxwr2psduhy4pl8vzrjv28zvazfs82u368", "bc1qxx0tjkg3qce48nvjyrnqssc9evqh25guursx7uk7uvkx6njj92vs40pp2u"},
+		},
+		{
+			// Receive-only children, as exported by BIP48 coordinators.
+			"wsh(sortedmulti(1," + xpubs[0] + "/0/*))",
+			[]string{"bc1qm78sug9d6g4jwlk9qulgtcp9ghepn2xjfz8xdhpa8g3q3hzcl8nsfez8at", "bc1q6uk7f77v7lspm803kjgvfpmreumdnjgaksfq3mvuhzc0zwvcy83qedrjvj", "bc1qntv6z9lyzxedfp63qgr7pm2gk9uzfjjzhhzm5j8599u6m89h2q6q3fzhu6"},
+			[]string{"bc1qe3x073dtr0vy8xd342ctnsdzfz5ule53ul933jutx5yesxj3032qzmp8pj", "bc1q4yx84f5t2zgk24dcn87azhhvuxwr2psduhy4pl8vzrjv28zvazfs82u368", "bc1qxx0tjkg3qce48nvjyrnqssc9evqh25guursx7uk7uvkx6njj92vs40pp2u"},
+		},
+		{
+			// BIP45 legacy multisig.
+			"sh(sortedmulti(2,[dc567276/45h]" + xpubs[0] + "/0/*,[f245ae38/45h]" + xpubs[1] + "/0/*,[c5d87297/45h]" + xpubs[2] + "/<0;1>/*))",
+			[]string{"3DwWNBMDdsP5Tf9wYyGT7qMkCEe5mTC3U3", "334QzbkBDRWfBWuE8Qhj5dXigYZpt7tpcT"},
+			[]string{"39DByP7DcYyQHLhwYewbnN92e2T9Nz4n81", "3DwUtJerhAjkm2UALCkQkNFnrPgFmMZ9hT"},
+		},
 	}
 	for _, test := range tests {
 		desc, err := nonstandard.OutputDescriptor([]byte(test.desc))
@@ -83,3 +123,21 @@ func TestAddresses(t *testing.T) {
 		}
 	}
 }
+
+func TestUnsupported(t *testing.T) {
+	xpub := "xpub6DiYrfRwNnjeX4vHsWMajJVFKrbEEnu8gAW9vDuQzgTWEsEHE16sGWeXXUV1LBWQE1yCTmeprSNcqZ3W74hqVdgDbtYHUv3eM4W2TEUhpan"
+	tests := []string{
+		"wsh(sortedmulti(1," + xpub + "/1h/*))",
+		"wsh(sortedmulti(1," + xpub + "/0/*h))",
+		"wsh(sortedmulti(1," + xpub + "/<0;2>/*))",
+	}
+	for _, test := range tests {
+		desc, err := nonstandard.OutputDescriptor([]byte(test))
+		if err != nil {
+			t.Fatalf("%s: %v", test, err)
+		}
+		if Supported(desc) {
+			t.Errorf("%s: descriptor reported as supported", test)
+		}
+	}
+}
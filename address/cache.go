@@ -0,0 +1,89 @@
+package address
+
+import (
+	"slices"
+
+	"seedhammer.com/bc/urtypes"
+)
+
+// GapLimit is the number of unused addresses wallets conventionally
+// derive ahead, and a suitable batch size for deriving addresses on
+// demand.
+const GapLimit = 20
+
+// Cache keeps the derived addresses of the most recently used
+// descriptors, so addresses can be derived in batches as they are
+// needed and re-used between uses of a descriptor.
+type Cache struct {
+	size int
+	// entries are ordered from least to most recently used.
+	entries []*cacheEntry
+}
+
+type cacheEntry struct {
+	key string
+	// chains holds the receive and change addresses derived so far.
+	chains [2][]string
+}
+
+// NewCache returns a cache of the addresses of at most size
+// descriptors.
+func NewCache(size int) *Cache {
+	return &Cache{size: max(1, size)}
+}
+
+// Receive returns the first n receive addresses of desc, deriving
+// the ones not already cached. It returns the addresses derived
+// before any error.
+func (c *Cache) Receive(desc urtypes.OutputDescriptor, n int) ([]string, error) {
+	return c.addresses(desc, false, n)
+}
+
+// Change is like Receive for change addresses.
+func (c *Cache) Change(desc urtypes.OutputDescriptor, n int) ([]string, error) {
+	return c.addresses(desc, true, n)
+}
+
+func (c *Cache) addresses(desc urtypes.OutputDescriptor, change bool, n int) ([]string, error) {
+	// Unsupported descriptors may not encode.
+	if !Supported(desc) {
+		return nil, errUnsupported
+	}
+	chain := &c.lookup(desc).chains[chainIdx(change)]
+	for len(*chain) < n {
+		addr, err := address(desc, uint32(len(*chain)), change)
+		if err != nil {
+			return *chain, err
+		}
+		*chain = append(*chain, addr)
+	}
+	return (*chain)[:n], nil
+}
+
+// lookup returns the entry for desc, creating it if necessary and
+// marking it most recently used.
+func (c *Cache) lookup(desc urtypes.OutputDescriptor) *cacheEntry {
+	key := string(desc.Encode())
+	idx := slices.IndexFunc(c.entries, func(e *cacheEntry) bool {
+		return e.key == key
+	})
+	var e *cacheEntry
+	if idx != -1 {
+		e = c.entries[idx]
+		c.entries = slices.Delete(c.entries, idx, idx+1)
+	} else {
+		e = &cacheEntry{key: key}
+		if len(c.entries) == c.size {
+			c.entries = slices.Delete(c.entries, 0, 1)
+		}
+	}
+	c.entries = append(c.entries, e)
+	return e
+}
+
+func chainIdx(change bool) int {
+	if change {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,51 @@
+package address
+
+import (
+	"reflect"
+	"testing"
+
+	"seedhammer.com/nonstandard"
+)
+
+func TestCache(t *testing.T) {
+	descs := []string{
+		"wpkh(xpub6DiYrfRwNnjeX4vHsWMajJVFKrbEEnu8gAW9vDuQzgTWEsEHE16sGWeXXUV1LBWQE1yCTmeprSNcqZ3W74hqVdgDbtYHUv3eM4W2TEUhpan)",
+		"pkh(xpub6DiYrfRwNnjeX4vHsWMajJVFKrbEEnu8gAW9vDuQzgTWEsEHE16sGWeXXUV1LBWQE1yCTmeprSNcqZ3W74hqVdgDbtYHUv3eM4W2TEUhpan)",
+	}
+	c := NewCache(1)
+	for _, d := range descs {
+		desc, err := nonstandard.OutputDescriptor([]byte(d))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Derive in batches.
+		if _, err := c.Receive(desc, 3); err != nil {
+			t.Fatal(err)
+		}
+		receive, err := c.Receive(desc, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		change, err := c.Change(desc, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want []string
+		for i := range uint32(5) {
+			addr, err := Receive(desc, i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want = append(want, addr)
+		}
+		if !reflect.DeepEqual(receive, want) {
+			t.Errorf("%s: cached receive addresses\n%v\nwant\n%v", d, receive, want)
+		}
+		if addr, _ := Change(desc, 1); change[1] != addr {
+			t.Errorf("%s: cached change address %s, want %s", d, change[1], addr)
+		}
+	}
+	if len(c.entries) != 1 {
+		t.Errorf("cache holds %d descriptors, want 1", len(c.entries))
+	}
+}
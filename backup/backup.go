@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/kortschak/qr"
+	"seedhammer.com/address"
 	"seedhammer.com/bc/fountain"
 	"seedhammer.com/bc/ur"
 	"seedhammer.com/bc/urtypes"
@@ -43,8 +44,25 @@ type Seed struct {
 	Mnemonic          bip39.Mnemonic
 	Keys              int
 	MasterFingerprint uint32
-	Font              *vector.Face
-	Size              PlateSize
+	// Label is engraved next to the master fingerprint, if not
+	// empty. See LabelString.
+	Label string
+	Font  *vector.Face
+	Size  PlateSize
+	// QRLevel is the preferred error correction level of the
+	// seed QR code. Lower levels are used if the code doesn't fit.
+	QRLevel qr.Level
+	// QRMaxVersion limits the size of the seed QR code. Zero means
+	// the largest size supported by constant time engraving.
+	QRMaxVersion int
+	// FlipVertical mirrors the seed side across both axes, for
+	// plates flipped vertically instead of horizontally after
+	// engraving the descriptor side. The sides then read in the
+	// same orientation and share the same bolt hole positions.
+	FlipVertical bool
+	// Template is the design of the seed side. Nil means
+	// StandardTemplate.
+	Template Template
 }
 
 type Descriptor struct {
@@ -52,8 +70,31 @@ type Descriptor struct {
 	KeyIdx     int
 	Font       *vector.Face
 	Size       PlateSize
+	// Constant engraves the descriptor text in a timing insensitive
+	// way. The QR code is omitted, because its engraving time depends
+	// on its content.
+	Constant bool
+	// QRLevel is the preferred error correction level of the QR
+	// codes. Lower levels are used if the codes don't fit the plate.
+	QRLevel qr.Level
+	// QRMaxVersion limits the size of the QR codes. Zero means no
+	// limit.
+	QRMaxVersion int
+	// Parts splits a descriptor too large for a single plate into
+	// numbered parts, each engraved on the descriptor side of a
+	// separate plate. Part selects the part to engrave, starting
+	// from zero. Parts less than 2 disables splitting.
+	Part, Parts int
+	// Addresses engraves the first receive and change addresses of
+	// the descriptor below its text, for confirming a recovered
+	// wallet without software. Bare scripts have no addresses, and
+	// constant engraving omits them along with the QR code.
+	Addresses bool
 }
 
+// MaxParts is the largest number of parts a descriptor is split into.
+const MaxParts = 4
+
 func dims(c engrave.Plan) (engrave.Plan, image.Point) {
 	b := engrave.Measure(c)
 	return engrave.Offset(-b.Min.X, -b.Min.Y, c), b.Size()
@@ -63,23 +104,99 @@ var ErrDescriptorTooLarge = errors.New("output descriptor is too large to backup
 
 const MaxTitleLen = 18
 
+const MaxLabelLen = 10
+
 const outerMargin = 3
 const innerMargin = 10
 
+// TitleString returns s without the runes not in face, truncated to
+// MaxTitleLen.
 func TitleString(face *vector.Face, s string) string {
-	s = strings.ToUpper(s)
+	return faceString(face, s, MaxTitleLen)
+}
+
+// LabelString returns s in upper case without the runes not in face,
+// truncated to MaxLabelLen.
+func LabelString(face *vector.Face, s string) string {
+	return faceString(face, strings.ToUpper(s), MaxLabelLen)
+}
+
+func faceString(face *vector.Face, s string, maxLen int) string {
 	res := ""
 	for _, r := range s {
-		if _, _, valid := face.Decode(r); valid {
+		if _, _, valid := face.Decode(r); valid && r != vector.Fallback {
 			res += string(r)
 		}
-		if len(res) == MaxTitleLen {
+		if len(res) == maxLen {
 			break
 		}
 	}
 	return res
 }
 
+// fiducialInset is the distance in millimeters from the plate edges
+// to the fiducials, between the outer margin and the content.
+const fiducialInset = 6
+
+// ErrFiducialsOverlap is returned by AddFiducials if the fiducials
+// overlap the content of a side.
+var ErrFiducialsOverlap = errors.New("fiducials overlap the plate content")
+
+// FiducialPositions returns the centers of the two alignment fiducials
+// of a plate side, in machine units. The fiducials are placed at the
+// middle of the left and right edges, so the fiducials of the sides of
+// a plate flipped horizontally coincide.
+func FiducialPositions(params engrave.Params, size PlateSize) [2]image.Point {
+	dims := size.Dims()
+	return [2]image.Point{
+		image.Pt(params.I(fiducialInset), params.I(dims.Y)/2),
+		image.Pt(params.I(dims.X-fiducialInset), params.I(dims.Y)/2),
+	}
+}
+
+// AddFiducials adds the fiducials of a plate side to side, for
+// measuring the alignment of a re-clamped plate. The fiducials are
+// small crosses. See engrave.Align.
+func AddFiducials(params engrave.Params, size PlateSize, side engrave.Plan) (engrave.Plan, error) {
+	arm := params.I(1)
+	clearance := params.I(2)
+	fids := FiducialPositions(params, size)
+	var keepout []image.Rectangle
+	for _, f := range fids {
+		keepout = append(keepout, image.Rectangle{Min: f, Max: f}.Inset(-clearance))
+	}
+	// Sample every line at a fraction of a millimeter.
+	step := max(1, params.Millimeter/4)
+	var pen image.Point
+	for c := range side {
+		if c.Line {
+			d := c.Coord.Sub(pen)
+			n := max(d.X, -d.X, d.Y, -d.Y)/step + 1
+			for i := range n + 1 {
+				p := pen.Add(d.Mul(i).Div(n))
+				for _, r := range keepout {
+					if p.In(r) {
+						return nil, ErrFiducialsOverlap
+					}
+				}
+			}
+		}
+		pen = c.Coord
+	}
+	fiducials := func(yield func(engrave.Command) bool) {
+		for _, c := range fids {
+			cont := yield(engrave.Move(c.Sub(image.Pt(arm, 0)))) &&
+				yield(engrave.Line(c.Add(image.Pt(arm, 0)))) &&
+				yield(engrave.Move(c.Sub(image.Pt(0, arm)))) &&
+				yield(engrave.Line(c.Add(image.Pt(0, arm))))
+			if !cont {
+				return
+			}
+		}
+	}
+	return engrave.Commands(side, fiducials), nil
+}
+
 type engraveFunc func(plateDims image.Point) (engrave.Plan, error)
 
 func engraveSide(scale int, size PlateSize, eng engraveFunc) (engrave.Plan, error) {
@@ -97,16 +214,370 @@ func engraveSide(scale int, size PlateSize, eng engraveFunc) (engrave.Plan, erro
 }
 
 func EngraveSeed(params engrave.Params, plate Seed) (engrave.Plan, error) {
-	return engraveSide(params.Millimeter, plate.Size, func(plateDims image.Point) (engrave.Plan, error) {
-		return frontSideSeed(params, plate, plateDims)
+	return withQRFallback(plate.QRLevel, func(level qr.Level) (engrave.Plan, error) {
+		return engraveSide(params.Millimeter, plate.Size, func(plateDims image.Point) (engrave.Plan, error) {
+			tmpl := plate.Template
+			if tmpl == nil {
+				tmpl = StandardTemplate
+			}
+			side, err := tmpl.Layout(params, plate, level, plateDims)
+			if err != nil || !plate.FlipVertical {
+				return side, err
+			}
+			side = engrave.Mirror(engrave.AxisX, engrave.Mirror(engrave.AxisY, side))
+			return engrave.Offset(plateDims.X, plateDims.Y, side), nil
+		})
 	})
 }
 
 func EngraveDescriptor(params engrave.Params, plate Descriptor) (engrave.Plan, error) {
+	level := plate.QRLevel
+	if plate.Constant {
+		// No QR codes to fall back from.
+		level = qr.L
+	}
+	return withQRFallback(level, func(level qr.Level) (engrave.Plan, error) {
+		return engraveSide(params.Millimeter, plate.Size, func(plateDims image.Point) (engrave.Plan, error) {
+			urs := splitUR(plate.Descriptor, plate.KeyIdx)
+			if plate.Parts > 1 {
+				urs = []string{partUR(plate.Descriptor, plate.Part, plate.Parts)}
+			}
+			var addrs []string
+			if plate.Addresses && !plate.Constant {
+				var err error
+				addrs, err = plateAddresses(plate.Descriptor)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return descriptorSide(params, plate.Font, urs, addrs, plate.Size, plateDims, plate.Constant, level, plate.QRMaxVersion)
+		})
+	})
+}
+
+// plateAddresses returns the lines listing the first receive and
+// change addresses of desc, or nil if desc has no addresses.
+func plateAddresses(desc urtypes.OutputDescriptor) ([]string, error) {
+	if desc.Script == urtypes.P2PK || desc.Script == urtypes.P2MS || !address.Supported(desc) {
+		return nil, nil
+	}
+	recv, err := address.Receive(desc, 0)
+	if err != nil {
+		return nil, err
+	}
+	change, err := address.Change(desc, 0)
+	if err != nil {
+		return nil, err
+	}
+	return []string{"RECEIVE:" + recv, "CHANGE:" + change}, nil
+}
+
+// EngraveDescriptorParts engraves the descriptor sides of plate, split
+// into the fewest parts that fit the plate, but at most MaxParts. The
+// Part and Parts fields of plate are ignored.
+func EngraveDescriptorParts(params engrave.Params, plate Descriptor) ([]engrave.Plan, error) {
+	var err error
+	for parts := 1; parts <= MaxParts; parts++ {
+		plate.Parts = parts
+		var sides []engrave.Plan
+		for part := range parts {
+			plate.Part = part
+			var side engrave.Plan
+			side, err = EngraveDescriptor(params, plate)
+			if err != nil {
+				break
+			}
+			sides = append(sides, side)
+		}
+		if err == nil {
+			return sides, nil
+		}
+		if !errors.Is(err, ErrDescriptorTooLarge) {
+			break
+		}
+	}
+	return nil, err
+}
+
+// withQRFallback calls eng with decreasing QR error correction
+// levels, starting from level, until it succeeds. The error from the
+// lowest level is returned if every level fails.
+func withQRFallback(level qr.Level, eng func(level qr.Level) (engrave.Plan, error)) (engrave.Plan, error) {
+	for {
+		p, err := eng(level)
+		if err == nil || level == qr.L {
+			return p, err
+		}
+		level--
+	}
+}
+
+// DepthTest describes a plate for finding the number of needle
+// passes that engraves a plate material deep enough.
+type DepthTest struct {
+	// Strokes is the number of strokes, where stroke i is
+	// engraved i+1 times.
+	Strokes int
+	Font    *vector.Face
+	Size    PlateSize
+}
+
+// MaxDepthTestStrokes is the maximum number of strokes of a
+// depth test plate.
+const MaxDepthTestStrokes = 9
+
+// EngraveDepthTest engraves a row of short strokes, each
+// labeled with its number of passes.
+func EngraveDepthTest(params engrave.Params, plate DepthTest) (engrave.Plan, error) {
+	if plate.Strokes < 1 || plate.Strokes > MaxDepthTestStrokes {
+		return nil, fmt.Errorf("depth test: %d strokes out of range [1,%d]", plate.Strokes, MaxDepthTestStrokes)
+	}
 	return engraveSide(params.Millimeter, plate.Size, func(plateDims image.Point) (engrave.Plan, error) {
-		urs := splitUR(plate.Descriptor, plate.KeyIdx)
-		return descriptorSide(params, plate.Font, urs, plate.Size, plateDims)
+		spacing := params.I(8)
+		length := params.I(6)
+		labelMargin := params.I(2)
+		var cmds []engrave.Plan
+		x0 := (plateDims.X - (plate.Strokes-1)*spacing) / 2
+		y0 := (plateDims.Y - length) / 2
+		for i := 0; i < plate.Strokes; i++ {
+			x := x0 + i*spacing
+			label, sz := dims(engrave.String(plate.Font, params.F(plateSmallFontSize), fmt.Sprintf("%d", i+1)).Engrave())
+			cmds = append(cmds,
+				engrave.Offset(x-sz.X/2, y0-labelMargin-sz.Y, label),
+				depthStroke(image.Pt(x, y0), image.Pt(x, y0+length), i+1),
+			)
+		}
+		return engrave.Commands(cmds...), nil
+	})
+}
+
+// depthStroke engraves the line between start and end the
+// specified number of times, alternating its direction.
+func depthStroke(start, end image.Point, passes int) engrave.Plan {
+	return func(yield func(engrave.Command) bool) {
+		if !yield(engrave.Move(start)) {
+			return
+		}
+		for i := 0; i < passes; i++ {
+			if !yield(engrave.Line(end)) {
+				return
+			}
+			start, end = end, start
+		}
+	}
+}
+
+// Data describes a plate with an arbitrary payload, such as an
+// extended public key or a URL, engraved as a QR code.
+type Data struct {
+	Payload []byte
+	// Caption is engraved below the QR code. Lines too long for
+	// the plate are wrapped.
+	Caption string
+	Font    *vector.Face
+	Size    PlateSize
+	// QRLevel is the preferred error correction level of the QR
+	// code. Lower levels are used if the code doesn't fit the plate.
+	QRLevel qr.Level
+	// QRMaxVersion limits the size of the QR code. Zero means no
+	// limit.
+	QRMaxVersion int
+}
+
+var ErrDataTooLarge = errors.New("data is too large to engrave")
+
+// maxDataQRScale is the largest number of strokes per module of
+// data plate QR codes.
+const maxDataQRScale = 8
+
+// EngraveData engraves the payload as a QR code as large as
+// fits the plate, with the caption below it.
+func EngraveData(params engrave.Params, plate Data) (engrave.Plan, error) {
+	side, err := withQRFallback(plate.QRLevel, func(level qr.Level) (engrave.Plan, error) {
+		return engraveSide(params.Millimeter, plate.Size, func(plateDims image.Point) (engrave.Plan, error) {
+			return dataSide(params, plate, level, plateDims)
+		})
+	})
+	if errors.Is(err, ErrDescriptorTooLarge) {
+		err = ErrDataTooLarge
+	}
+	return side, err
+}
+
+func dataSide(params engrave.Params, plate Data, level qr.Level, plateDims image.Point) (engrave.Plan, error) {
+	// Stay clear of the screw holes.
+	margin := params.I(innerMargin)
+	caption := engrave.Text{
+		Face:  plate.Font,
+		Size:  params.F(plateSmallFontSize),
+		Text:  plate.Caption,
+		Align: engrave.AlignCenter,
+	}
+	bounds := plateDims.Sub(image.Pt(2*margin, 2*margin))
+	// Engrave the largest QR code that fits with the caption.
+	content, _, err := engrave.Fit(bounds, 1, maxDataQRScale, func(scale int) engrave.Block {
+		return engrave.Stack{
+			Blocks: []engrave.Block{
+				engrave.QRCode{
+					StrokeWidth: params.StrokeWidth,
+					Scale:       scale,
+					Level:       level,
+					MaxVersion:  plate.QRMaxVersion,
+					Content:     plate.Payload,
+				},
+				caption,
+			},
+			Gap:   params.I(2),
+			Align: engrave.AlignCenter,
+		}
 	})
+	if err != nil {
+		if errors.Is(err, engrave.ErrNoFit) {
+			err = ErrDataTooLarge
+		}
+		return nil, err
+	}
+	side := engrave.Frame{
+		Size:   plateDims,
+		Margin: margin,
+		Items: []engrave.Anchored{
+			{X: engrave.AlignCenter, Y: engrave.AlignCenter, Block: content},
+		},
+	}
+	p, _, err := side.Layout(plateDims.X)
+	return p, err
+}
+
+// MultiSeed describes a large plate with several 12-word seeds,
+// each engraved with its master fingerprint and SeedQR.
+type MultiSeed struct {
+	Title string
+	Seeds []MultiSeedEntry
+	Font  *vector.Face
+	// QRLevel is the preferred error correction level of the
+	// SeedQR codes. Lower levels are used if the codes don't fit.
+	QRLevel qr.Level
+}
+
+// MultiSeedEntry is a seed of a MultiSeed plate.
+type MultiSeedEntry struct {
+	Mnemonic          bip39.Mnemonic
+	MasterFingerprint uint32
+	// Label is engraved next to the master fingerprint, if not
+	// empty. See LabelString.
+	Label string
+}
+
+// MaxMultiSeeds is the largest number of seeds of a MultiSeed
+// plate.
+const MaxMultiSeeds = 3
+
+// multiSeedWords is the length of the seeds of MultiSeed plates.
+const multiSeedWords = 12
+
+// EngraveMultiSeed engraves the seeds of plate in blocks stacked
+// on a LargePlate.
+func EngraveMultiSeed(params engrave.Params, plate MultiSeed) (engrave.Plan, error) {
+	if n := len(plate.Seeds); n < 1 || n > MaxMultiSeeds {
+		return nil, fmt.Errorf("multi-seed: %d seeds out of range [1,%d]", n, MaxMultiSeeds)
+	}
+	for i, s := range plate.Seeds {
+		if len(s.Mnemonic) != multiSeedWords {
+			return nil, fmt.Errorf("multi-seed: seed %d has %d words, expected %d", i+1, len(s.Mnemonic), multiSeedWords)
+		}
+	}
+	return withQRFallback(plate.QRLevel, func(level qr.Level) (engrave.Plan, error) {
+		return engraveSide(params.Millimeter, LargePlate, func(plateDims image.Point) (engrave.Plan, error) {
+			return multiSeedSide(params, plate, level, plateDims)
+		})
+	})
+}
+
+func multiSeedSide(params engrave.Params, plate MultiSeed, qrLevel qr.Level, plateDims image.Point) (engrave.Plan, error) {
+	constant := engrave.NewConstantStringer(plate.Font, params.F(plateFontSizeMultiSeed), bip39.ShortestWord, bip39.LongestWord)
+	margin := params.I(innerMargin)
+	width := plateDims.X - 2*margin
+	var cmds []engrave.Plan
+	y := margin
+	if t := TitleString(plate.Font, plate.Title); t != "" {
+		title, sz := dims(engrave.String(plate.Font, params.F(plateSmallFontSize), t).Engrave())
+		cmds = append(cmds, engrave.Offset((plateDims.X-sz.X)/2, y, title))
+		y += sz.Y
+	}
+	var blocks []engrave.Plan
+	var heights []int
+	total := 0
+	for i, s := range plate.Seeds {
+		block, sz, err := multiSeedBlock(params, plate, constant, i, s, width, qrLevel)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+		heights = append(heights, sz.Y)
+		total += sz.Y
+	}
+	// Spread the blocks evenly across the rest of the plate.
+	space := plateDims.Y - margin - y - total
+	if space < 0 {
+		return nil, ErrDescriptorTooLarge
+	}
+	gap := space / (len(blocks) + 1)
+	for i, b := range blocks {
+		y += gap
+		cmds = append(cmds, engrave.Offset(margin, y, b))
+		y += heights[i]
+	}
+	return engrave.Commands(cmds...), nil
+}
+
+// multiSeedBlock engraves the block of seed i of a MultiSeed plate:
+// its number and master fingerprint above two columns of words and
+// the SeedQR, aligned to the right edge. Like the seed side, the
+// positions of the words don't depend on the words.
+func multiSeedBlock(params engrave.Params, plate MultiSeed, constant *engrave.ConstantStringer, i int, s MultiSeedEntry, width int, qrLevel qr.Level) (engrave.Plan, image.Point, error) {
+	fontSize := params.F(plateFontSizeMultiSeed)
+	smallSize := params.F(plateSmallFontSize)
+	// Compute character width, assuming the font is fixed width.
+	charWidthf, _, ok := plate.Font.Decode('W')
+	if !ok {
+		panic("W not in font")
+	}
+	charWidth := int(float32(charWidthf*fontSize) / float32(plate.Font.Metrics().Height))
+	// The column width fits the word number, a space and the
+	// longest word.
+	colWidth := (3 + bip39.LongestWord) * charWidth
+	gap := params.I(1)
+	var cmds []engrave.Plan
+	num, numsz := dims(engrave.String(plate.Font, smallSize, fmt.Sprintf("%d/%d", i+1, len(plate.Seeds))).Engrave())
+	cmds = append(cmds, num)
+	mfp := strings.ToUpper(fmt.Sprintf("%.8x", s.MasterFingerprint))
+	if l := LabelString(plate.Font, s.Label); l != "" {
+		mfp += " " + l
+	}
+	mfpc, mfpsz := dims(engrave.String(plate.Font, smallSize, mfp).Engrave())
+	header := max(numsz.Y, mfpsz.Y) + params.I(2)
+	half := len(s.Mnemonic) / 2
+	// The numbers of the first column are a single digit; skip
+	// their padding.
+	col2x := colWidth - charWidth + gap
+	cmds = append(cmds,
+		engrave.Offset(-charWidth, header, wordColumn(constant, plate.Font, fontSize, s.Mnemonic, 0, half)),
+		engrave.Offset(col2x, header, wordColumn(constant, plate.Font, fontSize, s.Mnemonic, half, len(s.Mnemonic))),
+	)
+	qrCmd, err := engrave.ConstantQR(params.StrokeWidth, 3, qrLevel, 0, seedqr.QR(s.Mnemonic))
+	if err != nil {
+		return nil, image.Point{}, err
+	}
+	qrc, qrsz := dims(qrCmd)
+	qrx := width - qrsz.X
+	if col2x+colWidth+gap > qrx {
+		return nil, image.Point{}, ErrDescriptorTooLarge
+	}
+	words := half * engrave.String(plate.Font, fontSize, "0").Measure().Y
+	cmds = append(cmds, engrave.Offset(qrx, header, qrc))
+	mfpx := max((width-mfpsz.X)/2, numsz.X+params.I(3))
+	cmds = append(cmds, engrave.Offset(mfpx, 0, mfpc))
+	return engrave.Commands(cmds...), image.Pt(width, header+max(words, qrsz.Y)), nil
 }
 
 // splitUR searches for the appropriate seqNum in the [UR] encoding
@@ -150,7 +621,22 @@ func EngraveDescriptor(params engrave.Params, plate Descriptor) (engrave.Plan, e
 //
 // [UR]: https://github.com/BlockchainCommons/Research/blob/master/papers/bcr-2020-005-ur.md
 func splitUR(desc urtypes.OutputDescriptor, keyIdx int) (urs []string) {
-	var shares [][]int
+	shares, seqLen := ShareParts(desc, keyIdx)
+	data := desc.Encode()
+	check := fountain.Checksum(data)
+	for _, frag := range shares {
+		seqNum := fountain.SeqNumFor(seqLen, check, frag)
+		qr := strings.ToUpper(ur.Encode("crypto-output", data, seqNum, seqLen))
+		urs = append(urs, qr)
+	}
+	return
+}
+
+// ShareParts returns the assignment of parts to the share of key
+// keyIdx in the scheme described by splitUR. Every element of
+// shares lists the parts combined into one fragment of the share,
+// numbered from 0 to parts-1.
+func ShareParts(desc urtypes.OutputDescriptor, keyIdx int) (shares [][]int, parts int) {
 	var seqLen int
 	m, n := desc.Threshold, len(desc.Keys)
 	switch {
@@ -195,62 +681,173 @@ func splitUR(desc urtypes.OutputDescriptor, keyIdx int) (urs []string) {
 		seqLen = 1
 		shares = [][]int{{0}}
 	}
-	data := desc.Encode()
-	check := fountain.Checksum(data)
-	for _, frag := range shares {
-		seqNum := fountain.SeqNumFor(seqLen, check, frag)
-		qr := strings.ToUpper(ur.Encode("crypto-output", data, seqNum, seqLen))
-		urs = append(urs, qr)
-	}
-	return
+	return shares, seqLen
+}
+
+// partUR returns part of the parts of the UR encoding of desc. Every
+// part is a separate fragment of the encoding, and its header contains
+// the part number and the checksum of the complete encoding. Unlike
+// the shares from splitUR, every part is needed for recovery.
+func partUR(desc urtypes.OutputDescriptor, part, parts int) string {
+	return strings.ToUpper(ur.Encode("crypto-output", desc.Encode(), part+1, parts))
 }
 
+// Recoverable reports whether every combination of threshold
+// shares recovers desc.
 func Recoverable(desc urtypes.OutputDescriptor) bool {
-	var shares [][]string
+	t := NewRecoveryTest(desc)
+	for t.Done < t.Total {
+		if !t.Step() {
+			return false
+		}
+	}
+	return true
+}
+
+// RecoveryTest checks that every combination of threshold shares
+// recovers a descriptor, one combination at a time.
+type RecoveryTest struct {
+	// Done is the number of combinations checked.
+	Done int
+	// Total is the number of combinations.
+	Total int
+
+	desc   urtypes.OutputDescriptor
+	shares [][]string
+	next   uint64
+}
+
+// NewRecoveryTest prepares a recovery test of desc.
+func NewRecoveryTest(desc urtypes.OutputDescriptor) *RecoveryTest {
+	t := &RecoveryTest{desc: desc}
+	if desc.Threshold > 0 {
+		t.Total = binomial(len(desc.Keys), desc.Threshold)
+	}
 	for k := range desc.Keys {
-		shares = append(shares, splitUR(desc, k))
+		t.shares = append(t.shares, splitUR(desc, k))
+	}
+	return t
+}
+
+// binomial returns the number of ways to choose k of n.
+func binomial(n, k int) int {
+	if k < 0 || k > n {
+		return 0
+	}
+	res := 1
+	for i := range k {
+		res = res * (n - i) / (i + 1)
 	}
+	return res
+}
+
+// Step checks the next combination of shares, and reports whether
+// it recovered the descriptor.
+func (t *RecoveryTest) Step() bool {
 	// Count to all bit patterns of n length, choose the ones with
 	// m bits.
-	allPerm := uint64(1)<<len(desc.Keys) - 1
-	for c := uint64(1); c <= allPerm; c++ {
-		if bits.OnesCount64(c) != desc.Threshold {
-			continue
-		}
-		c := c
-		d := new(ur.Decoder)
-		for c != 0 {
-			share := bits.TrailingZeros64(c)
-			c &^= 1 << share
-			for _, ur := range shares[share] {
-				d.Add(ur)
-			}
-		}
-		typ, enc, err := d.Result()
-		if err != nil {
-			return false
+	c := t.next + 1
+	for bits.OnesCount64(c) != t.desc.Threshold {
+		c++
+	}
+	t.next = c
+	t.Done++
+	d := new(ur.Decoder)
+	for c != 0 {
+		share := bits.TrailingZeros64(c)
+		c &^= 1 << share
+		for _, ur := range t.shares[share] {
+			d.Add(ur)
 		}
-		if enc == nil {
-			return false
+	}
+	typ, enc, err := d.Result()
+	if err != nil {
+		return false
+	}
+	if enc == nil {
+		return false
+	}
+	got, err := urtypes.Parse(typ, enc)
+	if err != nil {
+		return false
+	}
+	gotDesc := got.(urtypes.OutputDescriptor)
+	// Titles and labels are not encoded.
+	gotDesc.Title = t.desc.Title
+	for i, k := range t.desc.Keys {
+		if i < len(gotDesc.Keys) {
+			gotDesc.Keys[i].Label = k.Label
 		}
-		got, err := urtypes.Parse(typ, enc)
-		if err != nil {
-			return false
+	}
+	return reflect.DeepEqual(gotDesc, t.desc)
+}
+
+// RecoverySheet returns instructions for recovering desc from its
+// plates, in plain text. It lists the plates needed, the parts of
+// the descriptor held by every plate, the script type and the keys
+// with their derivation paths. The sheet contains no seeds or
+// public keys.
+func RecoverySheet(desc urtypes.OutputDescriptor) string {
+	var b strings.Builder
+	b.WriteString("SEEDHAMMER RECOVERY SHEET\n")
+	if desc.Title != "" {
+		fmt.Fprintf(&b, "Wallet: %s\n", desc.Title)
+	}
+	fmt.Fprintf(&b, "Script: %s\n", desc.Script)
+	n := len(desc.Keys)
+	if n > 1 {
+		fmt.Fprintf(&b, "Type: %d-of-%d sorted multisig\n", desc.Threshold, n)
+	} else {
+		b.WriteString("Type: singlesig\n")
+	}
+	b.WriteString("\nKeys (fingerprint, derivation path):\n")
+	for i, k := range desc.Keys {
+		fmt.Fprintf(&b, "%d: %.8X %s", i+1, k.MasterFingerprint, k.DerivationPath)
+		if k.Label != "" {
+			fmt.Fprintf(&b, " %s", k.Label)
 		}
-		gotDesc := got.(urtypes.OutputDescriptor)
-		gotDesc.Title = desc.Title
-		if !reflect.DeepEqual(gotDesc, desc) {
-			return false
+		b.WriteByte('\n')
+	}
+	_, parts := ShareParts(desc, 0)
+	b.WriteString("\nRecovery:\n")
+	b.WriteString("Plate N belongs to key N, and its back side holds the seed of the key. The front side holds the descriptor QR codes, in the UR format (UR:CRYPTO-OUTPUT). ")
+	if parts == 1 {
+		b.WriteString("Every plate holds the complete descriptor; scan the QR code of any plate.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "The descriptor is split into %d parts. Scan the QR codes of any %d plates with a wallet that reads multi-part UR codes to recover the descriptor.\n", parts, desc.Threshold)
+	b.WriteString("\nParts per plate, combined parts are xor'ed:\n")
+	for k := range desc.Keys {
+		fmt.Fprintf(&b, "%d: %s\n", k+1, FormatShareParts(desc, k))
+	}
+	return b.String()
+}
+
+// FormatShareParts formats the parts of the descriptor QR codes of
+// the share of key keyIdx. Parts combined into a single QR code are
+// separated by "xor".
+func FormatShareParts(desc urtypes.OutputDescriptor, keyIdx int) string {
+	shares, _ := ShareParts(desc, keyIdx)
+	var frags []string
+	for _, frag := range shares {
+		var parts []string
+		for _, p := range frag {
+			parts = append(parts, fmt.Sprint(p+1))
 		}
+		frags = append(frags, strings.Join(parts, " xor "))
 	}
-	return true
+	return strings.Join(frags, ", ")
 }
 
 const plateFontSize = 4.1
 const plateFontSizeUR = 3.8
 const plateSmallFontSize = 3.
 
-func frontSideSeed(params engrave.Params, plate Seed, plateDims image.Point) (engrave.Plan, error) {
+// plateFontSizeMultiSeed trades word size for fitting the words and
+// SeedQR of MultiSeed plates side by side.
+const plateFontSizeMultiSeed = 2.8
+
+func frontSideSeed(params engrave.Params, plate Seed, qrLevel qr.Level, plateDims image.Point) (engrave.Plan, error) {
 	constant := engrave.NewConstantStringer(plate.Font, params.F(plateFontSize), bip39.ShortestWord, bip39.LongestWord)
 	var cmds []engrave.Plan
 	cmd := func(c engrave.Plan) {
@@ -271,6 +868,9 @@ func frontSideSeed(params engrave.Params, plate Seed, plateDims image.Point) (en
 	metaMargin := params.I(4)
 	page := fmt.Sprintf("%d/%d", plate.KeyIdx+1, plate.Keys)
 	mfp := strings.ToUpper(fmt.Sprintf("%.8x", plate.MasterFingerprint))
+	if l := LabelString(plate.Font, plate.Label); l != "" {
+		mfp += " " + l
+	}
 	{
 		offy := (plateDims.Y-col1b.Y)/2 - metaMargin
 		pagec, sz := dims(engrave.String(plate.Font, params.F(plateSmallFontSize), page).Engrave())
@@ -293,7 +893,7 @@ func frontSideSeed(params engrave.Params, plate Seed, plateDims image.Point) (en
 	cmd(engrave.Offset(params.I(44), (plateDims.Y-col1b.Y)/2, col2))
 
 	// Engrave seed QR.
-	qrCmd, err := engrave.ConstantQR(params.StrokeWidth, 3, qr.M, seedqr.QR(plate.Mnemonic))
+	qrCmd, err := engrave.ConstantQR(params.StrokeWidth, 3, qrLevel, plate.QRMaxVersion, seedqr.QR(plate.Mnemonic))
 	if err != nil {
 		return nil, err
 	}
@@ -307,10 +907,9 @@ func frontSideSeed(params engrave.Params, plate Seed, plateDims image.Point) (en
 	}
 
 	// Engrave title.
-	title := strings.ToUpper(plate.Title)
 	{
 		offy := (plateDims.Y+col1b.Y)/2 + metaMargin
-		title, sz := dims(engrave.String(plate.Font, params.F(plateSmallFontSize), title).Engrave())
+		title, sz := dims(engrave.String(plate.Font, params.F(plateSmallFontSize), TitleString(plate.Font, plate.Title)).Engrave())
 		cmd(engrave.Offset((plateDims.X-sz.X)/2, offy, title))
 	}
 	all := engrave.Commands(cmds...)
@@ -339,7 +938,12 @@ func wordColumn(constant *engrave.ConstantStringer, font *vector.Face, fontSize
 	return engrave.Commands(cmds...)
 }
 
-func descriptorSide(params engrave.Params, fnt *vector.Face, urs []string, size PlateSize, plateDims image.Point) (engrave.Plan, error) {
+// urScheme is the scheme prefix of upper case URs. It is the same for
+// every UR, and is engraved in the regular way by constant descriptor
+// sides.
+const urScheme = "UR:"
+
+func descriptorSide(params engrave.Params, fnt *vector.Face, urs, addrs []string, size PlateSize, plateDims image.Point, constant bool, qrLevel qr.Level, qrMaxVersion int) (engrave.Plan, error) {
 	var cmds []engrave.Plan
 	cmd := func(c engrave.Plan) {
 		cmds = append(cmds, c)
@@ -364,9 +968,13 @@ func descriptorSide(params engrave.Params, fnt *vector.Face, urs []string, size
 	holeLines := int(math.Ceil(float64(innerMargin-margin) / float64(fontSize)))
 	width := plateDims.X - 2*margin
 	charPerLine := int(width / charWidth)
+	var cs *engrave.ConstantStringer
+	if constant {
+		cs = engrave.NewConstantAlphabetStringer(fnt, fontSize, engrave.URAlphabet, 1, charPerLine)
+	}
 	offy := params.I(outerMargin)
 	for i, ur := range urs {
-		qrcmd, err := engrave.QR(params.StrokeWidth, 2, qr.M, []byte(ur))
+		qrcmd, err := engrave.QR(params.StrokeWidth, 2, qrLevel, qrMaxVersion, []byte(ur))
 		if err != nil {
 			return nil, err
 		}
@@ -375,6 +983,17 @@ func descriptorSide(params engrave.Params, fnt *vector.Face, urs []string, size
 		charPerQRLine := (width - 2*qrBorder - qrsz.X) / charWidth
 		qrLines := (qrsz.Y + 2*qrBorder + fontSize - 1) / fontSize
 		qrLineStart := holeLines
+		if constant {
+			qrLines = 0
+		}
+		// scheme is the length of the UR scheme not yet engraved.
+		scheme := 0
+		if constant {
+			if !strings.HasPrefix(ur, urScheme) {
+				return nil, fmt.Errorf("backup: %q is not an upper case UR", ur)
+			}
+			scheme = len(urScheme)
+		}
 		lineno := 0
 		for len(ur) > 0 {
 			n := charPerLine
@@ -403,18 +1022,54 @@ func descriptorSide(params engrave.Params, fnt *vector.Face, urs []string, size
 			}
 			s := ur[:n]
 			ur = ur[n:]
-			cmd(engrave.Offset(offx+margin, offy+lineno*fontSize, str(s)))
+			x, y := offx+margin, offy+lineno*fontSize
+			if constant {
+				prefix := min(scheme, len(s))
+				scheme -= prefix
+				if prefix > 0 {
+					cmd(engrave.Offset(x, y, str(s[:prefix])))
+					s = s[prefix:]
+					x += prefix * charWidth
+				}
+				if len(s) > 0 {
+					cmd(engrave.Offset(x, y, cs.String(s)))
+				}
+			} else {
+				cmd(engrave.Offset(x, y, str(s)))
+			}
 			lineno++
 		}
-		qrx := plateDims.X - qrsz.X - margin - qrBorder
-		qry := qrLineStart*fontSize + (qrLines*fontSize-qrsz.Y)/2
-		cmd(engrave.Offset(qrx, offy+qry, qr))
+		if !constant {
+			qrx := plateDims.X - qrsz.X - margin - qrBorder
+			qry := qrLineStart*fontSize + (qrLines*fontSize-qrsz.Y)/2
+			cmd(engrave.Offset(qrx, offy+qry, qr))
+		}
 		offy += lineno * fontSize
 		if i != len(urs)-1 {
 			// Space UR sections.
 			offy += params.I(1)
 		}
 	}
+	if len(addrs) > 0 {
+		// Start below both the text and the QR code of the last UR.
+		offy = engrave.Measure(engrave.Commands(cmds...)).Max.Y + params.I(1)
+		lineno := 0
+		for _, a := range addrs {
+			for len(a) > 0 {
+				n, offx := charPerLine, 0
+				holeLine := offy+lineno*fontSize < innerMargin ||
+					offy+(lineno+1)*fontSize > plateDims.Y-innerMargin
+				if holeLine {
+					n -= 2 * holeChars
+					offx = holeChars * charWidth
+				}
+				n = min(max(n, 1), len(a))
+				cmd(engrave.Offset(offx+margin, offy+lineno*fontSize, str(a[:n])))
+				a = a[n:]
+				lineno++
+			}
+		}
+	}
 
 	return engrave.Commands(cmds...), nil
 }
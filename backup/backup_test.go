@@ -7,12 +7,17 @@ import (
 	"fmt"
 	"image"
 	"image/png"
+	"iter"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/kortschak/qr"
+	"seedhammer.com/bc/ur"
 	"seedhammer.com/bc/urtypes"
 	"seedhammer.com/bip32"
 	"seedhammer.com/bip39"
@@ -190,6 +195,98 @@ func TestEngrave(t *testing.T) {
 	}
 }
 
+func TestEngraveConstantDescriptor(t *testing.T) {
+	desc := urtypes.OutputDescriptor{
+		Script:    urtypes.P2WPKH,
+		Threshold: 1,
+		Type:      urtypes.Singlesig,
+		Keys:      make([]urtypes.KeyDescriptor, 1),
+	}
+	path := desc.Script.DerivationPath()
+	var patterns [2][]int
+	for i, seedLen := range []int{12, 24} {
+		_, plate := genTestPlate(t, desc, path, seedLen, 0, SquarePlate)
+		plate.Constant = true
+		side, err := EngraveDescriptor(mjolnir.Params, plate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		patterns[i] = timingPattern(side)
+	}
+	if !slices.Equal(patterns[0], patterns[1]) {
+		t.Error("constant descriptor engravings differ in timing")
+	}
+}
+
+func TestEngraveQRLevel(t *testing.T) {
+	desc := urtypes.OutputDescriptor{
+		Script:    urtypes.P2WPKH,
+		Threshold: 1,
+		Type:      urtypes.Singlesig,
+		Keys:      make([]urtypes.KeyDescriptor, 1),
+	}
+	seed, plate := genTestPlate(t, desc, desc.Script.DerivationPath(), 24, 0, SquarePlate)
+	// A 24 word SeedQR doesn't fit a constant time QR code with high
+	// error correction.
+	seed.QRLevel = qr.H
+	if _, err := EngraveSeed(mjolnir.Params, seed); err != nil {
+		t.Errorf("seed side didn't fall back to a lower level: %v", err)
+	}
+	plate.QRLevel = qr.H
+	if _, err := EngraveDescriptor(mjolnir.Params, plate); err != nil {
+		t.Errorf("descriptor side: %v", err)
+	}
+	plate.QRMaxVersion = 1
+	if _, err := EngraveDescriptor(mjolnir.Params, plate); !errors.Is(err, engrave.ErrQRTooLarge) {
+		t.Errorf("got %v, expected %v", err, engrave.ErrQRTooLarge)
+	}
+}
+
+func TestEngraveData(t *testing.T) {
+	const xpub = "xpub6ENfRaMWq2UoFy5FrLRMwiEkdgFdMgjEoikR34RBGzhsx8JzAkn7fyQeR5odirEwERvmxhSEv7rsmV7nuzjSKKKJHBP2aQZVu3R2d5ERgcw"
+	plate := Data{
+		Payload: []byte(xpub),
+		Caption: "Satoshi's xpub\n" + xpub,
+		Font:    constant.Font,
+		Size:    SquarePlate,
+		QRLevel: qr.M,
+	}
+	if _, err := EngraveData(mjolnir.Params, plate); err != nil {
+		t.Fatal(err)
+	}
+	plate.Payload = bytes.Repeat([]byte(xpub), 25)
+	if _, err := EngraveData(mjolnir.Params, plate); !errors.Is(err, ErrDataTooLarge) {
+		t.Errorf("got %v, expected %v", err, ErrDataTooLarge)
+	}
+}
+
+func TestEngraveDepthTest(t *testing.T) {
+	plate := DepthTest{Strokes: 5, Font: constant.Font, Size: SquarePlate}
+	if _, err := EngraveDepthTest(mjolnir.Params, plate); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{0, MaxDepthTestStrokes + 1} {
+		plate.Strokes = n
+		if _, err := EngraveDepthTest(mjolnir.Params, plate); err == nil {
+			t.Errorf("%d strokes accepted", n)
+		}
+	}
+	start, end := image.Pt(10, 10), image.Pt(10, 20)
+	var got []engrave.Command
+	for c := range depthStroke(start, end, 3) {
+		got = append(got, c)
+	}
+	want := []engrave.Command{
+		engrave.Move(start),
+		engrave.Line(end),
+		engrave.Line(start),
+		engrave.Line(end),
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("depth stroke engraved %v, want %v", got, want)
+	}
+}
+
 func TestSplitUR(t *testing.T) {
 	t.Parallel()
 
@@ -222,16 +319,78 @@ func TestSplitUR(t *testing.T) {
 	}
 }
 
+func TestRecoveryTest(t *testing.T) {
+	desc := urtypes.OutputDescriptor{
+		Script:    urtypes.P2WSH,
+		Threshold: 3,
+		Type:      urtypes.SortedMulti,
+		Keys:      make([]urtypes.KeyDescriptor, 5),
+	}
+	genTestPlate(t, desc, desc.Script.DerivationPath(), 12, 0, LargePlate)
+	rt := NewRecoveryTest(desc)
+	if rt.Total != 10 {
+		t.Fatalf("3-of-5: %d combinations, want 10", rt.Total)
+	}
+	for rt.Done < rt.Total {
+		if !rt.Step() {
+			t.Fatalf("3-of-5: combination %d failed to recover", rt.Done)
+		}
+	}
+	// Every part is held by some share.
+	held := make(map[int]int)
+	for k := range desc.Keys {
+		shares, parts := ShareParts(desc, k)
+		if parts != 6 {
+			t.Errorf("3-of-5: %d parts, want 6", parts)
+		}
+		for _, frag := range shares {
+			for _, p := range frag {
+				held[p]++
+			}
+		}
+	}
+	for p := range 6 {
+		if held[p] == 0 {
+			t.Errorf("3-of-5: part %d not held by any share", p+1)
+		}
+	}
+}
+
+func TestRecoverySheet(t *testing.T) {
+	desc := urtypes.OutputDescriptor{
+		Title:     "Family",
+		Script:    urtypes.P2WSH,
+		Threshold: 2,
+		Type:      urtypes.SortedMulti,
+		Keys:      make([]urtypes.KeyDescriptor, 3),
+	}
+	genTestPlate(t, desc, desc.Script.DerivationPath(), 12, 0, LargePlate)
+	sheet := RecoverySheet(desc)
+	for _, want := range []string{
+		"Wallet: Family",
+		"2-of-3",
+		desc.Script.String(),
+		fmt.Sprintf("1: %.8X %s", desc.Keys[0].MasterFingerprint, desc.Keys[0].DerivationPath),
+		"3: 1 xor 2",
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("recovery sheet lacks %q:\n%s", want, sheet)
+		}
+	}
+}
+
 func TestTitleString(t *testing.T) {
 	tests := []struct {
 		test  string
 		title string
 	}{
-		{"Satoshi's Wallet", "SATOSHI'S WALLET"},
-		{"Anø de:Æby09 . asd asd asd as das d asd asdf sdf s fd", "AN DE:BY09 . ASD A"},
-		{"Æg", "G"},
+		{"Satoshi's Wallet", "Satoshi's Wallet"},
+		{"Anø de:Æby09 . asd asd asd as das d asd asdf sdf s fd", "An de:by09 . asd a"},
+		{"Æg", "g"},
+		{"a+b=100% & c_d", "a+b=100% & c_d"},
 		{"🤡 💩", " "},
 		{"$€#,", "#,"},
+		{"a\x00b", "ab"},
 	}
 	for _, test := range tests {
 		s := TitleString(constant.Font, test.test)
@@ -241,6 +400,188 @@ func TestTitleString(t *testing.T) {
 	}
 }
 
+func TestEngraveDescriptorParts(t *testing.T) {
+	desc := urtypes.OutputDescriptor{
+		Title:     "Satoshi Stash",
+		Script:    urtypes.P2WSH,
+		Threshold: 1,
+		Type:      urtypes.SortedMulti,
+		Keys:      make([]urtypes.KeyDescriptor, 5),
+	}
+	_, plate := genTestPlate(t, desc, desc.Script.DerivationPath(), 24, 0, LargePlate)
+	if _, err := EngraveDescriptor(mjolnir.Params, plate); !errors.Is(err, ErrDescriptorTooLarge) {
+		t.Fatalf("got error %v, expected %v", err, ErrDescriptorTooLarge)
+	}
+	sides, err := EngraveDescriptorParts(mjolnir.Params, plate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sides) < 2 {
+		t.Fatalf("descriptor split into %d parts", len(sides))
+	}
+	// Recover from the parts, in reverse order.
+	d := new(ur.Decoder)
+	for part := len(sides) - 1; part >= 0; part-- {
+		if err := d.Add(partUR(desc, part, len(sides))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	typ, enc, err := d.Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != "crypto-output" || !bytes.Equal(enc, desc.Encode()) {
+		t.Error("parts don't recover the descriptor")
+	}
+}
+
+func TestLabelString(t *testing.T) {
+	tests := []struct {
+		test  string
+		label string
+	}{
+		{"Home safe", "HOME SAFE"},
+		{"Bank box downtown", "BANK BOX D"},
+		{"Æg", "G"},
+	}
+	for _, test := range tests {
+		s := LabelString(constant.Font, test.test)
+		if s != test.label {
+			t.Errorf("got %q, wanted %q", s, test.label)
+		}
+	}
+}
+
+func TestEngraveLabel(t *testing.T) {
+	desc := urtypes.OutputDescriptor{
+		Title:     strings.Repeat("W", MaxTitleLen),
+		Script:    urtypes.P2WSH,
+		Threshold: 2,
+		Type:      urtypes.SortedMulti,
+		Keys:      make([]urtypes.KeyDescriptor, 3),
+	}
+	for _, sz := range []PlateSize{SquarePlate, LargePlate} {
+		seed, _ := genTestPlate(t, desc, desc.Script.DerivationPath(), 24, 0, sz)
+		unlabeled, err := EngraveSeed(mjolnir.Params, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seed.Label = strings.Repeat("W", MaxLabelLen)
+		labeled, err := EngraveSeed(mjolnir.Params, seed)
+		if err != nil {
+			t.Fatalf("%v: longest label: %v", sz, err)
+		}
+		if countCommands(labeled) <= countCommands(unlabeled) {
+			t.Errorf("%v: label not engraved", sz)
+		}
+	}
+}
+
+func TestEngraveAddresses(t *testing.T) {
+	singlesig := urtypes.OutputDescriptor{
+		Script:    urtypes.P2WPKH,
+		Threshold: 1,
+		Type:      urtypes.Singlesig,
+		Keys:      make([]urtypes.KeyDescriptor, 1),
+	}
+	multisig := urtypes.OutputDescriptor{
+		Script:    urtypes.P2WSH,
+		Threshold: 2,
+		Type:      urtypes.SortedMulti,
+		Keys:      make([]urtypes.KeyDescriptor, 3),
+	}
+	tests := []struct {
+		desc urtypes.OutputDescriptor
+		size PlateSize
+	}{
+		{singlesig, SquarePlate},
+		{singlesig, LargePlate},
+		{multisig, LargePlate},
+	}
+	for _, test := range tests {
+		desc, sz := test.desc, test.size
+		_, plate := genTestPlate(t, desc, desc.Script.DerivationPath(), 24, 0, sz)
+		without, err := EngraveDescriptor(mjolnir.Params, plate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plate.Addresses = true
+		with, err := EngraveDescriptor(mjolnir.Params, plate)
+		if err != nil {
+			t.Fatalf("%v %v: %v", desc.Script, sz, err)
+		}
+		if countCommands(with) <= countCommands(without) {
+			t.Errorf("%v %v: addresses not engraved", desc.Script, sz)
+		}
+	}
+}
+
+func TestEngraveFlipVertical(t *testing.T) {
+	desc := urtypes.OutputDescriptor{
+		Script:    urtypes.P2WPKH,
+		Threshold: 1,
+		Type:      urtypes.Singlesig,
+		Keys:      make([]urtypes.KeyDescriptor, 1),
+	}
+	for _, sz := range []PlateSize{SquarePlate, LargePlate} {
+		seed, _ := genTestPlate(t, desc, desc.Script.DerivationPath(), 24, 0, sz)
+		side, err := EngraveSeed(mjolnir.Params, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seed.FlipVertical = true
+		flipped, err := EngraveSeed(mjolnir.Params, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dims := sz.Dims().Mul(mjolnir.Params.Millimeter)
+		next, stop := iter.Pull(iter.Seq[engrave.Command](flipped))
+		for c := range side {
+			f, ok := next()
+			if !ok {
+				t.Fatalf("%v: flipped side is shorter", sz)
+			}
+			if want := dims.Sub(c.Coord); f.Line != c.Line || f.Coord != want {
+				t.Fatalf("%v: flipped command %v, want %v", sz, f, engrave.Command{Line: c.Line, Coord: want})
+			}
+		}
+		stop()
+	}
+}
+
+// timingPattern records the lengths of consecutive moves (negative)
+// and lines of p.
+func timingPattern(p engrave.Plan) []int {
+	var pattern []int
+	var needle image.Point
+	line := false
+	for c := range p {
+		n := engrave.ManhattanDist(needle, c.Coord)
+		needle = c.Coord
+		if n == 0 {
+			continue
+		}
+		if !c.Line {
+			n = -n
+		}
+		if len(pattern) > 0 && c.Line == line {
+			pattern[len(pattern)-1] += n
+		} else {
+			pattern = append(pattern, n)
+		}
+		line = c.Line
+	}
+	return pattern
+}
+
+func countCommands(p engrave.Plan) int {
+	n := 0
+	for range p {
+		n++
+	}
+	return n
+}
+
 func genTestPlate(t *testing.T, desc urtypes.OutputDescriptor, path []uint32, seedlen int, keyIdx int, plateSize PlateSize) (Seed, Descriptor) {
 	var mnemonic bip39.Mnemonic
 	for i := range desc.Keys {
@@ -280,17 +621,134 @@ func genTestPlate(t *testing.T, desc urtypes.OutputDescriptor, path []uint32, se
 		}
 	}
 	return Seed{
-			Title:             desc.Title,
-			KeyIdx:            keyIdx,
-			Mnemonic:          mnemonic,
-			Keys:              len(desc.Keys),
-			MasterFingerprint: desc.Keys[keyIdx].MasterFingerprint,
-			Font:              constant.Font,
-			Size:              plateSize,
-		}, Descriptor{
-			Descriptor: desc,
-			KeyIdx:     keyIdx,
-			Font:       constant.Font,
-			Size:       plateSize,
+		Title:             desc.Title,
+		KeyIdx:            keyIdx,
+		Mnemonic:          mnemonic,
+		Keys:              len(desc.Keys),
+		MasterFingerprint: desc.Keys[keyIdx].MasterFingerprint,
+		Font:              constant.Font,
+		Size:              plateSize,
+		QRLevel:           qr.M,
+	}, Descriptor{
+		Descriptor: desc,
+		KeyIdx:     keyIdx,
+		Font:       constant.Font,
+		Size:       plateSize,
+		QRLevel:    qr.M,
+	}
+}
+
+func TestAddFiducials(t *testing.T) {
+	params := mjolnir.Params
+	fids := FiducialPositions(params, SquarePlate)
+	empty := func(yield func(engrave.Command) bool) {}
+	plan, err := AddFiducials(params, SquarePlate, empty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := engrave.Measure(plan), (image.Rectangle{Min: fids[0], Max: fids[1]}).Inset(-params.I(1)); got != want {
+		t.Errorf("fiducials measure %v, want %v", got, want)
+	}
+	// A line across the plate.
+	across := func(yield func(engrave.Command) bool) {
+		_ = yield(engrave.Move(image.Pt(0, fids[0].Y))) &&
+			yield(engrave.Line(image.Pt(params.I(20), fids[0].Y)))
+	}
+	if _, err := AddFiducials(params, SquarePlate, across); !errors.Is(err, ErrFiducialsOverlap) {
+		t.Errorf("got %v, expected %v", err, ErrFiducialsOverlap)
+	}
+}
+
+func TestEngraveMultiSeed(t *testing.T) {
+	var seeds []MultiSeedEntry
+	for i := range MaxMultiSeeds {
+		m := make(bip39.Mnemonic, 12)
+		for j := range m {
+			m[j] = bip39.Word(i*100 + j)
+		}
+		m = m.FixChecksum()
+		seeds = append(seeds, MultiSeedEntry{
+			Mnemonic:          m,
+			MasterFingerprint: 0xdeadbeef,
+			Label:             strings.Repeat("W", MaxLabelLen),
+		})
+	}
+	for n := 1; n <= MaxMultiSeeds; n++ {
+		plate := MultiSeed{
+			Title:   strings.Repeat("W", MaxTitleLen),
+			Seeds:   seeds[:n],
+			Font:    constant.Font,
+			QRLevel: qr.H,
+		}
+		side, err := EngraveMultiSeed(mjolnir.Params, plate)
+		if err != nil {
+			t.Fatalf("%d seeds: %v", n, err)
+		}
+		// The engraving must not depend on the words.
+		other := slices.Clone(plate.Seeds)
+		other[0].Mnemonic = seeds[n%MaxMultiSeeds].Mnemonic
+		plate.Seeds = other
+		side2, err := EngraveMultiSeed(mjolnir.Params, plate)
+		if err != nil {
+			t.Fatalf("%d seeds: %v", n, err)
+		}
+		if !slices.Equal(timingPattern(side), timingPattern(side2)) {
+			t.Errorf("%d seeds: engravings differ in timing", n)
 		}
+	}
+	long := MultiSeed{
+		Seeds: []MultiSeedEntry{{Mnemonic: make(bip39.Mnemonic, 24)}},
+		Font:  constant.Font,
+	}
+	if _, err := EngraveMultiSeed(mjolnir.Params, long); err == nil {
+		t.Error("24-word seed engraved on a multi-seed plate")
+	}
+	many := MultiSeed{
+		Seeds: append(seeds, seeds[0]),
+		Font:  constant.Font,
+	}
+	if _, err := EngraveMultiSeed(mjolnir.Params, many); err == nil {
+		t.Errorf("%d seeds engraved on a multi-seed plate", len(many.Seeds))
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	templates := []struct {
+		name string
+		tmpl Template
+	}{
+		{"standard", StandardTemplate},
+		{"words", WordsTemplate},
+		{"qr", QRTemplate},
+	}
+	for _, tt := range templates {
+		for _, size := range []PlateSize{SquarePlate, LargePlate} {
+			for _, n := range []int{12, 24} {
+				desc := urtypes.OutputDescriptor{
+					Script: urtypes.P2WPKH,
+					Type:   urtypes.Singlesig,
+					Keys:   make([]urtypes.KeyDescriptor, 1),
+				}
+				plate, _ := genTestPlate(t, desc, desc.Script.DerivationPath(), n, 0, size)
+				plate.Template = tt.tmpl
+				side, err := EngraveSeed(mjolnir.Params, plate)
+				if err != nil {
+					t.Fatalf("%s: %d words: %v", tt.name, n, err)
+				}
+				// The engraving must not depend on the words.
+				m := make(bip39.Mnemonic, n)
+				for i := range m {
+					m[i] = bip39.Word(1000 + i)
+				}
+				plate.Mnemonic = m.FixChecksum()
+				side2, err := EngraveSeed(mjolnir.Params, plate)
+				if err != nil {
+					t.Fatalf("%s: %d words: %v", tt.name, n, err)
+				}
+				if !slices.Equal(timingPattern(side), timingPattern(side2)) {
+					t.Errorf("%s: %d words: engravings differ in timing", tt.name, n)
+				}
+			}
+		}
+	}
 }
@@ -0,0 +1,150 @@
+package backup
+
+import (
+	"errors"
+	"image"
+	"strings"
+
+	"github.com/kortschak/qr"
+	"seedhammer.com/bip39"
+	"seedhammer.com/engrave"
+	"seedhammer.com/seedqr"
+)
+
+// Template is the design of the seed side of a plate.
+type Template interface {
+	// Regions reports the content regions of the design.
+	Regions() Region
+	// Layout engraves the seed side of plate, with its seed QR
+	// code, if any, at the error correction level.
+	Layout(params engrave.Params, plate Seed, level qr.Level, plateDims image.Point) (engrave.Plan, error)
+}
+
+// Region is a set of content regions of a Template.
+type Region int
+
+const (
+	// RegionTitle is the wallet title.
+	RegionTitle Region = 1 << iota
+	// RegionWords is the grid of numbered seed words.
+	RegionWords
+	// RegionQR is the SeedQR code.
+	RegionQR
+	// RegionFingerprint is the master fingerprint, key number and
+	// label.
+	RegionFingerprint
+)
+
+// The built-in templates.
+var (
+	// StandardTemplate is the SeedHammer design of SH02 and SH03
+	// plates, with every region.
+	StandardTemplate Template = standardTemplate{}
+	// WordsTemplate engraves the seed words only.
+	WordsTemplate Template = wordsTemplate{}
+	// QRTemplate engraves the SeedQR code only, as large as
+	// fits.
+	QRTemplate Template = qrTemplate{}
+)
+
+type standardTemplate struct{}
+
+func (standardTemplate) Regions() Region {
+	return RegionTitle | RegionWords | RegionQR | RegionFingerprint
+}
+
+func (standardTemplate) Layout(params engrave.Params, plate Seed, level qr.Level, plateDims image.Point) (engrave.Plan, error) {
+	return frontSideSeed(params, plate, level, plateDims)
+}
+
+type wordsTemplate struct{}
+
+func (wordsTemplate) Regions() Region {
+	return RegionWords
+}
+
+func (wordsTemplate) Layout(params engrave.Params, plate Seed, level qr.Level, plateDims image.Point) (engrave.Plan, error) {
+	fontSize := params.F(plateFontSize)
+	constant := engrave.NewConstantStringer(plate.Font, fontSize, bip39.ShortestWord, bip39.LongestWord)
+	// The columns fit the word numbers, a space and the longest
+	// word. Their sizes don't depend on the words, to keep the
+	// positions of the words independent of them.
+	line := engrave.String(plate.Font, fontSize, strings.Repeat("W", 3+bip39.LongestWord)).Measure()
+	rows := (len(plate.Mnemonic) + 1) / 2
+	var cols []engrave.Block
+	for start := 0; start < len(plate.Mnemonic); start += rows {
+		end := min(start+rows, len(plate.Mnemonic))
+		cols = append(cols, engrave.Sized{
+			Plan: wordColumn(constant, plate.Font, fontSize, plate.Mnemonic, start, end),
+			Size: image.Pt(line.X, rows*line.Y),
+		})
+	}
+	return seedArea(params, plate.Size, plateDims, engrave.Columns{
+		Blocks: cols,
+		Gap:    params.I(2),
+	})
+}
+
+type qrTemplate struct{}
+
+func (qrTemplate) Regions() Region {
+	return RegionQR
+}
+
+func (qrTemplate) Layout(params engrave.Params, plate Seed, level qr.Level, plateDims image.Point) (engrave.Plan, error) {
+	margin := params.I(innerMargin)
+	area := plateArea(plate.Size, plateDims)
+	bounds := area.Size().Sub(image.Pt(2*margin, 2*margin))
+	// Constant time QR codes are engraved at scale 3 or 4. The
+	// scale depends on the QR code version only, which in turn
+	// depends on the seed length.
+	code, _, err := engrave.Fit(bounds, 3, 4, func(scale int) engrave.Block {
+		return engrave.QRCode{
+			StrokeWidth: params.StrokeWidth,
+			Scale:       scale,
+			Level:       level,
+			MaxVersion:  plate.QRMaxVersion,
+			Content:     seedqr.QR(plate.Mnemonic),
+			Constant:    true,
+		}
+	})
+	if err != nil {
+		if errors.Is(err, engrave.ErrNoFit) {
+			err = ErrDescriptorTooLarge
+		}
+		return nil, err
+	}
+	return seedArea(params, plate.Size, plateDims, code)
+}
+
+// plateArea returns the area of the seed side clear of the middle
+// holes of large plates.
+func plateArea(size PlateSize, plateDims image.Point) image.Rectangle {
+	area := image.Rectangle{Max: plateDims}
+	if size == LargePlate {
+		// Use the lower square, like the standard template.
+		area.Min.Y = plateDims.Y - plateDims.X
+	}
+	return area
+}
+
+// seedArea engraves content centered in the plate area of the seed
+// side.
+func seedArea(params engrave.Params, size PlateSize, plateDims image.Point, content engrave.Block) (engrave.Plan, error) {
+	area := plateArea(size, plateDims)
+	side := engrave.Frame{
+		Size:   area.Size(),
+		Margin: params.I(innerMargin),
+		Items: []engrave.Anchored{
+			{X: engrave.AlignCenter, Y: engrave.AlignCenter, Block: content},
+		},
+	}
+	p, _, err := side.Layout(area.Dx())
+	if err != nil {
+		if errors.Is(err, engrave.ErrNoFit) {
+			err = ErrDescriptorTooLarge
+		}
+		return nil, err
+	}
+	return engrave.Offset(area.Min.X, area.Min.Y, p), nil
+}
@@ -21,6 +21,46 @@ func Encode(_type string, message []byte, seqNum, seqLen int) string {
 	return fmt.Sprintf("ur:%s/%d-%d/%s", _type, seqNum, seqLen, bytewords.Encode(data))
 }
 
+// Encoder generates the parts of a UR, for example for displaying
+// as an animated QR code.
+type Encoder struct {
+	typ     string
+	message []byte
+	seqLen  int
+	seqNum  int
+}
+
+// NewEncoder creates an Encoder that splits message into fragments of
+// at most maxFragmentLen bytes. A message that fits a single fragment
+// is encoded as a single-part UR.
+func NewEncoder(_type string, message []byte, maxFragmentLen int) *Encoder {
+	if maxFragmentLen < 1 {
+		panic("ur: fragment length must be positive")
+	}
+	seqLen := max((len(message)+maxFragmentLen-1)/maxFragmentLen, 1)
+	return &Encoder{
+		typ:     _type,
+		message: message,
+		seqLen:  seqLen,
+	}
+}
+
+// SeqLen returns the number of fragments of the message.
+func (e *Encoder) SeqLen() int {
+	return e.seqLen
+}
+
+// Next returns the next part. The first SeqLen parts contain one
+// fragment each, after which the parts are mixes of fragments, so a
+// decoder may recover from missed parts. Single-part URs are
+// repeated.
+func (e *Encoder) Next() string {
+	if e.seqLen > 1 {
+		e.seqNum++
+	}
+	return Encode(e.typ, e.message, e.seqNum, e.seqLen)
+}
+
 type Decoder struct {
 	typ  string
 	data []byte
@@ -1,7 +1,9 @@
 package ur
 
 import (
+	"bytes"
 	"encoding/hex"
+	"math/rand"
 	"reflect"
 	"strings"
 	"testing"
@@ -117,3 +119,40 @@ func TestDecode(t *testing.T) {
 		}
 	}
 }
+
+func TestEncoder(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 10, 100, 1000} {
+		msg := make([]byte, n)
+		rng.Read(msg)
+		e := NewEncoder("bytes", msg, 30)
+		if want := (n + 29) / 30; e.SeqLen() != want {
+			t.Errorf("%d bytes: %d fragments, want %d", n, e.SeqLen(), want)
+		}
+		var d Decoder
+		for i := 0; ; i++ {
+			if i > 10*e.SeqLen() {
+				t.Fatalf("%d bytes: no result after %d parts", n, i)
+			}
+			part := e.Next()
+			// Skip every third part to exercise the mixed parts.
+			if e.SeqLen() > 1 && i%3 == 2 {
+				continue
+			}
+			if err := d.Add(part); err != nil {
+				t.Fatalf("%d bytes: %s: %v", n, part, err)
+			}
+			typ, got, err := d.Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got == nil {
+				continue
+			}
+			if typ != "bytes" || !bytes.Equal(got, msg) {
+				t.Errorf("%d bytes: decoded %s %x, want bytes %x", n, typ, got, msg)
+			}
+			break
+		}
+	}
+}
@@ -33,6 +33,9 @@ type KeyDescriptor struct {
 	KeyData           []byte
 	ChainCode         []byte
 	ParentFingerprint uint32
+	// Label is a human readable name of the key, such as the
+	// location of its backup. It is not part of the encoding.
+	Label string
 }
 
 type Derivation struct {
@@ -64,6 +67,11 @@ const (
 	P2WSH
 	P2WPKH
 	P2TR
+	// P2PK is a bare public key script, pk().
+	P2PK
+	// P2MS is a bare multisig script, multi() or sortedmulti()
+	// without a script hash.
+	P2MS
 )
 
 func (s Script) String() string {
@@ -82,6 +90,10 @@ func (s Script) String() string {
 		return "Segwit (P2WPKH)"
 	case P2TR:
 		return "Taproot (P2TR)"
+	case P2PK:
+		return "Bare (P2PK)"
+	case P2MS:
+		return "Bare multisig (P2MS)"
 	default:
 		return "Unknown"
 	}
@@ -92,11 +104,14 @@ type MultisigType int
 const (
 	Singlesig MultisigType = iota
 	SortedMulti
+	// Multi is a multisig script with keys in the order of the
+	// descriptor.
+	Multi
 )
 
 // Singlesig reports whether the script is for single-sig.
 func (s Script) Singlesig() bool {
-	for _, s2 := range []Script{P2PKH, P2WPKH, P2SH_P2WPKH, P2TR} {
+	for _, s2 := range []Script{P2PKH, P2WPKH, P2SH_P2WPKH, P2TR, P2PK} {
 		if s == s2 {
 			return true
 		}
@@ -105,7 +120,8 @@ func (s Script) Singlesig() bool {
 }
 
 // DerivationPath returns the standard derivation path
-// for the script. It panics if the script is unknown.
+// for the script, or nil for bare scripts that have none. It
+// panics if the script is unknown.
 func (s Script) DerivationPath() Path {
 	switch s {
 	case P2WPKH:
@@ -150,6 +166,8 @@ func (s Script) DerivationPath() Path {
 			hdkeychain.HardenedKeyStart + 0,
 			hdkeychain.HardenedKeyStart + 2,
 		}
+	case P2PK, P2MS:
+		return nil
 	}
 	panic("unknown script")
 }
@@ -161,7 +179,7 @@ func (s Script) DerivationPath() Path {
 func (o OutputDescriptor) Encode() []byte {
 	var v any
 	switch o.Type {
-	case SortedMulti:
+	case SortedMulti, Multi:
 		m := struct {
 			Threshold int        `cbor:"1,keyasint,omitempty"`
 			Keys      []cbor.Tag `cbor:"2,keyasint"`
@@ -174,8 +192,12 @@ func (o OutputDescriptor) Encode() []byte {
 				Content: k.toCBOR(),
 			})
 		}
+		tag := uint64(tagSortedMulti)
+		if o.Type == Multi {
+			tag = tagMulti
+		}
 		v = cbor.Tag{
-			Number:  uint64(tagSortedMulti),
+			Number:  tag,
 			Content: m,
 		}
 	case Singlesig:
@@ -202,6 +224,10 @@ func (o OutputDescriptor) Encode() []byte {
 		tags = []uint64{tagWPKH}
 	case P2TR:
 		tags = []uint64{tagTR}
+	case P2PK:
+		tags = []uint64{tagPK}
+	case P2MS:
+		// Bare multisig has no script tag.
 	default:
 		panic("invalid type")
 	}
@@ -246,7 +272,7 @@ func (k KeyDescriptor) toCBOR() hdKey {
 		case ChildDerivation:
 			children = append(children, c.Index, c.Hardened)
 		case RangeDerivation:
-			children = append(children, c.Index, c.End, c.Hardened)
+			children = append(children, []any{c.Index, c.End}, c.Hardened)
 		case WildcardDerivation:
 			children = append(children, []any{}, c.Hardened)
 		}
@@ -366,6 +392,7 @@ const (
 
 	tagSH    = 400
 	tagWSH   = 401
+	tagPK    = 402
 	tagP2PKH = 403
 	tagWPKH  = 404
 	tagTR    = 409
@@ -409,23 +436,10 @@ func Parse(typ string, enc []byte) (any, error) {
 		}
 		return s, nil
 	case "crypto-account":
-		// Limited support for crypto-account: unpack a single output
-		// descriptor and treat it like crypto-output.
-		var acc account
-		if err := decMode.Unmarshal(enc, &acc); err != nil {
-			return nil, fmt.Errorf("ur: crypto-account: %w", err)
-		}
-		if len(acc.OutputDescriptors) != 1 {
-			return nil, fmt.Errorf("ur: crypto-account: zero or multiple crypto-outputs")
-		}
-		enc = acc.OutputDescriptors[0]
-		desc, err := parseOutputDescriptor(decMode, acc.OutputDescriptors[0])
+		desc, err := parseAccount(enc)
 		if err != nil {
 			return nil, fmt.Errorf("ur: crypto-account: %w", err)
 		}
-		if !desc.Script.Singlesig() {
-			return nil, fmt.Errorf("ur: crypto-account: invalid single-sig script: %s", desc.Script)
-		}
 		return desc, nil
 	case "crypto-output":
 		desc, err := parseOutputDescriptor(decMode, enc)
@@ -450,6 +464,65 @@ func Parse(typ string, enc []byte) (any, error) {
 	}
 }
 
+// accountScripts lists the single-sig scripts of crypto-account
+// outputs, in order of preference.
+var accountScripts = []Script{P2WPKH, P2TR, P2SH_P2WPKH, P2PKH}
+
+// parseAccount decodes a crypto-account and returns the single-sig
+// output descriptor of the most preferred script. Outputs for other
+// scripts, such as multisig cosigner keys, are ignored.
+func parseAccount(enc []byte) (OutputDescriptor, error) {
+	var acc account
+	if err := decMode.Unmarshal(enc, &acc); err != nil {
+		return OutputDescriptor{}, err
+	}
+	if len(acc.OutputDescriptors) == 0 {
+		return OutputDescriptor{}, errors.New("no crypto-outputs")
+	}
+	var descs []OutputDescriptor
+	var lastErr error
+	for _, o := range acc.OutputDescriptors {
+		desc, err := parseOutputDescriptor(decMode, o)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if !desc.Script.Singlesig() {
+			lastErr = fmt.Errorf("invalid single-sig script: %s", desc.Script)
+			continue
+		}
+		if k := &desc.Keys[0]; k.MasterFingerprint == 0 {
+			k.MasterFingerprint = acc.MasterFingerprint
+		}
+		descs = append(descs, desc)
+	}
+	for _, s := range accountScripts {
+		for _, desc := range descs {
+			if desc.Script == s {
+				return desc, nil
+			}
+		}
+	}
+	return OutputDescriptor{}, lastErr
+}
+
+// SinglesigDescriptor returns the single-sig output descriptor of k,
+// if its derivation path is the standard path of a single-sig script.
+func (k KeyDescriptor) SinglesigDescriptor() (OutputDescriptor, bool) {
+	for _, s := range accountScripts {
+		if !reflect.DeepEqual(s.DerivationPath(), k.DerivationPath) {
+			continue
+		}
+		return OutputDescriptor{
+			Type:      Singlesig,
+			Threshold: 1,
+			Script:    s,
+			Keys:      []KeyDescriptor{k},
+		}, true
+	}
+	return OutputDescriptor{}, false
+}
+
 const mainnet = 0
 const testnet = 1
 
@@ -528,6 +601,12 @@ func parseOutputDescriptor(mode cbor.DecMode, enc []byte) (OutputDescriptor, err
 	first := tags[0]
 	tags = tags[1:]
 	switch first {
+	case tagMulti, tagSortedMulti:
+		// Bare multisig.
+		desc.Script = P2MS
+		tags = append([]uint64{first}, tags...)
+	case tagPK:
+		desc.Script = P2PK
 	case tagSH:
 		desc.Script = P2SH
 		if len(tags) == 0 {
@@ -569,8 +648,11 @@ func parseOutputDescriptor(mode cbor.DecMode, enc []byte) (OutputDescriptor, err
 		}
 		desc.Threshold = 1
 		desc.Keys = append(desc.Keys, k)
-	case tagSortedMulti:
+	case tagSortedMulti, tagMulti:
 		desc.Type = SortedMulti
+		if funcNumber == tagMulti {
+			desc.Type = Multi
+		}
 		var m multi
 		if err := mode.Unmarshal(enc, &m); err != nil {
 			return OutputDescriptor{}, err
@@ -586,6 +668,9 @@ func parseOutputDescriptor(mode cbor.DecMode, enc []byte) (OutputDescriptor, err
 	default:
 		return desc, fmt.Errorf("unknown script function tag: %d", funcNumber)
 	}
+	if desc.Script == P2PK && desc.Type != Singlesig {
+		return OutputDescriptor{}, errors.New("ur: multisig bare public key")
+	}
 	return desc, nil
 }
 
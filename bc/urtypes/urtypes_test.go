@@ -68,10 +68,20 @@ func TestOutputDescriptor(t *testing.T) {
 			},
 		},
 	}
+	bareKey := twoOfThree.Keys[0]
+	bareKey.DerivationPath = nil
 	tests := []struct {
 		desc OutputDescriptor
 		want string
 	}{
+		{
+			OutputDescriptor{Script: P2PK, Threshold: 1, Keys: []KeyDescriptor{bareKey}},
+			"d90192d9012fa4035821022196adc25fde169fe92e70769059102275d2b40cc98776eaab92b82a86135e92045820438eff7b3b36b6d11a60a22ccb9306eea305b0439f1ea09d5928015de373811606d90130a1021add4fadee081a22969377",
+		},
+		{
+			OutputDescriptor{Script: P2MS, Threshold: 2, Type: Multi, Keys: twoOfThree.Keys[:2]},
+			"d90196a201020282d9012fa4035821022196adc25fde169fe92e70769059102275d2b40cc98776eaab92b82a86135e92045820438eff7b3b36b6d11a60a22ccb9306eea305b0439f1ea09d5928015de373811606d90130a201881830f500f500f502f5021add4fadee081a22969377d9012fa403582102fb72507fc20ddba92991b17c4bb466130ad93a886e73175033bb43e3bc785a6d04582095b34913937fa5f1c6205b525bb57de1517625e04586b595be68e71362d3edc506d90130a201881830f500f500f502f5021a9bacd5c0081a97ec38f9",
+		},
 		{
 			OutputDescriptor{
 				Script:    P2WSH,
@@ -125,6 +135,25 @@ func TestOutputDescriptor(t *testing.T) {
 			},
 			"d90194d9012fa4035821033ed51bcff930c614e861bfedff57699b67085a9f197775bcc541a9ebe8268de9045820212399a8db125c85f941ea12231d8b5c7a76b83e01d03d16c53958c518284f4506d90130a201861854f500f500f5021a9c43e6c2081ad1e5a62d",
 		},
+		{
+			OutputDescriptor{
+				Script: P2WPKH, Threshold: 1, Keys: []KeyDescriptor{
+					{
+						Network:           &chaincfg.MainNetParams,
+						MasterFingerprint: 0x9c43e6c2,
+						DerivationPath:    Path{hdkeychain.HardenedKeyStart + 84, hdkeychain.HardenedKeyStart, hdkeychain.HardenedKeyStart},
+						KeyData:           []uint8{0x3, 0x3e, 0xd5, 0x1b, 0xcf, 0xf9, 0x30, 0xc6, 0x14, 0xe8, 0x61, 0xbf, 0xed, 0xff, 0x57, 0x69, 0x9b, 0x67, 0x8, 0x5a, 0x9f, 0x19, 0x77, 0x75, 0xbc, 0xc5, 0x41, 0xa9, 0xeb, 0xe8, 0x26, 0x8d, 0xe9},
+						ChainCode:         []uint8{0x21, 0x23, 0x99, 0xa8, 0xdb, 0x12, 0x5c, 0x85, 0xf9, 0x41, 0xea, 0x12, 0x23, 0x1d, 0x8b, 0x5c, 0x7a, 0x76, 0xb8, 0x3e, 0x1, 0xd0, 0x3d, 0x16, 0xc5, 0x39, 0x58, 0xc5, 0x18, 0x28, 0x4f, 0x45},
+						Children: []Derivation{
+							{Type: RangeDerivation, Index: 0, End: 1},
+							{Type: WildcardDerivation},
+						},
+						ParentFingerprint: 0xd1e5a62d,
+					},
+				},
+			},
+			"d90194d9012fa5035821033ed51bcff930c614e861bfedff57699b67085a9f197775bcc541a9ebe8268de9045820212399a8db125c85f941ea12231d8b5c7a76b83e01d03d16c53958c518284f4506d90130a201861854f500f500f5021a9c43e6c207d90130a10184820001f480f4081ad1e5a62d",
+		},
 		{
 			OutputDescriptor{
 				Script: P2SH_P2WPKH, Threshold: 1, Keys: []KeyDescriptor{
@@ -321,3 +350,51 @@ func TestIncompleteCryptoAccount(t *testing.T) {
 		t.Fatalf("invalid crypto-account %s parsed succesfully", enc)
 	}
 }
+
+func TestCryptoAccountMultipleOutputs(t *testing.T) {
+	key := func(s Script) KeyDescriptor {
+		return KeyDescriptor{
+			Network:           &chaincfg.MainNetParams,
+			DerivationPath:    s.DerivationPath(),
+			KeyData:           []uint8{0x2, 0xa1, 0xe9, 0xcd, 0x9e, 0xfc, 0x5, 0x1f, 0x3e, 0x3, 0x74, 0xbf, 0x21, 0x39, 0x90, 0xd2, 0x3b, 0xf3, 0xd7, 0x7f, 0xdd, 0xf1, 0x72, 0xbc, 0xc6, 0x23, 0x43, 0xc4, 0xd7, 0x82, 0xe7, 0x80, 0xec},
+			ChainCode:         []uint8{0x3f, 0xac, 0x4d, 0x0, 0x92, 0x28, 0x2, 0xa9, 0xf2, 0xbd, 0x52, 0xc, 0xc4, 0x51, 0x22, 0x30, 0xcf, 0x29, 0xb, 0x4a, 0x5d, 0x29, 0x7e, 0x5d, 0x3a, 0x69, 0xb9, 0x9f, 0x6, 0x57, 0x7f, 0x66},
+			ParentFingerprint: 0x43ecdeeb,
+		}
+	}
+	acc := account{MasterFingerprint: 0x4bbaa801}
+	for _, s := range []Script{P2PKH, P2WSH, P2WPKH, P2SH_P2WPKH} {
+		desc := OutputDescriptor{
+			Script:    s,
+			Threshold: 1,
+			Type:      Singlesig,
+			Keys:      []KeyDescriptor{key(s)},
+		}
+		acc.OutputDescriptors = append(acc.OutputDescriptors, desc.Encode())
+	}
+	enc, err := encMode.Marshal(acc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse("crypto-account", enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := key(P2WPKH)
+	want.MasterFingerprint = acc.MasterFingerprint
+	wantDesc := OutputDescriptor{
+		Script:    P2WPKH,
+		Threshold: 1,
+		Type:      Singlesig,
+		Keys:      []KeyDescriptor{want},
+	}
+	if !reflect.DeepEqual(parsed, wantDesc) {
+		t.Errorf("crypto-account decoded to\n%+v\nexpected\n%+v", parsed, wantDesc)
+	}
+	got, ok := want.SinglesigDescriptor()
+	if !ok || !reflect.DeepEqual(got, wantDesc) {
+		t.Errorf("key converted to\n%+v\nexpected\n%+v", got, wantDesc)
+	}
+	if _, ok := key(P2WSH).SinglesigDescriptor(); ok {
+		t.Error("multisig key converted to single-sig descriptor")
+	}
+}
@@ -3,7 +3,12 @@
 package bip32
 
 import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
 	"seedhammer.com/bc/urtypes"
 )
 
@@ -21,3 +26,82 @@ func Derive(mk *hdkeychain.ExtendedKey, path urtypes.Path) (mfp uint32, xpub *hd
 	xpub, err = key.Neuter()
 	return
 }
+
+// version is a SLIP-132 version of extended public keys.
+type version struct {
+	Bytes   uint32
+	Script  urtypes.Script
+	Network *chaincfg.Params
+}
+
+// versions lists the supported SLIP-132 versions. The plain xpub
+// and tpub versions come first and imply the P2PKH script.
+//
+// [SLIP-132]: https://github.com/satoshilabs/slips/blob/master/slip-0132.md
+var versions = []version{
+	{0x0488b21e, urtypes.P2PKH, &chaincfg.MainNetParams},        // xpub
+	{0x043587cf, urtypes.P2PKH, &chaincfg.TestNet3Params},       // tpub
+	{0x049d7cb2, urtypes.P2SH_P2WPKH, &chaincfg.MainNetParams},  // ypub
+	{0x04b24746, urtypes.P2WPKH, &chaincfg.MainNetParams},       // zpub
+	{0x0295b43f, urtypes.P2SH_P2WSH, &chaincfg.MainNetParams},   // Ypub
+	{0x02aa7ed3, urtypes.P2WSH, &chaincfg.MainNetParams},        // Zpub
+	{0x044a5262, urtypes.P2SH_P2WPKH, &chaincfg.TestNet3Params}, // upub
+	{0x045f1cf6, urtypes.P2WPKH, &chaincfg.TestNet3Params},      // vpub
+	{0x024289ef, urtypes.P2SH_P2WSH, &chaincfg.TestNet3Params},  // Upub
+	{0x02575483, urtypes.P2WSH, &chaincfg.TestNet3Params},       // Vpub
+}
+
+// ParseKey parses an extended public key in any of the SLIP-132
+// versions, along with its implied script. Plain xpubs and tpubs
+// imply the P2PKH script. The returned key has the standard version
+// bytes of its network.
+func ParseKey(k string) (urtypes.Script, *hdkeychain.ExtendedKey, error) {
+	key, err := hdkeychain.NewKeyFromString(k)
+	if err != nil {
+		return 0, nil, fmt.Errorf("bip32: invalid extended key: %q", k)
+	}
+	ver := binary.BigEndian.Uint32(key.Version())
+	for _, v := range versions {
+		if v.Bytes == ver {
+			key.SetNet(v.Network)
+			return v.Script, key, nil
+		}
+	}
+	return 0, nil, fmt.Errorf("bip32: unsupported version: %.8x", ver)
+}
+
+// FormatKey serializes the extended public key with the SLIP-132
+// version for script on the network of the key. Scripts without a
+// SLIP-132 version, such as P2SH and P2TR, use the plain xpub or tpub
+// version.
+func FormatKey(key *hdkeychain.ExtendedKey, script urtypes.Script) (string, error) {
+	if key.IsPrivate() {
+		return "", errors.New("bip32: private extended key")
+	}
+	var net *chaincfg.Params
+	ver := binary.BigEndian.Uint32(key.Version())
+	for _, v := range versions {
+		if v.Bytes == ver {
+			net = v.Network
+			break
+		}
+	}
+	if net == nil {
+		return "", fmt.Errorf("bip32: unsupported version: %.8x", ver)
+	}
+	// Fall back to the plain version, listed first.
+	ver = 0
+	for _, v := range versions {
+		if v.Network != net {
+			continue
+		}
+		if ver == 0 || v.Script == script {
+			ver = v.Bytes
+		}
+	}
+	k, err := key.CloneWithVersion(binary.BigEndian.AppendUint32(nil, ver))
+	if err != nil {
+		return "", fmt.Errorf("bip32: %w", err)
+	}
+	return k.String(), nil
+}
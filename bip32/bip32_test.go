@@ -0,0 +1,62 @@
+package bip32
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"seedhammer.com/bc/urtypes"
+)
+
+func TestSLIP132(t *testing.T) {
+	const (
+		xpub = "xpub6C9j4wAxxkWN4cq8G4N2mkV6NrGGhnLFCGdh8GsYY1xreEveW5YEXJMjDZWLAcnZ26xqVft5FmgBxPixdMGoVQZMdtEJRRADxrn4facoGnx"
+		tpub = "tpubDCXMbAzeg2TpLR1yiFM7yfpThyMvhAqJjuDzUpvgsvikPXbMaJPKfk2ZTbb7h7jnp1Vk7FPwnsWEeaDa2D83Nr1ehUyc6wpTYpNURb6Qt26"
+	)
+	tests := []struct {
+		key     string
+		script  urtypes.Script
+		network *chaincfg.Params
+		normal  string
+	}{
+		{xpub, urtypes.P2PKH, &chaincfg.MainNetParams, xpub},
+		{"ypub6WyzNbqt7S3quv2F6R9eyqabYpQieQKk7P9uufmRv2LjhLjskjho9N1sEmTvAXSURk5eF9UdiS2jqgLXM3gpHeExWDvj1KyiEaqi47h3Ef1", urtypes.P2SH_P2WPKH, &chaincfg.MainNetParams, xpub},
+		{"zpub6qpFgGWoG7bKmDDMvmwHBvg6inZAb2KF2Vg8h4fKJ2ickSZ71PsMmRg1FyRWAS6PqPCSzd5CB6PHixx64k6q5svZNZd9bEoCWJuMSkSRzJx", urtypes.P2WPKH, &chaincfg.MainNetParams, xpub},
+		{"Ypub6ht5VqaKgPcDLVBd35cdouvQGcSyrm1LReoapw2yHoB9KXJnX965EUso3URPixfNfD9d7jUkbeRExqxHeGqmS8MdLh38QjSi8K7ae5rcihQ", urtypes.P2SH_P2WSH, &chaincfg.MainNetParams, xpub},
+		{"Zpub72iLoWFEq59hBnNjsSQG211uSabRoNzqLmKocKvrfoZ2Nd81moFdrYXw4gNyisKJ4rGRsD5K4Jmnr8ZrMyFnEN3ED2jYzeGCQ3BE2fiCsDJ", urtypes.P2WSH, &chaincfg.MainNetParams, xpub},
+		{tpub, urtypes.P2PKH, &chaincfg.TestNet3Params, tpub},
+		{"upub5Dew9wADWhsvWjFmkz1A9VCarwpvsvMkSw52n6BtPzqDUwUxk73Yf7PK9wdaAtpnoBcRFF6PsncYJXtGUG2m6hWZ2s92fghm9gb8Voj5yXL", urtypes.P2SH_P2WPKH, &chaincfg.TestNet3Params, tpub},
+		{"vpub5YVCTbq8fPRQN2StbLnnMaJ62uyNpYMFN3bFZV5mn1D6Y3JBzmD7HB3TB9bAAoUiCpjDzigxLSy6BpVqBxSmtwC9uCqTFbXFRQemtQXQnHC", urtypes.P2WPKH, &chaincfg.TestNet3Params, tpub},
+		{"Upub5QZ2HAtf5fSHwJR9heU8yZYPajsC6H3LmCihhMTRmmfd783sWWRpkEFExeb3jL3h2egQ7q6Wm113RhW2mVBiFBdDsLFS56Am3Qs15obYCjB", urtypes.P2SH_P2WSH, &chaincfg.TestNet3Params, tpub},
+		{"Vpub5jPHaqZaELymnbcGY1FmBedtki1e2u2qgKEvUkMK9n3WADs6mAbPNHuNyrYdjEhcSHoCsJh5DfMbJz7bVBbj3RJpjfwrezzFK8veUNUnEoW", urtypes.P2WSH, &chaincfg.TestNet3Params, tpub},
+	}
+	for _, test := range tests {
+		script, key, err := ParseKey(test.key)
+		if err != nil {
+			t.Fatalf("%s: %v", test.key, err)
+		}
+		if script != test.script {
+			t.Errorf("%s: script %v, want %v", test.key, script, test.script)
+		}
+		if !key.IsForNet(test.network) {
+			t.Errorf("%s: not for network %s", test.key, test.network.Name)
+		}
+		if got := key.String(); got != test.normal {
+			t.Errorf("%s: normalized to %s, want %s", test.key, got, test.normal)
+		}
+		enc, err := FormatKey(key, script)
+		if err != nil {
+			t.Fatalf("%s: %v", test.key, err)
+		}
+		if enc != test.key {
+			t.Errorf("%s: formatted as %s", test.key, enc)
+		}
+	}
+	// Taproot has no SLIP-132 version.
+	_, key, err := ParseKey(xpub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc, err := FormatKey(key, urtypes.P2TR); err != nil || enc != xpub {
+		t.Errorf("P2TR key formatted as %s, %v; want %s", enc, err, xpub)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"iter"
 	"math"
 	"math/big"
 	"sort"
@@ -105,6 +106,36 @@ func ClosestWord(word string) (Word, bool) {
 	return Word(i), strings.HasPrefix(match, word)
 }
 
+// Suggest returns an iterator over the words that start with
+// prefix, in word list order.
+func Suggest(prefix string) iter.Seq[Word] {
+	return func(yield func(Word) bool) {
+		w, ok := ClosestWord(prefix)
+		if !ok {
+			return
+		}
+		for ; w < NumWords && strings.HasPrefix(LabelFor(w), prefix); w++ {
+			if !yield(w) {
+				return
+			}
+		}
+	}
+}
+
+// NextLetters returns the letters that continue prefix towards a
+// word, as a set with bit i set for the letter 'a'+i, along with the
+// number of words that start with prefix. Input methods use it to
+// restrict the letters accepted after prefix.
+func NextLetters(prefix string) (letters uint32, matches int) {
+	for w := range Suggest(prefix) {
+		matches++
+		if suffix := LabelFor(w)[len(prefix):]; len(suffix) > 0 {
+			letters |= 1 << (suffix[0] - 'a')
+		}
+	}
+	return letters, matches
+}
+
 // Valid reports whether the mnemonic checksum is correct.
 func (m Mnemonic) Valid() bool {
 	// Panics in splitMnemonic.
@@ -127,6 +158,27 @@ func (m Mnemonic) FixChecksum() Mnemonic {
 	return m2
 }
 
+// FinalWords returns the words, in word list order, that complete
+// the mnemonic with a correct checksum. The last word of m is
+// ignored.
+func (m Mnemonic) FinalWords() []Word {
+	if len(m)%3 != 0 {
+		return nil
+	}
+	m2 := make(Mnemonic, len(m))
+	copy(m2, m)
+	checkBits := len(m) / 3
+	var words []Word
+	// The last word holds the remaining entropy bits followed by
+	// the checksum.
+	for i := range NumWords >> checkBits {
+		m2[len(m2)-1] = i << checkBits
+		ent, _ := splitMnemonic(m2)
+		words = append(words, ChecksumWord(ent))
+	}
+	return words
+}
+
 // Entropy returns the entropy represented by the mnemonic. It
 // panics if the mnemonic is invalid.
 func (m Mnemonic) Entropy() []byte {
@@ -137,6 +189,27 @@ func (m Mnemonic) Entropy() []byte {
 	return ent
 }
 
+// NewMnemonic returns the mnemonic that encodes entropy. The entropy
+// must be between 16 and 32 bytes long, in multiples of 4.
+func NewMnemonic(entropy []byte) (Mnemonic, error) {
+	n := len(entropy)
+	if n < 16 || n > 32 || n%4 != 0 {
+		return nil, fmt.Errorf("bip39: invalid entropy length: %d", n)
+	}
+	const wordBits = 11
+	checkBits := n / 4
+	ent := new(big.Int).SetBytes(entropy)
+	ent.Lsh(ent, uint(checkBits))
+	ent.Or(ent, big.NewInt(int64(Checksum(entropy))))
+	m := make(Mnemonic, (n*8+checkBits)/wordBits)
+	mask := big.NewInt(1<<wordBits - 1)
+	for i := len(m) - 1; i >= 0; i-- {
+		m[i] = Word(new(big.Int).And(ent, mask).Int64())
+		ent.Rsh(ent, wordBits)
+	}
+	return m, nil
+}
+
 func splitMnemonic(m Mnemonic) (entropy []byte, checksum byte) {
 	ent := big.NewInt(0)
 	const wordBits = 11
@@ -3,6 +3,7 @@ package bip39
 import (
 	"bytes"
 	"encoding/hex"
+	"slices"
 	"testing"
 )
 
@@ -30,6 +31,31 @@ func TestVectors(t *testing.T) {
 	}
 }
 
+func TestNewMnemonic(t *testing.T) {
+	for _, v := range testVectors {
+		e, err := hex.DecodeString(v.entropy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := NewMnemonic(e)
+		if err != nil {
+			t.Fatalf("entropy %s: %v", v.entropy, err)
+		}
+		want, err := ParseMnemonic(v.mnemonic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(m, want) {
+			t.Errorf("entropy %s encoded to %v, want %v", v.entropy, m, want)
+		}
+	}
+	for _, n := range []int{0, 12, 17, 36} {
+		if _, err := NewMnemonic(make([]byte, n)); err == nil {
+			t.Errorf("NewMnemonic accepted %d bytes of entropy", n)
+		}
+	}
+}
+
 func TestInvalidSeeds(t *testing.T) {
 	tests := []string{
 		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
@@ -56,6 +82,72 @@ func TestChecksumWord(t *testing.T) {
 	}
 }
 
+func TestFinalWords(t *testing.T) {
+	for _, n := range []int{12, 24} {
+		mnemonic := make(Mnemonic, n)
+		for j := range mnemonic {
+			mnemonic[j] = RandomWord()
+		}
+		words := mnemonic.FinalWords()
+		if want := 1 << (11 - n/3); len(words) != want {
+			t.Errorf("%d words: got %d final words, want %d", n, len(words), want)
+		}
+		valid := 0
+		for w := range NumWords {
+			mnemonic[n-1] = w
+			if mnemonic.Valid() {
+				if valid >= len(words) || words[valid] != w {
+					t.Errorf("%d words: valid final word %s missing", n, LabelFor(w))
+				}
+				valid++
+			}
+		}
+		if valid != len(words) {
+			t.Errorf("%d words: got %d final words, %d are valid", n, len(words), valid)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	var got []string
+	for w := range Suggest("act") {
+		got = append(got, LabelFor(w))
+	}
+	if want := []string{"act", "action", "actor", "actress", "actual"}; !slices.Equal(got, want) {
+		t.Errorf("Suggest(\"act\") = %v, want %v", got, want)
+	}
+	n := 0
+	for range Suggest("") {
+		n++
+	}
+	if n != int(NumWords) {
+		t.Errorf("Suggest(\"\") returned %d words, want %d", n, NumWords)
+	}
+	letters := func(s string) uint32 {
+		var l uint32
+		for _, r := range s {
+			l |= 1 << (r - 'a')
+		}
+		return l
+	}
+	tests := []struct {
+		prefix  string
+		letters string
+		matches int
+	}{
+		{"act", "ioru", 5},
+		{"zo", "no", 2},
+		{"zoo", "", 1},
+		{"xy", "", 0},
+	}
+	for _, test := range tests {
+		l, n := NextLetters(test.prefix)
+		if want := letters(test.letters); l != want || n != test.matches {
+			t.Errorf("NextLetters(%q) = %#x, %d, want %#x, %d", test.prefix, l, n, want, test.matches)
+		}
+	}
+}
+
 var testVectors = []struct {
 	entropy  string
 	mnemonic string
@@ -0,0 +1,84 @@
+package bip39
+
+import (
+	"sort"
+)
+
+// Repairs returns the mnemonics with a correct checksum that differ
+// from m by a transposition of two words or by the substitution of a
+// single word, the most common mistakes of transcribing a mnemonic.
+// Transpositions come first, followed by substitutions ordered by
+// the similarity of the substituted word to the original.
+func Repairs(m Mnemonic) []Mnemonic {
+	if len(m)%3 != 0 {
+		return nil
+	}
+	type repair struct {
+		m    Mnemonic
+		dist int
+	}
+	var repairs []repair
+	for i := range m {
+		for j := i + 1; j < len(m); j++ {
+			if m[i] == m[j] {
+				continue
+			}
+			c := make(Mnemonic, len(m))
+			copy(c, m)
+			c[i], c[j] = c[j], c[i]
+			if c.Valid() {
+				repairs = append(repairs, repair{m: c})
+			}
+		}
+	}
+	c := make(Mnemonic, len(m))
+	copy(c, m)
+	for i, orig := range m {
+		for w := range NumWords {
+			if w == orig {
+				continue
+			}
+			c[i] = w
+			if !c.Valid() {
+				continue
+			}
+			r := make(Mnemonic, len(m))
+			copy(r, c)
+			repairs = append(repairs, repair{
+				m:    r,
+				dist: editDistance(LabelFor(orig), LabelFor(w)),
+			})
+		}
+		c[i] = orig
+	}
+	clear(c)
+	sort.SliceStable(repairs, func(i, j int) bool {
+		return repairs[i].dist < repairs[j].dist
+	})
+	res := make([]Mnemonic, len(repairs))
+	for i, r := range repairs {
+		res[i] = r.m
+	}
+	return res
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range len(a) {
+		cur[0] = i + 1
+		for j := range len(b) {
+			cost := 1
+			if a[i] == b[j] {
+				cost = 0
+			}
+			cur[j+1] = min(prev[j+1]+1, cur[j]+1, prev[j]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
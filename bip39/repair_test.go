@@ -0,0 +1,65 @@
+package bip39
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRepairs(t *testing.T) {
+	orig, err := ParseMnemonic("legal winner thank year wave sausage worth useful legal winner thank yellow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		corrupt func(m Mnemonic)
+	}{
+		{"swap", func(m Mnemonic) { m[2], m[3] = m[3], m[2] }},
+		// "worth" misread as "north".
+		{"substitution", func(m Mnemonic) {
+			w, _ := ClosestWord("north")
+			m[6] = w
+		}},
+	}
+	for _, test := range tests {
+		m := slices.Clone(orig)
+		test.corrupt(m)
+		if m.Valid() {
+			t.Fatalf("%s: corrupted mnemonic is valid", test.name)
+		}
+		repairs := Repairs(m)
+		idx := slices.IndexFunc(repairs, func(r Mnemonic) bool {
+			return slices.Equal(r, orig)
+		})
+		if idx == -1 {
+			t.Errorf("%s: original mnemonic not among the repairs", test.name)
+			continue
+		}
+		// The original is among the most similar repairs.
+		if idx > 5 {
+			t.Errorf("%s: original mnemonic ranked %d of %d", test.name, idx+1, len(repairs))
+		}
+		for _, r := range repairs {
+			if !r.Valid() {
+				t.Errorf("%s: invalid repair %v", test.name, r)
+			}
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"wave", "wage", 1},
+		{"wave", "wave", 0},
+		{"abandon", "about", 5},
+		{"", "zoo", 3},
+	}
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b); got != test.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
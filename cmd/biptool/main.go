@@ -0,0 +1,159 @@
+// command biptool is a tool for inspecting bitcoin seeds, keys and output
+// descriptors offline.
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"seedhammer.com/address"
+	"seedhammer.com/bip39"
+	"seedhammer.com/nonstandard"
+	"seedhammer.com/seedqr"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "biptool: %v\n", err)
+		}
+		os.Exit(2)
+	}
+}
+
+const usage = `usage: biptool <command> [flags]
+
+The -qr and -png flags render the output as a QR code. Mnemonics are
+rendered in the SeedQR format.
+
+Commands:
+  address        derive receive or change addresses from an output descriptor
+  bip39 encode   convert entropy to a BIP39 mnemonic
+  bip39 decode   convert a BIP39 mnemonic to entropy and seed
+`
+
+func run(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return flag.ErrHelp
+	}
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "address":
+		return addressCmd(args)
+	case "bip39":
+		return bip39Cmd(args)
+	case "help", "-h", "-help":
+		fmt.Fprint(os.Stderr, usage)
+		return flag.ErrHelp
+	default:
+		return fmt.Errorf("unknown command: %s", cmd)
+	}
+}
+
+func addressCmd(args []string) error {
+	fs := flag.NewFlagSet("address", flag.ContinueOnError)
+	desc := fs.String("desc", "", "output descriptor or extended public key")
+	n := fs.Int("n", 20, "number of addresses")
+	start := fs.Uint("start", 0, "index of first address")
+	change := fs.Bool("change", false, "derive change addresses")
+	var qr qrOutput
+	qr.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *desc == "" {
+		return errors.New("address: specify a descriptor with -desc")
+	}
+	if qr.png != "" && *n != 1 {
+		return errors.New("address: -png requires -n 1")
+	}
+	d, err := nonstandard.OutputDescriptor([]byte(*desc))
+	if err != nil {
+		return fmt.Errorf("address: %w", err)
+	}
+	derive := address.Receive
+	if *change {
+		derive = address.Change
+	}
+	for i := range *n {
+		idx := uint32(*start) + uint32(i)
+		addr, err := derive(d, idx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d: %s\n", idx, addr)
+		if err := qr.write([]byte(addr)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func bip39Cmd(args []string) error {
+	if len(args) == 0 {
+		return errors.New("bip39: specify encode or decode")
+	}
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "encode":
+		return bip39Encode(args)
+	case "decode":
+		return bip39Decode(args)
+	default:
+		return fmt.Errorf("bip39: unknown command: %s", cmd)
+	}
+}
+
+func bip39Encode(args []string) error {
+	fs := flag.NewFlagSet("bip39 encode", flag.ContinueOnError)
+	entropy := fs.String("entropy", "", "entropy in hex")
+	pass := fs.String("passphrase", "", "passphrase for computing the seed")
+	var qr qrOutput
+	qr.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ent, err := hex.DecodeString(*entropy)
+	if err != nil {
+		return fmt.Errorf("bip39: invalid entropy: %w", err)
+	}
+	m, err := bip39.NewMnemonic(ent)
+	if err != nil {
+		return err
+	}
+	printMnemonic(m, *pass)
+	return qr.write(seedqr.QR(m))
+}
+
+func bip39Decode(args []string) error {
+	fs := flag.NewFlagSet("bip39 decode", flag.ContinueOnError)
+	mnemonic := fs.String("mnemonic", "", "space separated mnemonic words")
+	pass := fs.String("passphrase", "", "passphrase for computing the seed")
+	var qr qrOutput
+	qr.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	words := strings.Fields(strings.ToLower(*mnemonic))
+	m, err := bip39.ParseMnemonic(strings.Join(words, " "))
+	if err != nil {
+		return err
+	}
+	printMnemonic(m, *pass)
+	return qr.write(seedqr.QR(m))
+}
+
+func printMnemonic(m bip39.Mnemonic, pass string) {
+	words := make([]string, len(m))
+	for i, w := range m {
+		words[i] = bip39.LabelFor(w)
+	}
+	fmt.Printf("mnemonic: %s\n", strings.Join(words, " "))
+	fmt.Printf("entropy: %x\n", m.Entropy())
+	fmt.Printf("seed: %x\n", bip39.MnemonicSeed(m, pass))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/kortschak/qr"
+)
+
+// qrOutput renders command output as QR codes.
+type qrOutput struct {
+	terminal bool
+	png      string
+}
+
+func (q *qrOutput) register(fs *flag.FlagSet) {
+	fs.BoolVar(&q.terminal, "qr", false, "render output as a QR code on the terminal")
+	fs.StringVar(&q.png, "png", "", "write output QR code to PNG `file`")
+}
+
+func (q *qrOutput) enabled() bool {
+	return q.terminal || q.png != ""
+}
+
+func (q *qrOutput) write(content []byte) error {
+	if !q.enabled() {
+		return nil
+	}
+	c, err := qr.Encode(string(content), qr.M)
+	if err != nil {
+		return err
+	}
+	if q.terminal {
+		if err := printQR(os.Stdout, c); err != nil {
+			return err
+		}
+	}
+	if q.png != "" {
+		const scale = 8
+		c.Scale = scale
+		if err := os.WriteFile(q.png, c.PNG(), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printQR renders a QR code with UTF-8 half blocks, two modules to a
+// character. Colors are forced to black on white to make the code
+// scannable regardless of the terminal color scheme.
+func printQR(w io.Writer, c *qr.Code) error {
+	const (
+		quietZone = 4
+		colors    = "\x1b[30;47m"
+		reset     = "\x1b[0m"
+	)
+	bw := bufio.NewWriter(w)
+	for y := -quietZone; y < c.Size+quietZone; y += 2 {
+		bw.WriteString(colors)
+		for x := -quietZone; x < c.Size+quietZone; x++ {
+			top, bottom := c.Black(x, y), c.Black(x, y+1)
+			switch {
+			case top && bottom:
+				bw.WriteString("█")
+			case top:
+				bw.WriteString("▀")
+			case bottom:
+				bw.WriteString("▄")
+			default:
+				bw.WriteByte(' ')
+			}
+		}
+		bw.WriteString(reset)
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
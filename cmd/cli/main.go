@@ -2,39 +2,71 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"flag"
 	"fmt"
 	"image"
 	"image/png"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/kortschak/qr"
 	"seedhammer.com/backup"
 	"seedhammer.com/bc/urtypes"
 	"seedhammer.com/bip32"
 	"seedhammer.com/bip39"
 	"seedhammer.com/driver/mjolnir"
 	"seedhammer.com/engrave"
+	"seedhammer.com/font/condensed"
 	"seedhammer.com/font/constant"
 	"seedhammer.com/nonstandard"
 )
 
 var (
-	serialDev  = flag.String("device", "", "serial device")
+	serialDev  = flag.String("device", "", "serial device of the engraver, or auto to detect it")
 	dryrun     = flag.Bool("n", false, "dry run")
 	output     = flag.String("o", "plates", "output plates to directory")
-	side       = flag.String("side", "front", "plate side, front or back")
+	side       = flag.String("side", "front", "plate side, front, back, depth for a depth test plate, data for a data plate or multi for several 12-word seeds on an SH03 plate")
+	strokes    = flag.Int("strokes", 5, "number of depth test strokes")
 	size       = flag.String("size", "SH02", "plate size (SH02, SH03)")
 	descriptor = flag.String("descriptor", "wpkh([97a6d3c2/84h/1h/0h]tpubDD5cTgxiP4qYJgBgkS6arjQH3GsJEHExFZWvumhNGGe4gBShn9u3b4TdpG2DvRg3knNXV7fBdmaw6cH2kKYdk2aXjQZYsnTchA4aFsZWehG)", "output descriptor")
-	mnemonic   = flag.String("mnemonic", "vocal tray giggle tool duck letter category pattern train magnet excite swamp", "seed phrase")
+	mnemonic   = flag.String("mnemonic", "vocal tray giggle tool duck letter category pattern train magnet excite swamp", "seed phrase, or comma separated seed phrases with -all and -side multi")
+	all        = flag.Bool("all", false, "engrave both sides of every plate of the descriptor")
+	simulate   = flag.Bool("simulate", false, "print simulated engraver commands and estimates")
+	constantUR = flag.Bool("constant", false, "engrave the descriptor text in constant time, without QR code")
+	shuffle    = flag.Bool("shuffle", false, "randomize the stroke order of the descriptor side")
+	fontName   = flag.String("font", "regular", "descriptor font (regular, condensed)")
+	optimize   = flag.Bool("optimize", false, "reorder strokes to minimize needle travel; breaks constant time engraving")
+	spread     = flag.Float64("spread", 0, "alternate strokes between plate regions of this size in millimeters, for thin plates; breaks constant time engraving")
+	shp        = flag.Bool("shp", false, "also write the plans to the output directory in the .shp plan file format")
+	planFile   = flag.String("plan", "", "engrave, simulate or render a .shp plan file instead of a backup")
+	data       = flag.String("data", "", "payload of -side data plates")
+	caption    = flag.String("caption", "", "caption of -side data plates")
+	qrLevel    = flag.String("qr", "M", "preferred QR error correction level (L, M, Q, H)")
+	qrVersion  = flag.Int("qrversion", 0, "maximum QR code version, or 0 for no limit")
+	labels     = flag.String("labels", "", "comma separated labels of the descriptor keys, engraved next to their fingerprints")
+	flipv      = flag.Bool("flipv", false, "engrave the back side for a plate flipped vertically instead of horizontally")
+	template   = flag.String("template", "standard", "back side design (standard, words, qr)")
+	fiducials  = flag.Bool("fiducials", false, "engrave alignment fiducials at the middle of the left and right plate edges")
+	align      = flag.String("align", "", "measured fiducial positions in millimeters, x1,y1,x2,y2, for aligning a re-clamped plate")
 )
 
+// probeTimeout bounds the wait for the handshake of every device
+// probed for an engraver.
+const probeTimeout = 2 * time.Second
+
 func main() {
 	flag.Parse()
 	if err := run(); err != nil {
@@ -44,6 +76,34 @@ func main() {
 }
 
 func run() error {
+	if *optimize && *spread > 0 {
+		return errors.New("-optimize and -spread are mutually exclusive")
+	}
+	if *all {
+		return runAll()
+	}
+	if *planFile != "" {
+		data, err := os.ReadFile(*planFile)
+		if err != nil {
+			return err
+		}
+		plan, err := engrave.UnmarshalPlan(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *planFile, err)
+		}
+		psz, err := plateSize()
+		if err != nil {
+			return err
+		}
+		return outputSide(plan, psz, 0)
+	}
+	if *side == "multi" {
+		sideCmd, err := multiSeedSide()
+		if err != nil {
+			return err
+		}
+		return outputSide(sideCmd, backup.LargePlate, 0)
+	}
 	if *mnemonic == "" {
 		return errors.New("specify a seed")
 	}
@@ -59,6 +119,7 @@ func run() error {
 			return err
 		}
 		desc.Title = backup.TitleString(constant.Font, "Satoshi's Nice Stash")
+		setLabels(desc)
 	}
 	network := &chaincfg.MainNetParams
 	if len(desc.Keys) > 0 {
@@ -97,7 +158,202 @@ func run() error {
 	if len(desc.Keys) == 0 {
 		return errors.New("descriptor contains no keys")
 	}
-	keyIdx := -1
+	keyIdx, err := keyIndex(desc, mk)
+	if err != nil {
+		return err
+	}
+	psz, err := plateSize()
+	if err != nil {
+		return err
+	}
+	var sideCmd engrave.Plan
+	switch *side {
+	case "back":
+		sideCmd, err = seedSide(desc, keyIdx, m, psz)
+	case "front":
+		sideCmd, err = descriptorSide(desc, keyIdx, psz)
+	case "depth":
+		sideCmd, err = backup.EngraveDepthTest(mjolnir.Params, backup.DepthTest{
+			Strokes: *strokes,
+			Font:    constant.Font,
+			Size:    psz,
+		})
+	case "data":
+		sideCmd, err = dataSide(psz)
+	default:
+		return fmt.Errorf("-side must be 'front', 'back', 'depth', 'data' or 'multi'")
+	}
+	if err != nil {
+		return err
+	}
+	return outputSide(sideCmd, psz, keyIdx)
+}
+
+// outputSide engraves, simulates or renders side as directed by
+// the flags.
+func outputSide(sideCmd engrave.Plan, psz backup.PlateSize, keyIdx int) error {
+	sideCmd, err := placeSide(psz, sideCmd)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("plate %d, %s side", keyIdx+1, *side)
+	switch {
+	case *simulate:
+		_, err = simulatePlan(name, sideCmd)
+	case *serialDev != "":
+		printEstimate(name, sideCmd)
+		err = hammer(sideCmd, *serialDev)
+	default:
+		if err := os.MkdirAll(*output, 0o755); err != nil {
+			return err
+		}
+		printEstimate(name, sideCmd)
+		err = dump(sideCmd, psz, keyIdx, *side, *output)
+	}
+	return err
+}
+
+// placeSide adds the fiducials of the -fiducials flag to side, and
+// aligns it with the positions of the -align flag.
+func placeSide(psz backup.PlateSize, side engrave.Plan) (engrave.Plan, error) {
+	var err error
+	if *fiducials {
+		side, err = backup.AddFiducials(mjolnir.Params, psz, side)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if *align != "" {
+		side, err = alignSide(psz, side)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return side, nil
+}
+
+// runAll engraves the front side of every plate of the descriptor, and the
+// back side of every plate whose seed is known.
+func runAll() error {
+	if *descriptor == "" {
+		return errors.New("-all requires a descriptor")
+	}
+	if *align != "" {
+		// The positions are measured for a single clamped plate.
+		return errors.New("-align can't be used with -all")
+	}
+	desc, err := nonstandard.OutputDescriptor([]byte(*descriptor))
+	if err != nil {
+		return err
+	}
+	if len(desc.Keys) == 0 {
+		return errors.New("descriptor contains no keys")
+	}
+	desc.Title = backup.TitleString(constant.Font, "Satoshi's Nice Stash")
+	setLabels(desc)
+	psz, err := plateSize()
+	if err != nil {
+		return err
+	}
+	seeds := make(map[int]bip39.Mnemonic)
+	// Ignore the default seed, which is not among the keys of
+	// other descriptors.
+	if isSet("mnemonic") {
+		for _, phrase := range strings.Split(*mnemonic, ",") {
+			phrase = strings.Join(strings.Fields(phrase), " ")
+			m, err := bip39.ParseMnemonic(phrase)
+			if err != nil {
+				return fmt.Errorf("invalid mnemonic: %w", err)
+			}
+			mk, err := hdkeychain.NewMaster(bip39.MnemonicSeed(m, ""), desc.Keys[0].Network)
+			if err != nil {
+				return err
+			}
+			keyIdx, err := keyIndex(desc, mk)
+			if err != nil {
+				return err
+			}
+			seeds[keyIdx] = m
+		}
+	}
+	type plateSide struct {
+		keyIdx int
+		name   string
+		plan   engrave.Plan
+	}
+	var sides []plateSide
+	for keyIdx := range desc.Keys {
+		front, err := descriptorSide(desc, keyIdx, psz)
+		if err != nil {
+			return err
+		}
+		sides = append(sides, plateSide{keyIdx, "front", front})
+		if m, ok := seeds[keyIdx]; ok {
+			back, err := seedSide(desc, keyIdx, m, psz)
+			if err != nil {
+				return err
+			}
+			sides = append(sides, plateSide{keyIdx, "back", back})
+		}
+	}
+	for i, s := range sides {
+		plan, err := placeSide(psz, s.plan)
+		if err != nil {
+			return fmt.Errorf("plate %d, %s side: %w", s.keyIdx+1, s.name, err)
+		}
+		sides[i].plan = plan
+	}
+	if *simulate {
+		var total time.Duration
+		for _, s := range sides {
+			d, err := simulatePlan(fmt.Sprintf("plate %d, %s side", s.keyIdx+1, s.name), s.plan)
+			if err != nil {
+				return err
+			}
+			total += d
+		}
+		fmt.Fprintf(os.Stderr, "total: estimated duration %v\n", total.Round(time.Second))
+		return nil
+	}
+	if *serialDev == "" {
+		if err := os.MkdirAll(*output, 0o755); err != nil {
+			return err
+		}
+		for _, s := range sides {
+			printEstimate(fmt.Sprintf("plate %d, %s side", s.keyIdx+1, s.name), s.plan)
+			if err := dump(s.plan, psz, s.keyIdx, s.name, *output); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	stdin := bufio.NewReader(os.Stdin)
+	for i, s := range sides {
+		printEstimate(fmt.Sprintf("plate %d, %s side", s.keyIdx+1, s.name), s.plan)
+		fmt.Printf("(%d/%d) Place plate %d with the %s side up, then press enter to engrave.", i+1, len(sides), s.keyIdx+1, s.name)
+		if _, err := stdin.ReadString('\n'); err != nil {
+			return err
+		}
+		if err := hammer(s.plan, *serialDev); err != nil {
+			return fmt.Errorf("plate %d, %s side: %w", s.keyIdx+1, s.name, err)
+		}
+	}
+	return nil
+}
+
+// isSet reports whether the named flag is set on the command line.
+func isSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func keyIndex(desc urtypes.OutputDescriptor, mk *hdkeychain.ExtendedKey) (int, error) {
 	for i, k := range desc.Keys {
 		_, xpub, err := bip32.Derive(mk, k.DerivationPath)
 		if err != nil {
@@ -106,63 +362,274 @@ func run() error {
 			continue
 		}
 		if k.String() == xpub.String() {
-			keyIdx = i
-			break
+			return i, nil
 		}
 	}
-	if keyIdx == -1 {
-		return errors.New("seed is not among the descriptor keys")
-	}
-	var psz backup.PlateSize
+	return 0, errors.New("seed is not among the descriptor keys")
+}
+
+func plateSize() (backup.PlateSize, error) {
 	switch *size {
 	case "SH02":
-		psz = backup.SquarePlate
+		return backup.SquarePlate, nil
 	case "SH03":
-		psz = backup.LargePlate
+		return backup.LargePlate, nil
 	default:
-		return fmt.Errorf("-size must be 'SH02' or 'SH03'")
+		return 0, fmt.Errorf("-size must be 'SH02' or 'SH03'")
 	}
-	params := mjolnir.Params
-	var sideCmd engrave.Plan
-	switch *side {
-	case "back":
-		desc := backup.Seed{
-			Title:             desc.Title,
-			KeyIdx:            keyIdx,
+}
+
+func correctionLevel() (qr.Level, error) {
+	switch *qrLevel {
+	case "L":
+		return qr.L, nil
+	case "M":
+		return qr.M, nil
+	case "Q":
+		return qr.Q, nil
+	case "H":
+		return qr.H, nil
+	default:
+		return 0, errors.New("-qr must be 'L', 'M', 'Q' or 'H'")
+	}
+}
+
+func seedTemplate() (backup.Template, error) {
+	switch *template {
+	case "standard":
+		return backup.StandardTemplate, nil
+	case "words":
+		return backup.WordsTemplate, nil
+	case "qr":
+		return backup.QRTemplate, nil
+	default:
+		return nil, errors.New("-template must be 'standard', 'words' or 'qr'")
+	}
+}
+
+func seedSide(desc urtypes.OutputDescriptor, keyIdx int, m bip39.Mnemonic, psz backup.PlateSize) (engrave.Plan, error) {
+	lvl, err := correctionLevel()
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := seedTemplate()
+	if err != nil {
+		return nil, err
+	}
+	return backup.EngraveSeed(mjolnir.Params, backup.Seed{
+		Title:             desc.Title,
+		KeyIdx:            keyIdx,
+		Mnemonic:          m,
+		Keys:              len(desc.Keys),
+		MasterFingerprint: desc.Keys[keyIdx].MasterFingerprint,
+		Label:             desc.Keys[keyIdx].Label,
+		Font:              constant.Font,
+		Size:              psz,
+		QRLevel:           lvl,
+		QRMaxVersion:      *qrVersion,
+		FlipVertical:      *flipv,
+		Template:          tmpl,
+	})
+}
+
+// setLabels labels the keys of desc from the -labels flag.
+func setLabels(desc urtypes.OutputDescriptor) {
+	if *labels == "" {
+		return
+	}
+	for i, l := range strings.Split(*labels, ",") {
+		if i < len(desc.Keys) {
+			desc.Keys[i].Label = backup.LabelString(constant.Font, strings.TrimSpace(l))
+		}
+	}
+}
+
+func dataSide(psz backup.PlateSize) (engrave.Plan, error) {
+	if *data == "" {
+		return nil, errors.New("specify -data")
+	}
+	lvl, err := correctionLevel()
+	if err != nil {
+		return nil, err
+	}
+	return backup.EngraveData(mjolnir.Params, backup.Data{
+		Payload:      []byte(*data),
+		Caption:      *caption,
+		Font:         constant.Font,
+		Size:         psz,
+		QRLevel:      lvl,
+		QRMaxVersion: *qrVersion,
+	})
+}
+
+// multiSeedSide engraves the comma separated seeds of the -mnemonic
+// flag, labeled by the -labels flag, on a single plate.
+func multiSeedSide() (engrave.Plan, error) {
+	lvl, err := correctionLevel()
+	if err != nil {
+		return nil, err
+	}
+	var lbls []string
+	if *labels != "" {
+		lbls = strings.Split(*labels, ",")
+	}
+	var seeds []backup.MultiSeedEntry
+	for i, phrase := range strings.Split(*mnemonic, ",") {
+		phrase = strings.Join(strings.Fields(phrase), " ")
+		m, err := bip39.ParseMnemonic(phrase)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mnemonic: %w", err)
+		}
+		mk, err := hdkeychain.NewMaster(bip39.MnemonicSeed(m, ""), &chaincfg.MainNetParams)
+		if err != nil {
+			return nil, err
+		}
+		mfp, _, err := bip32.Derive(mk, urtypes.Path{0})
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key: %w", err)
+		}
+		s := backup.MultiSeedEntry{
 			Mnemonic:          m,
-			Keys:              len(desc.Keys),
-			MasterFingerprint: desc.Keys[keyIdx].MasterFingerprint,
-			Font:              constant.Font,
-			Size:              psz,
+			MasterFingerprint: mfp,
 		}
-		sideCmd, err = backup.EngraveSeed(params, desc)
-	case "front":
-		desc := backup.Descriptor{
-			Descriptor: desc,
-			KeyIdx:     keyIdx,
-			Font:       constant.Font,
-			Size:       psz,
+		if i < len(lbls) {
+			s.Label = backup.LabelString(constant.Font, strings.TrimSpace(lbls[i]))
 		}
-		sideCmd, err = backup.EngraveDescriptor(params, desc)
+		seeds = append(seeds, s)
+	}
+	return backup.EngraveMultiSeed(mjolnir.Params, backup.MultiSeed{
+		Title:   backup.TitleString(constant.Font, "Satoshi's Nice Stash"),
+		Seeds:   seeds,
+		Font:    constant.Font,
+		QRLevel: lvl,
+	})
+}
+
+func descriptorSide(desc urtypes.OutputDescriptor, keyIdx int, psz backup.PlateSize) (engrave.Plan, error) {
+	if *shuffle && *constantUR {
+		return nil, errors.New("-shuffle and -constant are mutually exclusive")
+	}
+	font := constant.Font
+	switch *fontName {
+	case "regular":
+	case "condensed":
+		font = condensed.Font
 	default:
-		return fmt.Errorf("-side must be 'front' or 'back'")
+		return nil, errors.New("-font must be 'regular' or 'condensed'")
 	}
+	lvl, err := correctionLevel()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	plan, err := backup.EngraveDescriptor(mjolnir.Params, backup.Descriptor{
+		Descriptor:   desc,
+		KeyIdx:       keyIdx,
+		Font:         font,
+		Size:         psz,
+		Constant:     *constantUR,
+		QRLevel:      lvl,
+		QRMaxVersion: *qrVersion,
+	})
+	if err != nil || !*shuffle {
+		return plan, err
+	}
+	var seed [8]byte
+	if _, err := rand.Read(seed[:]); err != nil {
+		return nil, err
 	}
+	// Shuffle the strokes of roughly a character at a time.
+	const region = 4
+	return engrave.Shuffle(int64(binary.LittleEndian.Uint64(seed[:])), mjolnir.Params.I(region), plan), nil
+}
 
-	if *serialDev != "" {
-		err = hammer(sideCmd, *serialDev)
-	} else {
-		if err := os.MkdirAll(*output, 0o755); err != nil {
-			return err
+// simulatePlan runs a plan through the engraver simulator, prints the
+// resulting commands to stdout and a summary to stderr.
+func simulatePlan(name string, plan engrave.Plan) (time.Duration, error) {
+	mm := float64(mjolnir.Params.Millimeter)
+	if *optimize {
+		sim, err := runSimulator(dryRunPlan(plan))
+		if err != nil {
+			return 0, err
 		}
-		err = dump(sideCmd, psz, keyIdx, *output)
+		fmt.Fprintf(os.Stderr, "%s: before optimization: needle distance %.0f mm, estimated duration %v\n",
+			name, sim.Distance/mm, sim.Duration.Round(time.Second))
+		plan = engrave.Optimize(plan)
 	}
-	return err
+	plan = dryRunPlan(spreadPlan(plan))
+	sim, err := runSimulator(plan)
+	if err != nil {
+		return 0, err
+	}
+	stdout := bufio.NewWriter(os.Stdout)
+	for _, c := range sim.Cmds {
+		fmt.Fprintln(stdout, c)
+	}
+	if err := stdout.Flush(); err != nil {
+		return 0, err
+	}
+	fmt.Fprintf(os.Stderr, "%s: %d commands, needle distance %.0f mm, estimated duration %v\n",
+		name, len(sim.Cmds), sim.Distance/mm, sim.Duration.Round(time.Second))
+	return sim.Duration, nil
+}
+
+// alignSide aligns side with the fiducial positions of the -align flag.
+func alignSide(psz backup.PlateSize, side engrave.Plan) (engrave.Plan, error) {
+	vals := strings.Split(*align, ",")
+	if len(vals) != 4 {
+		return nil, errors.New("-align must specify 4 values")
+	}
+	var coords [4]int
+	for i, v := range vals {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -align value: %q", v)
+		}
+		coords[i] = mjolnir.Params.F(float32(f))
+	}
+	got := [2]image.Point{{coords[0], coords[1]}, {coords[2], coords[3]}}
+	return engrave.Align(backup.FiducialPositions(mjolnir.Params, psz), got, side), nil
 }
 
-func dump(sideCmd engrave.Plan, size backup.PlateSize, keyIdx int, output string) error {
+// printEstimate prints the estimated duration and stroke length of
+// engraving a plan to stderr.
+func printEstimate(name string, plan engrave.Plan) {
+	if *optimize {
+		plan = engrave.Optimize(plan)
+	}
+	plan = spreadPlan(plan)
+	d, stroke := engrave.Estimate(plan, mjolnir.Params)
+	fmt.Fprintf(os.Stderr, "%s: estimated duration %v, stroke length %.0f mm\n",
+		name, d.Round(time.Second), float64(stroke)/float64(mjolnir.Params.Millimeter))
+}
+
+// spreadPlan applies the stroke order of the -spread flag to plan.
+func spreadPlan(plan engrave.Plan) engrave.Plan {
+	if *spread <= 0 {
+		return plan
+	}
+	return engrave.Spread(mjolnir.Params.F(float32(*spread)), plan)
+}
+
+// dryRunPlan applies the -n flag to plan. It must be applied after
+// reordering strokes, because reordering drops the moves of a dry
+// run.
+func dryRunPlan(plan engrave.Plan) engrave.Plan {
+	if !*dryrun {
+		return plan
+	}
+	return engrave.DryRun(plan)
+}
+
+func runSimulator(plan engrave.Plan) (*mjolnir.Simulator, error) {
+	sim := mjolnir.NewSimulator()
+	defer sim.Close()
+	if err := mjolnir.Engrave(sim, mjolnir.Options{}, plan, nil); err != nil {
+		return nil, err
+	}
+	return sim, nil
+}
+
+func dump(sideCmd engrave.Plan, size backup.PlateSize, keyIdx int, side, output string) error {
 	const ppmm = 24
 	dims := size.Dims().Mul(ppmm)
 	img := image.NewNRGBA(image.Rectangle{Max: dims})
@@ -176,33 +643,54 @@ func dump(sideCmd engrave.Plan, size backup.PlateSize, keyIdx int, output string
 	if err := png.Encode(buf, img); err != nil {
 		return err
 	}
-	file := filepath.Join(output, fmt.Sprintf("plate-%d-side-%s.png", keyIdx, *side))
-	if err := os.WriteFile(file, buf.Bytes(), 0o644); err != nil {
+	name := filepath.Join(output, fmt.Sprintf("plate-%d-side-%s", keyIdx, side))
+	if err := os.WriteFile(name+".png", buf.Bytes(), 0o644); err != nil {
 		return err
 	}
+	if *shp {
+		if err := os.WriteFile(name+".shp", engrave.MarshalPlan(sideCmd), 0o644); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func hammer(side engrave.Plan, dev string) error {
-	s, err := mjolnir.Open(dev)
+	var s io.ReadWriteCloser
+	var err error
+	if dev != "auto" {
+		s, err = mjolnir.Open(dev)
+	} else {
+		dev, s, err = mjolnir.Discover(probeTimeout)
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "engraver: using %s\n", dev)
+		}
+	}
 	if err != nil {
 		return err
 	}
 	defer s.Close()
 
-	if *dryrun {
-		side = engrave.DryRun(side)
+	if *optimize {
+		side = engrave.Optimize(side)
 	}
+	side = dryRunPlan(spreadPlan(side))
 	quit := make(chan os.Signal, 1)
-	cancel := make(chan struct{})
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
-	engraveErr := make(chan error)
+	// Stop listening when the plate is done, because runAll
+	// engraves several.
+	defer signal.Stop(quit)
+	cancel := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
 	go func() {
-		<-quit
-		signal.Reset(os.Interrupt)
-		close(cancel)
-		<-engraveErr
-		os.Exit(1)
+		select {
+		case <-quit:
+			// A second interrupt kills the program.
+			signal.Reset(os.Interrupt)
+			close(cancel)
+		case <-done:
+		}
 	}()
 	return mjolnir.Engrave(s, mjolnir.Options{}, side, cancel)
 }
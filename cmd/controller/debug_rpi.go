@@ -123,35 +123,25 @@ func dumpImage(name string, img image.Image) {
 	log.Printf("screenshot: dumped %s", name)
 }
 
-func dumpFile(path string, r io.Reader) (ferr error) {
-	const mntDir = "/mnt"
-	if err := os.MkdirAll(mntDir, 0o644); err != nil {
-		return fmt.Errorf("mkdir %s: %w", mntDir, err)
-	}
-	if err := syscall.Mount("/dev/mmcblk0p1", mntDir, "vfat", 0, ""); err != nil {
-		return fmt.Errorf("mount /dev/mmcblk0p1: %w", err)
-	}
-	defer func() {
-		if err := syscall.Unmount(mntDir, 0); ferr == nil {
-			ferr = err
+func dumpFile(path string, r io.Reader) error {
+	return withBootFS(func(mntDir string) (ferr error) {
+		path = filepath.Join(mntDir, path)
+		dir := filepath.Dir(path)
+		if err := os.MkdirAll(dir, 0o644); err != nil {
+			return fmt.Errorf("mkdir %s: %w", dir, err)
 		}
-	}()
-	path = filepath.Join(mntDir, path)
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0o644); err != nil {
-		return fmt.Errorf("mkdir %s: %w", dir, err)
-	}
-	f, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err := f.Close(); ferr == nil {
-			ferr = err
+		f, err := os.Create(path)
+		if err != nil {
+			return err
 		}
-	}()
-	_, err = io.Copy(f, r)
-	return err
+		defer func() {
+			if err := f.Close(); ferr == nil {
+				ferr = err
+			}
+		}()
+		_, err = io.Copy(f, r)
+		return err
+	})
 }
 
 func openSerial(path string) (s *os.File, err error) {
@@ -0,0 +1,85 @@
+//go:build linux && arm
+
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// The customer OTP (one-time programmable) memory of the Raspberry
+// Pi is 8 rows of 32 bits, accessed through the property interface
+// of the firmware mailbox. Bits can be set but never cleared.
+const (
+	otpRows = 8
+
+	mboxRequest   = 0x00000000
+	mboxSuccess   = 0x80000000
+	tagGetOTP     = 0x00030021
+	tagSetOTP     = 0x00038021
+	tagResponse   = 0x80000000
+	ioctlMboxProp = 0xc0046400 // _IOWR(100, 0, char *)
+)
+
+// readOTP returns the contents of the customer OTP memory.
+func readOTP() ([]byte, error) {
+	rows, err := otpProperty(tagGetOTP, make([]uint32, otpRows))
+	if err != nil {
+		return nil, fmt.Errorf("otp: read: %w", err)
+	}
+	data := make([]byte, 4*otpRows)
+	for i, r := range rows {
+		binary.LittleEndian.PutUint32(data[4*i:], r)
+	}
+	return data, nil
+}
+
+// programOTP permanently programs data into the customer OTP memory.
+func programOTP(data []byte) error {
+	if len(data) != 4*otpRows {
+		return errors.New("otp: invalid data length")
+	}
+	rows := make([]uint32, otpRows)
+	for i := range rows {
+		rows[i] = binary.LittleEndian.Uint32(data[4*i:])
+	}
+	if _, err := otpProperty(tagSetOTP, rows); err != nil {
+		return fmt.Errorf("otp: program: %w", err)
+	}
+	return nil
+}
+
+// otpProperty issues a customer OTP property request for all rows
+// and returns the rows of the response.
+func otpProperty(tag uint32, rows []uint32) ([]uint32, error) {
+	f, err := os.OpenFile("/dev/vcio", os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	// The request is the buffer size and code, followed by the tag,
+	// its value size and code, the first row and row count, the rows
+	// and the end tag.
+	const header, values = 5, 2 + otpRows
+	var buf [header + values + 1]uint32
+	buf[0] = uint32(len(buf) * 4)
+	buf[1] = mboxRequest
+	buf[2] = tag
+	buf[3] = values * 4
+	buf[4] = 0
+	buf[5] = 0
+	buf[6] = otpRows
+	copy(buf[7:], rows)
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), ioctlMboxProp, uintptr(unsafe.Pointer(&buf[0]))); errno != 0 {
+		return nil, errno
+	}
+	if buf[1] != mboxSuccess || buf[4]&tagResponse == 0 {
+		return nil, fmt.Errorf("mailbox error %#x", buf[1])
+	}
+	return buf[7 : 7+otpRows], nil
+}
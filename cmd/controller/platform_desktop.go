@@ -0,0 +1,499 @@
+//go:build !linux || !arm
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"seedhammer.com/backup"
+	"seedhammer.com/driver/mjolnir"
+	"seedhammer.com/engrave"
+	"seedhammer.com/gui"
+	"seedhammer.com/image/rgb565"
+	"seedhammer.com/zbar"
+)
+
+// The desktop platform simulates the controller hardware. The
+// display, buttons, SD card slot and camera are provided by a web
+// page served on the address in the sh_http environment variable,
+// and the engraver is simulated.
+
+//go:embed desktop.html
+var desktopPage []byte
+
+type Platform struct {
+	dir      string
+	settings gui.Settings
+	events   chan gui.Event
+	wakeups  chan struct{}
+	timer    *time.Timer
+	display  *rgb565.Image
+	dirty    struct {
+		rect  image.Rectangle
+		begun bool
+	}
+	camera struct {
+		frames chan *image.YCbCr
+		// requested is set when a frame was requested since
+		// the previous events.
+		requested bool
+		state     cameraState
+		sent      cameraState
+	}
+
+	mu      sync.Mutex
+	clients map[chan []byte]struct{}
+	// stop is closed while the simulated emergency stop switch
+	// is open.
+	stop chan struct{}
+	// latest maps the names of state events, screen and camera,
+	// to their most recent update, for new clients.
+	latest map[string][]byte
+}
+
+// cameraState is the camera configuration requested from the pages.
+// The zero value turns the webcam off.
+type cameraState struct {
+	Width, Height int
+	// Zoom selects a digital zoom factor of 2^Zoom.
+	Zoom int
+}
+
+func Init() (*Platform, error) {
+	dir := os.Getenv("sh_datadir")
+	if dir == "" {
+		cfg, err := os.UserConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(cfg, "seedhammer")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	addr := os.Getenv("sh_http")
+	if addr == "" {
+		addr = "localhost:8080"
+	}
+	p := &Platform{
+		dir:     dir,
+		events:  make(chan gui.Event, 10),
+		wakeups: make(chan struct{}, 1),
+		display: rgb565.New(image.Rect(0, 0, 240, 240)),
+		clients: make(map[chan []byte]struct{}),
+		latest:  make(map[string][]byte),
+		stop:    make(chan struct{}),
+	}
+	p.camera.frames = make(chan *image.YCbCr, 1)
+	// The simulated SD card slot starts out empty.
+	p.events <- gui.SDCardEvent{Inserted: false}.Event()
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(desktopPage)
+	})
+	mux.HandleFunc("GET /updates", p.serveUpdates)
+	mux.HandleFunc("POST /input", p.serveInput)
+	mux.HandleFunc("POST /frame", p.serveFrame)
+	go func() {
+		log.Fatal(http.Serve(l, mux))
+	}()
+	log.Printf("controller: simulator running on http://%s", l.Addr())
+	return p, nil
+}
+
+// serveUpdates streams screen and camera updates as server-sent
+// events.
+func (p *Platform) serveUpdates(w http.ResponseWriter, r *http.Request) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	updates := make(chan []byte, 10)
+	p.mu.Lock()
+	p.clients[updates] = struct{}{}
+	for _, u := range p.latest {
+		updates <- u
+	}
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.clients, updates)
+		p.mu.Unlock()
+	}()
+	for {
+		select {
+		case u := <-updates:
+			if _, err := w.Write(u); err != nil {
+				return
+			}
+			f.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// broadcast sends an event to every connected page.
+func (p *Platform) broadcast(event string, data any) {
+	js, err := json.Marshal(data)
+	if err != nil {
+		panic(err)
+	}
+	u := []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", event, js))
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if event != "beep" {
+		p.latest[event] = u
+	}
+	for c := range p.clients {
+		select {
+		case c <- u:
+		default:
+			// Drop updates to slow pages.
+		}
+	}
+}
+
+func (p *Platform) serveInput(w http.ResponseWriter, r *http.Request) {
+	var in struct {
+		Button  string `json:"button"`
+		Rune    string `json:"rune"`
+		Pressed bool   `json:"pressed"`
+		SDCard  *bool  `json:"sdcard"`
+		EStop   *bool  `json:"estop"`
+		Pointer *struct {
+			X, Y    int
+			Pressed bool
+		} `json:"pointer"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var e gui.Event
+	switch {
+	case in.SDCard != nil:
+		e = gui.SDCardEvent{Inserted: *in.SDCard}.Event()
+	case in.EStop != nil:
+		if !p.emergencyStop(*in.EStop) {
+			return
+		}
+		e = gui.EmergencyStopEvent{Triggered: *in.EStop}.Event()
+	case in.Pointer != nil:
+		pe := in.Pointer
+		e = gui.PointerEvent{Pos: image.Pt(pe.X, pe.Y), Pressed: pe.Pressed}.Event()
+	case in.Rune != "":
+		e = gui.ButtonEvent{Button: gui.Rune, Rune: []rune(in.Rune)[0], Pressed: true}.Event()
+	default:
+		b, err := parseButton(in.Button)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		e = gui.ButtonEvent{Button: b, Pressed: in.Pressed}.Event()
+	}
+	p.events <- e
+}
+
+// emergencyStop opens or closes the simulated emergency stop
+// switch, and reports whether its state changed. Like the switch
+// of the Raspberry Pi platform, opening it halts the simulated
+// engraver before the event is reported.
+func (p *Platform) emergencyStop(triggered bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	select {
+	case <-p.stop:
+		if !triggered {
+			p.stop = make(chan struct{})
+			return true
+		}
+	default:
+		if triggered {
+			close(p.stop)
+			return true
+		}
+	}
+	return false
+}
+
+func parseButton(name string) (gui.Button, error) {
+	for b := gui.Up; b <= gui.Button3; b++ {
+		if b.String() == name {
+			return b, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown button: %s", name)
+}
+
+// serveFrame receives a JPEG encoded webcam frame.
+func (p *Platform) serveFrame(w http.ResponseWriter, r *http.Request) {
+	img, err := jpeg.Decode(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f, ok := img.(*image.YCbCr)
+	if !ok {
+		b := img.Bounds()
+		f = image.NewYCbCr(b, image.YCbCrSubsampleRatio444)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				c := f.ColorModel().Convert(img.At(x, y)).(color.YCbCr)
+				f.Y[f.YOffset(x, y)] = c.Y
+				f.Cb[f.COffset(x, y)] = c.Cb
+				f.Cr[f.COffset(x, y)] = c.Cr
+			}
+		}
+	}
+	select {
+	case p.camera.frames <- f:
+	default:
+		// Drop the frame while the previous is pending.
+	}
+}
+
+func (p *Platform) Wakeup() {
+	select {
+	case p.wakeups <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Platform) Beep() {
+	p.broadcast("beep", nil)
+}
+
+func (p *Platform) Pointer(on bool) {
+}
+
+func (p *Platform) AppendEvents(deadline time.Time, evts []gui.Event) []gui.Event {
+	c := &p.camera
+	if !c.requested {
+		c.state = cameraState{}
+	}
+	c.requested = false
+	p.updateCamera()
+	for {
+		select {
+		case e := <-p.events:
+			evts = append(evts, e)
+		case f := <-c.frames:
+			evts = append(evts, gui.FrameEvent{Image: f}.Event())
+		default:
+			if len(evts) > 0 {
+				return evts
+			}
+			d := time.Until(deadline)
+			if p.timer == nil {
+				p.timer = time.NewTimer(d)
+			} else {
+				p.timer.Stop()
+			}
+			if d <= 0 {
+				p.Wakeup()
+			} else {
+				p.timer.Reset(d)
+			}
+			select {
+			case e := <-p.events:
+				evts = append(evts, e)
+			case f := <-c.frames:
+				evts = append(evts, gui.FrameEvent{Image: f}.Event())
+			case <-p.timer.C:
+				return evts
+			case <-p.wakeups:
+				return evts
+			}
+		}
+	}
+}
+
+func (p *Platform) CameraFrame(dims image.Point, ctrls gui.CameraControls) {
+	c := &p.camera
+	c.requested = true
+	c.state = cameraState{Width: dims.X, Height: dims.Y, Zoom: ctrls.ZoomLevel}
+	p.updateCamera()
+}
+
+// updateCamera sends the camera state to the pages, if it changed.
+func (p *Platform) updateCamera() {
+	st := p.camera.state
+	if st == p.camera.sent {
+		return
+	}
+	p.camera.sent = st
+	p.broadcast("camera", st)
+}
+
+func (p *Platform) DisplaySize() image.Point {
+	return p.display.Bounds().Size()
+}
+
+func (p *Platform) Dirty(r image.Rectangle) error {
+	p.dirty.rect = r.Intersect(p.display.Bounds())
+	p.dirty.begun = !p.dirty.rect.Empty()
+	return nil
+}
+
+func (p *Platform) NextChunk() (draw.RGBA64Image, bool) {
+	if !p.dirty.begun {
+		if !p.dirty.rect.Empty() {
+			p.dirty.rect = image.Rectangle{}
+			p.flush()
+		}
+		return nil, false
+	}
+	p.dirty.begun = false
+	return p.display.SubImage(p.dirty.rect).(draw.RGBA64Image), true
+}
+
+// flush sends the display to the connected pages.
+func (p *Platform) flush() {
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, p.display); err != nil {
+		panic(err)
+	}
+	p.broadcast("screen", "data:image/png;base64,"+base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+func (p *Platform) ScanQR(img *image.Gray) ([][]byte, error) {
+	return zbar.Scan(img)
+}
+
+func (p *Platform) PlateSizes() []backup.PlateSize {
+	return []backup.PlateSize{backup.SquarePlate, backup.LargePlate}
+}
+
+func (p *Platform) EngraverParams() engrave.Params {
+	return mjolnir.Params
+}
+
+func (p *Platform) Engraver() (gui.Engraver, error) {
+	return &engraver{dev: mjolnir.NewSimulator(), estop: p.estop}, nil
+}
+
+// estop returns a channel that is closed while the simulated
+// emergency stop switch is open.
+func (p *Platform) estop() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stop
+}
+
+type engraver struct {
+	dev   *mjolnir.Simulator
+	estop func() <-chan struct{}
+}
+
+func (e *engraver) Engrave(sz backup.PlateSize, plan engrave.Plan, progress func(completed int), pause <-chan bool, quit <-chan struct{}) error {
+	err := mjolnir.Engrave(e.dev, mjolnir.Options{Progress: progress, Pause: pause, Stop: e.estop()}, plan, quit)
+	if errors.Is(err, mjolnir.ErrStopped) {
+		err = gui.ErrEmergencyStop
+	}
+	return err
+}
+
+func (e *engraver) Close() {
+	e.dev.Close()
+}
+
+// settingsFile is the name of the settings file in the data
+// directory.
+const settingsFile = "settings.json"
+
+func (p *Platform) LoadSettings() (gui.Settings, error) {
+	var s gui.Settings
+	data, err := os.ReadFile(filepath.Join(p.dir, settingsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(data, &s)
+	p.settings = s
+	return s, err
+}
+
+func (p *Platform) StoreSettings(s gui.Settings) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(p.dir, settingsFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	p.settings = s
+	return nil
+}
+
+func (p *Platform) ExportFile(name string, data []byte) error {
+	return os.WriteFile(filepath.Join(p.dir, name), data, 0o600)
+}
+
+func (p *Platform) ImportFiles(ext string) (map[string][]byte, error) {
+	return readFiles(p.dir, ext)
+}
+
+// secretFile is the name of the device secret file in the data
+// directory.
+const secretFile = "secret"
+
+// DeviceSecret returns the random secret stored in the data
+// directory by ProvisionSecret.
+func (p *Platform) DeviceSecret() ([]byte, error) {
+	secret, err := os.ReadFile(filepath.Join(p.dir, secretFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, gui.ErrNoDeviceSecret
+	}
+	return secret, err
+}
+
+// ProvisionSecret generates the device secret, unless it exists.
+func (p *Platform) ProvisionSecret() error {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(p.dir, secretFile), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(secret); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
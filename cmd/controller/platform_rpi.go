@@ -4,6 +4,7 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"image"
 	"image/draw"
@@ -18,9 +19,13 @@ import (
 
 	"golang.org/x/sys/unix"
 	"seedhammer.com/backup"
+	"seedhammer.com/driver/buzzer"
 	"seedhammer.com/driver/drm"
+	"seedhammer.com/driver/estop"
 	"seedhammer.com/driver/libcamera"
 	"seedhammer.com/driver/mjolnir"
+	"seedhammer.com/driver/pointer"
+	"seedhammer.com/driver/usbkbd"
 	"seedhammer.com/driver/wshat"
 	"seedhammer.com/engrave"
 	"seedhammer.com/gui"
@@ -34,16 +39,24 @@ var (
 )
 
 type Platform struct {
-	display *drm.LCD
-	events  chan gui.Event
+	display  *drm.LCD
+	estop    *estop.Switch
+	buzzer   *buzzer.Buzzer
+	pointer  *pointer.Pointer
+	settings gui.Settings
+	events   chan gui.Event
+	// keys receives the events of USB keyboards, which are
+	// ignored unless enabled in the settings.
+	keys    chan gui.Event
 	wakeups chan struct{}
 	timer   *time.Timer
 	camera  struct {
-		frames chan gui.FrameEvent
-		out    chan gui.FrameEvent
-		frame  *gui.FrameEvent
-		close  func()
-		active bool
+		frames   chan gui.FrameEvent
+		out      chan gui.FrameEvent
+		frame    *gui.FrameEvent
+		controls libcamera.Controls
+		close    func()
+		active   bool
 	}
 }
 
@@ -52,6 +65,7 @@ func Init() (*Platform, error) {
 	_ = mountFS()
 	p := &Platform{
 		events:  make(chan gui.Event, 10),
+		keys:    make(chan gui.Event, 10),
 		wakeups: make(chan struct{}, 1),
 	}
 	c := &p.camera
@@ -68,6 +82,34 @@ func Init() (*Platform, error) {
 	if err := wshat.Open(p.events); err != nil {
 		return nil, err
 	}
+	usbkbd.Open(p.keys)
+	// The emergency stop switch is optional, and its pin is
+	// specified on the kernel command line. For example,
+	// sh_estop=GPIO4.
+	if pin := os.Getenv("sh_estop"); pin != "" {
+		s, err := estop.Open(pin, p.events)
+		if err != nil {
+			return nil, err
+		}
+		p.estop = s
+	}
+	// The buzzer is optional as well. For example, sh_buzzer=GPIO17.
+	if pin := os.Getenv("sh_buzzer"); pin != "" {
+		b, err := buzzer.Open(pin)
+		if err != nil {
+			return nil, err
+		}
+		p.buzzer = b
+	}
+	// So is the pointer light for tracing outlines. For example,
+	// sh_pointer=GPIO27.
+	if pin := os.Getenv("sh_pointer"); pin != "" {
+		l, err := pointer.Open(pin)
+		if err != nil {
+			return nil, err
+		}
+		p.pointer = l
+	}
 	d, err := drm.Open()
 	if err != nil {
 		return nil, err
@@ -76,6 +118,21 @@ func Init() (*Platform, error) {
 	return p, nil
 }
 
+func (p *Platform) Beep() {
+	if p.buzzer != nil {
+		p.buzzer.Beep()
+	}
+}
+
+func (p *Platform) Pointer(on bool) {
+	if p.pointer == nil {
+		return
+	}
+	if err := p.pointer.Light(on); err != nil {
+		log.Printf("%v", err)
+	}
+}
+
 func (p *Platform) Wakeup() {
 	select {
 	case p.wakeups <- struct{}{}:
@@ -103,6 +160,8 @@ func (p *Platform) AppendEvents(deadline time.Time, evts []gui.Event) []gui.Even
 		select {
 		case e := <-p.events:
 			evts = append(evts, e)
+		case e := <-p.keys:
+			evts = p.appendKey(evts, e)
 		case f := <-c.frames:
 			c.frame = &f
 			evts = append(evts, f.Event())
@@ -129,6 +188,8 @@ func (p *Platform) AppendEvents(deadline time.Time, evts []gui.Event) []gui.Even
 			select {
 			case e := <-p.events:
 				evts = append(evts, e)
+			case e := <-p.keys:
+				evts = p.appendKey(evts, e)
 			case f := <-c.frames:
 				c.frame = &f
 				evts = append(evts, f.Event())
@@ -141,6 +202,15 @@ func (p *Platform) AppendEvents(deadline time.Time, evts []gui.Event) []gui.Even
 	}
 }
 
+// appendKey appends a USB keyboard event to evts if keyboard input
+// is enabled.
+func (p *Platform) appendKey(evts []gui.Event, e gui.Event) []gui.Event {
+	if p.settings.Keyboard != gui.KeyboardOn {
+		return evts
+	}
+	return append(evts, e)
+}
+
 func (p *Platform) DisplaySize() image.Point {
 	return p.display.Size()
 }
@@ -158,28 +228,73 @@ func (p *Platform) PlateSizes() []backup.PlateSize {
 }
 
 func (p *Platform) EngraverParams() engrave.Params {
-	return mjolnir.Params
+	params := p.profile().Params()
+	if p.settings.Order == gui.OrderSpread {
+		params.SpreadRegion = params.I(spreadRegion)
+	}
+	return params
+}
+
+// spreadRegion is the size in millimeters of the plate regions
+// strokes alternate between with the spread stroke order.
+const spreadRegion = 20
+
+// profile returns the engraver profile of the speed setting.
+func (p *Platform) profile() mjolnir.Profile {
+	switch p.settings.Speed {
+	case gui.SpeedFine:
+		return mjolnir.FineProfile
+	case gui.SpeedFast:
+		return mjolnir.FastProfile
+	default:
+		return mjolnir.NormalProfile
+	}
 }
 
 func (p *Platform) Engraver() (gui.Engraver, error) {
 	var dev io.ReadWriteCloser
+	var path string
+	var err error
 	if engraverHook == nil {
-		var err error
-		dev, err = mjolnir.Open("")
-		if err != nil {
-			return nil, err
+		path, dev, err = mjolnir.Discover(queryTimeout)
+		if err == nil {
+			log.Printf("engraver: using %s", path)
 		}
 	} else {
 		dev = engraverHook()
+		_, err = mjolnir.Query(dev, queryTimeout)
 	}
-	return &engraver{dev: dev}, nil
+	if err != nil {
+		switch {
+		case errors.Is(err, mjolnir.ErrNoResponse):
+			err = gui.ErrEngraverNoResponse
+		case errors.Is(err, mjolnir.ErrWrongDevice):
+			err = fmt.Errorf("%w: %w", gui.ErrEngraverUnknown, err)
+		case errors.Is(err, mjolnir.ErrBusy):
+			err = gui.ErrEngraverBusy
+		}
+		return nil, err
+	}
+	return &engraver{dev: dev, path: path, estop: p.estop, profile: p.profile()}, nil
 }
 
+// queryTimeout bounds the wait for every response of the
+// engraver handshake.
+const queryTimeout = 2 * time.Second
+
+// engraveTimeout bounds the wait for every response of the
+// engraver during engraving. It exceeds the duration of the
+// longest stroke at the slowest speed.
+const engraveTimeout = 30 * time.Second
+
 type engraver struct {
-	dev io.ReadWriteCloser
+	dev     io.ReadWriteCloser
+	path    string
+	estop   *estop.Switch
+	profile mjolnir.Profile
 }
 
-func (e *engraver) Engrave(sz backup.PlateSize, plan engrave.Plan, quit <-chan struct{}) error {
+func (e *engraver) Engrave(sz backup.PlateSize, plan engrave.Plan, progress func(completed int), pause <-chan bool, quit <-chan struct{}) error {
 	const x = 97
 	y := 0
 	switch sz {
@@ -188,7 +303,19 @@ func (e *engraver) Engrave(sz backup.PlateSize, plan engrave.Plan, quit <-chan s
 	}
 	mm := mjolnir.Params.Millimeter
 	plan = engrave.Offset(x*mm, y*mm, plan)
-	return mjolnir.Engrave(e.dev, mjolnir.Options{}, plan, quit)
+	opts := mjolnir.Options{Profile: e.profile, Progress: progress, Pause: pause, Timeout: engraveTimeout}
+	if e.estop != nil {
+		opts.Stop = e.estop.Stop()
+	}
+	err := mjolnir.Engrave(e.dev, opts, plan, quit)
+	if errors.Is(err, mjolnir.ErrStopped) {
+		err = gui.ErrEmergencyStop
+	}
+	return err
+}
+
+func (e *engraver) Device() string {
+	return e.path
 }
 
 func (e *engraver) Close() {
@@ -199,10 +326,11 @@ func (p *Platform) ScanQR(img *image.Gray) ([][]byte, error) {
 	return zbar.Scan(img)
 }
 
-func (p *Platform) CameraFrame(dims image.Point) {
+func (p *Platform) CameraFrame(dims image.Point, ctrls gui.CameraControls) {
 	c := &p.camera
+	c.controls.Set(ctrls.ZoomLevel, ctrls.Exposure)
 	if c.close == nil {
-		c.close = libcamera.Open(dims, p.camera.frames, p.camera.out)
+		c.close = libcamera.Open(dims, p.camera.frames, p.camera.out, &c.controls)
 	}
 	c.active = true
 }
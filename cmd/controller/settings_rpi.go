@@ -0,0 +1,153 @@
+//go:build linux && arm
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"seedhammer.com/gui"
+)
+
+// settingsFile is the name of the settings file on the
+// boot partition of the SD card.
+const settingsFile = "settings.json"
+
+func (p *Platform) LoadSettings() (gui.Settings, error) {
+	var s gui.Settings
+	err := withBootFS(func(dir string) error {
+		data, err := os.ReadFile(filepath.Join(dir, settingsFile))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, &s)
+	})
+	p.settings = s
+	return s, err
+}
+
+func (p *Platform) StoreSettings(s gui.Settings) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	err = withBootFS(func(dir string) error {
+		// Replace the settings atomically.
+		path := filepath.Join(dir, settingsFile)
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, data, 0o644); err != nil {
+			return err
+		}
+		return os.Rename(tmp, path)
+	})
+	if err == nil {
+		p.settings = s
+	}
+	return err
+}
+
+// ExportFile writes a file to the boot partition of the SD card.
+func (p *Platform) ExportFile(name string, data []byte) error {
+	return withBootFS(func(dir string) error {
+		path := filepath.Join(dir, name)
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, data, 0o644); err != nil {
+			return err
+		}
+		return os.Rename(tmp, path)
+	})
+}
+
+// ImportFiles reads files from the boot partition of the SD card.
+func (p *Platform) ImportFiles(ext string) (map[string][]byte, error) {
+	var files map[string][]byte
+	err := withBootFS(func(dir string) error {
+		var err error
+		files, err = readFiles(dir, ext)
+		return err
+	})
+	return files, err
+}
+
+// The device secret fills the customer OTP memory: random rows
+// followed by a check word, which rejects memory programmed by
+// other software or only partially programmed.
+const otpSecretRows = otpRows - 1
+
+// DeviceSecret returns the random secret programmed into the customer
+// OTP memory of the Raspberry Pi by ProvisionSecret. Unlike the serial
+// number, the secret is not reported by the device, and unlike the
+// settings, it stays with the device when the SD card is removed.
+func (p *Platform) DeviceSecret() ([]byte, error) {
+	otp, err := readOTP()
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(otp, make([]byte, len(otp))) {
+		return nil, gui.ErrNoDeviceSecret
+	}
+	secret, check := otp[:4*otpSecretRows], otp[4*otpSecretRows:]
+	if !bytes.Equal(check, otpCheck(secret)) {
+		return nil, errors.New("otp: memory not programmed by this controller, or programming failed")
+	}
+	return secret, nil
+}
+
+// ProvisionSecret programs a random secret into the customer OTP
+// memory. Programming is permanent, and fails unless the memory is
+// blank.
+func (p *Platform) ProvisionSecret() error {
+	otp, err := readOTP()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(otp, make([]byte, len(otp))) {
+		return errors.New("otp: memory already programmed")
+	}
+	secret := make([]byte, 4*otpSecretRows)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	if err := programOTP(append(secret, otpCheck(secret)...)); err != nil {
+		return err
+	}
+	// Verify the programming, which can't be repeated.
+	_, err = p.DeviceSecret()
+	return err
+}
+
+// otpCheck returns the check word of an OTP secret.
+func otpCheck(secret []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte("seedhammer device secret"))
+	h.Write(secret)
+	return h.Sum(nil)[:4]
+}
+
+// withBootFS mounts the boot partition of the SD card for the
+// duration of f.
+func withBootFS(f func(dir string) error) (ferr error) {
+	const mntDir = "/mnt"
+	if err := os.MkdirAll(mntDir, 0o644); err != nil {
+		return fmt.Errorf("mkdir %s: %w", mntDir, err)
+	}
+	if err := syscall.Mount("/dev/mmcblk0p1", mntDir, "vfat", 0, ""); err != nil {
+		return fmt.Errorf("mount /dev/mmcblk0p1: %w", err)
+	}
+	defer func() {
+		if err := syscall.Unmount(mntDir, 0); ferr == nil {
+			ferr = err
+		}
+	}()
+	return f(mntDir)
+}
@@ -0,0 +1,444 @@
+// command replay runs the controller user interface without hardware,
+// feeding it a script of input events and writing a screenshot after every
+// step. Scripts make bug reports reproducible and serve as end-to-end
+// regression tests.
+//
+// A script is a JSON array of steps. Every field of a step is optional,
+// and the fields are applied in the order listed:
+//
+//	[
+//		{"sdcard": false},
+//		{"click": ["down", "b3"]},
+//		{"press": ["b3"]},
+//		{"release": ["b3"]},
+//		{"runes": "ACCIDENT"},
+//		{"qr": "UR:CRYPTO-SEED/..."},
+//		{"wait": "2s"},
+//		{"screenshot": "seed.png"}
+//	]
+//
+// Buttons are named up, down, left, right, center, b1, b2, b3. Runes are
+// entered like the runes debug command, with spaces and the end of the
+// runes clicking b2. A QR code is shown to the camera until the scan
+// screen exits. Waits advance the clock of the user interface, which
+// otherwise only advances to complete animations and long presses.
+//
+// Screenshots are named after the step index, 000.png being the screen
+// before the first step, unless the step names its screenshot.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kortschak/qr"
+	"seedhammer.com/backup"
+	"seedhammer.com/driver/mjolnir"
+	"seedhammer.com/engrave"
+	"seedhammer.com/gui"
+	"seedhammer.com/image/rgb565"
+	"seedhammer.com/zbar"
+)
+
+var (
+	output  = flag.String("o", "screenshots", "output screenshots to directory")
+	version = flag.String("version", "replay", "version shown by the user interface")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: replay [flags] script.json\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	script, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		return err
+	}
+	var steps []step
+	if err := json.Unmarshal(script, &steps); err != nil {
+		return fmt.Errorf("%s: %w", flag.Arg(0), err)
+	}
+	for i, s := range steps {
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("%s: step %d: %w", flag.Arg(0), i+1, err)
+		}
+	}
+	if err := os.MkdirAll(*output, 0o755); err != nil {
+		return err
+	}
+	p := newPlatform(steps)
+	for range gui.Run(p, *version) {
+		if p.done || p.err != nil {
+			break
+		}
+	}
+	return p.err
+}
+
+// step is a scripted input. See the package documentation.
+type step struct {
+	SDCard     *bool    `json:"sdcard"`
+	Click      []string `json:"click"`
+	Press      []string `json:"press"`
+	Release    []string `json:"release"`
+	Runes      string   `json:"runes"`
+	QR         string   `json:"qr"`
+	Wait       string   `json:"wait"`
+	Screenshot string   `json:"screenshot"`
+}
+
+func (s step) validate() error {
+	for _, names := range [][]string{s.Click, s.Press, s.Release} {
+		for _, name := range names {
+			if _, err := parseButton(name); err != nil {
+				return err
+			}
+		}
+	}
+	if s.Wait != "" {
+		if _, err := time.ParseDuration(s.Wait); err != nil {
+			return err
+		}
+	}
+	if s.QR != "" {
+		if _, err := qr.Encode(s.QR, qr.M); err != nil {
+			return fmt.Errorf("qr: %w", err)
+		}
+	}
+	return nil
+}
+
+func parseButton(name string) (gui.Button, error) {
+	for b := gui.Up; b <= gui.Button3; b++ {
+		if b.String() == name {
+			return b, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown button: %s", name)
+}
+
+// Limits on the frames of a step.
+const (
+	// settleTime is the time to wait for background work, such
+	// as QR scanning and engraving, to wake up the user interface.
+	settleTime = 100 * time.Millisecond
+	// maxAnimation is the longest wakeup the clock advances to
+	// without a wait step.
+	maxAnimation = 5 * time.Second
+	// maxStepFrames bounds the frames of endless animations.
+	maxStepFrames = 1000
+)
+
+// Platform is a gui.Platform that replays a script.
+type Platform struct {
+	steps   []step
+	step    int
+	pending []gui.Event
+	frames  int
+	done    bool
+	err     error
+
+	now      time.Time
+	wakeups  chan struct{}
+	settings gui.Settings
+
+	display *rgb565.Image
+	dirty   struct {
+		rect  image.Rectangle
+		begun bool
+	}
+	camera struct {
+		// requested is set when a frame was requested since
+		// the previous events.
+		requested bool
+		// active is set while the scan screen is running.
+		active bool
+		size   image.Point
+		// qr is the content in view of the camera.
+		qr    string
+		frame *image.YCbCr
+	}
+}
+
+func newPlatform(steps []step) *Platform {
+	return &Platform{
+		steps:   steps,
+		now:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		wakeups: make(chan struct{}, 1),
+		display: rgb565.New(image.Rect(0, 0, 240, 240)),
+	}
+}
+
+func (p *Platform) AppendEvents(deadline time.Time, evts []gui.Event) []gui.Event {
+	p.frames++
+	if len(p.pending) > 0 {
+		evts = append(evts, p.pending...)
+		p.pending = p.pending[:0]
+		return evts
+	}
+	if p.camera.requested {
+		p.camera.requested = false
+		p.camera.active = true
+		if p.camera.qr != "" {
+			evts = append(evts, gui.FrameEvent{Image: p.qrFrame()}.Event())
+			// Pace frames like a camera; the scanner wakes up the
+			// user interface when it completes.
+			p.waitWakeup(settleTime)
+			return evts
+		}
+	} else if p.camera.active {
+		// The scan screen exited; take the code out of view.
+		p.camera.active = false
+		p.camera.qr = ""
+	}
+	if p.waitWakeup(settleTime) {
+		return evts
+	}
+	if !deadline.IsZero() && deadline.Sub(p.now) <= maxAnimation && p.frames < maxStepFrames {
+		if deadline.After(p.now) {
+			p.now = deadline
+		}
+		return evts
+	}
+	// The user interface has settled.
+	name := fmt.Sprintf("%03d.png", p.step)
+	if p.step > 0 && p.steps[p.step-1].Screenshot != "" {
+		name = p.steps[p.step-1].Screenshot
+	}
+	if err := p.screenshot(filepath.Join(*output, name)); err != nil {
+		p.err = err
+		return evts
+	}
+	if p.step == len(p.steps) {
+		p.done = true
+		return evts
+	}
+	p.apply(p.steps[p.step])
+	p.step++
+	p.frames = 0
+	evts = append(evts, p.pending...)
+	p.pending = p.pending[:0]
+	return evts
+}
+
+// apply queues the events of s and applies its other inputs.
+func (p *Platform) apply(s step) {
+	if s.SDCard != nil {
+		p.pending = append(p.pending, gui.SDCardEvent{Inserted: *s.SDCard}.Event())
+	}
+	for _, name := range s.Click {
+		b, _ := parseButton(name)
+		p.pending = append(p.pending,
+			gui.ButtonEvent{Button: b, Pressed: true}.Event(),
+			gui.ButtonEvent{Button: b, Pressed: false}.Event(),
+		)
+	}
+	for _, name := range s.Press {
+		b, _ := parseButton(name)
+		p.pending = append(p.pending, gui.ButtonEvent{Button: b, Pressed: true}.Event())
+	}
+	for _, name := range s.Release {
+		b, _ := parseButton(name)
+		p.pending = append(p.pending, gui.ButtonEvent{Button: b, Pressed: false}.Event())
+	}
+	if s.Runes != "" {
+		for _, r := range strings.ToUpper(s.Runes) {
+			if r == ' ' {
+				p.pending = append(p.pending,
+					gui.ButtonEvent{Button: gui.Button2, Pressed: true}.Event(),
+					gui.ButtonEvent{Button: gui.Button2, Pressed: false}.Event(),
+				)
+				continue
+			}
+			p.pending = append(p.pending, gui.ButtonEvent{Button: gui.Rune, Rune: r, Pressed: true}.Event())
+		}
+		p.pending = append(p.pending,
+			gui.ButtonEvent{Button: gui.Button2, Pressed: true}.Event(),
+			gui.ButtonEvent{Button: gui.Button2, Pressed: false}.Event(),
+		)
+	}
+	if s.QR != "" {
+		p.camera.qr = s.QR
+		p.camera.frame = nil
+	}
+	if s.Wait != "" {
+		d, _ := time.ParseDuration(s.Wait)
+		p.now = p.now.Add(d)
+	}
+}
+
+// waitWakeup waits at most d for a wakeup, and reports whether
+// one arrived.
+func (p *Platform) waitWakeup(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-p.wakeups:
+		return true
+	case <-t.C:
+		return false
+	}
+}
+
+// qrFrame renders the QR code in view to a camera frame, centered in the
+// region aimed at by the camera corners.
+func (p *Platform) qrFrame() *image.YCbCr {
+	c := &p.camera
+	if c.frame != nil && c.frame.Bounds().Size() == c.size {
+		return c.frame
+	}
+	code, err := qr.Encode(c.qr, qr.M)
+	if err != nil {
+		// Validated when the script was loaded.
+		panic(err)
+	}
+	f := image.NewYCbCr(image.Rectangle{Max: c.size}, image.YCbCrSubsampleRatio420)
+	for i := range f.Y {
+		f.Y[i] = 0xff
+	}
+	for i := range f.Cb {
+		f.Cb[i] = 0x80
+		f.Cr[i] = 0x80
+	}
+	scale := max(1, min(c.size.X, c.size.Y)/2/code.Size)
+	off := c.size.Sub(image.Pt(code.Size, code.Size).Mul(scale)).Div(2)
+	for y := range code.Size * scale {
+		for x := range code.Size * scale {
+			if code.Black(x/scale, y/scale) {
+				f.Y[f.YOffset(off.X+x, off.Y+y)] = 0
+			}
+		}
+	}
+	c.frame = f
+	return f
+}
+
+func (p *Platform) screenshot(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := png.Encode(f, p.display); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (p *Platform) Wakeup() {
+	select {
+	case p.wakeups <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Platform) PlateSizes() []backup.PlateSize {
+	return []backup.PlateSize{backup.SquarePlate, backup.LargePlate}
+}
+
+func (p *Platform) Engraver() (gui.Engraver, error) {
+	return &engraver{dev: mjolnir.NewSimulator()}, nil
+}
+
+func (p *Platform) EngraverParams() engrave.Params {
+	return mjolnir.Params
+}
+
+func (p *Platform) CameraFrame(size image.Point, ctrls gui.CameraControls) {
+	p.camera.requested = true
+	p.camera.size = size
+}
+
+func (p *Platform) Now() time.Time {
+	return p.now
+}
+
+func (p *Platform) DisplaySize() image.Point {
+	return p.display.Bounds().Size()
+}
+
+func (p *Platform) Dirty(r image.Rectangle) error {
+	p.dirty.rect = r.Intersect(p.display.Bounds())
+	p.dirty.begun = !p.dirty.rect.Empty()
+	return nil
+}
+
+func (p *Platform) NextChunk() (draw.RGBA64Image, bool) {
+	if !p.dirty.begun {
+		return nil, false
+	}
+	p.dirty.begun = false
+	return p.display.SubImage(p.dirty.rect).(draw.RGBA64Image), true
+}
+
+func (p *Platform) ScanQR(img *image.Gray) ([][]byte, error) {
+	return zbar.Scan(img)
+}
+
+func (p *Platform) Debug() bool {
+	return false
+}
+
+func (p *Platform) LoadSettings() (gui.Settings, error) {
+	return p.settings, nil
+}
+
+func (p *Platform) StoreSettings(s gui.Settings) error {
+	p.settings = s
+	return nil
+}
+
+func (p *Platform) DeviceSecret() ([]byte, error) {
+	// Replays are deterministic.
+	return []byte("replay"), nil
+}
+
+func (p *Platform) ProvisionSecret() error {
+	return nil
+}
+
+func (p *Platform) ExportFile(name string, data []byte) error {
+	return nil
+}
+
+func (p *Platform) ImportFiles(ext string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+func (p *Platform) Beep() {
+}
+
+func (p *Platform) Pointer(on bool) {
+}
+
+type engraver struct {
+	dev *mjolnir.Simulator
+}
+
+func (e *engraver) Engrave(sz backup.PlateSize, plan engrave.Plan, progress func(completed int), pause <-chan bool, quit <-chan struct{}) error {
+	return mjolnir.Engrave(e.dev, mjolnir.Options{Progress: progress, Pause: pause}, plan, quit)
+}
+
+func (e *engraver) Close() {
+	e.dev.Close()
+}
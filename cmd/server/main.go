@@ -0,0 +1,88 @@
+// command server drives the SeedHammer engraver on behalf of a host
+// application, connected through a serial line such as a USB gadget
+// serial device.
+//
+// The protocol is line based. The host submits a job with
+//
+//	job <id>
+//	move <x> <y>
+//	line <x> <y>
+//	...
+//	end
+//
+// where coordinates are in machine units, at most 182x134 millimeters
+// from the origin. The server replies
+//
+//	queued <id> <position>
+//
+// and reports the progress of the job with
+//
+//	progress <id> <percent>
+//	done <id>
+//	cancelled <id>
+//	failed <id> <error>
+//
+// A queued or running job is cancelled with
+//
+//	cancel <id>
+//
+// and
+//
+//	status
+//
+// lists the queued jobs followed by "ok". Malformed requests are answered
+// with "error <message>". Closing the connection cancels every job.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tarm/serial"
+	"seedhammer.com/driver/mjolnir"
+)
+
+var (
+	listen    = flag.String("listen", "", "host serial device (default stdin and stdout)")
+	serialDev = flag.String("device", "", "engraver serial device")
+	simulate  = flag.Bool("sim", false, "simulate the engraver")
+	queueSize = flag.Int("queue", 16, "maximum number of queued jobs")
+)
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "server: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var host io.ReadWriter = struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}
+	if *listen != "" {
+		s, err := serial.OpenPort(&serial.Config{Name: *listen, Baud: 115200})
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+		host = s
+	}
+	if *queueSize < 1 {
+		return errors.New("-queue must be positive")
+	}
+	srv := newServer(host, *queueSize, openEngraver)
+	return srv.Serve()
+}
+
+func openEngraver() (io.ReadWriteCloser, error) {
+	if *simulate {
+		return mjolnir.NewSimulator(), nil
+	}
+	return mjolnir.Open(*serialDev)
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"seedhammer.com/driver/mjolnir"
+	"seedhammer.com/engrave"
+)
+
+type server struct {
+	host io.Reader
+	open func() (io.ReadWriteCloser, error)
+
+	wmu sync.Mutex
+	w   *bufio.Writer
+
+	mu sync.Mutex
+	// queue holds the job being engraved followed by the jobs
+	// waiting in ready, at most queueSize in total.
+	queue     []*job
+	queueSize int
+	ready     chan *job
+}
+
+type job struct {
+	id     string
+	plan   []engrave.Command
+	cancel chan struct{}
+}
+
+// stop cancels the job, if not already cancelled.
+func (j *job) stop() {
+	select {
+	case <-j.cancel:
+	default:
+		close(j.cancel)
+	}
+}
+
+// maxJobSize bounds the number of commands in a job.
+const maxJobSize = 1 << 20
+
+func newServer(host io.ReadWriter, queueSize int, open func() (io.ReadWriteCloser, error)) *server {
+	return &server{
+		host:      host,
+		open:      open,
+		w:         bufio.NewWriter(host),
+		queueSize: queueSize,
+		ready:     make(chan *job, queueSize),
+	}
+}
+
+// Serve processes requests until the host connection is closed. It
+// then cancels every job and returns when the engraver is idle.
+func (s *server) Serve() error {
+	engraved := make(chan struct{})
+	go func() {
+		defer close(engraved)
+		s.engrave()
+	}()
+	defer func() {
+		s.shutdown()
+		<-engraved
+	}()
+	r := bufio.NewScanner(s.host)
+	for r.Scan() {
+		fields := strings.Fields(r.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch cmd, args := fields[0], fields[1:]; {
+		case cmd == "job" && len(args) == 1:
+			s.submit(args[0], r)
+		case cmd == "cancel" && len(args) == 1:
+			s.cancel(args[0])
+		case cmd == "status" && len(args) == 0:
+			s.status()
+		default:
+			s.reply("error unknown request: %s", r.Text())
+		}
+	}
+	return r.Err()
+}
+
+// lineBreaks flattens multi-line messages, such as errors, to a
+// single reply line.
+var lineBreaks = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+func (s *server) reply(format string, args ...any) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	lineBreaks.WriteString(s.w, fmt.Sprintf(format, args...))
+	s.w.WriteByte('\n')
+	s.w.Flush()
+}
+
+func (s *server) submit(id string, r *bufio.Scanner) {
+	j := &job{id: id, cancel: make(chan struct{})}
+	var err error
+	ended := false
+	for r.Scan() {
+		line := r.Text()
+		if line == "end" {
+			ended = true
+			break
+		}
+		if err != nil {
+			// Skip the remaining commands of the invalid job.
+			continue
+		}
+		var c engrave.Command
+		c, err = parseCommand(line)
+		if err == nil && len(j.plan) == maxJobSize {
+			err = errors.New("job too large")
+		}
+		j.plan = append(j.plan, c)
+	}
+	if err == nil && !ended {
+		err = errors.New("missing end")
+	}
+	if err != nil {
+		s.reply("error job %s: %v", id, err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, q := range s.queue {
+		if q.id == id {
+			s.reply("error job %s: duplicate id", id)
+			return
+		}
+	}
+	if len(s.queue) >= s.queueSize {
+		s.reply("error job %s: queue full", id)
+		return
+	}
+	// The send doesn't block, because ready holds fewer jobs than
+	// the queue.
+	s.ready <- j
+	s.queue = append(s.queue, j)
+	s.reply("queued %s %d", id, len(s.queue))
+}
+
+// workArea is the reach of the needle, in millimeters, that covers
+// a plate. The largest plates are 85x134 millimeters and mounted 97
+// millimeters from the origin.
+var workArea = image.Rect(0, 0, 97+85, 134)
+
+func parseCommand(line string) (engrave.Command, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return engrave.Command{}, fmt.Errorf("invalid command: %q", line)
+	}
+	x, errx := strconv.ParseUint(fields[1], 10, 24)
+	y, erry := strconv.ParseUint(fields[2], 10, 24)
+	if errx != nil || erry != nil {
+		return engrave.Command{}, fmt.Errorf("invalid coordinates: %q", line)
+	}
+	p := image.Pt(int(x), int(y))
+	if lim := workArea.Max.Mul(mjolnir.Params.Millimeter); p.X > lim.X || p.Y > lim.Y {
+		return engrave.Command{}, fmt.Errorf("coordinates outside work area: %q", line)
+	}
+	switch fields[0] {
+	case "move":
+		return engrave.Move(p), nil
+	case "line":
+		return engrave.Line(p), nil
+	default:
+		return engrave.Command{}, fmt.Errorf("invalid command: %q", line)
+	}
+}
+
+func (s *server) cancel(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.queue {
+		if j.id == id {
+			j.stop()
+			return
+		}
+	}
+	s.reply("error cancel %s: unknown job", id)
+}
+
+// shutdown cancels all jobs and stops the engraving of new ones.
+func (s *server) shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.queue {
+		j.stop()
+	}
+	close(s.ready)
+}
+
+func (s *server) status() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, j := range s.queue {
+		state := "queued"
+		if i == 0 {
+			state = "engraving"
+		}
+		s.reply("job %s %s", j.id, state)
+	}
+	s.reply("ok")
+}
+
+// engrave runs queued jobs in order.
+func (s *server) engrave() {
+	for j := range s.ready {
+		result := s.run(j)
+		// Remove the job before reporting its result, so the
+		// host can't observe it after the result.
+		s.mu.Lock()
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+		s.reply("%s", result)
+	}
+}
+
+// run engraves a job and returns its result.
+func (s *server) run(j *job) string {
+	select {
+	case <-j.cancel:
+		return fmt.Sprintf("cancelled %s", j.id)
+	default:
+	}
+	dev, err := s.open()
+	if err != nil {
+		return fmt.Sprintf("failed %s %v", j.id, err)
+	}
+	defer dev.Close()
+	last := -1
+	opts := mjolnir.Options{
+		Progress: func(completed int) {
+			if p := 100 * completed / len(j.plan); p > last {
+				last = p
+				s.reply("progress %s %d", j.id, p)
+			}
+		},
+	}
+	plan := func(yield func(engrave.Command) bool) {
+		for _, c := range j.plan {
+			if !yield(c) {
+				return
+			}
+		}
+	}
+	err = mjolnir.Engrave(dev, opts, plan, j.cancel)
+	switch {
+	case errors.Is(err, mjolnir.ErrCancelled):
+		return fmt.Sprintf("cancelled %s", j.id)
+	case err != nil:
+		return fmt.Sprintf("failed %s %v", j.id, err)
+	default:
+		return fmt.Sprintf("done %s", j.id)
+	}
+}
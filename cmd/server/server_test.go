@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"seedhammer.com/driver/mjolnir"
+)
+
+func TestTruncatedJob(t *testing.T) {
+	h := newHost(t, 1, openSimulator)
+	h.send("job a\nmove 1 2\nline 3 4\n")
+	h.close()
+	h.expect("error job a: missing end")
+}
+
+func TestOutsideWorkArea(t *testing.T) {
+	h := newHost(t, 1, openSimulator)
+	h.send("job a\nmove 100 100\nline 22933 100\nend\n")
+	h.expect(`error job a: coordinates outside work area: "line 22933 100"`)
+	h.send("job b\nmove 100 100\nline 22932 16884\nend\n")
+	h.expect("queued b 1")
+	h.until("done b")
+}
+
+func TestQueueFull(t *testing.T) {
+	gate := make(chan struct{})
+	defer close(gate)
+	h := newHost(t, 2, gated(gate))
+	const job = "move 1 2\nline 3 4\nend\n"
+	h.send("job a\n" + job + "job b\n" + job + "job c\n" + job)
+	h.expect("queued a 1")
+	h.expect("queued b 2")
+	h.expect("error job c: queue full")
+}
+
+func TestJobDone(t *testing.T) {
+	h := newHost(t, 1, openSimulator)
+	h.send("job a\nmove 100 100\nline 200 100\nline 200 200\nend\n")
+	h.expect("queued a 1")
+	progress := h.until("done a")
+	if n := len(progress); n == 0 || progress[n-1] != "progress a 100" {
+		t.Errorf("progress replies %q, want a final %q", progress, "progress a 100")
+	}
+	h.send("status\n")
+	h.expect("ok")
+}
+
+func TestMultiLineError(t *testing.T) {
+	h := newHost(t, 1, func() (io.ReadWriteCloser, error) {
+		return nil, errors.New("unexpected reply\r\nexp: 0x1\ngot: 0x2")
+	})
+	h.send("job a\nmove 1 2\nend\n")
+	h.expect("queued a 1")
+	h.expect("failed a unexpected reply exp: 0x1 got: 0x2")
+	h.send("status\n")
+	h.expect("ok")
+}
+
+func TestCancelQueued(t *testing.T) {
+	gate := make(chan struct{})
+	h := newHost(t, 2, gated(gate))
+	const job = "move 100 100\nline 200 100\nend\n"
+	h.send("job a\n" + job + "job b\n" + job)
+	h.expect("queued a 1")
+	h.expect("queued b 2")
+	h.send("status\n")
+	h.expect("job a engraving")
+	h.expect("job b queued")
+	h.expect("ok")
+	h.send("cancel b\n")
+	close(gate)
+	h.until("done a")
+	h.expect("cancelled b")
+	h.send("cancel b\n")
+	h.expect("error cancel b: unknown job")
+}
+
+func TestCancelRunning(t *testing.T) {
+	h := newHost(t, 1, openSlowSimulator)
+	h.send(longJob("a"))
+	h.expect("queued a 1")
+	h.until("progress a 0")
+	h.send("cancel a\n")
+	if skipped := h.until("cancelled a"); slices.Contains(skipped, "done a") {
+		t.Fatal("job completed before it was cancelled")
+	}
+	h.send("status\n")
+	h.expect("ok")
+}
+
+func TestHostClosed(t *testing.T) {
+	h := newHost(t, 2, openSlowSimulator)
+	h.send(longJob("a") + longJob("b"))
+	h.expect("queued a 1")
+	h.expect("queued b 2")
+	h.until("progress a 0")
+	h.close()
+	h.until("cancelled a")
+	h.expect("cancelled b")
+}
+
+// longJob returns a job that takes seconds to engrave on a slowDevice.
+func longJob(id string) string {
+	job := new(strings.Builder)
+	fmt.Fprintf(job, "job %s\n", id)
+	for i := range 1000 {
+		fmt.Fprintf(job, "line %d 100\n", 100+i)
+	}
+	job.WriteString("end\n")
+	return job.String()
+}
+
+func openSlowSimulator() (io.ReadWriteCloser, error) {
+	return &slowDevice{ReadWriteCloser: mjolnir.NewSimulator()}, nil
+}
+
+// slowDevice delays every read from an engraver.
+type slowDevice struct {
+	io.ReadWriteCloser
+}
+
+func (d *slowDevice) Read(p []byte) (int, error) {
+	time.Sleep(5 * time.Millisecond)
+	return d.ReadWriteCloser.Read(p)
+}
+
+func openSimulator() (io.ReadWriteCloser, error) {
+	return mjolnir.NewSimulator(), nil
+}
+
+// gated returns an engraver connector that waits for gate to
+// close before connecting to a simulated engraver.
+func gated(gate <-chan struct{}) func() (io.ReadWriteCloser, error) {
+	return func() (io.ReadWriteCloser, error) {
+		<-gate
+		return openSimulator()
+	}
+}
+
+// host is the host end of the connection to a server.
+type host struct {
+	t       *testing.T
+	w       *io.PipeWriter
+	replies chan string
+	served  chan error
+}
+
+func newHost(t *testing.T, queueSize int, open func() (io.ReadWriteCloser, error)) *host {
+	reqr, reqw := io.Pipe()
+	repr, repw := io.Pipe()
+	h := &host{
+		t:       t,
+		w:       reqw,
+		replies: make(chan string, 100),
+		served:  make(chan error, 1),
+	}
+	s := newServer(struct {
+		io.Reader
+		io.Writer
+	}{reqr, repw}, queueSize, open)
+	go func() {
+		h.served <- s.Serve()
+		repw.Close()
+	}()
+	go func() {
+		defer close(h.replies)
+		r := bufio.NewScanner(repr)
+		for r.Scan() {
+			h.replies <- r.Text()
+		}
+	}()
+	t.Cleanup(h.close)
+	return h
+}
+
+// send sends requests to the server.
+func (h *host) send(requests string) {
+	h.t.Helper()
+	if _, err := io.WriteString(h.w, requests); err != nil {
+		h.t.Fatal(err)
+	}
+}
+
+// next returns the next reply.
+func (h *host) next() (string, bool) {
+	h.t.Helper()
+	select {
+	case r, ok := <-h.replies:
+		return r, ok
+	case <-time.After(5 * time.Second):
+		h.t.Fatal("no reply")
+		return "", false
+	}
+}
+
+// expect fails the test unless the next reply is want.
+func (h *host) expect(want string) {
+	h.t.Helper()
+	if got, ok := h.next(); !ok || got != want {
+		h.t.Fatalf("replied %q, want %q", got, want)
+	}
+}
+
+// until skips replies until want. It returns the skipped replies.
+func (h *host) until(want string) []string {
+	h.t.Helper()
+	var skipped []string
+	for {
+		got, ok := h.next()
+		if !ok {
+			h.t.Fatalf("connection closed after replies %q, want %q", skipped, want)
+		}
+		if got == want {
+			return skipped
+		}
+		skipped = append(skipped, got)
+	}
+}
+
+// close closes the connection and waits for the server to stop.
+func (h *host) close() {
+	h.w.Close()
+	select {
+	case err := <-h.served:
+		if err != nil && !errors.Is(err, io.ErrClosedPipe) {
+			h.t.Error(err)
+		}
+		h.served <- nil
+	case <-time.After(5 * time.Second):
+		h.t.Error("server didn't stop")
+	}
+}
@@ -0,0 +1,282 @@
+// command webpreview serves a web page for previewing the plates of a
+// wallet backup. The plates are laid out and rendered exactly as the
+// controller engraves them.
+//
+// Seed phrases are optional. The seed side of a plate whose seed is not
+// given is previewed with random words of the chosen length, because the
+// layout depends only on the number of words.
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"image"
+	"image/png"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/kortschak/qr"
+	"seedhammer.com/backup"
+	"seedhammer.com/bc/urtypes"
+	"seedhammer.com/bip32"
+	"seedhammer.com/bip39"
+	"seedhammer.com/driver/mjolnir"
+	"seedhammer.com/engrave"
+	"seedhammer.com/font/condensed"
+	"seedhammer.com/font/constant"
+	"seedhammer.com/font/vector"
+	"seedhammer.com/nonstandard"
+)
+
+var (
+	addr = flag.String("http", "localhost:8080", "listen address")
+	ppmm = flag.Int("ppmm", 8, "pixels per millimeter of the previews")
+)
+
+func main() {
+	flag.Parse()
+	http.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		render(w, &preview{Size: "SH02", Font: "regular", Words: 24})
+	})
+	http.HandleFunc("POST /{$}", servePreview)
+	log.Printf("webpreview: serving on http://%s", *addr)
+	if err := http.ListenAndServe(*addr, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "webpreview: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// preview is the form and the resulting plates.
+type preview struct {
+	Descriptor string
+	Title      string
+	Seeds      string
+	Words      int
+	Size       string
+	Font       string
+
+	Err    string
+	Plates []plate
+}
+
+type plate struct {
+	Name string
+	// Image is the data URL of the rendering.
+	Image template.URL
+	Err   string
+}
+
+func servePreview(w http.ResponseWriter, r *http.Request) {
+	p := &preview{
+		Descriptor: r.FormValue("descriptor"),
+		Title:      r.FormValue("title"),
+		Seeds:      r.FormValue("seeds"),
+		Size:       r.FormValue("size"),
+		Font:       r.FormValue("font"),
+		Words:      24,
+	}
+	if r.FormValue("words") == "12" {
+		p.Words = 12
+	}
+	if err := p.render(); err != nil {
+		p.Err = err.Error()
+	}
+	render(w, p)
+}
+
+func render(w http.ResponseWriter, p *preview) {
+	if err := page.Execute(w, p); err != nil {
+		log.Printf("webpreview: %v", err)
+	}
+}
+
+// render lays out and renders the plates of the preview.
+func (p *preview) render() error {
+	desc, err := nonstandard.OutputDescriptor([]byte(strings.TrimSpace(p.Descriptor)))
+	if err != nil {
+		return err
+	}
+	if len(desc.Keys) == 0 {
+		return errors.New("descriptor contains no keys")
+	}
+	if p.Title != "" {
+		desc.Title = p.Title
+	}
+	desc.Title = backup.TitleString(constant.Font, desc.Title)
+	var size backup.PlateSize
+	switch p.Size {
+	case "SH02":
+		size = backup.SquarePlate
+	case "SH03":
+		size = backup.LargePlate
+	default:
+		return fmt.Errorf("unknown plate size: %s", p.Size)
+	}
+	var font *vector.Face
+	switch p.Font {
+	case "regular":
+		font = constant.Font
+	case "condensed":
+		font = condensed.Font
+	default:
+		return fmt.Errorf("unknown font: %s", p.Font)
+	}
+	seeds := make(map[int]bip39.Mnemonic)
+	for _, phrase := range strings.Split(p.Seeds, "\n") {
+		phrase = strings.Join(strings.Fields(phrase), " ")
+		if phrase == "" {
+			continue
+		}
+		m, err := bip39.ParseMnemonic(phrase)
+		if err != nil {
+			return fmt.Errorf("invalid seed phrase: %w", err)
+		}
+		mk, err := hdkeychain.NewMaster(bip39.MnemonicSeed(m, ""), desc.Keys[0].Network)
+		if err != nil {
+			return err
+		}
+		keyIdx, err := keyIndex(desc, mk)
+		if err != nil {
+			return err
+		}
+		seeds[keyIdx] = m
+	}
+	for keyIdx, k := range desc.Keys {
+		name := fmt.Sprintf("Plate %d of %d", keyIdx+1, len(desc.Keys))
+		front, err := backup.EngraveDescriptor(mjolnir.Params, backup.Descriptor{
+			Descriptor: desc,
+			KeyIdx:     keyIdx,
+			Font:       font,
+			Size:       size,
+			QRLevel:    qr.M,
+		})
+		p.Plates = append(p.Plates, rasterize(name+", descriptor side", size, front, err))
+		m, ok := seeds[keyIdx]
+		if !ok {
+			m = make(bip39.Mnemonic, p.Words)
+			for i := range m {
+				m[i] = bip39.RandomWord()
+			}
+			m = m.FixChecksum()
+		}
+		back, err := backup.EngraveSeed(mjolnir.Params, backup.Seed{
+			Title:             desc.Title,
+			KeyIdx:            keyIdx,
+			Mnemonic:          m,
+			Keys:              len(desc.Keys),
+			MasterFingerprint: k.MasterFingerprint,
+			Font:              constant.Font,
+			Size:              size,
+			QRLevel:           qr.M,
+		})
+		name += ", seed side"
+		if !ok {
+			name += " (random words)"
+		}
+		p.Plates = append(p.Plates, rasterize(name, size, back, err))
+	}
+	return nil
+}
+
+func keyIndex(desc urtypes.OutputDescriptor, mk *hdkeychain.ExtendedKey) (int, error) {
+	for i, k := range desc.Keys {
+		_, xpub, err := bip32.Derive(mk, k.DerivationPath)
+		if err != nil {
+			continue
+		}
+		if k.String() == xpub.String() {
+			return i, nil
+		}
+	}
+	return 0, errors.New("seed is not among the descriptor keys")
+}
+
+// rasterize renders the plan of a plate side, or records the error
+// from laying it out.
+func rasterize(name string, size backup.PlateSize, plan engrave.Plan, err error) plate {
+	pl := plate{Name: name}
+	if err != nil {
+		pl.Err = err.Error()
+		return pl
+	}
+	dims := size.Dims().Mul(*ppmm)
+	img := image.NewNRGBA(image.Rectangle{Max: dims})
+	params := mjolnir.Params
+	r := engrave.NewRasterizer(img, img.Bounds(), float32(*ppmm)/float32(params.Millimeter), params.StrokeWidth**ppmm/params.Millimeter)
+	for c := range plan {
+		r.Command(c)
+	}
+	r.Rasterize()
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		pl.Err = err.Error()
+		return pl
+	}
+	pl.Image = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()))
+	return pl
+}
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>SeedHammer plate preview</title>
+<style>
+	body { font-family: sans-serif; max-width: 960px; margin: 16px auto; }
+	textarea { width: 100%; }
+	.plates { display: flex; flex-wrap: wrap; gap: 16px; }
+	.plate img { background: #ccc; max-width: 100%; }
+	.error { color: #c00; }
+</style>
+</head>
+<body>
+<h1>Plate preview</h1>
+<p>
+Seed phrases are optional and never leave this computer, but consider previewing with a new seed
+instead of a seed in use.
+</p>
+<form method="post">
+	<p><label>Output descriptor<br><textarea name="descriptor" rows="5">{{.Descriptor}}</textarea></label></p>
+	<p><label>Title <input name="title" value="{{.Title}}"></label></p>
+	<p><label>Seed phrases, one per line<br><textarea name="seeds" rows="3">{{.Seeds}}</textarea></label></p>
+	<p>
+	<label>Words of unknown seeds
+		<select name="words">
+			<option {{if eq .Words 12}}selected{{end}}>12</option>
+			<option {{if eq .Words 24}}selected{{end}}>24</option>
+		</select>
+	</label>
+	<label>Plate size
+		<select name="size">
+			<option {{if eq .Size "SH02"}}selected{{end}}>SH02</option>
+			<option {{if eq .Size "SH03"}}selected{{end}}>SH03</option>
+		</select>
+	</label>
+	<label>Descriptor font
+		<select name="font">
+			<option {{if eq .Font "regular"}}selected{{end}}>regular</option>
+			<option {{if eq .Font "condensed"}}selected{{end}}>condensed</option>
+		</select>
+	</label>
+	</p>
+	<p><input type="submit" value="Preview"></p>
+</form>
+{{if .Err}}<p class="error">{{.Err}}</p>{{end}}
+<div class="plates">
+{{range .Plates}}
+	<div class="plate">
+		<h3>{{.Name}}</h3>
+		{{if .Err}}<p class="error">{{.Err}}</p>{{else}}<img src="{{.Image}}" alt="{{.Name}}">{{end}}
+	</div>
+{{end}}
+</div>
+</body>
+</html>
+`))
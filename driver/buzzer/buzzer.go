@@ -0,0 +1,55 @@
+// package buzzer implements a driver for an active buzzer connected
+// between a GPIO pin and ground.
+package buzzer
+
+import (
+	"fmt"
+	"time"
+
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/gpio/gpioreg"
+	"periph.io/x/host/v3"
+)
+
+// Buzzer sounds an active buzzer.
+type Buzzer struct {
+	beeps chan struct{}
+}
+
+// beepDuration is the length of a beep.
+const beepDuration = 30 * time.Millisecond
+
+// Open initializes the buzzer connected to the named pin, for
+// example "GPIO17".
+func Open(pin string) (*Buzzer, error) {
+	if _, err := host.Init(); err != nil {
+		return nil, err
+	}
+	p := gpioreg.ByName(pin)
+	if p == nil {
+		return nil, fmt.Errorf("buzzer: unknown pin: %s", pin)
+	}
+	if err := p.Out(gpio.Low); err != nil {
+		return nil, fmt.Errorf("buzzer: %w", err)
+	}
+	b := &Buzzer{beeps: make(chan struct{}, 1)}
+	go b.run(p)
+	return b, nil
+}
+
+// Beep sounds a short beep without blocking. Beeps requested
+// while the buzzer is sounding are merged.
+func (b *Buzzer) Beep() {
+	select {
+	case b.beeps <- struct{}{}:
+	default:
+	}
+}
+
+func (b *Buzzer) run(pin gpio.PinOut) {
+	for range b.beeps {
+		pin.Out(gpio.High)
+		time.Sleep(beepDuration)
+		pin.Out(gpio.Low)
+	}
+}
@@ -0,0 +1,79 @@
+// package estop implements a driver for an external, normally-closed
+// emergency stop switch connected between a GPIO pin and ground.
+package estop
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/gpio/gpioreg"
+	"periph.io/x/host/v3"
+	"seedhammer.com/gui"
+)
+
+// Switch tracks the state of an emergency stop switch.
+type Switch struct {
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+// Open starts monitoring the switch connected to the named pin,
+// for example "GPIO4". Changes to the switch state are reported
+// as [gui.EmergencyStopEvent] events.
+func Open(pin string, ch chan<- gui.Event) (*Switch, error) {
+	if _, err := host.Init(); err != nil {
+		return nil, err
+	}
+	p := gpioreg.ByName(pin)
+	if p == nil {
+		return nil, fmt.Errorf("estop: unknown pin: %s", pin)
+	}
+	// The closed switch pulls the pin low. An open switch or a broken
+	// wire lets the pull-up raise it, triggering the stop.
+	if err := p.In(gpio.PullUp, gpio.BothEdges); err != nil {
+		return nil, fmt.Errorf("estop: %w", err)
+	}
+	s := &Switch{stop: make(chan struct{})}
+	go s.run(p, ch)
+	return s, nil
+}
+
+// Stop returns a channel that is closed when the switch is
+// triggered.
+func (s *Switch) Stop() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stop
+}
+
+func (s *Switch) run(pin gpio.PinIn, ch chan<- gui.Event) {
+	const releaseTimeout = 100 * time.Millisecond
+	triggered := false
+	for {
+		open := pin.Read() == gpio.High
+		switch {
+		case open && !triggered:
+			// Don't debounce the trigger; halt the engraver
+			// before notifying anyone else.
+			triggered = true
+			s.mu.Lock()
+			close(s.stop)
+			s.mu.Unlock()
+			ch <- gui.EmergencyStopEvent{Triggered: true}.Event()
+		case !open && triggered:
+			// Re-arm only after the switch has settled.
+			if pin.WaitForEdge(releaseTimeout) {
+				continue
+			}
+			triggered = false
+			s.mu.Lock()
+			s.stop = make(chan struct{})
+			s.mu.Unlock()
+			ch <- gui.EmergencyStopEvent{Triggered: false}.Event()
+			continue
+		}
+		pin.WaitForEdge(-1)
+	}
+}
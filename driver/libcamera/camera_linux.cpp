@@ -15,6 +15,8 @@ static std::unique_ptr<CameraConfiguration> config;
 static std::shared_ptr<Camera> camera;
 static std::vector<std::unique_ptr<Request>> requests;
 static uintptr_t callback_handle;
+// crop is the sensor area of the unzoomed stream.
+static Rectangle crop;
 
 static void requestComplete(Request *req) {
   if (req->status() == Request::RequestCancelled)
@@ -47,9 +49,18 @@ buffer buffer_at(size_t idx) {
 
 size_t num_buffers() { return requests.size(); }
 
-int queue_request(size_t buf_idx) {
+int queue_request(size_t buf_idx, unsigned int zoom, float ev) {
   auto &req = requests.at(buf_idx);
   req.get()->reuse(Request::ReuseBuffers);
+  // Digital zoom crops the center of the unzoomed area.
+  Rectangle zoomed = {};
+  zoomed.width = crop.width / zoom;
+  zoomed.height = crop.height / zoom;
+  zoomed.x = crop.x + (crop.width - zoomed.width) / 2;
+  zoomed.y = crop.y + (crop.height - zoomed.height) / 2;
+  auto &ctrls = req.get()->controls();
+  ctrls.set(controls::ScalerCrop, zoomed);
+  ctrls.set(controls::ExposureValue, ev);
   return camera->queueRequest(req.get());
 }
 
@@ -178,7 +189,7 @@ int start_camera(unsigned int width, unsigned int height) {
   Size sz = {width, height};
   auto max_size =
       camera->properties().get(properties::PixelArraySize).value_or(sz);
-  Rectangle crop = {};
+  crop = {};
   crop.x = (max_size.width - width) / 2;
   crop.y = (max_size.height - height) / 2;
   crop.width = width;
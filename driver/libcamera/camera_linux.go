@@ -18,12 +18,28 @@ import (
 	"fmt"
 	"image"
 	"runtime/cgo"
+	"sync/atomic"
 	"syscall"
 
 	"seedhammer.com/gui"
 )
 
+// Controls are the adjustable controls of a running camera. They
+// are applied to every frame requested after Set.
+type Controls struct {
+	zoomLevel atomic.Int32
+	exposure  atomic.Int32
+}
+
+// Set the digital zoom factor to 2^zoomLevel and the exposure
+// compensation to exposure half stops.
+func (c *Controls) Set(zoomLevel, exposure int) {
+	c.zoomLevel.Store(int32(zoomLevel))
+	c.exposure.Store(int32(exposure))
+}
+
 type Camera struct {
+	controls  *Controls
 	frames    chan gui.FrameEvent
 	out       <-chan gui.FrameEvent
 	bufs      chan C.size_t
@@ -56,8 +72,9 @@ func requestCallback(handle C.uintptr_t, bufIdx C.size_t) {
 	}
 }
 
-func Open(dims image.Point, frames chan gui.FrameEvent, out <-chan gui.FrameEvent) func() {
+func Open(dims image.Point, frames chan gui.FrameEvent, out <-chan gui.FrameEvent, ctrls *Controls) func() {
 	c := &Camera{
+		controls:  ctrls,
 		frames:    frames,
 		out:       out,
 		destroyed: make(chan struct{}),
@@ -107,7 +124,9 @@ func (c *Camera) setup(dims image.Point) error {
 			case f := <-c.out:
 				for bufIdx, img := range imgs {
 					if img == f.Image {
-						if res := C.queue_request(C.uint32_t(bufIdx)); res != 0 {
+						zoom := C.uint(1) << c.controls.zoomLevel.Load()
+						ev := C.float(c.controls.exposure.Load()) / 2
+						if res := C.queue_request(C.size_t(bufIdx), zoom, ev); res != 0 {
 							return fmt.Errorf("queue_request: %d", res)
 						}
 						return nil
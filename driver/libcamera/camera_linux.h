@@ -18,7 +18,7 @@ typedef struct {
 extern int open_camera(unsigned int width, unsigned int height, uintptr_t handle);
 extern int start_camera(unsigned int width, unsigned int height);
 extern void close_camera();
-extern int queue_request(size_t buf_idx);
+extern int queue_request(size_t buf_idx, unsigned int zoom, float ev);
 extern size_t num_buffers();
 extern buffer buffer_at(size_t idx);
 extern format frame_format();
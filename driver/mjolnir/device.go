@@ -4,25 +4,32 @@ package mjolnir
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 
 	"github.com/tarm/serial"
 )
 
-func Open(dev string) (io.ReadWriteCloser, error) {
-	// Hardware parameters.
-	const (
-		baudRate         = 115200
-		stopBits         = 1
-		parity           = false
-		wordLen          = 8
-		controlHandshake = 0
-		flowReplace      = 0
-		xonLimit         = 2048
-		xoffLimit        = 512
-	)
+// Hardware parameters.
+const (
+	baudRate         = 115200
+	stopBits         = 1
+	parity           = false
+	wordLen          = 8
+	controlHandshake = 0
+	flowReplace      = 0
+	xonLimit         = 2048
+	xoffLimit        = 512
+)
 
+// Open opens the serial device dev, or the first of the default
+// devices if dev is empty.
+func Open(dev string) (io.ReadWriteCloser, error) {
 	var devices []string
 	if dev != "" {
 		devices = append(devices, dev)
@@ -39,8 +46,7 @@ func Open(dev string) (io.ReadWriteCloser, error) {
 	}
 	var firstErr error
 	for _, dev := range devices {
-		c := &serial.Config{Name: dev, Baud: baudRate}
-		s, err := serial.OpenPort(c)
+		s, err := openPort(dev)
 		if err == nil {
 			return s, nil
 		}
@@ -50,3 +56,71 @@ func Open(dev string) (io.ReadWriteCloser, error) {
 	}
 	return nil, firstErr
 }
+
+// Discover probes the USB serial adapters of the kind built into
+// engravers, and returns the path of the first that completes the
+// engraver handshake of Query within timeout, along with its open
+// connection. Other serial devices, such as modems, are left alone.
+func Discover(timeout time.Duration) (string, io.ReadWriteCloser, error) {
+	devices := engraverPorts("/sys/class/tty")
+	if len(devices) == 0 {
+		return "", nil, fmt.Errorf("discover: %w", ErrNoResponse)
+	}
+	var firstErr error
+	for _, path := range devices {
+		dev, err := openPort(path)
+		if err == nil {
+			if _, err = Query(dev, timeout); err == nil {
+				return path, dev, nil
+			}
+		}
+		// Prefer errors from devices that responded, such
+		// as a busy engraver.
+		if firstErr == nil || errors.Is(firstErr, ErrNoResponse) && !errors.Is(err, ErrNoResponse) {
+			firstErr = fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return "", nil, firstErr
+}
+
+// engraverUSB lists the USB vendor and product IDs of the FTDI
+// serial adapters used by engravers. The controller kernel includes
+// the FTDI driver only.
+var engraverUSB = []struct{ vendor, product string }{
+	{"0403", "6001"}, // FT232R.
+	{"0403", "6014"}, // FT232H.
+	{"0403", "6015"}, // FT-X series.
+}
+
+// engraverPorts lists the devices of the serial ports in the Linux
+// sysfs tty class directory whose USB IDs match engraverUSB.
+func engraverPorts(class string) []string {
+	ttys, _ := filepath.Glob(filepath.Join(class, "ttyUSB*"))
+	var devices []string
+	for _, tty := range ttys {
+		// The device of a tty is the USB interface, whose parent
+		// is the USB device.
+		intf, err := filepath.EvalSymlinks(filepath.Join(tty, "device"))
+		if err != nil {
+			continue
+		}
+		usb := filepath.Dir(intf)
+		vendor, err1 := os.ReadFile(filepath.Join(usb, "idVendor"))
+		product, err2 := os.ReadFile(filepath.Join(usb, "idProduct"))
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		for _, id := range engraverUSB {
+			if strings.TrimSpace(string(vendor)) == id.vendor && strings.TrimSpace(string(product)) == id.product {
+				devices = append(devices, filepath.Join("/dev", filepath.Base(tty)))
+				break
+			}
+		}
+	}
+	return devices
+}
+
+func openPort(dev string) (io.ReadWriteCloser, error) {
+	c := &serial.Config{Name: dev, Baud: baudRate}
+	return serial.OpenPort(c)
+}
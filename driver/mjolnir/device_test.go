@@ -0,0 +1,44 @@
+//go:build !tinygo
+
+package mjolnir
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestEngraverPorts(t *testing.T) {
+	root := t.TempDir()
+	class := filepath.Join(root, "class", "tty")
+	// addPort adds a tty to a fake sysfs, linked to the interface of
+	// a USB device with the IDs.
+	addPort := func(tty, vendor, product string) {
+		usb := filepath.Join(root, "devices", tty)
+		intf := filepath.Join(usb, "1-1:1.0")
+		if err := os.MkdirAll(intf, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(usb, "idVendor"), []byte(vendor+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(usb, "idProduct"), []byte(product+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		dir := filepath.Join(class, tty)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(intf, filepath.Join(dir, "device")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	addPort("ttyUSB0", "1a86", "7523")
+	addPort("ttyUSB1", "0403", "6001")
+	addPort("ttyACM0", "0403", "6001")
+	got := engraverPorts(class)
+	if want := []string{"/dev/ttyUSB1"}; !slices.Equal(got, want) {
+		t.Errorf("engraver ports %v, want %v", got, want)
+	}
+}
@@ -5,8 +5,13 @@ package mjolnir
 import (
 	"errors"
 	"io"
+	"time"
 )
 
 func Open(dev string) (io.ReadWriteCloser, error) {
 	return nil, errors.New("not implemented")
 }
+
+func Discover(timeout time.Duration) (string, io.ReadWriteCloser, error) {
+	return "", nil, errors.New("not implemented")
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"image"
 	"io"
+	"time"
 
 	"seedhammer.com/engrave"
 )
@@ -19,15 +20,79 @@ type program struct {
 	sent  int
 }
 
-var Params = engrave.Params{
-	StrokeWidth: 38,
-	Millimeter:  126,
-}
+// Params are the parameters of the engraver with the
+// speeds of NormalProfile.
+var Params = NormalProfile.Params()
 
 type Options struct {
+	// Profile selects the speeds not specified by MoveSpeed
+	// and PrintSpeed.
+	Profile    Profile
 	MoveSpeed  float32
 	PrintSpeed float32
 	End        image.Point
+	// Stop, if not nil, halts the engraver when closed. Unlike
+	// quit, the needle is left where it is and Engrave returns
+	// ErrStopped.
+	Stop <-chan struct{}
+	// Progress, if not nil, is called with the number of plan
+	// commands executed by the engraver.
+	Progress func(completed int)
+	// Pause, if not nil, receives pause requests. After receiving
+	// true, the engraver completes the commands already sent and
+	// waits before the next move until false is received.
+	Pause <-chan bool
+	// Timeout, if not zero, bounds the wait for every response of
+	// the engraver. It must exceed the duration of the longest
+	// command. Engrave returns a *StalledError if it expires, and
+	// leaves a read of the device pending.
+	Timeout time.Duration
+}
+
+// Profile is an engraving speed profile, trading quality
+// for speed.
+type Profile int
+
+const (
+	NormalProfile Profile = iota
+	FineProfile
+	FastProfile
+)
+
+// Speeds returns the move and print speeds of the profile.
+func (p Profile) Speeds() (move, print float32) {
+	switch p {
+	case FineProfile:
+		return defaultMoveSpeed, .05
+	case FastProfile:
+		return .7, .2
+	default:
+		return defaultMoveSpeed, defaultPrintSpeed
+	}
+}
+
+// Params returns the engraver parameters with the speeds of
+// the profile.
+func (p Profile) Params() engrave.Params {
+	move, print := stepDelays(p.Speeds())
+	return engrave.Params{
+		StrokeWidth: 38,
+		Millimeter:  126,
+		// The engraver interprets speeds as step delays in
+		// microseconds, and pen delays in milliseconds.
+		MoveStep: time.Duration(move) * time.Microsecond,
+		LineStep: time.Duration(print) * time.Microsecond,
+		PenDown:  penDelay * time.Millisecond,
+		PenUp:    penDelay * time.Millisecond,
+	}
+}
+
+// stepDelays converts speeds between 0 (lowest) and 1 (highest)
+// to the step delays of the engraver, in the range [1000,30].
+func stepDelays(moveSpeed, printSpeed float32) (move, print int) {
+	move = int(moveSpeed*float32(30) + (1.-moveSpeed)*float32(1000))
+	print = int(printSpeed*float32(30) + (1.-printSpeed)*float32(1000))
+	return
 }
 
 var safePoint = image.Pt(119, 43)
@@ -37,6 +102,10 @@ const (
 
 	defaultMoveSpeed  = .5
 	defaultPrintSpeed = .1
+
+	// penDelay is the delay for lowering and raising the
+	// needle.
+	penDelay = 0x14
 )
 
 const (
@@ -78,12 +147,25 @@ func Engrave(dev io.ReadWriter, opts Options, plan engrave.Plan, quit <-chan str
 		eerr = bufw.Flush()
 	}
 	defer flush()
+	stopped := func() bool {
+		select {
+		case <-opts.Stop:
+			return true
+		default:
+			return false
+		}
+	}
 	wr := func(data ...byte) {
 		<-writeMut
 		defer func() { writeMut <- struct{}{} }()
 		if eerr != nil {
 			return
 		}
+		// Don't send anything to a stopped engraver.
+		if stopped() {
+			eerr = ErrStopped
+			return
+		}
 		_, eerr = bufw.Write(data)
 	}
 	done := make(chan struct{})
@@ -91,18 +173,34 @@ func Engrave(dev io.ReadWriter, opts Options, plan engrave.Plan, quit <-chan str
 	go func() {
 		select {
 		case <-quit:
-			select {
-			case <-writeMut:
-			case <-done:
-				return
-			}
-			dev.Write([]byte{cancelCmd})
-			writeMut <- struct{}{}
-			<-done
+		case <-opts.Stop:
+		case <-done:
+			return
+		}
+		select {
+		case <-writeMut:
 		case <-done:
+			return
 		}
+		dev.Write([]byte{cancelCmd})
+		writeMut <- struct{}{}
+		<-done
 	}()
-	bufr := bufio.NewReaderSize(dev, 100)
+	// confirmed is the number of plan commands reported executed.
+	confirmed := 0
+	progress := func(completed int) {
+		confirmed = completed
+		if opts.Progress != nil {
+			opts.Progress(completed)
+		}
+	}
+	var rd io.Reader = dev
+	if opts.Timeout > 0 {
+		w := newWatchdog(dev, opts.Timeout)
+		defer w.Close()
+		rd = w
+	}
+	bufr := bufio.NewReaderSize(rd, 100)
 	r := func(c int) []byte {
 		flush()
 		if eerr != nil {
@@ -110,6 +208,9 @@ func Engrave(dev io.ReadWriter, opts Options, plan engrave.Plan, quit <-chan str
 		}
 		data := make([]byte, c)
 		n, err := bufr.Read(data)
+		if errors.Is(err, errTimeout) {
+			err = &StalledError{Completed: confirmed}
+		}
 		eerr = err
 		data = data[:n]
 		return data
@@ -165,8 +266,8 @@ func Engrave(dev io.ReadWriter, opts Options, plan engrave.Plan, quit <-chan str
 		return
 	}
 	queryPos := func() (x int, y int, z int) {
-		wr(0x16)
-		expect(0x16)
+		wr(queryPosCmd)
+		expect(queryPosCmd)
 		x, y, z = parseCoords(atleast(9))
 		return
 	}
@@ -185,17 +286,22 @@ func Engrave(dev io.ReadWriter, opts Options, plan engrave.Plan, quit <-chan str
 		wr(setDelaysCmd, byte(penDown), byte(penUp))
 		expect(setDelaysCmd)
 	}
-	setDelays(0x14, 0x14)
+	setDelays(penDelay, penDelay)
 
 	// Init done.
 
-	runProgram := func(plan engrave.Plan) {
+	runProgram := func(plan engrave.Plan, progress func(completed int)) {
 		p := &program{}
 		for c := range plan {
 			p.Command(c)
 		}
 		p.Prepare()
+		// next is the command received but not yet sent, if any.
+		var next *[cmdSize]byte
 		defer func() {
+			if next != nil {
+				p.sent++
+			}
 			for i := p.sent; i < p.count; i++ {
 				<-p.cmds
 			}
@@ -205,62 +311,110 @@ func Engrave(dev io.ReadWriter, opts Options, plan engrave.Plan, quit <-chan str
 				p.Command(c)
 			}
 		}()
-		p.sent = 0
-		// Round up to nearest batch size. Note that the rounding
-		// adds another, empty, batch in case we fill up the last one.
-		// Otherwise, the engraver won't send a completed status.
-		nbatches := (p.count + progBatchSize) / progBatchSize
-		if nbatches > 0xffff {
-			eerr = errors.New("engrave: program too large")
-			return
+		paused := false
+		// pauseRequested reports whether a pause is pending.
+		pauseRequested := func() bool {
+			for {
+				select {
+				case paused = <-opts.Pause:
+				default:
+					return paused
+				}
+			}
 		}
-		wr(initProgramCmd, byte(nbatches), byte(nbatches>>8))
-	done:
-		for {
-			status := r(1)
-			if eerr != nil {
+		// resume waits for the end of a pause.
+		resume := func() {
+			for paused && eerr == nil {
+				select {
+				case paused = <-opts.Pause:
+				case <-quit:
+					eerr = ErrCancelled
+				case <-opts.Stop:
+					eerr = ErrStopped
+				}
+			}
+		}
+		p.sent = 0
+		for p.sent < p.count && eerr == nil {
+			start := p.sent
+			// Round up to nearest batch size. Note that the rounding
+			// adds another, empty, batch in case we fill up the last one.
+			// Otherwise, the engraver won't send a completed status.
+			nbatches := (p.count - start + progBatchSize) / progBatchSize
+			if nbatches > 0xffff {
+				eerr = errors.New("engrave: program too large")
 				return
 			}
 			paddedCount := nbatches * progBatchSize
-			switch status[0] {
-			case bufferProgramStatus:
-				if p.sent == paddedCount {
-					break
-				}
-				ncmd := progBatchSize
-				if rem := p.count - p.sent; ncmd > rem {
-					ncmd = rem
+			wr(initProgramCmd, byte(nbatches), byte(nbatches>>8))
+			// sent counts the commands of this program, including
+			// padding.
+			sent := 0
+			pausing := false
+			stepped := 0
+		done:
+			for {
+				status := r(1)
+				if eerr != nil {
+					return
 				}
-				for i := 0; i < ncmd; i++ {
-					cmd := <-p.cmds
-					p.sent++
-					wr(cmd[:]...)
-				}
-				// Pad with 0xff.
-				pad := [cmdSize]byte{}
-				for i := range pad {
-					pad[i] = nopCmd
-				}
-				for i := ncmd; i < progBatchSize; i++ {
-					p.sent++
-					wr(pad[:]...)
-				}
-			case programStepStatus:
-			case programCompleteStatus:
-				break done
-			case cancellingStatus:
-			case cancelledStatus:
-				if eerr == nil {
-					eerr = ErrCancelled
+				switch status[0] {
+				case bufferProgramStatus:
+					if sent == paddedCount {
+						break
+					}
+					for i := 0; i < progBatchSize; i++ {
+						sent++
+						if !pausing && p.sent < p.count {
+							if next == nil {
+								cmd := <-p.cmds
+								next = &cmd
+							}
+							// Pause at a move boundary, but always make
+							// progress.
+							pausing = next[0] == moveCmd && p.sent > start && pauseRequested()
+							if !pausing {
+								wr(next[:]...)
+								next = nil
+								p.sent++
+								continue
+							}
+						}
+						// Pad with 0xff.
+						pad := [cmdSize]byte{}
+						for i := range pad {
+							pad[i] = nopCmd
+						}
+						wr(pad[:]...)
+					}
+				case programStepStatus:
+					stepped++
+					// Padding is executed after the commands.
+					if progress != nil && start+stepped <= p.sent {
+						progress(start + stepped)
+					}
+				case programCompleteStatus:
+					break done
+				case cancellingStatus:
+				case cancelledStatus:
+					if eerr == nil {
+						eerr = ErrCancelled
+						if stopped() {
+							eerr = ErrStopped
+						}
+					}
 				}
 			}
+			if pausing {
+				resume()
+			}
 		}
 	}
 
 	moveTo := func(p image.Point) {
 		runProgram(func(yield func(engrave.Command) bool) {
 			yield(engrave.Move(p))
-		})
+		}, nil)
 	}
 
 	setSpeeds(300, 300, 0xe6)
@@ -284,16 +438,16 @@ func Engrave(dev io.ReadWriter, opts Options, plan engrave.Plan, quit <-chan str
 	// 0 lowest, 1 highest.
 	moveSpeed := opts.MoveSpeed
 	printSpeed := opts.PrintSpeed
+	profileMove, profilePrint := opts.Profile.Speeds()
 	if moveSpeed == 0 {
-		moveSpeed = defaultMoveSpeed
+		moveSpeed = profileMove
 	}
 	if printSpeed == 0 {
-		printSpeed = defaultPrintSpeed
+		printSpeed = profilePrint
 	}
-	mms := int(moveSpeed*float32(30) + (1.-moveSpeed)*float32(1000))
-	mps := int(printSpeed*float32(30) + (1.-printSpeed)*float32(1000))
+	mms, mps := stepDelays(moveSpeed, printSpeed)
 	setSpeeds(mps, mms, 0xe6)
-	runProgram(plan)
+	runProgram(plan, progress)
 	if eerr == nil || eerr == ErrCancelled {
 		setSpeeds(300, 300, 0xe6)
 		if opts.End != (image.Point{}) {
@@ -307,7 +461,105 @@ func Engrave(dev io.ReadWriter, opts Options, plan engrave.Plan, quit <-chan str
 	return eerr
 }
 
-var ErrCancelled = errors.New("cancelled")
+var (
+	ErrCancelled = errors.New("cancelled")
+	// ErrStopped is returned when the engraving was halted
+	// through Options.Stop.
+	ErrStopped = errors.New("stopped")
+)
+
+// StalledError is returned by Engrave when the engraver stops
+// responding, for example because its cable was disconnected. It
+// matches ErrNoResponse.
+type StalledError struct {
+	// Completed is the number of plan commands confirmed executed
+	// by the engraver. An engraving may be resumed after them,
+	// once the engraver responds again.
+	//
+	// The stalled Engrave leaves a read of its device pending,
+	// which consumes the next reply of the engraver. Close the
+	// device and open it again to resume.
+	Completed int
+}
+
+func (e *StalledError) Error() string {
+	return fmt.Sprintf("engraver stalled after %d commands: %v", e.Completed, ErrNoResponse)
+}
+
+func (e *StalledError) Is(target error) bool {
+	return target == ErrNoResponse
+}
+
+// errTimeout is returned by a watchdog read that timed out.
+var errTimeout = errors.New("read timed out")
+
+// watchdog is a reader that fails reads that take longer than a
+// timeout. A timed out read is left pending in the background until
+// the underlying reader returns, so the reader must not be used
+// after a timeout, except to close it.
+type watchdog struct {
+	timeout time.Duration
+	reqs    chan struct{}
+	results chan readResult
+	pending bool
+	buf     []byte
+	// err is the error of the read that filled buf.
+	err error
+}
+
+type readResult struct {
+	data []byte
+	err  error
+}
+
+func newWatchdog(r io.Reader, timeout time.Duration) *watchdog {
+	w := &watchdog{
+		timeout: timeout,
+		reqs:    make(chan struct{}),
+		results: make(chan readResult, 1),
+	}
+	go func() {
+		buf := make([]byte, 100)
+		for range w.reqs {
+			n, err := r.Read(buf)
+			w.results <- readResult{bytes.Clone(buf[:n]), err}
+		}
+	}()
+	return w
+}
+
+func (w *watchdog) Read(p []byte) (int, error) {
+	if len(w.buf) == 0 {
+		if err := w.err; err != nil {
+			w.err = nil
+			return 0, err
+		}
+		if !w.pending {
+			w.reqs <- struct{}{}
+			w.pending = true
+		}
+		t := time.NewTimer(w.timeout)
+		defer t.Stop()
+		select {
+		case res := <-w.results:
+			w.pending = false
+			if len(res.data) == 0 {
+				return 0, res.err
+			}
+			w.buf, w.err = res.data, res.err
+		case <-t.C:
+			return 0, errTimeout
+		}
+	}
+	n := copy(p, w.buf)
+	w.buf = w.buf[n:]
+	return n, nil
+}
+
+// Close stops the watchdog after its pending read, if any.
+func (w *watchdog) Close() {
+	close(w.reqs)
+}
 
 func mkcoords(p image.Point) [9]byte {
 	x, y := p.X, p.Y
@@ -1,10 +1,16 @@
 package mjolnir
 
 import (
+	"errors"
 	"image"
+	"io"
+	"slices"
 	"testing"
+	"time"
 
+	"seedhammer.com/bip39"
 	"seedhammer.com/engrave"
+	"seedhammer.com/font/constant"
 )
 
 func TestEndToEnd(t *testing.T) {
@@ -21,7 +27,387 @@ func TestEndToEnd(t *testing.T) {
 			}
 		}
 	}
-	if err := Engrave(s, Options{}, design, nil); err != nil {
+	completed := 0
+	opts := Options{
+		Progress: func(n int) {
+			if n != completed+1 {
+				t.Errorf("progress skipped from %d to %d", completed, n)
+			}
+			completed = n
+		},
+	}
+	if err := Engrave(s, opts, design, nil); err != nil {
 		t.Error(err)
 	}
+	if want := 2000 * 3; completed != want {
+		t.Errorf("progress reported %d completed commands, expected %d", completed, want)
+	}
+}
+
+func TestStop(t *testing.T) {
+	s := NewSimulator()
+	defer s.Close()
+
+	stop := make(chan struct{})
+	const n = 2000
+	yields := 0
+	design := func(yield func(engrave.Command) bool) {
+		for i := 0; i < n; i++ {
+			// The plan is iterated twice; stop halfway through
+			// the second iteration.
+			yields++
+			if yields == n+n/2 {
+				close(stop)
+			}
+			if !yield(engrave.Line(image.Pt(i, i))) {
+				return
+			}
+		}
+	}
+	err := Engrave(s, Options{Stop: stop}, design, nil)
+	if !errors.Is(err, ErrStopped) {
+		t.Fatalf("Engrave returned %v, expected %v", err, ErrStopped)
+	}
+	// The needle must not be moved after the stop.
+	if last := s.Cmds[len(s.Cmds)-1]; last.Type != LineTo {
+		t.Errorf("engraver moved after stop: %+v", last)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	s := NewSimulator()
+	defer s.Close()
+
+	quit := make(chan struct{})
+	const n = 2000
+	yields := 0
+	design := func(yield func(engrave.Command) bool) {
+		for i := 0; i < n; i++ {
+			yields++
+			if yields == n+n/2 {
+				close(quit)
+			}
+			if !yield(engrave.Line(image.Pt(i, i))) {
+				return
+			}
+		}
+	}
+	err := Engrave(s, Options{}, design, quit)
+	if !errors.Is(err, ErrCancelled) {
+		t.Fatalf("Engrave returned %v, expected %v", err, ErrCancelled)
+	}
+}
+
+func TestStalled(t *testing.T) {
+	s := NewSimulator()
+	defer s.Close()
+
+	const n = 2000
+	yields := 0
+	dev := &stallingDevice{ReadWriter: s, stall: make(chan struct{}), unplug: make(chan struct{})}
+	defer close(dev.unplug)
+	design := func(yield func(engrave.Command) bool) {
+		for i := 0; i < n; i++ {
+			// The plan is iterated twice; stall halfway through
+			// the second iteration.
+			yields++
+			if yields == n+n/2 {
+				close(dev.stall)
+			}
+			if !yield(engrave.Line(image.Pt(i, i))) {
+				return
+			}
+		}
+	}
+	completed := 0
+	opts := Options{
+		Timeout: 100 * time.Millisecond,
+		Progress: func(c int) {
+			completed = c
+		},
+	}
+	err := Engrave(dev, opts, design, nil)
+	if !errors.Is(err, ErrNoResponse) {
+		t.Fatalf("Engrave returned %v, expected %v", err, ErrNoResponse)
+	}
+	var serr *StalledError
+	if !errors.As(err, &serr) {
+		t.Fatalf("Engrave returned %T, expected %T", err, serr)
+	}
+	if serr.Completed != completed || completed == 0 || completed >= n {
+		t.Errorf("stalled after %d commands, progress reported %d of %d", serr.Completed, completed, n)
+	}
+}
+
+// stallingDevice stops responding when stall is closed, until
+// unplug is closed.
+type stallingDevice struct {
+	io.ReadWriter
+	stall, unplug chan struct{}
+}
+
+func (d *stallingDevice) Read(p []byte) (int, error) {
+	select {
+	case <-d.stall:
+		<-d.unplug
+		return 0, io.EOF
+	default:
+		return d.ReadWriter.Read(p)
+	}
+}
+
+func TestPause(t *testing.T) {
+	design := func(yield func(engrave.Command) bool) {
+		for i := 0; i < 500; i++ {
+			cont := yield(engrave.Line(image.Pt(i, i*2))) &&
+				yield(engrave.Move(image.Pt(i, i)))
+			if !cont {
+				return
+			}
+		}
+	}
+	want := NewSimulator()
+	defer want.Close()
+	if err := Engrave(want, Options{}, design, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSimulator()
+	defer s.Close()
+	// An unbuffered channel ensures the engraver received both
+	// requests.
+	pause := make(chan bool)
+	resumed := make(chan struct{})
+	go func() {
+		pause <- true
+		pause <- false
+		close(resumed)
+	}()
+	completed := 0
+	opts := Options{
+		Pause: pause,
+		Progress: func(n int) {
+			completed = n
+		},
+	}
+	if err := Engrave(s, opts, design, nil); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-resumed:
+	default:
+		t.Error("engraving was not paused")
+	}
+	if completed != 500*2 {
+		t.Errorf("progress reported %d completed commands, expected %d", completed, 500*2)
+	}
+	if !slices.Equal(s.Cmds, want.Cmds) {
+		t.Error("paused engraving differs from uninterrupted engraving")
+	}
+}
+
+func TestSimulatorImage(t *testing.T) {
+	mm := Params.Millimeter
+	sp := safePoint.Mul(mm)
+	design := func(yield func(engrave.Command) bool) {
+		_ = yield(engrave.Move(sp)) &&
+			yield(engrave.Line(sp.Add(image.Pt(10*mm, 0)))) &&
+			yield(engrave.Move(sp.Add(image.Pt(0, 10*mm)))) &&
+			yield(engrave.Line(sp.Add(image.Pt(10*mm, 10*mm))))
+	}
+	s := NewSimulator()
+	if err := Engrave(s, Options{}, design, nil); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	const ppmm = 8
+	painted := func(img image.Image, x, y int) bool {
+		// Strokes are black on transparent images and white
+		// animation frames.
+		r, _, _, a := img.At((safePoint.X+x)*ppmm, (safePoint.Y+y)*ppmm).RGBA()
+		return a != 0 && r < 0x8000
+	}
+	img := s.Image(ppmm)
+	if !painted(img, 5, 0) || !painted(img, 5, 10) {
+		t.Error("engraved lines not drawn")
+	}
+	if painted(img, 5, 5) || painted(img, 0, 5) {
+		t.Error("moves drawn")
+	}
+	// Animate a command per frame.
+	anim := s.Animation(ppmm, len(s.Cmds), 100*time.Millisecond)
+	if n := len(anim.Image); n != len(s.Cmds) {
+		t.Fatalf("animation has %d frames, want %d", n, len(s.Cmds))
+	}
+	if d := anim.Delay[0]; d != 10 {
+		t.Errorf("frame delay %d, want 10", d)
+	}
+	first := slices.IndexFunc(s.Cmds, func(c Cmd) bool { return c.Type == LineTo })
+	if f := anim.Image[first]; !painted(f, 5, 0) || painted(f, 5, 10) {
+		t.Error("frame of the first line doesn't show it alone")
+	}
+	if f := anim.Image[len(anim.Image)-1]; !painted(f, 5, 0) || !painted(f, 5, 10) {
+		t.Error("last frame doesn't show every line")
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	c := engrave.NewConstantStringer(constant.Font, Params.F(4.1), bip39.ShortestWord, bip39.LongestWord)
+	var prev time.Duration
+	for _, p := range []Profile{FineProfile, NormalProfile, FastProfile} {
+		// Constant time engravings must remain constant
+		// regardless of speed.
+		var want time.Duration
+		for i, w := range []string{"ZOO", "ABANDON", "TOMORROW"} {
+			s := NewSimulator()
+			if err := Engrave(s, Options{Profile: p}, c.String(w), nil); err != nil {
+				t.Fatal(err)
+			}
+			s.Close()
+			if i == 0 {
+				want = s.Duration
+			} else if s.Duration != want {
+				t.Errorf("profile %d: %s engraved in %v, expected %v", p, w, s.Duration, want)
+			}
+		}
+		if prev != 0 && want >= prev {
+			t.Errorf("profile %d: duration %v not faster than %v", p, want, prev)
+		}
+		prev = want
+	}
+}
+
+func TestSimulatorEstimate(t *testing.T) {
+	sp := safePoint.Mul(Params.Millimeter)
+	simulate := func(plan engrave.Plan) *Simulator {
+		s := NewSimulator()
+		defer s.Close()
+		if err := Engrave(s, Options{}, plan, nil); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	const dist = 1000
+	basePlan := func(yield func(engrave.Command) bool) {
+		yield(engrave.Line(sp))
+	}
+	plan := func(yield func(engrave.Command) bool) {
+		_ = yield(engrave.Line(sp.Add(image.Pt(dist, 0)))) &&
+			yield(engrave.Line(sp))
+	}
+	base := simulate(basePlan)
+	s := simulate(plan)
+	if got := s.Distance - base.Distance; got != 2*dist {
+		t.Errorf("simulated line distance is %v, expected %v", got, 2*dist)
+	}
+	if s.Duration <= base.Duration {
+		t.Errorf("simulated duration %v doesn't exceed the empty plan duration %v", s.Duration, base.Duration)
+	}
+	baseEst, _ := engrave.Estimate(basePlan, Params)
+	est, _ := engrave.Estimate(plan, Params)
+	if got, want := est-baseEst, s.Duration-base.Duration; got != want {
+		t.Errorf("estimated duration %v, simulated %v", got, want)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	s := NewSimulator()
+	defer s.Close()
+	end := image.Pt(100, 200)
+	if err := Engrave(s, Options{End: end}, func(yield func(engrave.Command) bool) {}, nil); err != nil {
+		t.Fatal(err)
+	}
+	st, err := Query(s, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Position != end {
+		t.Errorf("queried position %v, expected %v", st.Position, end)
+	}
+
+	tests := []struct {
+		reply []byte
+		err   error
+	}{
+		{nil, ErrNoResponse},
+		{[]byte("OK\r\n"), ErrWrongDevice},
+		{[]byte{programStepStatus}, ErrBusy},
+		{append([]byte{initializedStatus, 0x42}, make([]byte, 9)...), ErrWrongDevice},
+	}
+	for _, test := range tests {
+		dev := &fakeDevice{reply: test.reply, closed: make(chan struct{})}
+		if _, err := Query(dev, 10*time.Millisecond); !errors.Is(err, test.err) {
+			t.Errorf("reply %#x: Query returned %v, expected %v", test.reply, err, test.err)
+		}
+		select {
+		case <-dev.closed:
+		case <-time.After(time.Second):
+			t.Errorf("reply %#x: device not closed", test.reply)
+		}
+	}
+}
+
+func TestQuerySilentDevice(t *testing.T) {
+	// A serial port blocks reads even when closed.
+	unplug := make(chan struct{})
+	defer close(unplug)
+	dev := &silentDevice{unplug: unplug}
+	res := make(chan error, 1)
+	go func() {
+		_, err := Query(dev, 10*time.Millisecond)
+		res <- err
+	}()
+	select {
+	case err := <-res:
+		if !errors.Is(err, ErrNoResponse) {
+			t.Errorf("Query returned %v, expected %v", err, ErrNoResponse)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Query blocked on a silent device")
+	}
+}
+
+// silentDevice accepts writes and never replies, even after Close,
+// until unplug is closed.
+type silentDevice struct {
+	unplug chan struct{}
+}
+
+func (d *silentDevice) Read(p []byte) (int, error) {
+	<-d.unplug
+	return 0, io.EOF
+}
+
+func (d *silentDevice) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (d *silentDevice) Close() error {
+	return nil
+}
+
+// fakeDevice replies with fixed data, and blocks when the reply
+// is exhausted.
+type fakeDevice struct {
+	reply  []byte
+	closed chan struct{}
+}
+
+func (d *fakeDevice) Read(p []byte) (int, error) {
+	if len(d.reply) == 0 {
+		<-d.closed
+		return 0, io.ErrClosedPipe
+	}
+	n := copy(p, d.reply)
+	d.reply = d.reply[n:]
+	return n, nil
+}
+
+func (d *fakeDevice) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (d *fakeDevice) Close() error {
+	close(d.closed)
+	return nil
 }
@@ -0,0 +1,103 @@
+package mjolnir
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"time"
+)
+
+// Status is the state of an engraver, as reported by Query.
+type Status struct {
+	// Position is the needle position in machine units. It is
+	// relative to the origin of the previous engraving, or to
+	// the position at power on.
+	Position image.Point
+}
+
+var (
+	// ErrNoResponse is returned by Query if the device didn't
+	// respond in time.
+	ErrNoResponse = errors.New("no response")
+	// ErrWrongDevice is returned by Query if the device doesn't
+	// respond like an engraver.
+	ErrWrongDevice = errors.New("not an engraver")
+	// ErrBusy is returned by Query if the engraver is executing
+	// a program, such as one left over from an interrupted
+	// engraving.
+	ErrBusy = errors.New("engraver busy")
+)
+
+const queryPosCmd = 0x16
+
+// Query performs the engraver handshake and queries its status,
+// without moving the needle. It waits at most timeout for every
+// response. If Query returns an error, dev is closed.
+func Query(dev io.ReadWriteCloser, timeout time.Duration) (st Status, err error) {
+	closed := false
+	defer func() {
+		if err != nil && !closed {
+			dev.Close()
+		}
+	}()
+	read := func(n int) ([]byte, error) {
+		type result struct {
+			data []byte
+			err  error
+		}
+		res := make(chan result, 1)
+		go func() {
+			data := make([]byte, n)
+			_, err := io.ReadFull(dev, data)
+			res <- result{data, err}
+		}()
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		select {
+		case r := <-res:
+			return r.data, r.err
+		case <-t.C:
+			// Closing a serial port doesn't interrupt a pending
+			// read, and may wait for it. Leave both in the
+			// background.
+			closed = true
+			go dev.Close()
+			return nil, ErrNoResponse
+		}
+	}
+	// Initialize, and re-initialize after a cancelled program.
+	for initialized, attempts := false, 0; !initialized; attempts++ {
+		if _, err := dev.Write([]byte{initCmd}); err != nil {
+			return Status{}, err
+		}
+		status, err := read(1)
+		if err != nil {
+			return Status{}, err
+		}
+		switch status[0] {
+		case initializedStatus:
+			initialized = true
+		case cancelledStatus:
+			if attempts > 0 {
+				return Status{}, ErrBusy
+			}
+		case bufferProgramStatus, programStepStatus, programCompleteStatus, cancellingStatus:
+			return Status{}, ErrBusy
+		default:
+			return Status{}, fmt.Errorf("%w: handshake reply %#x", ErrWrongDevice, status[0])
+		}
+	}
+	if _, err := dev.Write([]byte{queryPosCmd}); err != nil {
+		return Status{}, err
+	}
+	reply, err := read(1 + 9)
+	if err != nil {
+		return Status{}, err
+	}
+	if reply[0] != queryPosCmd {
+		return Status{}, fmt.Errorf("%w: position reply %#x", ErrWrongDevice, reply[0])
+	}
+	x, y := coordsFromCmd(reply[1:])
+	return Status{Position: image.Pt(int(x), int(y))}, nil
+}
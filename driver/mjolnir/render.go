@@ -0,0 +1,69 @@
+package mjolnir
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"time"
+
+	"seedhammer.com/engrave"
+)
+
+// Image rasterizes the strokes of the commands executed by the
+// simulator at ppmm pixels per millimeter, for inspecting an
+// engraving visually. The image spans the machine coordinates from
+// the origin to the farthest command. Call Image after the
+// engraving completes.
+func (s *Simulator) Image(ppmm int) *image.NRGBA {
+	return s.render(s.bounds(ppmm), ppmm, s.Cmds)
+}
+
+// Animation is like Image, but renders the engraving in frames to
+// show the order of its strokes. Every frame adds the strokes of an
+// equal share of the commands, and is shown for delay.
+func (s *Simulator) Animation(ppmm, frames int, delay time.Duration) *gif.GIF {
+	bounds := s.bounds(ppmm)
+	palette := color.Palette{color.White, color.Black}
+	anim := new(gif.GIF)
+	for i := 1; i <= frames; i++ {
+		img := s.render(bounds, ppmm, s.Cmds[:len(s.Cmds)*i/frames])
+		frame := image.NewPaletted(bounds, palette)
+		draw.Draw(frame, bounds, img, bounds.Min, draw.Over)
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, int(delay/(10*time.Millisecond)))
+	}
+	return anim
+}
+
+// bounds returns the image bounds of the executed commands.
+func (s *Simulator) bounds(ppmm int) image.Rectangle {
+	var end image.Point
+	for _, c := range s.Cmds {
+		end.X = max(end.X, int(c.X))
+		end.Y = max(end.Y, int(c.Y))
+	}
+	// Leave room for the strokes of the farthest commands.
+	pad := strokeWidth(ppmm) + 1
+	return image.Rectangle{Max: end.Mul(ppmm).Div(Params.Millimeter).Add(image.Pt(pad, pad))}
+}
+
+// strokeWidth returns the width of strokes in pixels, but at least
+// a pixel.
+func strokeWidth(ppmm int) int {
+	return max(1, Params.StrokeWidth*ppmm/Params.Millimeter)
+}
+
+func (s *Simulator) render(bounds image.Rectangle, ppmm int, cmds []Cmd) *image.NRGBA {
+	img := image.NewNRGBA(bounds)
+	scale := float32(ppmm) / float32(Params.Millimeter)
+	r := engrave.NewRasterizer(img, bounds, scale, strokeWidth(ppmm))
+	for _, c := range cmds {
+		r.Command(engrave.Command{
+			Line:  c.Type == LineTo,
+			Coord: image.Pt(int(c.X), int(c.Y)),
+		})
+	}
+	r.Rasterize()
+	return img
+}
@@ -2,14 +2,31 @@ package mjolnir
 
 import (
 	"errors"
+	"fmt"
+	"image"
+	"math"
+	"time"
 )
 
 type Simulator struct {
 	state     deviceState
 	ncmds     int
 	nbuffered int
+	pos       image.Point
+	line      bool
+	// Speeds and delays as set by the driver.
+	printSpeed, moveSpeed int
+	penDown, penUp        int
+
+	Cmds []Cmd
+	// Distance is the total distance traveled by the needle,
+	// in machine units.
+	Distance float64
+	// Duration is the estimated time for executing Cmds. It
+	// assumes that speeds are step delays in microseconds and
+	// that the pen delays are in milliseconds.
+	Duration time.Duration
 
-	Cmds  []Cmd
 	close chan struct{}
 	in    chan ioRequest
 	out   chan ioResult
@@ -27,6 +44,21 @@ const (
 	LineTo
 )
 
+func (t CmdType) String() string {
+	switch t {
+	case MoveTo:
+		return "move"
+	case LineTo:
+		return "line"
+	default:
+		panic("invalid command type")
+	}
+}
+
+func (c Cmd) String() string {
+	return fmt.Sprintf("%s %d %d", c.Type, c.X, c.Y)
+}
+
 func NewSimulator() *Simulator {
 	sim := &Simulator{
 		close: make(chan struct{}),
@@ -45,7 +77,9 @@ const (
 	stateSetSpeed
 	stateSetDelays
 	stateMoveToOrigin
+	stateQueryPosition
 	stateExecuting
+	stateCancelling
 )
 
 type ioRequest struct {
@@ -77,6 +111,32 @@ func (s *Simulator) run() {
 	}
 }
 
+// cmd records a command and updates the distance and duration
+// estimates.
+func (s *Simulator) cmd(c Cmd) {
+	s.Cmds = append(s.Cmds, c)
+	to := image.Pt(int(c.X), int(c.Y))
+	d := to.Sub(s.pos)
+	s.pos = to
+	s.Distance += math.Hypot(float64(d.X), float64(d.Y))
+	// The axes move concurrently, so the longest axis
+	// determines the time.
+	steps := max(d.X, -d.X, d.Y, -d.Y)
+	line := c.Type == LineTo
+	delay := s.moveSpeed
+	if line {
+		delay = s.printSpeed
+	}
+	s.Duration += time.Duration(steps*delay) * time.Microsecond
+	switch {
+	case line && !s.line:
+		s.Duration += time.Duration(s.penDown) * time.Millisecond
+	case !line && s.line:
+		s.Duration += time.Duration(s.penUp) * time.Millisecond
+	}
+	s.line = line
+}
+
 func coordsFromCmd(cmd []byte) (uint32, uint32) {
 	x := uint32(cmd[0]) | uint32(cmd[1])<<8 | uint32(cmd[2])<<16
 	y := uint32(cmd[3]) | uint32(cmd[4])<<8 | uint32(cmd[5])<<16
@@ -104,16 +164,31 @@ func (s *Simulator) doRead(data []byte) (int, error) {
 	case stateMoveToOrigin:
 		s.state = stateReady
 		return read([]byte{moveToOriginCmd, moveToOriginCmdResponse})
+	case stateQueryPosition:
+		s.state = stateReady
+		x, y := uint32(s.pos.X), uint32(s.pos.Y)
+		return read([]byte{
+			queryPosCmd,
+			byte(x), byte(x >> 8), byte(x >> 16),
+			byte(y), byte(y >> 8), byte(y >> 16),
+			0, 0, 0,
+		})
 	case stateExecuting:
 		switch {
 		case s.nbuffered == 0 && s.ncmds > 0:
 			return read([]byte{bufferProgramStatus})
 		case s.nbuffered == 0 && s.ncmds == 0:
+			// The engraver leaves programming mode when done.
+			s.state = stateReady
 			return read([]byte{programCompleteStatus})
 		default:
 			s.nbuffered--
 			return read([]byte{programStepStatus})
 		}
+	case stateCancelling:
+		s.state = stateReady
+		s.nbuffered, s.ncmds = 0, 0
+		return read([]byte{cancelledStatus})
 	default:
 		return 0, errors.New("invalid device state")
 	}
@@ -145,36 +220,46 @@ func (s *Simulator) doWrite(data []byte) (n int, err error) {
 		data = data[1:]
 		switch cmd {
 		case cancelCmd:
-			s.state = stateReady
+			if s.state == stateExecuting {
+				// A cancelled program reports it.
+				s.state = stateCancelling
+			} else {
+				s.state = stateReady
+			}
 		case initCmd:
 			if s.state == stateExecuting {
 				// 0x00 is line to in programming mode.
 				x, y := coordsFromCmd(data)
-				s.Cmds = append(s.Cmds, Cmd{LineTo, x, y})
+				s.cmd(Cmd{LineTo, x, y})
 				batchCmd()
 			} else {
 				s.state = stateInitializing
 			}
 		case setSpeedCmd:
 			s.state = stateSetSpeed
-			skip(6)
+			speeds := read(6)
+			s.printSpeed = int(speeds[0]) | int(speeds[1])<<8
+			s.moveSpeed = int(speeds[2]) | int(speeds[3])<<8
 		case setDelaysCmd:
 			s.state = stateSetDelays
-			skip(2)
+			delays := read(2)
+			s.penDown, s.penUp = int(delays[0]), int(delays[1])
 		case moveToOriginCmd:
 			s.state = stateMoveToOrigin
 			subCmd := read(1)
 			if err == nil && subCmd[0] != moveToOriginCmdExtra {
 				err = errors.New("invalid origin command")
 			}
-			s.Cmds = append(s.Cmds, Cmd{MoveTo, 0, 0})
+			s.cmd(Cmd{MoveTo, 0, 0})
+		case queryPosCmd:
+			s.state = stateQueryPosition
 		case initProgramCmd:
 			s.state = stateExecuting
 			ncmds := read(2)
 			s.ncmds = (int(ncmds[0]) | int(ncmds[1])<<8) * progBatchSize
 		case moveCmd:
 			x, y := coordsFromCmd(data)
-			s.Cmds = append(s.Cmds, Cmd{MoveTo, x, y})
+			s.cmd(Cmd{MoveTo, x, y})
 			batchCmd()
 		case nopCmd:
 			batchCmd()
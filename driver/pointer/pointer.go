@@ -0,0 +1,40 @@
+// package pointer implements a driver for a pointer light, such as
+// an indicator LED or a laser pointer module, switched by a GPIO pin.
+package pointer
+
+import (
+	"fmt"
+
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/gpio/gpioreg"
+	"periph.io/x/host/v3"
+)
+
+// Pointer switches a pointer light.
+type Pointer struct {
+	pin gpio.PinOut
+}
+
+// Open initializes the pointer connected to the named pin, for
+// example "GPIO27". The pointer is initially off.
+func Open(pin string) (*Pointer, error) {
+	if _, err := host.Init(); err != nil {
+		return nil, err
+	}
+	p := gpioreg.ByName(pin)
+	if p == nil {
+		return nil, fmt.Errorf("pointer: unknown pin: %s", pin)
+	}
+	if err := p.Out(gpio.Low); err != nil {
+		return nil, fmt.Errorf("pointer: %w", err)
+	}
+	return &Pointer{pin: p}, nil
+}
+
+// Light switches the pointer on or off.
+func (p *Pointer) Light(on bool) error {
+	if err := p.pin.Out(gpio.Level(on)); err != nil {
+		return fmt.Errorf("pointer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+// package usbkbd implements an input driver for USB keyboards through
+// the Linux evdev interface.
+package usbkbd
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"seedhammer.com/gui"
+)
+
+// inputEvent mirrors struct input_event of linux/input.h.
+type inputEvent struct {
+	Time  unix.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+// Event types and key codes from linux/input-event-codes.h.
+const (
+	evKey = 0x01
+
+	keyEsc        = 1
+	keyBackspace  = 14
+	keyEnter      = 28
+	keyLeftShift  = 42
+	keyRightShift = 54
+	keyCapsLock   = 58
+	keyF1         = 59
+	keyF2         = 60
+	keyF3         = 61
+	keyKPEnter    = 96
+	keyUp         = 103
+	keyLeft       = 105
+	keyRight      = 106
+	keyDown       = 108
+)
+
+// Key values of input events.
+const (
+	valueRelease = 0
+	valuePress   = 1
+	valueRepeat  = 2
+)
+
+// runes maps the key codes of a US layout keyboard to their
+// unshifted and shifted runes.
+var runes = map[uint16][2]rune{
+	2: {'1', '!'}, 3: {'2', '@'}, 4: {'3', '#'}, 5: {'4', '$'}, 6: {'5', '%'},
+	7: {'6', '^'}, 8: {'7', '&'}, 9: {'8', '*'}, 10: {'9', '('}, 11: {'0', ')'},
+	12: {'-', '_'}, 13: {'=', '+'}, 26: {'[', '{'}, 27: {']', '}'},
+	39: {';', ':'}, 40: {'\'', '"'}, 41: {'`', '~'}, 43: {'\\', '|'},
+	51: {',', '<'}, 52: {'.', '>'}, 53: {'/', '?'}, 57: {' ', ' '},
+}
+
+func init() {
+	rows := []struct {
+		first   uint16
+		letters string
+	}{
+		{16, "qwertyuiop"},
+		{30, "asdfghjkl"},
+		{44, "zxcvbnm"},
+	}
+	for _, row := range rows {
+		for i, l := range row.letters {
+			runes[row.first+uint16(i)] = [2]rune{l, l - 'a' + 'A'}
+		}
+	}
+}
+
+// buttons maps key codes to the buttons they emulate. The arrow
+// keys and enter work the joystick, escape goes back, and the
+// function keys are the buttons beside the screen.
+var buttons = map[uint16]gui.Button{
+	keyUp:      gui.Up,
+	keyDown:    gui.Down,
+	keyLeft:    gui.Left,
+	keyRight:   gui.Right,
+	keyEnter:   gui.Center,
+	keyKPEnter: gui.Center,
+	keyEsc:     gui.Button1,
+	keyF1:      gui.Button1,
+	keyF2:      gui.Button2,
+	keyF3:      gui.Button3,
+}
+
+// inputDir contains the evdev device nodes.
+const inputDir = "/dev/input"
+
+// Open starts forwarding the keys of USB keyboards to ch, as
+// [gui.Rune] events for typed characters and button events for
+// the keys listed in buttons. Keyboards may be connected at any
+// time; Open polls for new devices, because the kernel creates
+// inputDir along with the first device.
+func Open(ch chan<- gui.Event) {
+	var mu sync.Mutex
+	open := make(map[string]bool)
+	go func() {
+		for {
+			devs, _ := filepath.Glob(filepath.Join(inputDir, "event*"))
+			for _, dev := range devs {
+				mu.Lock()
+				known := open[dev]
+				open[dev] = true
+				mu.Unlock()
+				if known {
+					continue
+				}
+				go func() {
+					// Devices that fail to open or read are retried
+					// after they reappear.
+					readDevice(dev, ch)
+					mu.Lock()
+					delete(open, dev)
+					mu.Unlock()
+				}()
+			}
+			time.Sleep(time.Second)
+		}
+	}()
+}
+
+func readDevice(dev string, ch chan<- gui.Event) {
+	f, err := os.Open(dev)
+	if err != nil {
+		// Wait for the device to disappear.
+		for {
+			time.Sleep(time.Second)
+			if _, err := os.Stat(dev); errors.Is(err, os.ErrNotExist) {
+				return
+			}
+		}
+	}
+	defer f.Close()
+	var k keyboard
+	buf := make([]byte, binary.Size(inputEvent{}))
+	for {
+		if _, err := io.ReadFull(f, buf); err != nil {
+			return
+		}
+		var e inputEvent
+		if _, err := binary.Decode(buf, binary.NativeEndian, &e); err != nil {
+			return
+		}
+		if e.Type != evKey {
+			continue
+		}
+		if ge, ok := k.event(e.Code, e.Value); ok {
+			ch <- ge
+		}
+	}
+}
+
+// keyboard tracks the modifier state of a keyboard.
+type keyboard struct {
+	shift    [2]bool
+	capsLock bool
+}
+
+// event translates a key event to a gui event, if any.
+func (k *keyboard) event(code uint16, value int32) (gui.Event, bool) {
+	pressed := value != valueRelease
+	switch code {
+	case keyLeftShift:
+		k.shift[0] = pressed
+		return gui.Event{}, false
+	case keyRightShift:
+		k.shift[1] = pressed
+		return gui.Event{}, false
+	case keyCapsLock:
+		if value == valuePress {
+			k.capsLock = !k.capsLock
+		}
+		return gui.Event{}, false
+	}
+	if b, ok := buttons[code]; ok {
+		// The gui repeats held buttons itself.
+		if value == valueRepeat {
+			return gui.Event{}, false
+		}
+		return gui.ButtonEvent{Button: b, Pressed: pressed}.Event(), true
+	}
+	// Typed characters repeat while their key is held.
+	if !pressed {
+		return gui.Event{}, false
+	}
+	if code == keyBackspace {
+		return gui.ButtonEvent{Button: gui.Rune, Rune: '\b', Pressed: true}.Event(), true
+	}
+	rs, ok := runes[code]
+	if !ok {
+		return gui.Event{}, false
+	}
+	shifted := k.shift[0] || k.shift[1]
+	if 'a' <= rs[0] && rs[0] <= 'z' && k.capsLock {
+		shifted = !shifted
+	}
+	r := rs[0]
+	if shifted {
+		r = rs[1]
+	}
+	return gui.ButtonEvent{Button: gui.Rune, Rune: r, Pressed: true}.Event(), true
+}
@@ -0,0 +1,44 @@
+package usbkbd
+
+import (
+	"testing"
+
+	"seedhammer.com/gui"
+)
+
+func TestKeyboard(t *testing.T) {
+	const keyA, keyDot = 30, 52
+	var k keyboard
+	var got []rune
+	typ := func(code uint16, value int32) {
+		e, ok := k.event(code, value)
+		if !ok {
+			return
+		}
+		if be, ok := e.AsButton(); ok && be.Button == gui.Rune {
+			got = append(got, be.Rune)
+		}
+	}
+	typ(keyA, valuePress)
+	typ(keyA, valueRepeat)
+	typ(keyA, valueRelease)
+	typ(keyLeftShift, valuePress)
+	typ(keyA, valuePress)
+	typ(keyDot, valuePress)
+	typ(keyLeftShift, valueRelease)
+	typ(keyCapsLock, valuePress)
+	typ(keyCapsLock, valueRelease)
+	typ(keyA, valuePress)
+	typ(keyDot, valuePress)
+	typ(keyBackspace, valuePress)
+	if want := "aaA>A.\b"; string(got) != want {
+		t.Errorf("typed %q, want %q", string(got), want)
+	}
+	e, ok := k.event(keyEnter, valuePress)
+	if be, _ := e.AsButton(); !ok || be.Button != gui.Center || !be.Pressed {
+		t.Errorf("enter generated %+v, want a press of %v", be, gui.Center)
+	}
+	if _, ok := k.event(keyEnter, valueRepeat); ok {
+		t.Error("repeated enter generated an event")
+	}
+}
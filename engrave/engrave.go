@@ -15,6 +15,9 @@ import (
 	"math"
 	"math/rand"
 	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/kortschak/qr"
 	"github.com/srwiley/rasterx"
@@ -30,6 +33,17 @@ type Params struct {
 	StrokeWidth int
 	// A Millimeter measured in machine units.
 	Millimeter int
+	// MoveStep and LineStep are the times for moving the needle
+	// one machine unit, raised and lowered. The axes move
+	// concurrently, so only the longest axis of a command counts.
+	MoveStep, LineStep time.Duration
+	// PenDown and PenUp are the times for lowering and raising
+	// the needle.
+	PenDown, PenUp time.Duration
+	// SpreadRegion, if positive, selects the stroke ordering of
+	// Spread with regions of the size in machine units, for plates
+	// too thin to engrave a dense area at a time.
+	SpreadRegion int
 }
 
 func (p Params) F(v float32) int {
@@ -85,6 +99,27 @@ func offsetting(x, y int) transform {
 	}
 }
 
+// Axis is a coordinate axis.
+type Axis int
+
+const (
+	AxisX Axis = iota
+	AxisY
+)
+
+func mirroring(axis Axis) transform {
+	if axis == AxisX {
+		return transform{
+			1, 0, 0,
+			0, -1, 0,
+		}
+	}
+	return transform{
+		-1, 0, 0,
+		0, 1, 0,
+	}
+}
+
 func transformPlan(t transform, p Plan) Plan {
 	return func(yield func(Command) bool) {
 		for c := range p {
@@ -104,6 +139,63 @@ func Rotate(radians float64, cmd Plan) Plan {
 	return transformPlan(rotating(radians), cmd)
 }
 
+// Estimate the duration of engraving plan, and the total length
+// of its lines in machine units. The needle is assumed to start
+// raised at the origin.
+func Estimate(plan Plan, params Params) (time.Duration, int) {
+	var pos image.Point
+	var dur time.Duration
+	var stroke float64
+	line := false
+	for c := range plan {
+		d := c.Coord.Sub(pos)
+		pos = c.Coord
+		steps := time.Duration(max(d.X, -d.X, d.Y, -d.Y))
+		switch {
+		case c.Line && !line:
+			dur += params.PenDown
+		case !c.Line && line:
+			dur += params.PenUp
+		}
+		line = c.Line
+		if line {
+			dur += steps * params.LineStep
+			stroke += math.Hypot(float64(d.X), float64(d.Y))
+		} else {
+			dur += steps * params.MoveStep
+		}
+	}
+	return dur, int(math.Round(stroke))
+}
+
+// Align rotates and offsets plan such that the points want move onto
+// the points got, for aligning a plan with features such as fiducials
+// measured on a re-clamped plate. Only the direction between the
+// points of got is used, not their distance.
+func Align(want, got [2]image.Point, plan Plan) Plan {
+	dw, dg := want[1].Sub(want[0]), got[1].Sub(got[0])
+	angle := math.Atan2(float64(dg.Y), float64(dg.X)) - math.Atan2(float64(dw.Y), float64(dw.X))
+	sin, cos := math.Sincos(angle)
+	return func(yield func(Command) bool) {
+		for c := range plan {
+			x, y := float64(c.Coord.X-want[0].X), float64(c.Coord.Y-want[0].Y)
+			c.Coord = image.Point{
+				X: got[0].X + int(math.Round(x*cos-y*sin)),
+				Y: got[0].Y + int(math.Round(x*sin+y*cos)),
+			}
+			if !yield(c) {
+				return
+			}
+		}
+	}
+}
+
+// Mirror reflects plan across axis. Mirroring across both axes
+// rotates the plan half a turn.
+func Mirror(axis Axis, plan Plan) Plan {
+	return transformPlan(mirroring(axis), plan)
+}
+
 func Move(p image.Point) Command {
 	return Command{
 		Line:  false,
@@ -129,8 +221,337 @@ func DryRun(p Plan) Plan {
 	}
 }
 
-func QR(strokeWidth int, scale int, level qr.Level, content []byte) (Plan, error) {
-	qr, err := qr.Encode(string(content), level)
+// Outline returns a dry run around the bounds of plan, for checking
+// the placement of the plan on the plate before engraving it. The
+// outline consists of moves only, which engravers run at their
+// highest speed.
+func Outline(p Plan) Plan {
+	b := Measure(p)
+	return func(yield func(Command) bool) {
+		if b == (image.Rectangle{}) {
+			return
+		}
+		corners := []image.Point{
+			b.Min,
+			image.Pt(b.Max.X, b.Min.Y),
+			b.Max,
+			image.Pt(b.Min.X, b.Max.Y),
+			b.Min,
+		}
+		for _, c := range corners {
+			if !yield(Move(c)) {
+				return
+			}
+		}
+	}
+}
+
+// Shuffle randomizes the order and direction of the strokes of a plan
+// to make it harder to reconstruct the engraving from its sound. A
+// stroke is a move followed by lines. Only consecutive strokes that start
+// in the same region sized square are shuffled, to limit the extra
+// movement. The shuffled plan is the same for every iteration and seed.
+//
+// Shuffle is weaker than constant time engraving, and it breaks the
+// timing of constant time plans.
+func Shuffle(seed int64, region int, p Plan) Plan {
+	return func(yield func(Command) bool) {
+		r := rand.New(rand.NewSource(seed))
+		var group [][]image.Point
+		var cell image.Point
+		flush := func() bool {
+			r.Shuffle(len(group), func(i, j int) {
+				group[i], group[j] = group[j], group[i]
+			})
+			for _, s := range group {
+				if r.Intn(2) == 1 {
+					slices.Reverse(s)
+				}
+				if !yield(Move(s[0])) {
+					return false
+				}
+				for _, pos := range s[1:] {
+					if !yield(Line(pos)) {
+						return false
+					}
+				}
+			}
+			group = group[:0]
+			return true
+		}
+		// end completes a stroke.
+		end := func(s []image.Point) bool {
+			if len(s) == 1 {
+				// Leave moves without lines in place.
+				return flush() && yield(Move(s[0]))
+			}
+			c := s[0].Div(region)
+			if len(group) > 0 && c != cell {
+				if !flush() {
+					return false
+				}
+			}
+			cell = c
+			group = append(group, s)
+			return true
+		}
+		var stroke []image.Point
+		for c := range p {
+			switch {
+			case !c.Line:
+				if stroke != nil && !end(stroke) {
+					return
+				}
+				stroke = []image.Point{c.Coord}
+			case stroke == nil:
+				// Lines from an unknown position can't be moved.
+				if !yield(c) {
+					return
+				}
+			default:
+				stroke = append(stroke, c.Coord)
+			}
+		}
+		if stroke != nil && !end(stroke) {
+			return
+		}
+		flush()
+	}
+}
+
+// Optimize reorders and reverses the strokes of a plan to reduce the
+// distance moved between them, using a nearest neighbour tour improved
+// by 2-opt. A stroke is a move followed by lines. The plan starts and
+// ends at the same points as p; other moves without lines are dropped.
+//
+// Like Shuffle, Optimize breaks the timing of constant time plans.
+func Optimize(p Plan) Plan {
+	opt := sync.OnceValue(func() []Command {
+		return optimize(p)
+	})
+	return func(yield func(Command) bool) {
+		for _, c := range opt() {
+			if !yield(c) {
+				return
+			}
+		}
+	}
+}
+
+func optimize(p Plan) []Command {
+	return reorder(p, func(start image.Point, strokes [][]image.Point) [][]image.Point {
+		tour := nearestNeighbourTour(start, strokes)
+		twoOpt(start, tour)
+		return tour
+	})
+}
+
+// Spread reorders the strokes of a plan to alternate between distant
+// regions of the plate rather than completing one dense area at a
+// time, to spread the work hardening of thin plates. Strokes are
+// grouped by the region sized square containing their start, and
+// every round takes the next stroke from each region, visiting the
+// region farthest from the previous one first. Like Optimize, the plan
+// starts and ends at the same points as p, other moves without lines
+// are dropped, and the timing of constant time plans is broken.
+func Spread(region int, p Plan) Plan {
+	spread := sync.OnceValue(func() []Command {
+		return reorder(p, func(start image.Point, strokes [][]image.Point) [][]image.Point {
+			return spreadTour(region, strokes)
+		})
+	})
+	return func(yield func(Command) bool) {
+		for _, c := range spread() {
+			if !yield(c) {
+				return
+			}
+		}
+	}
+}
+
+func spreadTour(region int, strokes [][]image.Point) [][]image.Point {
+	var cells []image.Point
+	regions := make(map[image.Point][][]image.Point)
+	for _, s := range strokes {
+		c := s[0].Div(region)
+		if _, ok := regions[c]; !ok {
+			cells = append(cells, c)
+		}
+		regions[c] = append(regions[c], s)
+	}
+	tour := make([][]image.Point, 0, len(strokes))
+	var prev image.Point
+	for len(tour) < len(strokes) {
+		round := slices.DeleteFunc(slices.Clone(cells), func(c image.Point) bool {
+			return len(regions[c]) == 0
+		})
+		for len(round) > 0 {
+			far, farDist := 0, -1
+			for i, c := range round {
+				d := ManhattanDist(prev, c)
+				if len(tour) == 0 {
+					// Start with the first stroke.
+					d = 0
+				}
+				if d > farDist {
+					far, farDist = i, d
+				}
+			}
+			c := round[far]
+			round = slices.Delete(round, far, far+1)
+			tour = append(tour, regions[c][0])
+			regions[c] = regions[c][1:]
+			prev = c
+		}
+	}
+	return tour
+}
+
+// reorder collects the strokes of p, orders them with order and
+// returns the commands of the resulting plan. A stroke is a move
+// followed by lines. The plan starts and ends at the same points as p;
+// other moves without lines are dropped.
+func reorder(p Plan, order func(start image.Point, strokes [][]image.Point) [][]image.Point) []Command {
+	var cmds []Command
+	var strokes [][]image.Point
+	var stroke []image.Point
+	var start, end *image.Point
+	for c := range p {
+		switch {
+		case !c.Line:
+			if start == nil {
+				start = &c.Coord
+			}
+			if len(stroke) > 1 {
+				strokes = append(strokes, stroke)
+			}
+			stroke = []image.Point{c.Coord}
+			end = &c.Coord
+		case stroke == nil:
+			// Lines from an unknown position can't be moved.
+			cmds = append(cmds, c)
+		default:
+			stroke = append(stroke, c.Coord)
+			end = nil
+		}
+	}
+	if len(stroke) > 1 {
+		strokes = append(strokes, stroke)
+	}
+	if start == nil {
+		return cmds
+	}
+	tour := order(*start, strokes)
+	cmds = append(cmds, Move(*start))
+	for _, s := range tour {
+		cmds = append(cmds, Move(s[0]))
+		for _, pos := range s[1:] {
+			cmds = append(cmds, Line(pos))
+		}
+	}
+	if end != nil {
+		cmds = append(cmds, Move(*end))
+	}
+	return cmds
+}
+
+// nearestNeighbourTour orders strokes by repeatedly choosing the stroke
+// closest to the needle, reversing it if its end is closest.
+func nearestNeighbourTour(needle image.Point, strokes [][]image.Point) [][]image.Point {
+	tour := make([][]image.Point, 0, len(strokes))
+	for len(strokes) > 0 {
+		best, bestDist, rev := 0, math.MaxInt, false
+		for i, s := range strokes {
+			if d := ManhattanDist(needle, s[0]); d < bestDist {
+				best, bestDist, rev = i, d, false
+			}
+			if d := ManhattanDist(needle, s[len(s)-1]); d < bestDist {
+				best, bestDist, rev = i, d, true
+			}
+		}
+		s := strokes[best]
+		strokes[best] = strokes[len(strokes)-1]
+		strokes = strokes[:len(strokes)-1]
+		if rev {
+			s = slices.Clone(s)
+			slices.Reverse(s)
+		}
+		tour = append(tour, s)
+		needle = s[len(s)-1]
+	}
+	return tour
+}
+
+// twoOpt improves tour in place by reversing sections that shorten the
+// moves around them. A reversed section is engraved backwards, so the
+// moves inside it are unchanged.
+func twoOpt(start image.Point, tour [][]image.Point) {
+	// Bound the running time for large plans.
+	const maxPasses = 10
+	first := func(i int) image.Point {
+		return tour[i][0]
+	}
+	last := func(i int) image.Point {
+		if i < 0 {
+			return start
+		}
+		s := tour[i]
+		return s[len(s)-1]
+	}
+	for pass := 0; pass < maxPasses; pass++ {
+		improved := false
+		for i := range tour {
+			for j := i + 1; j < len(tour); j++ {
+				// The move after the section, if any.
+				after := 0
+				if j+1 < len(tour) {
+					after = ManhattanDist(last(j), first(j+1)) - ManhattanDist(first(i), first(j+1))
+				}
+				gain := ManhattanDist(last(i-1), first(i)) - ManhattanDist(last(i-1), last(j)) + after
+				if gain <= 0 {
+					continue
+				}
+				slices.Reverse(tour[i : j+1])
+				for k := i; k <= j; k++ {
+					s := slices.Clone(tour[k])
+					slices.Reverse(s)
+					tour[k] = s
+				}
+				improved = true
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+}
+
+// ErrQRTooLarge is returned when content doesn't fit a QR code of
+// the maximum version.
+var ErrQRTooLarge = errors.New("engrave: content too large for QR code")
+
+// maxConstantQRVersion is the largest QR code version supported by
+// ConstantQR.
+const maxConstantQRVersion = 3
+
+// encodeQR encodes content in the smallest QR code of the level, but
+// no larger than maxVersion. A zero maxVersion means no limit.
+func encodeQR(level qr.Level, maxVersion int, content []byte) (*qr.Code, error) {
+	c, err := qr.Encode(string(content), level)
+	if err != nil {
+		return nil, err
+	}
+	// A version v QR code is 17+4v modules wide.
+	if maxVersion > 0 && c.Size > 17+4*maxVersion {
+		return nil, ErrQRTooLarge
+	}
+	return c, nil
+}
+
+// QR engraves content as a QR code of the error correction level and at
+// most maxVersion. A zero maxVersion means no limit.
+func QR(strokeWidth int, scale int, level qr.Level, maxVersion int, content []byte) (Plan, error) {
+	qr, err := encodeQR(level, maxVersion, content)
 	if err != nil {
 		return nil, err
 	}
@@ -245,17 +666,20 @@ func bitmapForQRStatic(dim int) ([]image.Point, []image.Point, bitmap) {
 }
 
 // ConstantQR is like QR that engraves the QR code in a pattern independent of content,
-// except for the QR code version (size).
-func ConstantQR(strokeWidth, scale int, level qr.Level, content []byte) (Plan, error) {
-	c, err := constantQR(strokeWidth, scale, level, content)
+// except for the QR code version (size). Versions larger than 3 are not supported.
+func ConstantQR(strokeWidth, scale int, level qr.Level, maxVersion int, content []byte) (Plan, error) {
+	c, err := constantQR(strokeWidth, scale, level, maxVersion, content)
 	if err != nil {
 		return nil, err
 	}
 	return c.engrave(), nil
 }
 
-func constantQR(strokeWidth, scale int, level qr.Level, content []byte) (*constantQRCmd, error) {
-	qrc, err := qr.Encode(string(content), level)
+func constantQR(strokeWidth, scale int, level qr.Level, maxVersion int, content []byte) (*constantQRCmd, error) {
+	if maxVersion == 0 || maxVersion > maxConstantQRVersion {
+		maxVersion = maxConstantQRVersion
+	}
+	qrc, err := encodeQR(level, maxVersion, content)
 	if err != nil {
 		return nil, err
 	}
@@ -641,7 +1065,13 @@ func (r Rect) Engrave(yield func(Command)) {
 	yield(Line(r.Min))
 }
 
-const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+const (
+	// UppercaseAlphabet is the alphabet of upper case seed words.
+	UppercaseAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	// URAlphabet is the alphabet of upper case UR encodings, except
+	// for the ':' following the "UR" scheme.
+	URAlphabet = UppercaseAlphabet + "0123456789-/"
+)
 
 // ConstantStringer can engrave text in a timing insensitive way.
 type ConstantStringer struct {
@@ -652,7 +1082,8 @@ type ConstantStringer struct {
 	wordStart   image.Point
 	wordEnd     image.Point
 	dims        image.Point
-	alphabet    [len(alphabet)]constantRune
+	runes       string
+	alphabet    []constantRune
 }
 
 type constantRune struct {
@@ -687,10 +1118,23 @@ func engraveConstantRune(yield func(Command), face *vector.Face, em int, r rune)
 	return image.Pt(adv*em/int(m.Height), em)
 }
 
+// NewConstantStringer is like NewConstantAlphabetStringer for the
+// UppercaseAlphabet.
 func NewConstantStringer(face *vector.Face, em int, shortest, longest int) *ConstantStringer {
+	return NewConstantAlphabetStringer(face, em, UppercaseAlphabet, shortest, longest)
+}
+
+// NewConstantAlphabetStringer creates a ConstantStringer for strings
+// of runes from alphabet, between shortest and longest runes long.
+// The runes of alphabet must be ASCII and engraved by a single stroke.
+// The engraving time of every string is that of the slowest rune times
+// longest.
+func NewConstantAlphabetStringer(face *vector.Face, em int, alphabet string, shortest, longest int) *ConstantStringer {
 	var runes []*collectProgram
 	cs := &ConstantStringer{
-		longest: longest,
+		longest:  longest,
+		runes:    alphabet,
+		alphabet: make([]constantRune, len(alphabet)),
 	}
 	// Collects path for every letter.
 	for _, r := range alphabet {
@@ -715,8 +1159,7 @@ func NewConstantStringer(face *vector.Face, em int, shortest, longest int) *Cons
 	cs.wordStart = image.Pt(0, cs.dims.Y/2)
 	cs.wordEnd = image.Pt(endx, cs.dims.Y/2)
 	center := image.Pt(cs.dims.X/2, cs.dims.Y/2)
-	for i, r := range alphabet {
-		c := runes[i]
+	for i, c := range runes {
 		path := c.path
 		last := len(path) - 1
 		n := c.len
@@ -760,7 +1203,7 @@ func NewConstantStringer(face *vector.Face, em int, shortest, longest int) *Cons
 				dir = -dir
 			}
 		}
-		cs.alphabet[r-'A'] = constantRune{
+		cs.alphabet[i] = constantRune{
 			path: path,
 		}
 		start, end := path[0], path[len(path)-1]
@@ -774,7 +1217,17 @@ func NewConstantStringer(face *vector.Face, em int, shortest, longest int) *Cons
 	return cs
 }
 
+// String engraves txt. It panics if txt contains runes not in the
+// alphabet of the ConstantStringer.
 func (c *ConstantStringer) String(txt string) Plan {
+	runes := make([]constantRune, len(txt))
+	for i, r := range txt {
+		idx := strings.IndexRune(c.runes, r)
+		if idx == -1 {
+			panic(fmt.Errorf("unsupported rune: %s", string(r)))
+		}
+		runes[i] = c.alphabet[idx]
+	}
 	cmd := func(yield func(Command) bool) {
 		needle := c.wordStart
 		if !yield(Move(needle)) {
@@ -782,8 +1235,7 @@ func (c *ConstantStringer) String(txt string) Plan {
 		}
 		repeats := c.longest / len(txt)
 		rest := c.longest - repeats*len(txt)
-		for i, r := range txt {
-			l := c.alphabet[r-'A']
+		for i, l := range runes {
 			extra := 0
 			if rest > 0 {
 				rest--
@@ -1019,6 +1471,9 @@ func (c *pattern) Command(cmd Command) {
 	c.end = cmd.Coord
 }
 
+// String engraves txt in face. Runes not in face are engraved
+// as the face's fallback glyph; use [vector.Face.Validate] to
+// detect them.
 func String(face *vector.Face, em int, txt string) *StringCmd {
 	return &StringCmd{
 		LineHeight: 1,
@@ -1063,8 +1518,13 @@ func (s *StringCmd) engrave(yield func(Command) bool) image.Point {
 			continue
 		}
 		adv, segs, found := s.face.Decode(r)
-		if !found {
-			panic(fmt.Errorf("unsupported rune: %s", string(r)))
+		if !found || r == vector.Fallback {
+			// Substitute the fallback glyph, or skip the rune if the
+			// face has none.
+			adv, segs, found = s.face.Decode(vector.Fallback)
+			if !found {
+				continue
+			}
 		}
 		if yield != nil {
 			cont := true
@@ -1084,6 +1544,9 @@ func (s *StringCmd) engrave(yield func(Command) bool) image.Point {
 					panic(errors.New("unsupported segment"))
 				}
 			}
+			if !cont {
+				return image.Point{}
+			}
 		}
 		pos.X += adv * s.em / int(m.Height)
 	}
@@ -1153,16 +1616,10 @@ func (m *measureProgram) Command(cmd Command) {
 }
 
 func (m *measureProgram) expand(p image.Point) {
-	if p.X < m.bounds.Min.X {
-		m.bounds.Min.X = p.X
-	} else if p.X > m.bounds.Max.X {
-		m.bounds.Max.X = p.X
-	}
-	if p.Y < m.bounds.Min.Y {
-		m.bounds.Min.Y = p.Y
-	} else if p.Y > m.bounds.Max.Y {
-		m.bounds.Max.Y = p.Y
-	}
+	m.bounds.Min.X = min(m.bounds.Min.X, p.X)
+	m.bounds.Max.X = max(m.bounds.Max.X, p.X)
+	m.bounds.Min.Y = min(m.bounds.Min.Y, p.Y)
+	m.bounds.Max.Y = max(m.bounds.Max.Y, p.Y)
 }
 
 func Measure(plan Plan) image.Rectangle {
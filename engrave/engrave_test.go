@@ -1,12 +1,16 @@
 package engrave
 
 import (
+	"errors"
 	"image"
 	"io"
+	"iter"
 	"math/rand"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/kortschak/qr"
 	"seedhammer.com/bip39"
@@ -22,7 +26,7 @@ func TestConstantQR(t *testing.T) {
 				t.Fatal(err)
 			}
 			lvl := qr.Q
-			cmd, err := constantQR(7, 4, lvl, entropy)
+			cmd, err := constantQR(7, 4, lvl, 0, entropy)
 			if err != nil {
 				t.Fatalf("entropy: %x: %v", entropy, err)
 			}
@@ -56,6 +60,125 @@ func TestConstantString(t *testing.T) {
 	}
 }
 
+func TestStringFallback(t *testing.T) {
+	if err := constant.Font.Validate("A€B"); err == nil {
+		t.Error("Validate accepted unsupported rune")
+	}
+	got := String(constant.Font, 1000, "A€B").Measure()
+	want := String(constant.Font, 1000, "A?B").Measure()
+	if got != want {
+		t.Errorf("string with fallback measures %v, wanted %v", got, want)
+	}
+}
+
+func TestStringBreak(t *testing.T) {
+	n := 0
+	for range String(constant.Font, 1000, "AB").Engrave() {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("iterated %d commands after break, want 1", n)
+	}
+}
+
+func TestConstantURString(t *testing.T) {
+	const longest = 20
+	s := NewConstantAlphabetStringer(constant.Font, 1000, URAlphabet, 1, longest)
+	bounds := image.Rect(0, 0, s.longest*s.dims.X, s.dims.Y)
+	for i := range URAlphabet {
+		for n := 1; n <= longest; n++ {
+			var txt []byte
+			for j := 0; j < n; j++ {
+				txt = append(txt, URAlphabet[(i+j)%len(URAlphabet)])
+			}
+			moves := measureMoves(s.String(string(txt)))
+			if !moves.In(bounds) {
+				t.Errorf("%s movement bounds %v are not inside bounds %v", txt, moves, bounds)
+			}
+		}
+	}
+}
+
+func TestShuffle(t *testing.T) {
+	plan, err := QR(1, 1, qr.M, 0, []byte("SEEDHAMMER"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan = Commands(plan, func(yield func(Command) bool) {
+		yield(Move(image.Pt(100, 100)))
+	})
+	const region = 10
+	shuffled := Shuffle(42, region, plan)
+	if !reflect.DeepEqual(lineSegments(plan), lineSegments(shuffled)) {
+		t.Error("shuffled plan engraves different lines")
+	}
+	if got, want := Measure(shuffled), Measure(plan); got != want {
+		t.Errorf("shuffled bounds %v, want %v", got, want)
+	}
+	var first, second, orig []Command
+	for c := range shuffled {
+		first = append(first, c)
+	}
+	for c := range shuffled {
+		second = append(second, c)
+	}
+	for c := range plan {
+		orig = append(orig, c)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Error("shuffled plan differs between iterations")
+	}
+	if reflect.DeepEqual(first, orig) {
+		t.Error("plan was not shuffled")
+	}
+	if got, want := first[len(first)-1], orig[len(orig)-1]; got != want {
+		t.Errorf("shuffled plan ends with %v, want %v", got, want)
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	plan, err := QR(1, 1, qr.M, 0, []byte("SEEDHAMMER"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan = Shuffle(1, 1000, plan)
+	moves := func(p Plan) int {
+		dist := 0
+		var needle image.Point
+		first := true
+		for c := range p {
+			if !c.Line && !first {
+				dist += ManhattanDist(needle, c.Coord)
+			}
+			first = false
+			needle = c.Coord
+		}
+		return dist
+	}
+	opt := Optimize(plan)
+	before, after := moves(plan), moves(opt)
+	if after >= before/2 {
+		t.Errorf("optimized move distance %d, want less than half of %d", after, before)
+	}
+	if !reflect.DeepEqual(lineSegments(plan), lineSegments(opt)) {
+		t.Error("optimized plan engraves different lines")
+	}
+	if got, want := Measure(opt), Measure(plan); got != want {
+		t.Errorf("optimized bounds %v, want %v", got, want)
+	}
+}
+
+func TestQRMaxVersion(t *testing.T) {
+	content := []byte("SEEDHAMMER SEEDHAMMER")
+	if _, err := QR(1, 1, qr.H, 1, content); !errors.Is(err, ErrQRTooLarge) {
+		t.Errorf("got %v, expected %v", err, ErrQRTooLarge)
+	}
+	if _, err := QR(1, 1, qr.L, 1, content); err != nil {
+		t.Error(err)
+	}
+}
+
 func FuzzConstantQR(f *testing.F) {
 	f.Fuzz(func(t *testing.T, entropy []byte) {
 		if len(entropy) < 16 {
@@ -64,15 +187,33 @@ func FuzzConstantQR(f *testing.F) {
 		if len(entropy) > 32 {
 			entropy = entropy[:32]
 		}
-		if _, err := ConstantQR(1, 3, qr.Q, entropy); err != nil {
+		if _, err := ConstantQR(1, 3, qr.Q, 0, entropy); err != nil {
 			t.Fatalf("entropy: %x: %v", entropy, err)
 		}
-		if _, err := ConstantQR(1, 3, qr.L, entropy); err != nil {
+		if _, err := ConstantQR(1, 3, qr.L, 0, entropy); err != nil {
 			t.Fatalf("entropy: %x: %v", entropy, err)
 		}
 	})
 }
 
+// lineSegments counts the line segments of a plan, regardless of
+// direction.
+func lineSegments(p Plan) map[[2]image.Point]int {
+	res := make(map[[2]image.Point]int)
+	var needle image.Point
+	for c := range p {
+		if c.Line {
+			s := [2]image.Point{needle, c.Coord}
+			if s[0].X > s[1].X || s[0].X == s[1].X && s[0].Y > s[1].Y {
+				s[0], s[1] = s[1], s[0]
+			}
+			res[s]++
+		}
+		needle = c.Coord
+	}
+	return res
+}
+
 func measureMoves(p Plan) image.Rectangle {
 	inf := image.Rectangle{Min: image.Pt(1e6, 1e6), Max: image.Pt(-1e6, -1e6)}
 	bounds := inf
@@ -97,3 +238,248 @@ func measureMoves(p Plan) image.Rectangle {
 	}
 	return bounds
 }
+
+func TestEstimate(t *testing.T) {
+	params := Params{
+		MoveStep: time.Millisecond,
+		LineStep: 2 * time.Millisecond,
+		PenDown:  10 * time.Millisecond,
+		PenUp:    20 * time.Millisecond,
+	}
+	plan := func(yield func(Command) bool) {
+		_ = yield(Move(image.Pt(10, 0))) &&
+			yield(Line(image.Pt(10, 30))) &&
+			yield(Line(image.Pt(50, 0))) &&
+			yield(Move(image.Pt(0, 0)))
+	}
+	dur, stroke := Estimate(plan, params)
+	// 10 move steps, 30+40 line steps, 50 move steps and
+	// the needle lowered and raised once.
+	if want := 60*time.Millisecond + 70*2*time.Millisecond + 30*time.Millisecond; dur != want {
+		t.Errorf("estimated %v, want %v", dur, want)
+	}
+	if want := 30 + 50; stroke != want {
+		t.Errorf("estimated stroke length %d, want %d", stroke, want)
+	}
+}
+
+func TestAlign(t *testing.T) {
+	want := [2]image.Point{{100, 100}, {200, 100}}
+	// Rotated a quarter turn and offset.
+	got := [2]image.Point{{110, 90}, {110, 190}}
+	plan := func(yield func(Command) bool) {
+		_ = yield(Move(image.Pt(100, 100))) &&
+			yield(Line(image.Pt(200, 100))) &&
+			yield(Line(image.Pt(100, 150)))
+	}
+	var aligned []image.Point
+	for c := range Align(want, got, plan) {
+		aligned = append(aligned, c.Coord)
+	}
+	expected := []image.Point{{110, 90}, {110, 190}, {60, 90}}
+	if !reflect.DeepEqual(aligned, expected) {
+		t.Errorf("aligned plan to %v, expected %v", aligned, expected)
+	}
+}
+
+func TestMirror(t *testing.T) {
+	plan := func(yield func(Command) bool) {
+		_ = yield(Move(image.Pt(1, 2))) &&
+			yield(Line(image.Pt(3, -4)))
+	}
+	tests := []struct {
+		axis Axis
+		want []image.Point
+	}{
+		{AxisX, []image.Point{{1, -2}, {3, 4}}},
+		{AxisY, []image.Point{{-1, 2}, {-3, -4}}},
+	}
+	for _, test := range tests {
+		var got []image.Point
+		for c := range Mirror(test.axis, plan) {
+			got = append(got, c.Coord)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("mirrored across axis %d to %v, expected %v", test.axis, got, test.want)
+		}
+	}
+}
+
+func TestOutline(t *testing.T) {
+	plan := func(yield func(Command) bool) {
+		_ = yield(Move(image.Pt(1, 2))) &&
+			yield(Line(image.Pt(3, -4))) &&
+			yield(Move(image.Pt(100, 100))) &&
+			yield(Line(image.Pt(5, 6)))
+	}
+	var got []Command
+	for c := range Outline(plan) {
+		if c.Line {
+			t.Fatalf("outline engraves line to %v", c.Coord)
+		}
+		got = append(got, c)
+	}
+	want := []Command{
+		Move(image.Pt(1, -4)),
+		Move(image.Pt(100, -4)),
+		Move(image.Pt(100, 100)),
+		Move(image.Pt(1, 100)),
+		Move(image.Pt(1, -4)),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("outline %v, want %v", got, want)
+	}
+}
+
+func TestSpread(t *testing.T) {
+	// Two dense areas, engraved one at a time.
+	var strokes []Plan
+	for _, off := range []image.Point{{0, 0}, {1000, 0}} {
+		for i := range 5 {
+			strokes = append(strokes, Offset(off.X, off.Y+i*10, func(yield func(Command) bool) {
+				_ = yield(Move(image.Pt(0, 0))) && yield(Line(image.Pt(50, 0)))
+			}))
+		}
+	}
+	plan := Commands(strokes...)
+	const region = 100
+	spread := Spread(region, plan)
+	if !reflect.DeepEqual(lineSegments(plan), lineSegments(spread)) {
+		t.Error("spread plan engraves different lines")
+	}
+	prev := image.Pt(-1, -1)
+	first := true
+	for c := range spread {
+		// Skip the move to the start point.
+		if c.Line || first {
+			first = false
+			continue
+		}
+		cell := c.Coord.Div(region)
+		if cell == prev {
+			t.Fatalf("consecutive strokes in region %v", cell)
+		}
+		prev = cell
+	}
+}
+
+func TestMarshalPlan(t *testing.T) {
+	plan, err := QR(3, 2, qr.M, 0, []byte("SEEDHAMMER"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan = Offset(-100, 50, plan)
+	data := MarshalPlan(plan)
+	got, err := UnmarshalPlan(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := slices.Collect(iter.Seq[Command](plan))
+	if cmds := slices.Collect(iter.Seq[Command](got)); !slices.Equal(cmds, want) {
+		t.Error("unmarshaled plan differs")
+	}
+	empty, err := UnmarshalPlan(MarshalPlan(Commands()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range empty {
+		t.Error("unmarshaled empty plan has commands")
+	}
+	corrupt := slices.Clone(data)
+	corrupt[len(corrupt)/2] ^= 0x01
+	for _, data := range [][]byte{corrupt, data[:len(data)-1], nil, append([]byte("SHP\x02"), data[4:]...)} {
+		if _, err := UnmarshalPlan(data); !errors.Is(err, ErrInvalidPlan) {
+			t.Errorf("invalid plan file unmarshaled with error %v", err)
+		}
+	}
+}
+
+func TestTextLines(t *testing.T) {
+	const fontSize = 10
+	width := String(constant.Font, fontSize, "WWWWWWWWWW").Measure().X
+	txt := Text{Face: constant.Font, Size: fontSize, Text: "ab cd efghijklmnopq\nr  s"}
+	got := txt.Lines(width)
+	want := []string{"ab cd", "efghijklmn", "opq", "r s"}
+	if !slices.Equal(got, want) {
+		t.Errorf("wrapped text to %q, want %q", got, want)
+	}
+}
+
+func TestLayout(t *testing.T) {
+	box := func(w, h int) Plan {
+		return func(yield func(Command) bool) {
+			_ = yield(Move(image.Pt(0, 0))) &&
+				yield(Line(image.Pt(w, h)))
+		}
+	}
+	tests := []struct {
+		name  string
+		block Block
+		width int
+		// first is the position of the first block.
+		first image.Point
+		size  image.Point
+	}{
+		{
+			"stack",
+			Stack{Blocks: []Block{box(10, 5), Text{}, box(20, 5)}, Gap: 2, Align: AlignCenter},
+			100,
+			image.Pt(5, 0),
+			image.Pt(20, 12),
+		},
+		{
+			"columns",
+			Columns{Blocks: []Block{Commands(), box(10, 10)}, Gap: 10},
+			50,
+			image.Pt(30, 0),
+			image.Pt(50, 10),
+		},
+		{
+			"frame",
+			Frame{
+				Size:   image.Pt(100, 100),
+				Margin: 10,
+				Items: []Anchored{
+					{X: AlignEnd, Y: AlignEnd, Block: box(10, 10)},
+				},
+			},
+			100,
+			image.Pt(80, 80),
+			image.Pt(100, 100),
+		},
+	}
+	for _, test := range tests {
+		p, sz, err := test.block.Layout(test.width)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		var first image.Point
+		for c := range p {
+			first = c.Coord
+			break
+		}
+		if first != test.first || sz != test.size {
+			t.Errorf("%s: laid out at %v with size %v, want %v with size %v", test.name, first, sz, test.first, test.size)
+		}
+	}
+	if _, _, err := box(20, 1).Layout(10); !errors.Is(err, ErrNoFit) {
+		t.Errorf("laid out too wide block, got %v, want %v", err, ErrNoFit)
+	}
+}
+
+func TestFit(t *testing.T) {
+	square := func(size int) Block {
+		return Text{Face: constant.Font, Size: size, Text: "W"}
+	}
+	_, sz, err := Fit(image.Pt(1000, 500), 1, 2000, square)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sz.Y != 500 {
+		t.Errorf("fitted text of height %d, want %d", sz.Y, 500)
+	}
+	if _, _, err := Fit(image.Pt(1000, 500), 600, 2000, square); !errors.Is(err, ErrNoFit) {
+		t.Errorf("fitted too large text, got %v, want %v", err, ErrNoFit)
+	}
+}
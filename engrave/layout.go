@@ -0,0 +1,303 @@
+package engrave
+
+import (
+	"errors"
+	"image"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kortschak/qr"
+	"seedhammer.com/font/vector"
+)
+
+// A Block is a rectangular part of a plate layout. Plate designs
+// compose blocks declaratively and lay them out once, instead of
+// placing every string and QR code by hand.
+type Block interface {
+	// Layout engraves the block no wider than width. The engraving
+	// is returned with its size, and spans the rectangle from the
+	// origin to the size.
+	Layout(width int) (Plan, image.Point, error)
+}
+
+// ErrNoFit is returned when a block doesn't fit its layout.
+var ErrNoFit = errors.New("engrave: layout doesn't fit")
+
+// Alignment is the placement of a block in the space along an
+// axis of its layout.
+type Alignment int
+
+const (
+	AlignStart Alignment = iota
+	AlignCenter
+	AlignEnd
+)
+
+func (a Alignment) offset(space, size int) int {
+	switch a {
+	case AlignCenter:
+		return (space - size) / 2
+	case AlignEnd:
+		return space - size
+	}
+	return 0
+}
+
+// Layout lays out the plan as a block of its bounds.
+func (p Plan) Layout(width int) (Plan, image.Point, error) {
+	p, sz := normalize(p)
+	if sz.X > width {
+		return nil, image.Point{}, ErrNoFit
+	}
+	return p, sz, nil
+}
+
+// Sized is a block of a plan with a fixed size. Use it for plans
+// whose bounds must not affect the layout, such as constant time
+// engravings whose bounds depend on their content.
+type Sized struct {
+	Plan Plan
+	Size image.Point
+}
+
+func (s Sized) Layout(width int) (Plan, image.Point, error) {
+	if s.Size.X > width {
+		return nil, image.Point{}, ErrNoFit
+	}
+	return s.Plan, s.Size, nil
+}
+
+// normalize offsets p to the origin and returns it with its size.
+func normalize(p Plan) (Plan, image.Point) {
+	b := Measure(p)
+	return Offset(-b.Min.X, -b.Min.Y, p), b.Size()
+}
+
+// Text is a block of text flowed into lines, breaking lines between
+// words where possible. Runs of spaces are collapsed, and words
+// longer than a line are broken between runes.
+type Text struct {
+	Face *vector.Face
+	// Size is the em size and the line height of the text.
+	Size  int
+	Text  string
+	Align Alignment
+}
+
+func (t Text) Layout(width int) (Plan, image.Point, error) {
+	lines := t.Lines(width)
+	plans := make([]Plan, len(lines))
+	widths := make([]int, len(lines))
+	var sz image.Point
+	for i, l := range lines {
+		p, lsz := normalize(String(t.Face, t.Size, l).Engrave())
+		if lsz.X > width {
+			return nil, image.Point{}, ErrNoFit
+		}
+		plans[i], widths[i] = p, lsz.X
+		sz.X = max(sz.X, lsz.X)
+	}
+	sz.Y = len(lines) * t.Size
+	var cmds []Plan
+	for i, p := range plans {
+		cmds = append(cmds, Offset(t.Align.offset(sz.X, widths[i]), i*t.Size, p))
+	}
+	return Commands(cmds...), sz, nil
+}
+
+// Lines splits the text into the lines of its layout no wider than
+// width.
+func (t Text) Lines(width int) []string {
+	fits := func(line string) bool {
+		return String(t.Face, t.Size, line).Measure().X <= width
+	}
+	var lines []string
+	for _, para := range strings.Split(t.Text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			if line != "" {
+				if fits(line + " " + word) {
+					line += " " + word
+					continue
+				}
+				lines = append(lines, line)
+			}
+			line = word
+			for !fits(line) {
+				// Break the word after the most runes that fit,
+				// but at least one.
+				n := 0
+				for i := range line {
+					if i > 0 && !fits(line[:i]) {
+						break
+					}
+					n = i
+				}
+				if n == 0 {
+					_, n = utf8.DecodeRuneInString(line)
+				}
+				lines = append(lines, line[:n])
+				line = line[n:]
+			}
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Stack is a block of blocks stacked from the top, spaced by Gap.
+// Empty blocks take up no space.
+type Stack struct {
+	Blocks []Block
+	Gap    int
+	// Align places the blocks horizontally.
+	Align Alignment
+}
+
+func (s Stack) Layout(width int) (Plan, image.Point, error) {
+	plans := make([]Plan, len(s.Blocks))
+	sizes := make([]image.Point, len(s.Blocks))
+	var sz image.Point
+	for i, b := range s.Blocks {
+		p, bsz, err := b.Layout(width)
+		if err != nil {
+			return nil, image.Point{}, err
+		}
+		plans[i], sizes[i] = p, bsz
+		sz.X = max(sz.X, bsz.X)
+	}
+	var cmds []Plan
+	for i, p := range plans {
+		bsz := sizes[i]
+		if bsz.Y == 0 {
+			continue
+		}
+		if len(cmds) > 0 {
+			sz.Y += s.Gap
+		}
+		cmds = append(cmds, Offset(s.Align.offset(sz.X, bsz.X), sz.Y, p))
+		sz.Y += bsz.Y
+	}
+	return Commands(cmds...), sz, nil
+}
+
+// Columns is a block of blocks side by side in columns of equal
+// width, spaced by Gap and aligned to their tops.
+type Columns struct {
+	Blocks []Block
+	Gap    int
+}
+
+func (c Columns) Layout(width int) (Plan, image.Point, error) {
+	n := len(c.Blocks)
+	if n == 0 {
+		return Commands(), image.Point{}, nil
+	}
+	colWidth := (width - (n-1)*c.Gap) / n
+	if colWidth < 0 {
+		return nil, image.Point{}, ErrNoFit
+	}
+	var cmds []Plan
+	sz := image.Pt(n*colWidth+(n-1)*c.Gap, 0)
+	for i, b := range c.Blocks {
+		p, bsz, err := b.Layout(colWidth)
+		if err != nil {
+			return nil, image.Point{}, err
+		}
+		cmds = append(cmds, Offset(i*(colWidth+c.Gap), 0, p))
+		sz.Y = max(sz.Y, bsz.Y)
+	}
+	return Commands(cmds...), sz, nil
+}
+
+// Frame is a block of a fixed size, with blocks anchored to its
+// center, edges or corners inside a margin. Anchored blocks may
+// overlap.
+type Frame struct {
+	Size   image.Point
+	Margin int
+	Items  []Anchored
+}
+
+// Anchored is a block of a Frame, placed by its alignment along
+// each axis.
+type Anchored struct {
+	X, Y  Alignment
+	Block Block
+}
+
+func (f Frame) Layout(width int) (Plan, image.Point, error) {
+	if f.Size.X > width {
+		return nil, image.Point{}, ErrNoFit
+	}
+	inner := f.Size.Sub(image.Pt(2*f.Margin, 2*f.Margin))
+	var cmds []Plan
+	for _, it := range f.Items {
+		p, sz, err := it.Block.Layout(inner.X)
+		if err != nil {
+			return nil, image.Point{}, err
+		}
+		if sz.Y > inner.Y {
+			return nil, image.Point{}, ErrNoFit
+		}
+		x := f.Margin + it.X.offset(inner.X, sz.X)
+		y := f.Margin + it.Y.offset(inner.Y, sz.Y)
+		cmds = append(cmds, Offset(x, y, p))
+	}
+	return Commands(cmds...), f.Size, nil
+}
+
+// QRCode is a block with content engraved as a QR code. See QR
+// for the meaning of its fields.
+type QRCode struct {
+	StrokeWidth int
+	Scale       int
+	Level       qr.Level
+	MaxVersion  int
+	Content     []byte
+	// Constant engraves the code with ConstantQR.
+	Constant bool
+}
+
+func (q QRCode) Layout(width int) (Plan, image.Point, error) {
+	engrave := QR
+	if q.Constant {
+		engrave = ConstantQR
+	}
+	p, err := engrave(q.StrokeWidth, q.Scale, q.Level, q.MaxVersion, q.Content)
+	if err != nil {
+		return nil, image.Point{}, err
+	}
+	return p.Layout(width)
+}
+
+// Fit lays out the block of the largest size between smallest and
+// largest that fits within bounds. The size is any parameter that
+// grows the block, such as the em size of its text or the scale of
+// its QR code. Fit returns ErrNoFit if not even the smallest block
+// fits.
+func Fit(bounds image.Point, smallest, largest int, block func(size int) Block) (Plan, image.Point, error) {
+	var plan Plan
+	var sz image.Point
+	found := false
+	// Binary search, assuming larger sizes lead to larger blocks.
+	for smallest <= largest {
+		mid := (smallest + largest) / 2
+		p, psz, err := block(mid).Layout(bounds.X)
+		if err != nil && !errors.Is(err, ErrNoFit) {
+			return nil, image.Point{}, err
+		}
+		if err == nil && psz.Y <= bounds.Y {
+			plan, sz, found = p, psz, true
+			smallest = mid + 1
+		} else {
+			largest = mid - 1
+		}
+	}
+	if !found {
+		return nil, image.Point{}, ErrNoFit
+	}
+	return plan, sz, nil
+}
@@ -0,0 +1,125 @@
+package engrave
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+)
+
+// The plan file format, .shp, stores a plan for engraving by another
+// machine than the one laying it out. It is
+//
+//	magic     "SHP"
+//	version   1 byte
+//	bounds    4 varints: the minimum and maximum coordinates of the plan
+//	count     uvarint: the number of commands
+//	commands  per command a varint of the x delta shifted left once,
+//	          with the lowest bit set for lines, followed by a varint
+//	          of the y delta. Deltas are from the previous coordinate,
+//	          starting from (0, 0).
+//	checksum  big endian CRC-32 (IEEE) of the preceding bytes
+//
+// where varints are in the format of [binary.AppendVarint].
+const (
+	planMagic   = "SHP"
+	planVersion = 1
+)
+
+// ErrInvalidPlan is returned by UnmarshalPlan for data not in the
+// plan file format.
+var ErrInvalidPlan = errors.New("engrave: invalid plan file")
+
+// MarshalPlan encodes p in the plan file format.
+func MarshalPlan(p Plan) []byte {
+	var cmds []Command
+	var bounds image.Rectangle
+	for c := range p {
+		if len(cmds) == 0 {
+			bounds = image.Rectangle{Min: c.Coord, Max: c.Coord}
+		}
+		bounds.Min.X = min(bounds.Min.X, c.Coord.X)
+		bounds.Min.Y = min(bounds.Min.Y, c.Coord.Y)
+		bounds.Max.X = max(bounds.Max.X, c.Coord.X)
+		bounds.Max.Y = max(bounds.Max.Y, c.Coord.Y)
+		cmds = append(cmds, c)
+	}
+	data := append([]byte(planMagic), planVersion)
+	for _, v := range []int{bounds.Min.X, bounds.Min.Y, bounds.Max.X, bounds.Max.Y} {
+		data = binary.AppendVarint(data, int64(v))
+	}
+	data = binary.AppendUvarint(data, uint64(len(cmds)))
+	var prev image.Point
+	for _, c := range cmds {
+		d := c.Coord.Sub(prev)
+		x := int64(d.X) << 1
+		if c.Line {
+			x |= 1
+		}
+		data = binary.AppendVarint(data, x)
+		data = binary.AppendVarint(data, int64(d.Y))
+		prev = c.Coord
+	}
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+}
+
+// UnmarshalPlan decodes a plan encoded by MarshalPlan. It verifies
+// the checksum and that the commands are within the bounds of the
+// header.
+func UnmarshalPlan(data []byte) (Plan, error) {
+	if len(data) < len(planMagic)+1+4 || !bytes.HasPrefix(data, []byte(planMagic)) {
+		return nil, ErrInvalidPlan
+	}
+	if v := data[len(planMagic)]; v != planVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidPlan, v)
+	}
+	data, sum := data[:len(data)-4], binary.BigEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(data) != sum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidPlan)
+	}
+	r := bytes.NewReader(data[len(planMagic)+1:])
+	var coords [4]int
+	for i := range coords {
+		v, err := binary.ReadVarint(r)
+		if err != nil {
+			return nil, ErrInvalidPlan
+		}
+		coords[i] = int(v)
+	}
+	bounds := image.Rect(coords[0], coords[1], coords[2], coords[3])
+	n, err := binary.ReadUvarint(r)
+	// Every command takes at least 2 bytes.
+	if err != nil || n > uint64(r.Len()/2) {
+		return nil, ErrInvalidPlan
+	}
+	cmds := make([]Command, 0, n)
+	var pos image.Point
+	for range n {
+		x, err := binary.ReadVarint(r)
+		if err != nil {
+			return nil, ErrInvalidPlan
+		}
+		y, err := binary.ReadVarint(r)
+		if err != nil {
+			return nil, ErrInvalidPlan
+		}
+		pos = pos.Add(image.Pt(int(x>>1), int(y)))
+		// The bounds include their maximum.
+		if !pos.In(image.Rectangle{Min: bounds.Min, Max: bounds.Max.Add(image.Pt(1, 1))}) {
+			return nil, fmt.Errorf("%w: command outside bounds", ErrInvalidPlan)
+		}
+		cmds = append(cmds, Command{Line: x&1 == 1, Coord: pos})
+	}
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("%w: trailing data", ErrInvalidPlan)
+	}
+	return func(yield func(Command) bool) {
+		for _, c := range cmds {
+			if !yield(c) {
+				return
+			}
+		}
+	}, nil
+}
@@ -131,7 +131,18 @@
                 ./scripts/config --disable INPUT_TOUCHSCREEN
                 ./scripts/config --disable RC_MAP
                 ./scripts/config --disable NAMESPACES
-                ./scripts/config --disable INPUT
+                # Enable USB keyboards, and nothing else of the input subsystem.
+                ./scripts/config --enable INPUT
+                ./scripts/config --enable INPUT_EVDEV
+                ./scripts/config --disable INPUT_MOUSEDEV
+                ./scripts/config --disable INPUT_KEYBOARD
+                ./scripts/config --disable INPUT_MOUSE
+                ./scripts/config --disable INPUT_JOYSTICK
+                ./scripts/config --disable INPUT_MISC
+                ./scripts/config --disable MAGIC_SYSRQ
+                ./scripts/config --enable HID
+                ./scripts/config --enable HID_GENERIC
+                ./scripts/config --enable USB_HID
                 # Enable v4l2
                 ./scripts/config --enable MEDIA_SUPPORT
                 ./scripts/config --enable VIDEO_V4L2
@@ -151,6 +162,8 @@
                 ./scripts/config --enable VIDEO_DW9807_VCM
                 # Enable SPI.
                 ./scripts/config --enable SPI_BCM2835
+                # Enable firmware mailbox for the customer OTP memory.
+                ./scripts/config --enable BCM_VCIO
                 # Enable FTDI USB serial driver.
                 ./scripts/config --enable USB_SERIAL
                 ./scripts/config --enable USB_SERIAL_FTDI_SIO
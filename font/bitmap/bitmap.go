@@ -11,11 +11,24 @@ import (
 )
 
 type Face struct {
-	data []byte
+	data  []byte
+	scale int
 }
 
 func NewFace(data []byte) *Face {
-	return &Face{data}
+	return &Face{data: data, scale: 1}
+}
+
+// Scaled returns a face with the glyphs of f enlarged by an
+// integer factor.
+func (f *Face) Scaled(factor int) *Face {
+	return &Face{data: f.data, scale: f.Scale() * factor}
+}
+
+// Scale returns the factor by which glyph images are enlarged.
+// Metrics, advances and kerning are already scaled.
+func (f *Face) Scale() int {
+	return max(1, f.scale)
 }
 
 type Kern struct {
@@ -51,10 +64,11 @@ const (
 var bo = binary.LittleEndian
 
 func (f *Face) Metrics() font.Metrics {
+	s := fixed.Int26_6(f.Scale())
 	return font.Metrics{
-		Ascent:  fixed.Int26_6(bo.Uint32(f.data[offAscent:])),
-		Descent: fixed.Int26_6(bo.Uint32(f.data[offDescent:])),
-		Height:  fixed.Int26_6(bo.Uint32(f.data[offHeight:])),
+		Ascent:  s * fixed.Int26_6(bo.Uint32(f.data[offAscent:])),
+		Descent: s * fixed.Int26_6(bo.Uint32(f.data[offDescent:])),
+		Height:  s * fixed.Int26_6(bo.Uint32(f.data[offHeight:])),
 	}
 }
 
@@ -81,7 +95,7 @@ func (f *Face) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
 	if !ok {
 		return 0, false
 	}
-	return g.Advance, true
+	return fixed.Int26_6(f.Scale()) * g.Advance, true
 }
 
 func (f *Face) Kern(r1, r2 rune) fixed.Int26_6 {
@@ -97,9 +111,10 @@ func (f *Face) Kern(r1, r2 rune) fixed.Int26_6 {
 	if !found {
 		return 0
 	}
-	return fixed.Int26_6(bo.Uint32(kerns[i*KernElemSize+2:]))
+	return fixed.Int26_6(f.Scale()) * fixed.Int26_6(bo.Uint32(kerns[i*KernElemSize+2:]))
 }
 
+// Glyph returns the unscaled image of r and its scaled advance.
 func (f *Face) Glyph(r rune) (alpha4.Image, fixed.Int26_6, bool) {
 	g, ok := f.glyphFor(r)
 	if !ok {
@@ -111,5 +126,5 @@ func (f *Face) Glyph(r rune) (alpha4.Image, fixed.Int26_6, bool) {
 	return alpha4.Image{
 		Pix:  f.data[start : start+(npixels+1)/2],
 		Rect: g.Rect,
-	}, g.Advance, true
+	}, fixed.Int26_6(f.Scale()) * g.Advance, true
 }
@@ -0,0 +1,6 @@
+// package condensed contains a narrow variant of the constant font, for
+// fitting long descriptors on a plate. condensed.svg is constant.svg with
+// its horizontal coordinates scaled by 2 and its vertical coordinates by 3.
+package condensed
+
+//go:generate go run ../vector/convert.go -package condensed condensed.svg condensed
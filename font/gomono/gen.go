@@ -0,0 +1,6 @@
+// package gomono contains the monospaced font for fingerprints and
+// addresses.
+package gomono
+
+//go:generate go run ../bitmap/convert.go -package gomono -ppem 14 Go-Mono.ttf regular
+//go:generate go run ../bitmap/convert.go -package gomono -ppem 14 Go-Mono-Bold.ttf bold
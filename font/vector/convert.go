@@ -482,6 +482,18 @@ func mapChar(id string) (rune, bool) {
 			r = '*'
 		case "at":
 			r = '@'
+		case "plus":
+			r = '+'
+		case "equals":
+			r = '='
+		case "percent":
+			r = '%'
+		case "ampersand":
+			r = '&'
+		case "underscore":
+			r = '_'
+		case "notdef":
+			r = vector.Fallback
 		default:
 			return 0, false
 		}
@@ -3,6 +3,7 @@ package vector
 
 import (
 	"encoding/binary"
+	"fmt"
 	"image"
 	"unicode"
 )
@@ -69,6 +70,10 @@ const (
 
 var bo = binary.LittleEndian
 
+// Fallback is the rune whose glyph, if any, replaces runes not
+// in a face.
+const Fallback = 0
+
 func (f *Face) Metrics() Metrics {
 	return Metrics{
 		Ascent: int8(f.data[offAscent]),
@@ -76,8 +81,21 @@ func (f *Face) Metrics() Metrics {
 	}
 }
 
+// Validate returns an error if txt contains a rune not in f.
+func (f *Face) Validate(txt string) error {
+	for _, r := range txt {
+		if r == '\n' {
+			continue
+		}
+		if _, _, ok := f.Decode(r); !ok || r == Fallback {
+			return fmt.Errorf("vector: unsupported rune: %q", r)
+		}
+	}
+	return nil
+}
+
 func (f *Face) Decode(ch rune) (int, Segments, bool) {
-	if int(ch) >= indexLen {
+	if ch < 0 || int(ch) >= indexLen {
 		return 0, Segments{}, false
 	}
 	index := f.data[offIndex:OffSegments]
@@ -0,0 +1,55 @@
+package gui
+
+import (
+	"image"
+	"image/color"
+)
+
+// largeNavNum and largeNavDen is the enlargement of navigation
+// buttons in large print mode.
+const largeNavNum, largeNavDen = 4, 3
+
+// scaledImage enlarges src by the factor num/den. It is a value
+// type so that operations on equal scaled images compare equal.
+type scaledImage struct {
+	src      image.Image
+	num, den int
+}
+
+func (s scaledImage) ColorModel() color.Model {
+	return s.src.ColorModel()
+}
+
+func (s scaledImage) Bounds() image.Rectangle {
+	b := s.src.Bounds()
+	return image.Rectangle{
+		Min: b.Min.Mul(s.num).Div(s.den),
+		Max: b.Max.Mul(s.num).Div(s.den),
+	}
+}
+
+func (s scaledImage) At(x, y int) color.Color {
+	return s.RGBA64At(x, y)
+}
+
+func (s scaledImage) RGBA64At(x, y int) color.RGBA64 {
+	x, y = x*s.den/s.num, y*s.den/s.num
+	if src, ok := s.src.(image.RGBA64Image); ok {
+		return src.RGBA64At(x, y)
+	}
+	r, g, b, a := s.src.At(x, y).RGBA()
+	return color.RGBA64{R: uint16(r), G: uint16(g), B: uint16(b), A: uint16(a)}
+}
+
+// navImage returns img sized for the navigation buttons in the
+// current accessibility mode.
+func navImage(ctx *Context, img image.Image) image.RGBA64Image {
+	if img, ok := img.(image.RGBA64Image); ok && ctx.Settings.Accessibility == AccessibilityOff {
+		return img
+	}
+	num, den := 1, 1
+	if ctx.Settings.Accessibility != AccessibilityOff {
+		num, den = largeNavNum, largeNavDen
+	}
+	return scaledImage{src: img, num: num, den: den}
+}
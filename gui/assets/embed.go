@@ -162,6 +162,22 @@ var (
 	//go:embed icon-left.bin
 	IconLeftData string
 
+	IconPause = &paletted.Image{
+		Pix:     unsafe.Slice(unsafe.StringData(IconPauseData[:195]), len(IconPauseData[:195])),
+		Rect:    paletted.Rectangle{MinX: 11, MinY: 10, MaxX: 24, MaxY: 25},
+		Palette: paletted.Palette(unsafe.Slice(unsafe.StringData(IconPauseData[195:]), len(IconPauseData[195:]))),
+	}
+	//go:embed icon-pause.bin
+	IconPauseData string
+
+	IconPlay = &paletted.Image{
+		Pix:     unsafe.Slice(unsafe.StringData(IconPlayData[:240]), len(IconPlayData[:240])),
+		Rect:    paletted.Rectangle{MinX: 12, MinY: 10, MaxX: 28, MaxY: 25},
+		Palette: paletted.Palette(unsafe.Slice(unsafe.StringData(IconPlayData[240:]), len(IconPlayData[240:]))),
+	}
+	//go:embed icon-play.bin
+	IconPlayData string
+
 	IconProgress = &paletted.Image{
 		Pix:     unsafe.Slice(unsafe.StringData(IconProgressData[:529]), len(IconProgressData[:529])),
 		Rect:    paletted.Rectangle{MinX: 6, MinY: 6, MaxX: 29, MaxY: 29},
@@ -257,20 +273,4 @@ var (
 	}
 	//go:embed sh03.bin
 	Sh03Data string
-
-	WarningBoxBg = ninepatch.New(&paletted.Image{
-		Pix:     unsafe.Slice(unsafe.StringData(WarningBoxBgData[:405]), len(WarningBoxBgData[:405])),
-		Rect:    paletted.Rectangle{MinX: 0, MinY: 0, MaxX: 27, MaxY: 15},
-		Palette: paletted.Palette(unsafe.Slice(unsafe.StringData(WarningBoxBgData[405:]), len(WarningBoxBgData[405:]))),
-	})
-	//go:embed warning-box-bg.bin
-	WarningBoxBgData string
-
-	WarningBoxBorder = ninepatch.New(&paletted.Image{
-		Pix:     unsafe.Slice(unsafe.StringData(WarningBoxBorderData[:405]), len(WarningBoxBorderData[:405])),
-		Rect:    paletted.Rectangle{MinX: 0, MinY: 0, MaxX: 27, MaxY: 15},
-		Palette: paletted.Palette(unsafe.Slice(unsafe.StringData(WarningBoxBorderData[405:]), len(WarningBoxBorderData[405:]))),
-	})
-	//go:embed warning-box-border.bin
-	WarningBoxBorderData string
 )
@@ -0,0 +1,238 @@
+package gui
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/kortschak/qr"
+	"seedhammer.com/backup"
+	"seedhammer.com/gui/assets"
+	"seedhammer.com/gui/layout"
+	"seedhammer.com/gui/op"
+	"seedhammer.com/gui/widget"
+)
+
+// AuditEntry records an engraving job in the audit log. Unlike
+// the backup registry, the log is not encrypted and entries
+// contain no secrets.
+type AuditEntry struct {
+	Time time.Time
+	// Wallet identifies the descriptor of the plate, or is zero
+	// for plates without a descriptor.
+	Wallet uint32
+	// MasterFingerprint identifies the seed of the plate.
+	MasterFingerprint uint32
+	KeyIdx            int
+	Keys              int
+	Size              backup.PlateSize
+	// Version is the controller version that engraved the plate.
+	Version string
+	// SideA is set for entries of side A of a two sided plate,
+	// logged before its side B is engraved.
+	SideA bool
+	// Hash chains the entry to the entries before it. It is the
+	// SHA-256 of the Hash of the previous entry followed by the
+	// line
+	//
+	//	<unix time> <wallet> <fingerprint> <key index> <keys> <size> <version>
+	//
+	// with the wallet and fingerprint in 8-digit hexadecimal and
+	// the version quoted. The line of a SideA entry ends with " A".
+	Hash []byte
+}
+
+// auditKeyInfo separates the audit signing key from other uses of
+// the device secret.
+const auditKeyInfo = "seedhammer audit log"
+
+// auditHash computes the hash of e chained to prev.
+func auditHash(prev []byte, e AuditEntry) []byte {
+	h := sha256.New()
+	h.Write(prev)
+	fmt.Fprintf(h, "%d %.8x %.8x %d %d %d %q", e.Time.Unix(), e.Wallet, e.MasterFingerprint, e.KeyIdx, e.Keys, e.Size, e.Version)
+	if e.SideA {
+		io.WriteString(h, " A")
+	}
+	io.WriteString(h, "\n")
+	return h.Sum(nil)
+}
+
+// appendAudit chains e to the log and appends it.
+func appendAudit(entries []AuditEntry, e AuditEntry) []AuditEntry {
+	var prev []byte
+	if len(entries) > 0 {
+		prev = entries[len(entries)-1].Hash
+	}
+	e.Hash = auditHash(prev, e)
+	return append(entries, e)
+}
+
+// verifyAudit checks the hash chain of the log.
+func verifyAudit(entries []AuditEntry) error {
+	var prev []byte
+	for i, e := range entries {
+		if !bytes.Equal(e.Hash, auditHash(prev, e)) {
+			return fmt.Errorf("audit: entry %d: hash mismatch", i+1)
+		}
+		prev = e.Hash
+	}
+	return nil
+}
+
+// auditKey derives the audit signing key from the device secret.
+func auditKey(secret []byte) *btcec.PrivateKey {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(auditKeyInfo))
+	key, _ := btcec.PrivKeyFromBytes(mac.Sum(nil))
+	return key
+}
+
+// signAudit signs the head of the log. The device signs the log only
+// when appending to it, so a log rewritten on the SD card can't be
+// exported.
+func signAudit(secret []byte, entries []AuditEntry) ([]byte, error) {
+	head := entries[len(entries)-1].Hash
+	sig, err := schnorr.Sign(auditKey(secret), head)
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	return sig.Serialize(), nil
+}
+
+// exportAudit verifies the signature of the head of the log made by
+// signAudit and encodes it as
+//
+//	SHAUDIT:<entries>:<head hash>:<public key>:<signature>
+//
+// in uppercase hexadecimal, suitable for a QR code. The public key
+// is the 32-byte BIP340 key of the device, and the signature is a
+// BIP340 signature of the head hash. The entries of the log are
+// verified against the export by recomputing the hash chain.
+func exportAudit(secret []byte, entries []AuditEntry, sig []byte) (string, error) {
+	if len(entries) == 0 {
+		return "", errors.New("audit: empty log")
+	}
+	if err := verifyAudit(entries); err != nil {
+		return "", err
+	}
+	head := entries[len(entries)-1].Hash
+	pub := auditKey(secret).PubKey()
+	s, err := schnorr.ParseSignature(sig)
+	if err != nil || !s.Verify(head, pub) {
+		return "", errors.New("audit: log not signed by this device")
+	}
+	return strings.ToUpper(fmt.Sprintf("SHAUDIT:%d:%x:%x:%x", len(entries), head, schnorr.SerializePubKey(pub), sig)), nil
+}
+
+// recordAudit appends an engraved plate to the audit log.
+func recordAudit(ctx *Context, b Backup) {
+	logAudit(ctx, AuditEntry{
+		Time:              ctx.Platform.Now(),
+		Wallet:            b.Wallet,
+		MasterFingerprint: b.MasterFingerprint,
+		KeyIdx:            b.KeyIdx,
+		Keys:              b.Keys,
+		Size:              b.Size,
+		Version:           ctx.Version,
+	})
+}
+
+// logAudit appends e to the audit log and signs the log.
+func logAudit(ctx *Context, e AuditEntry) {
+	settings := ctx.Settings
+	settings.AuditLog = appendAudit(settings.AuditLog, e)
+	settings.AuditSignature = nil
+	secret, err := ctx.Platform.DeviceSecret()
+	if err == nil {
+		settings.AuditSignature, err = signAudit(secret, settings.AuditLog)
+	}
+	if err != nil {
+		log.Printf("gui: audit log not signed: %v", err)
+	}
+	recordSettings(ctx, settings)
+}
+
+// AuditScreen shows the signed export of the audit log as a QR
+// code.
+type AuditScreen struct {
+	Entries int
+	code    image.Image
+}
+
+func auditFlow(ctx *Context, ops op.Ctx, th *Colors) {
+	scr := new(AuditScreen)
+	secret, err := ctx.Platform.DeviceSecret()
+	var export string
+	if err == nil {
+		export, err = exportAudit(secret, ctx.Settings.AuditLog, ctx.Settings.AuditSignature)
+	}
+	var code *qr.Code
+	if err == nil {
+		code, err = qr.Encode(export, qr.L)
+	}
+	if err != nil {
+		log.Printf("gui: %v", err)
+		errScr := &ErrorScreen{
+			Title: "Audit Log Unavailable",
+			Body:  fmt.Sprintf("The audit log can't be exported.\n\nError details: %v", err),
+		}
+		for {
+			dims := ctx.Platform.DisplaySize()
+			dismissed := errScr.Layout(ctx, ops.Begin(), th, dims)
+			d := ops.End()
+			if dismissed {
+				return
+			}
+			op.ColorOp(ops, th.Background)
+			d.Add(ops)
+			ctx.Frame()
+		}
+	}
+	dims := ctx.Platform.DisplaySize()
+	// Leave room for the title, lead and navigation buttons.
+	code.Scale = max(1, (dims.Y-2*leadingSize)/(code.Size+8))
+	scr.Entries = len(ctx.Settings.AuditLog)
+	scr.code = code.Image()
+	scr.Show(ctx, ops, th)
+}
+
+// Show runs the screen until the user exits.
+func (s *AuditScreen) Show(ctx *Context, ops op.Ctx, th *Colors) {
+	inp := new(InputTracker)
+	for {
+		for {
+			e, ok := inp.Next(ctx, Button1)
+			if !ok {
+				break
+			}
+			if e.Button == Button1 && inp.Clicked(e.Button) {
+				return
+			}
+		}
+		dims := ctx.Platform.DisplaySize()
+		op.ColorOp(ops, th.Background)
+		layoutTitle(ctx, ops, dims.X, th.Text, "Audit Log")
+		r := layout.Rectangle{Max: dims}
+		_, content := r.CutTop(leadingSize)
+		content, lead := content.CutBottom(leadingSize)
+		op.ImageOp(ops.Begin(), s.code, false)
+		op.Position(ops, ops.End(), content.Center(s.code.Bounds().Size()))
+		const margin = 8
+		leadsz := widget.Labelwf(ops.Begin(), ctx.Styles.lead, dims.X-2*margin, th.Text, "%d jobs, signed by this device.", s.Entries)
+		op.Position(ops, ops.End(), lead.Center(leadsz))
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
+			{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack},
+		}...)
+		ctx.Frame()
+	}
+}
@@ -0,0 +1,282 @@
+package gui
+
+import (
+	"fmt"
+	"image"
+	"log"
+
+	"seedhammer.com/backup"
+	"seedhammer.com/engrave"
+	"seedhammer.com/gui/assets"
+	"seedhammer.com/gui/layout"
+	"seedhammer.com/gui/op"
+	"seedhammer.com/gui/widget"
+)
+
+const (
+	// calibrationStep is the origin adjustment per button press,
+	// in millimeters.
+	calibrationStep = 0.1
+	// maxCalibration bounds the origin adjustment, in millimeters.
+	maxCalibration = 2
+)
+
+// CalibrateScreen adjusts the engraving origin.
+type CalibrateScreen struct {
+	// Origin is the origin being calibrated.
+	Origin image.Point
+
+	// jogY is set when the rotary encoder jogs the Y axis,
+	// which is the axis last moved by the joystick.
+	jogY    bool
+	engrave struct {
+		job      *engraveJob
+		progress float32
+	}
+}
+
+func calibrateFlow(ctx *Context, ops op.Ctx, th *Colors) {
+	s := &CalibrateScreen{Origin: ctx.Settings.Origin}
+	for {
+		if !s.Calibrate(ctx, ops, th) {
+			return
+		}
+		settings := ctx.Settings
+		settings.Origin = s.Origin
+		if err := storeSettings(ctx, ops, th, settings); err != nil {
+			continue
+		}
+		return
+	}
+}
+
+// Calibrate runs the calibration screen until the user either
+// saves or discards the calibration. It reports whether the
+// calibration should be saved.
+func (s *CalibrateScreen) Calibrate(ctx *Context, ops op.Ctx, th *Colors) bool {
+	defer s.cancel()
+	params := ctx.Platform.EngraverParams()
+	step := params.F(calibrationStep)
+	limit := params.F(maxCalibration)
+	inp := new(InputTracker)
+	for {
+		if j := s.engrave.job; j != nil {
+		loop:
+			for {
+				select {
+				case p := <-j.progress:
+					s.engrave.progress = p.Fraction()
+				case err := <-j.errs:
+					s.engrave.job = nil
+					if err != nil {
+						log.Printf("gui: test pattern failed: %v", err)
+						s.showError(ctx, ops, th, &ErrorScreen{
+							Title: "Engraving Failed",
+							Body:  fmt.Sprintf("The test pattern could not be engraved.\n\nError details: %v", err),
+						})
+					}
+					break loop
+				default:
+					break loop
+				}
+			}
+		}
+		for {
+			e, ok := inp.Next(ctx, Button1, Button2, Button3, Left, Right, Up, Down, CCW, CW)
+			if !ok {
+				break
+			}
+			if s.engrave.job != nil {
+				// Only cancelling is possible while the test pattern
+				// is being engraved.
+				if e.Button == Button1 && inp.Clicked(e.Button) {
+					s.cancel()
+				}
+				continue
+			}
+			switch e.Button {
+			case Button1:
+				if inp.Clicked(e.Button) {
+					return false
+				}
+			case Button2:
+				if inp.Clicked(e.Button) {
+					s.testPattern(ctx, ops, th)
+				}
+			case Button3:
+				if inp.Clicked(e.Button) {
+					return true
+				}
+			case Left, Right, Up, Down:
+				if !e.Pressed {
+					break
+				}
+				switch e.Button {
+				case Left:
+					s.Origin.X -= step
+				case Right:
+					s.Origin.X += step
+				case Up:
+					s.Origin.Y -= step
+				case Down:
+					s.Origin.Y += step
+				}
+				s.jogY = e.Button == Up || e.Button == Down
+				s.Origin.X = min(max(s.Origin.X, -limit), limit)
+				s.Origin.Y = min(max(s.Origin.Y, -limit), limit)
+			case CCW, CW:
+				if !e.Pressed {
+					break
+				}
+				// Jog the last moved axis one step per detent.
+				d := step
+				if e.Button == CCW {
+					d = -d
+				}
+				if s.jogY {
+					s.Origin.Y = min(max(s.Origin.Y+d, -limit), limit)
+				} else {
+					s.Origin.X = min(max(s.Origin.X+d, -limit), limit)
+				}
+			}
+		}
+		dims := ctx.Platform.DisplaySize()
+		s.draw(ctx, ops, th, dims)
+		s.drawNav(ctx, inp, ops, th, dims)
+		ctx.Frame()
+	}
+}
+
+func (s *CalibrateScreen) cancel() {
+	if j := s.engrave.job; j != nil {
+		close(j.cancel)
+		// Wait for the engraver to stop.
+		<-j.errs
+		s.engrave.job = nil
+	}
+}
+
+// testPattern starts engraving the calibration pattern after
+// confirmation from the user.
+func (s *CalibrateScreen) testPattern(ctx *Context, ops op.Ctx, th *Colors) {
+	confirm := &ConfirmWarningScreen{
+		Title: "Engrave Test?",
+		Body:  "Mount a blank plate in the engraver to engrave the test pattern.\n\nHold button to confirm.",
+		Icon:  assets.IconHammer,
+	}
+loop:
+	for {
+		dims := ctx.Platform.DisplaySize()
+		res := confirm.Layout(ctx, ops.Begin(), th, dims)
+		d := ops.End()
+		switch res {
+		case ConfirmNo:
+			return
+		case ConfirmYes:
+			break loop
+		}
+		s.draw(ctx, ops, th, dims)
+		d.Add(ops)
+		ctx.Frame()
+	}
+	dev, err := ctx.Platform.Engraver()
+	if err != nil {
+		log.Printf("gui: failed to connect to engraver: %v", err)
+		s.showError(ctx, ops, th, &ErrorScreen{
+			Title: "Connection Error",
+			Body:  fmt.Sprintf("Ensure the engraver is turned on and verify that it is connected to the middle port of this device.\n\nError details: %v", err),
+		})
+		return
+	}
+	// Calibrate with the smallest plate, to fit every engraver.
+	size := backup.SquarePlate
+	plan := engrave.Offset(s.Origin.X, s.Origin.Y, calibrationPlan(ctx.Platform.EngraverParams(), size))
+	s.engrave.progress = 0
+	s.engrave.job = startEngrave(ctx, dev, size, plan)
+}
+
+// calibrationPlan engraves crosses near the corners of a plate.
+// The crosses line up with the corners when the engraver is
+// calibrated.
+func calibrationPlan(params engrave.Params, size backup.PlateSize) engrave.Plan {
+	const inset = 5
+	dims := size.Dims()
+	corners := []image.Point{
+		{inset, inset},
+		{dims.X - inset, inset},
+		{dims.X - inset, dims.Y - inset},
+		{inset, dims.Y - inset},
+	}
+	arm := params.I(2)
+	return func(yield func(engrave.Command) bool) {
+		for _, c := range corners {
+			c = image.Pt(params.I(c.X), params.I(c.Y))
+			cont := yield(engrave.Move(c.Sub(image.Pt(arm, 0)))) &&
+				yield(engrave.Line(c.Add(image.Pt(arm, 0)))) &&
+				yield(engrave.Move(c.Sub(image.Pt(0, arm)))) &&
+				yield(engrave.Line(c.Add(image.Pt(0, arm))))
+			if !cont {
+				return
+			}
+		}
+	}
+}
+
+func (s *CalibrateScreen) showError(ctx *Context, ops op.Ctx, th *Colors, errScr *ErrorScreen) {
+	for {
+		dims := ctx.Platform.DisplaySize()
+		dismissed := errScr.Layout(ctx, ops.Begin(), th, dims)
+		d := ops.End()
+		if dismissed {
+			break
+		}
+		s.draw(ctx, ops, th, dims)
+		d.Add(ops)
+		ctx.Frame()
+	}
+}
+
+func (s *CalibrateScreen) draw(ctx *Context, ops op.Ctx, th *Colors, dims image.Point) {
+	op.ColorOp(ops, th.Background)
+	layoutTitle(ctx, ops, dims.X, th.Text, "Calibrate")
+
+	const margin = 8
+	r := layout.Rectangle{Max: dims}
+	_, content := r.CutTop(leadingSize)
+	content, lead := content.CutBottom(leadingSize)
+	content = content.Shrink(0, margin, 0, margin)
+	mm := float32(ctx.Platform.EngraverParams().Millimeter)
+	if mm == 0 {
+		mm = 1
+	}
+	var bodysz image.Point
+	if s.engrave.job != nil {
+		bodysz = widget.Labelwf(ops.Begin(), ctx.Styles.lead, content.Dx(), th.Text, "Engraving test pattern\n%d%%", int(s.engrave.progress*100))
+	} else {
+		x, y := float32(s.Origin.X)/mm, float32(s.Origin.Y)/mm
+		bodysz = widget.Labelwf(ops.Begin(), ctx.Styles.lead, content.Dx(), th.Text, "X offset: %s%.1f mm\nY offset: %s%.1f mm", plusSign(x), x, plusSign(y), y)
+	}
+	op.Position(ops, ops.End(), content.Center(bodysz))
+	leadsz := widget.Labelwf(ops.Begin(), ctx.Styles.lead, dims.X-2*margin, th.Text, "Align the test crosses with the plate corners.")
+	op.Position(ops, ops.End(), lead.Center(leadsz))
+}
+
+// plusSign returns the sign prefix of non-negative v.
+func plusSign(v float32) string {
+	if v >= 0 {
+		return "+"
+	}
+	return ""
+}
+
+func (s *CalibrateScreen) drawNav(ctx *Context, inp *InputTracker, ops op.Ctx, th *Colors, dims image.Point) {
+	if s.engrave.job != nil {
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{{Button: Button1, Style: StyleSecondary, Icon: assets.IconDiscard}}...)
+		return
+	}
+	layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
+		{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack},
+		{Button: Button2, Style: StyleSecondary, Icon: assets.IconHammer},
+		{Button: Button3, Style: StylePrimary, Icon: assets.IconCheckmark},
+	}...)
+}
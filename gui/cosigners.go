@@ -0,0 +1,85 @@
+package gui
+
+import (
+	"fmt"
+
+	"seedhammer.com/bc/urtypes"
+	"seedhammer.com/gui/assets"
+	"seedhammer.com/gui/op"
+)
+
+// cosignersFlow guides the user through engraving the plates of
+// the remaining cosigners of a multisig descriptor, after the
+// plate of key done is engraved.
+func cosignersFlow(ctx *Context, ops op.Ctx, th *Colors, desc urtypes.OutputDescriptor, done int) {
+	showErr := func(errScreen *ErrorScreen) {
+		for {
+			dims := ctx.Platform.DisplaySize()
+			dismissed := errScreen.Layout(ctx, ops.Begin(), th, dims)
+			d := ops.End()
+			if dismissed {
+				break
+			}
+			op.ColorOp(ops, th.Background)
+			d.Add(ops)
+			ctx.Frame()
+		}
+	}
+	engraved := make([]bool, len(desc.Keys))
+	engraved[done] = true
+	for keyIdx, k := range desc.Keys {
+		for !engraved[keyIdx] {
+			if !cosignerPrompt(ctx, ops, th, len(desc.Keys), keyIdx, k.MasterFingerprint) {
+				return
+			}
+			mnemonic, ok := newMnemonicFlow(ctx, ops, th)
+			if !ok {
+				continue
+			}
+			if isMnemonicComplete(mnemonic) && !mnemonic.Valid() && !repairFlow(ctx, ops, th, mnemonic, []uint32{k.MasterFingerprint}) {
+				continue
+			}
+			if idx, ok := descriptorKeyIdx(desc, mnemonic, ""); !ok || idx != keyIdx {
+				showErr(&ErrorScreen{
+					Title: "Wrong Seed",
+					Body:  fmt.Sprintf("The seed does not match cosigner %d of %d.", keyIdx+1, len(desc.Keys)),
+				})
+				continue
+			}
+			plate, err := engravePlate(ctx.Platform.PlateSizes(), ctx.Platform.EngraverParams(), ctx.Settings, desc, keyIdx, mnemonic)
+			if err != nil {
+				showErr(NewErrorScreen(err))
+				return
+			}
+			if !NewEngraveScreen(ctx, plate).Engrave(ctx, ops, &engraveTheme) {
+				continue
+			}
+			recordBackup(ctx, descriptorBackup(desc, keyIdx, plate))
+			engraved[keyIdx] = true
+		}
+	}
+}
+
+// cosignerPrompt asks the user to continue with the plate of
+// cosigner keyIdx. It reports whether the user confirmed.
+func cosignerPrompt(ctx *Context, ops op.Ctx, th *Colors, keys, keyIdx int, mfp uint32) bool {
+	confirm := &ConfirmWarningScreen{
+		Title: "Next Cosigner",
+		Body:  fmt.Sprintf("Engrave the plate of cosigner %d of %d, fingerprint %.8X.\n\nLong press to input its seed.", keyIdx+1, keys, mfp),
+		Icon:  assets.IconCheckmark,
+	}
+	for {
+		dims := ctx.Platform.DisplaySize()
+		res := confirm.Layout(ctx, ops.Begin(), th, dims)
+		d := ops.End()
+		switch res {
+		case ConfirmYes:
+			return true
+		case ConfirmNo:
+			return false
+		}
+		op.ColorOp(ops, th.Background)
+		d.Add(ops)
+		ctx.Frame()
+	}
+}
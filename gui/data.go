@@ -0,0 +1,78 @@
+package gui
+
+import (
+	"unicode/utf8"
+
+	"seedhammer.com/backup"
+	"seedhammer.com/engrave"
+	"seedhammer.com/font/constant"
+	"seedhammer.com/gui/op"
+)
+
+// maxDataCaption is the longest payload engraved as the caption
+// of a data plate.
+const maxDataCaption = 160
+
+// dataPlateFlow scans a QR code and engraves its content on a
+// data plate.
+func dataPlateFlow(ctx *Context, ops op.Ctx, th *Colors) {
+	for {
+		res, ok := (&ScanScreen{
+			Title: "Scan",
+			Lead:  "Data to engrave",
+			Raw:   true,
+		}).Scan(ctx, ops)
+		if !ok {
+			return
+		}
+		payload := res.([]byte)
+		plate, err := engraveData(ctx.Platform.PlateSizes(), ctx.Platform.EngraverParams(), ctx.Settings, payload)
+		if err != nil {
+			errScr := NewErrorScreen(err)
+			for {
+				dims := ctx.Platform.DisplaySize()
+				dismissed := errScr.Layout(ctx, ops.Begin(), th, dims)
+				d := ops.End()
+				if dismissed {
+					break
+				}
+				op.ColorOp(ops, th.Background)
+				d.Add(ops)
+				ctx.Frame()
+			}
+			continue
+		}
+		if NewEngraveScreen(ctx, plate).Engrave(ctx, ops, &engraveTheme) {
+			return
+		}
+	}
+}
+
+// engraveData engraves payload on the first plate size that fits
+// it. Short textual payloads are repeated as the caption.
+func engraveData(sizes []backup.PlateSize, params engrave.Params, settings Settings, payload []byte) (Plate, error) {
+	caption := ""
+	if utf8.Valid(payload) && utf8.RuneCount(payload) <= maxDataCaption {
+		caption = string(payload)
+	}
+	var lastErr error
+	for _, sz := range sizes {
+		side, err := backup.EngraveData(params, backup.Data{
+			Payload: payload,
+			Caption: caption,
+			Font:    constant.Font,
+			Size:    sz,
+			QRLevel: settings.QR.level(),
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return Plate{
+			Size:     sz,
+			Sides:    []engrave.Plan{side},
+			SeedSide: -1,
+		}, nil
+	}
+	return Plate{}, lastErr
+}
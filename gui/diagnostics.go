@@ -0,0 +1,273 @@
+package gui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"log"
+	"time"
+
+	"github.com/kortschak/qr"
+	"seedhammer.com/backup"
+	"seedhammer.com/engrave"
+	"seedhammer.com/gui/assets"
+	"seedhammer.com/gui/layout"
+	"seedhammer.com/gui/op"
+	"seedhammer.com/gui/widget"
+)
+
+// diagnostic is a self-test of a hardware component.
+type diagnostic int
+
+const (
+	diagCamera diagnostic = iota
+	diagDecoder
+	diagEngraver
+	numDiagnostics
+)
+
+func (d diagnostic) String() string {
+	switch d {
+	case diagCamera:
+		return "Camera"
+	case diagDecoder:
+		return "QR decoder"
+	case diagEngraver:
+		return "Engraver"
+	}
+	panic("invalid diagnostic")
+}
+
+type diagnosticState int
+
+const (
+	diagUntested diagnosticState = iota
+	diagRunning
+	diagPassed
+	diagFailed
+)
+
+func (s diagnosticState) String() string {
+	switch s {
+	case diagUntested:
+		return "-"
+	case diagRunning:
+		return "testing"
+	case diagPassed:
+		return "pass"
+	case diagFailed:
+		return "FAIL"
+	}
+	panic("invalid state")
+}
+
+// cameraTimeout bounds the wait for the first camera frame.
+const cameraTimeout = 5 * time.Second
+
+// diagnosticsQR is the content of the QR decoder test pattern.
+const diagnosticsQR = "SEEDHAMMER"
+
+// DiagnosticsScreen runs self-tests of the hardware components.
+type DiagnosticsScreen struct {
+	selected diagnostic
+	results  [numDiagnostics]struct {
+		state diagnosticState
+		err   error
+	}
+	camera struct {
+		deadline time.Time
+	}
+	engrave struct {
+		job *engraveJob
+	}
+}
+
+func diagnosticsFlow(ctx *Context, ops op.Ctx, th *Colors) {
+	new(DiagnosticsScreen).Diagnose(ctx, ops, th)
+}
+
+// Diagnose runs the screen until the user exits.
+func (s *DiagnosticsScreen) Diagnose(ctx *Context, ops op.Ctx, th *Colors) {
+	defer s.cancel()
+	inp := new(InputTracker)
+	for {
+		s.update(ctx)
+		for {
+			e, ok := inp.Next(ctx, Button1, Button3, Up, Down)
+			if !ok {
+				break
+			}
+			switch e.Button {
+			case Button1:
+				if inp.Clicked(e.Button) {
+					return
+				}
+			case Button3:
+				if inp.Clicked(e.Button) && s.results[s.selected].state != diagRunning {
+					s.start(ctx, ops, th, s.selected)
+				}
+			case Up:
+				if e.Pressed && s.selected > 0 {
+					s.selected--
+				}
+			case Down:
+				if e.Pressed && s.selected < numDiagnostics-1 {
+					s.selected++
+				}
+			}
+		}
+		dims := ctx.Platform.DisplaySize()
+		s.draw(ctx, ops, th, dims)
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
+			{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack},
+			{Button: Button3, Style: StylePrimary, Icon: assets.IconCheckmark},
+		}...)
+		ctx.Frame()
+	}
+}
+
+func (s *DiagnosticsScreen) report(d diagnostic, err error) {
+	r := &s.results[d]
+	r.err = err
+	r.state = diagPassed
+	if err != nil {
+		log.Printf("gui: diagnostic %v failed: %v", d, err)
+		r.state = diagFailed
+	}
+}
+
+// start begins a diagnostic. Diagnostics that complete immediately
+// are reported before start returns.
+func (s *DiagnosticsScreen) start(ctx *Context, ops op.Ctx, th *Colors, d diagnostic) {
+	switch d {
+	case diagCamera:
+		s.results[d].state = diagRunning
+		s.camera.deadline = ctx.Platform.Now().Add(cameraTimeout)
+	case diagDecoder:
+		s.report(d, testDecoder(ctx.Platform))
+	case diagEngraver:
+		confirm := &ConfirmWarningScreen{
+			Title: "Test Engraver?",
+			Body:  "The engraver will home and trace a pattern without hammering.\n\nHold button to confirm.",
+			Icon:  assets.IconHammer,
+		}
+		for {
+			dims := ctx.Platform.DisplaySize()
+			res := confirm.Layout(ctx, ops.Begin(), th, dims)
+			d := ops.End()
+			switch res {
+			case ConfirmNo:
+				return
+			case ConfirmYes:
+				s.startEngraver(ctx)
+				return
+			}
+			s.draw(ctx, ops, th, dims)
+			d.Add(ops)
+			ctx.Frame()
+		}
+	}
+}
+
+func (s *DiagnosticsScreen) startEngraver(ctx *Context) {
+	dev, err := ctx.Platform.Engraver()
+	if err != nil {
+		s.report(diagEngraver, err)
+		return
+	}
+	s.results[diagEngraver].state = diagRunning
+	plan := engrave.DryRun(calibrationPlan(ctx.Platform.EngraverParams(), backup.SquarePlate))
+	s.engrave.job = startEngrave(ctx, dev, backup.SquarePlate, plan)
+}
+
+// update polls the running diagnostics.
+func (s *DiagnosticsScreen) update(ctx *Context) {
+	if s.results[diagCamera].state == diagRunning {
+		ctx.Platform.CameraFrame(ctx.Platform.DisplaySize(), CameraControls{})
+		for {
+			f, ok := ctx.FrameEvent()
+			if !ok {
+				break
+			}
+			if f.Error != nil {
+				s.report(diagCamera, f.Error)
+			} else if f.Image != nil {
+				s.report(diagCamera, nil)
+			}
+		}
+		if s.results[diagCamera].state == diagRunning {
+			if ctx.Platform.Now().After(s.camera.deadline) {
+				s.report(diagCamera, errors.New("no camera frame received"))
+			} else {
+				ctx.WakeupAt(s.camera.deadline)
+			}
+		}
+	}
+	if j := s.engrave.job; j != nil {
+		select {
+		case err := <-j.errs:
+			s.engrave.job = nil
+			s.report(diagEngraver, err)
+		default:
+		}
+	}
+}
+
+func (s *DiagnosticsScreen) cancel() {
+	if j := s.engrave.job; j != nil {
+		close(j.cancel)
+		<-j.errs
+		s.engrave.job = nil
+	}
+}
+
+// testDecoder verifies that the platform decodes a generated QR
+// code.
+func testDecoder(p Platform) error {
+	code, err := qr.Encode(diagnosticsQR, qr.M)
+	if err != nil {
+		return err
+	}
+	src := code.Image()
+	img := image.NewGray(src.Bounds())
+	draw.Draw(img, img.Bounds(), src, src.Bounds().Min, draw.Src)
+	results, err := p.ScanQR(img)
+	if err != nil {
+		return err
+	}
+	for _, res := range results {
+		if bytes.Equal(res, []byte(diagnosticsQR)) {
+			return nil
+		}
+	}
+	return errors.New("test pattern not decoded")
+}
+
+func (s *DiagnosticsScreen) draw(ctx *Context, ops op.Ctx, th *Colors, dims image.Point) {
+	op.ColorOp(ops, th.Background)
+	layoutTitle(ctx, ops, dims.X, th.Text, "Diagnostics")
+
+	const margin = 8
+	r := layout.Rectangle{Max: dims}
+	_, content := r.CutTop(leadingSize)
+	content, lead := content.CutBottom(leadingSize)
+	content = content.Shrink(0, margin, 0, margin)
+	y := 0
+	for d := diagnostic(0); d < numDiagnostics; d++ {
+		style := ctx.Styles.subtitle
+		if d != s.selected {
+			style = ctx.Styles.body
+		}
+		sz := widget.Labelwf(ops.Begin(), style, content.Dx(), th.Text, "%s: %s", d.String(), s.results[d].state.String())
+		op.Position(ops, ops.End(), content.Min.Add(image.Pt(0, y)))
+		y += sz.Y + margin
+	}
+	leadTxt := "Select a component to test."
+	if err := s.results[s.selected].err; err != nil {
+		leadTxt = fmt.Sprintf("Error: %v", err)
+	}
+	leadsz := widget.Labelwf(ops.Begin(), ctx.Styles.lead, dims.X-2*margin, th.Text, leadTxt)
+	op.Position(ops, ops.End(), lead.Center(leadsz))
+}
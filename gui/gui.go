@@ -2,6 +2,8 @@
 package gui
 
 import (
+	"bytes"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"image"
@@ -9,8 +11,13 @@ import (
 	"image/draw"
 	"log"
 	"math"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
@@ -43,37 +50,89 @@ type Context struct {
 
 	// Global UI state.
 	Version        string
+	Settings       Settings
 	Calibrated     bool
 	EmptySDSlot    bool
+	EmergencyStop  bool
 	RotateCamera   bool
+	Camera         CameraControls
 	LastDescriptor *urtypes.OutputDescriptor
-
-	events []Event
+	// Unlocked is set when the device PIN has been entered.
+	Unlocked bool
+
+	events  []Event
+	secrets secrets
+	// pinRetry is the earliest time for the next PIN attempt.
+	pinRetry time.Time
+	// scanner is created by the first scan.
+	scanner *qrScanner
+	// addresses is created by the first address screen.
+	addresses *address.Cache
+	// engraver is the device of the latest engraver connection,
+	// if known.
+	engraver string
+	// unsaved is set when Settings contain records not yet
+	// stored.
+	unsaved bool
+}
+
+// sdCard tracks the SD card slot. Unsaved settings are stored
+// when the card they were loaded from, or a blank card, is
+// inserted. The settings of any other card are loaded instead.
+func (c *Context) sdCard(e SDCardEvent) {
+	c.EmptySDSlot = !e.Inserted
+	if !e.Inserted {
+		return
+	}
+	s, err := c.Platform.LoadSettings()
+	if err != nil {
+		log.Printf("gui: failed to load settings: %v", err)
+		return
+	}
+	if bytes.Equal(s.ID, c.Settings.ID) || reflect.ValueOf(s).IsZero() {
+		flushSettings(c)
+		return
+	}
+	if c.unsaved {
+		log.Printf("gui: settings not stored on a different SD card")
+		c.unsaved = false
+	}
+	c.loadSettings(s)
 }
 
 func NewContext(pl Platform) *Context {
 	c := &Context{
 		Platform: pl,
-		Styles:   NewStyles(),
 	}
+	s, err := pl.LoadSettings()
+	if err != nil {
+		log.Printf("gui: failed to load settings: %v", err)
+	}
+	c.loadSettings(s)
 	return c
 }
 
-func (c *Context) WakeupAt(t time.Time) {
-	if c.Wakeup.IsZero() || t.Before(c.Wakeup) {
-		c.Wakeup = t
+// loadSettings replaces the settings with s.
+func (c *Context) loadSettings(s Settings) {
+	if s.ID == nil {
+		s.ID = make([]byte, 16)
+		rand.Read(s.ID)
 	}
+	c.Settings = s
+	c.applyStyles()
 }
 
-const repeatStartDelay = 400 * time.Millisecond
-const repeatDelay = 100 * time.Millisecond
+// applyStyles updates the themes and text styles to the contrast
+// and accessibility settings.
+func (c *Context) applyStyles() {
+	applyTheme(c.Settings.Contrast)
+	c.Styles = NewStyles(c.Settings.Contrast, c.Settings.Accessibility)
+}
 
-func isRepeatButton(b Button) bool {
-	switch b {
-	case Up, Down, Right, Left:
-		return true
+func (c *Context) WakeupAt(t time.Time) {
+	if c.Wakeup.IsZero() || t.Before(c.Wakeup) {
+		c.Wakeup = t
 	}
-	return false
 }
 
 func (c *Context) Reset() {
@@ -111,52 +170,15 @@ func (c *Context) Next(btns ...Button) (ButtonEvent, bool) {
 	return ButtonEvent{}, false
 }
 
-type InputTracker struct {
-	Pressed [nbuttons]bool
-	clicked [nbuttons]bool
-	repeats [nbuttons]time.Time
-}
-
-func (t *InputTracker) Next(c *Context, btns ...Button) (ButtonEvent, bool) {
-	now := c.Platform.Now()
-	for _, b := range btns {
-		if !isRepeatButton(b) {
-			continue
-		}
-		if !t.Pressed[b] {
-			t.repeats[b] = time.Time{}
-			continue
-		}
-		wakeup := t.repeats[b]
-		if wakeup.IsZero() {
-			wakeup = now.Add(repeatStartDelay)
-		}
-		repeat := !now.Before(wakeup)
-		if repeat {
-			wakeup = now.Add(repeatDelay)
-		}
-		t.repeats[b] = wakeup
-		c.WakeupAt(wakeup)
-		if repeat {
-			return ButtonEvent{Button: b, Pressed: true}, true
+// NextPointer returns the next pointer event.
+func (c *Context) NextPointer() (PointerEvent, bool) {
+	for i, e := range c.events {
+		if e, ok := e.AsPointer(); ok {
+			c.events = append(c.events[:i], c.events[i+1:]...)
+			return e, true
 		}
 	}
-
-	e, ok := c.Next(btns...)
-	if !ok {
-		return ButtonEvent{}, false
-	}
-	if int(e.Button) < len(t.clicked) {
-		t.clicked[e.Button] = !e.Pressed && t.Pressed[e.Button]
-		t.Pressed[e.Button] = e.Pressed
-	}
-	return e, true
-}
-
-func (t *InputTracker) Clicked(b Button) bool {
-	c := t.clicked[b]
-	t.clicked[b] = false
-	return c
+	return PointerEvent{}, false
 }
 
 const longestWord = "TOMORROW"
@@ -165,6 +187,12 @@ type program int
 
 const (
 	backupWallet program = iota
+	deviceSettings
+	diagnostics
+	dataPlate
+	verifyBackup
+	recoverWallet
+	planFile
 )
 
 type richText struct {
@@ -196,41 +224,57 @@ func (r *richText) Add(ops op.Ctx, style text.Style, width int, col color.NRGBA,
 	r.Y = offy + m.Descent.Ceil()
 }
 
+// addressCacheSize is the number of descriptors whose addresses
+// are kept between address screens.
+const addressCacheSize = 4
+
+// maxAddresses is the number of addresses of every chain shown by
+// the address screen.
+const maxAddresses = 1000
+
 func ShowAddressesScreen(ctx *Context, ops op.Ctx, th *Colors, desc urtypes.OutputDescriptor) {
 	var s struct {
 		addresses [2][]string
-		page      int
-		scroll    int
-	}
-
-	counter := 0
-	for page := range len(s.addresses) {
-		for len(s.addresses[page]) < 20 {
-			var addr string
-			var err error
-			switch page {
-			case 0:
-				addr, err = address.Receive(desc, uint32(counter))
-			case 1:
-				addr, err = address.Change(desc, uint32(counter))
-			}
-			counter++
-			if err != nil {
-				// Very unlikely.
-				continue
-			}
-			const addrLen = 12
-			fmtAddr := fmt.Sprintf("%d: %s", len(s.addresses[page])+1, shortenAddress(addrLen, addr))
-			s.addresses[page] = append(s.addresses[page], fmtAddr)
+		// done is set for the chains that can't be extended.
+		done   [2]bool
+		page   int
+		scroll widget.ScrollArea
+	}
+	if ctx.addresses == nil {
+		ctx.addresses = address.NewCache(addressCacheSize)
+	}
+	// derive the next batch of addresses of the current page.
+	derive := func() {
+		page := s.page
+		addrs := s.addresses[page]
+		n := min(len(addrs)+address.GapLimit, maxAddresses)
+		var all []string
+		var err error
+		switch page {
+		case 0:
+			all, err = ctx.addresses.Receive(desc, n)
+		case 1:
+			all, err = ctx.addresses.Change(desc, n)
+		}
+		if err != nil {
+			// Very unlikely.
+			log.Printf("gui: address derivation: %v", err)
 		}
+		for i := len(addrs); i < len(all); i++ {
+			addrs = append(addrs, fmt.Sprintf("%d:\t%s", i+1, all[i]))
+		}
+		s.addresses[page] = addrs
+		s.done[page] = err != nil || len(addrs) == maxAddresses
 	}
 
 	const maxPage = len(s.addresses)
 	inp := new(InputTracker)
 	for {
-		scrollDelta := 0
+		// scrollDelta counts half pages, lineDelta counts lines
+		// scrolled by the rotary encoder.
+		scrollDelta, lineDelta := 0, 0
 		for {
-			e, ok := inp.Next(ctx, Button1, Left, Right, Up, Down)
+			e, ok := inp.Next(ctx, Button1, Left, Right, Up, Down, CCW, CW)
 			if !ok {
 				break
 			}
@@ -242,12 +286,12 @@ func ShowAddressesScreen(ctx *Context, ops op.Ctx, th *Colors, desc urtypes.Outp
 			case Left:
 				if e.Pressed {
 					s.page = (s.page - 1 + maxPage) % maxPage
-					s.scroll = 0
+					s.scroll.Reset()
 				}
 			case Right:
 				if e.Pressed {
 					s.page = (s.page + 1) % maxPage
-					s.scroll = 0
+					s.scroll.Reset()
 				}
 			case Up:
 				if e.Pressed {
@@ -257,8 +301,19 @@ func ShowAddressesScreen(ctx *Context, ops op.Ctx, th *Colors, desc urtypes.Outp
 				if e.Pressed {
 					scrollDelta++
 				}
+			case CCW:
+				if e.Pressed {
+					lineDelta--
+				}
+			case CW:
+				if e.Pressed {
+					lineDelta++
+				}
 			}
 		}
+		if len(s.addresses[s.page]) == 0 && !s.done[s.page] {
+			derive()
+		}
 		op.ColorOp(ops, th.Background)
 		dims := ctx.Platform.DisplaySize()
 
@@ -288,34 +343,42 @@ func ShowAddressesScreen(ctx *Context, ops op.Ctx, th *Colors, desc urtypes.Outp
 		op.Position(ops, left, content.W(leftsz))
 		op.Position(ops, right, content.E(rightsz))
 
+		// Align the addresses after their numbers, and shorten
+		// them to fit.
+		style := ctx.Styles.mono
+		style.TabStop = style.Measure(math.MaxInt, "%d: ", maxAddresses).X
+		style.MiddleEllipsis = true
 		var bodytxt richText
 		ops.Begin()
 		addrs := s.addresses[s.page]
 		for _, addr := range addrs {
 			ops := ops
-			bodytxt.Add(ops, ctx.Styles.body, inner.Dx(), th.Text, addr)
+			bodytxt.Add(ops, style, inner.Dx(), th.Text, addr)
 		}
 		addresses := ops.End()
 
-		s.scroll += scrollDelta * body.Dy() / 2
-		maxScroll := bodytxt.Y - inner.Dy()
-		s.scroll = min(max(0, s.scroll), maxScroll)
-		pos := inner.Min.Sub(image.Pt(0, s.scroll))
+		s.scroll.Scroll(scrollDelta*body.Dy()/2 + lineDelta*style.LineHeight())
+		scroll, moving := s.scroll.Update(bodytxt.Y, inner.Dy())
+		if moving {
+			ctx.Platform.Wakeup()
+		}
+		// Derive more addresses when scrolling within a screen of
+		// the end.
+		if !s.done[s.page] && scroll+2*inner.Dy() >= bodytxt.Y {
+			derive()
+			ctx.Platform.Wakeup()
+		}
+		pos := inner.Min.Sub(image.Pt(0, scroll))
 		op.Position(ops.Begin(), addresses, pos)
 		fadeClip(ops, ops.End(), image.Rectangle(body))
+		s.scroll.Layout(ops, th.Text, image.Rectangle(inner), bodytxt.Y)
+		widget.PageIndicator(ops, th.Text, image.Pt(dims.X/2, dims.Y-scrollFadeDist/2), maxPage, s.page)
 
-		layoutNavigation(inp, ops, th, dims, []NavButton{{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack}}...)
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack}}...)
 		ctx.Frame()
 	}
 }
 
-func shortenAddress(n int, addr string) string {
-	if len(addr) <= n {
-		return addr
-	}
-	return addr[:n/2] + "......" + addr[len(addr)-n/2:]
-}
-
 func descriptorKeyIdx(desc urtypes.OutputDescriptor, m bip39.Mnemonic, pass string) (int, bool) {
 	if len(desc.Keys) == 0 {
 		return 0, false
@@ -354,6 +417,8 @@ func deriveMasterKey(m bip39.Mnemonic, net *chaincfg.Params) (*hdkeychain.Extend
 type ScanScreen struct {
 	Title string
 	Lead  string
+	// Raw disables the decoding of scanned QR codes.
+	Raw bool
 }
 
 func (s *ScanScreen) Scan(ctx *Context, ops op.Ctx) (any, bool) {
@@ -361,15 +426,40 @@ func (s *ScanScreen) Scan(ctx *Context, ops op.Ctx) (any, bool) {
 		feed, feed2, gray *image.Gray
 		cameraErr         error
 		decoder           QRDecoder
+		sharpness         int
 	)
+	if ctx.scanner == nil {
+		ctx.scanner = newQRScanner(ctx.Platform)
+	}
+	scanner := ctx.scanner
+	defer scanner.Discard()
 	inp := new(InputTracker)
 	for {
 		const cameraFrameScale = 3
 		for {
-			e, ok := inp.Next(ctx, Button1, Button2)
+			e, ok := inp.Next(ctx, Button1, Button2, Up, Down, Left, Right)
 			if !ok {
 				break
 			}
+			cam := &ctx.Camera
+			switch e.Button {
+			case Up:
+				if e.Pressed {
+					cam.ZoomLevel = min(cam.ZoomLevel+1, maxZoomLevel)
+				}
+			case Down:
+				if e.Pressed {
+					cam.ZoomLevel = max(cam.ZoomLevel-1, 0)
+				}
+			case Right:
+				if e.Pressed {
+					cam.Exposure = min(cam.Exposure+1, maxExposure)
+				}
+			case Left:
+				if e.Pressed {
+					cam.Exposure = max(cam.Exposure-1, -maxExposure)
+				}
+			}
 			if !inp.Clicked(e.Button) {
 				continue
 			}
@@ -388,29 +478,42 @@ func (s *ScanScreen) Scan(ctx *Context, ops op.Ctx) (any, bool) {
 			feed2 = &copy
 			gray = new(image.Gray)
 		}
-		ctx.Platform.CameraFrame(dims.Mul(cameraFrameScale))
+		ctx.Platform.CameraFrame(dims.Mul(cameraFrameScale), ctx.Camera)
+		// Skip to the most recent frame.
+		var latest *image.YCbCr
 		for {
 			f, ok := ctx.FrameEvent()
 			if !ok {
 				break
 			}
 			cameraErr = f.Error
+			latest = nil
 			if cameraErr == nil {
-				ycbcr := f.Image.(*image.YCbCr)
-				*gray = image.Gray{Pix: ycbcr.Y, Stride: ycbcr.YStride, Rect: ycbcr.Bounds()}
-
-				// Swap image (but not backing store) to ensure the graphics backend treats
-				// it as dirty.
-				feed, feed2 = feed2, feed
-				scaleRot(feed, gray, ctx.RotateCamera)
-				results, _ := ctx.Platform.ScanQR(gray)
-				for _, res := range results {
-					if v, ok := decoder.parseQR(res); ok {
-						return v, true
-					}
+				latest = f.Image.(*image.YCbCr)
+			}
+		}
+		if latest != nil {
+			*gray = image.Gray{Pix: latest.Y, Stride: latest.YStride, Rect: latest.Bounds()}
+
+			// Swap image (but not backing store) to ensure the graphics backend treats
+			// it as dirty.
+			feed, feed2 = feed2, feed
+			scaleRot(feed, gray, ctx.RotateCamera)
+			sharpness = frameSharpness(feed)
+		}
+		if results, ok := scanner.Results(); ok {
+			for _, res := range results {
+				if s.Raw {
+					return res, true
+				}
+				if v, ok := decoder.parseQR(res); ok {
+					return v, true
 				}
 			}
 		}
+		if latest != nil {
+			scanner.Submit(gray)
+		}
 		th := &cameraTheme
 		r := layout.Rectangle{Max: dims}
 
@@ -453,8 +556,22 @@ func (s *ScanScreen) Scan(ctx *Context, ops op.Ctx) (any, bool) {
 			background(ops, ops.End(), image.Rectangle{Min: pos, Max: pos.Add(sz)}, pos)
 		}
 
+		// Camera feedback, to explain a stalled scan.
+		if cameraErr == nil && feed != nil {
+			cam := ctx.Camera
+			ev := float32(cam.Exposure) / 2
+			// The text formatter doesn't support the + flag.
+			sign := ""
+			if ev >= 0 {
+				sign = "+"
+			}
+			sz = widget.Labelwf(ops.Begin(), ctx.Styles.debug, width, th.Text, "FOCUS %d%%  ZOOM %dX  EV %s%.1f", sharpness, 1<<cam.ZoomLevel, sign, ev)
+			pos := image.Pt((dims.X-sz.X)/2, title.Max.Y+8)
+			background(ops, ops.End(), image.Rectangle{Min: pos, Max: pos.Add(sz)}, pos)
+		}
+
 		nav := func(btn Button, icn image.RGBA64Image) {
-			nav := layoutNavigation(inp, ops.Begin(), th, dims, []NavButton{{Button: btn, Style: StyleSecondary, Icon: icn}}...)
+			nav := layoutNavigation(ctx, inp, ops.Begin(), th, dims, []NavButton{{Button: btn, Style: StyleSecondary, Icon: icn}}...)
 			nav = image.Rectangle(layout.Rectangle(nav).Shrink(underlay.Padding()).Shrink(-2, -4, -2, -2))
 			background(ops, ops.End(), nav, image.Point{})
 		}
@@ -464,29 +581,72 @@ func (s *ScanScreen) Scan(ctx *Context, ops op.Ctx) (any, bool) {
 	}
 }
 
+// frameSharpness estimates the focus of a camera frame as the mean
+// horizontal gradient of its center, in percent of a sharp QR code.
+func frameSharpness(img *image.Gray) int {
+	b := img.Bounds()
+	c := image.Rectangle{
+		Min: b.Min.Add(b.Size().Div(4)),
+		Max: b.Max.Sub(b.Size().Div(4)),
+	}
+	if c.Dx() < 2 {
+		return 0
+	}
+	sum := 0
+	for y := c.Min.Y; y < c.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(c.Min.X, y):img.PixOffset(c.Max.X, y)]
+		for x := 1; x < len(row); x++ {
+			d := int(row[x]) - int(row[x-1])
+			if d < 0 {
+				d = -d
+			}
+			sum += d
+		}
+	}
+	mean := sum / (c.Dy() * (c.Dx() - 1))
+	// A sharp code alternates between black and white every few
+	// pixels.
+	const sharp = 32
+	return min(100, mean*100/sharp)
+}
+
 // scaleRot is a specialized function for fast scaling and rotation of
-// the camera frames for display.
+// the camera frames for display. Every destination pixel is the average
+// of the source pixels it covers, so fine details such as QR code modules
+// blend instead of disappearing. The scale need not be an integer.
 func scaleRot(dst, src *image.Gray, rot180 bool) {
 	db := dst.Bounds()
 	sb := src.Bounds()
-	if db.Empty() {
+	if db.Empty() || sb.Empty() {
 		return
 	}
-	scale := sb.Dx() / db.Dx()
-	for y := 0; y < db.Dy(); y++ {
-		sx := sb.Max.X - 1 - y*scale
-		dy := db.Max.Y - y
+	w, h := db.Dx(), db.Dy()
+	for y := 0; y < h; y++ {
+		// Destination rows cover source columns from the right.
+		sx0 := sb.Max.X - (y+1)*sb.Dx()/h
+		sx1 := sb.Max.X - y*sb.Dx()/h
+		sx0 = min(sx0, sx1-1)
+		dy := db.Max.Y - 1 - y
 		if rot180 {
 			dy = y + db.Min.Y
 		}
-		for x := 0; x < db.Dx(); x++ {
-			sy := x*scale + sb.Min.Y
-			c := src.GrayAt(sx, sy)
+		for x := 0; x < w; x++ {
+			// Destination columns cover source rows from the top.
+			sy0 := sb.Min.Y + x*sb.Dy()/w
+			sy1 := sb.Min.Y + (x+1)*sb.Dy()/w
+			sy1 = max(sy1, sy0+1)
+			sum := 0
+			for sy := sy0; sy < sy1; sy++ {
+				off := src.PixOffset(sx0, sy)
+				for _, c := range src.Pix[off : off+sx1-sx0] {
+					sum += int(c)
+				}
+			}
 			dx := db.Max.X - 1 - x
 			if rot180 {
 				dx = x + db.Min.X
 			}
-			dst.SetGray(dx, dy, c)
+			dst.Pix[dst.PixOffset(dx, dy)] = uint8(sum / ((sx1 - sx0) * (sy1 - sy0)))
 		}
 	}
 }
@@ -565,22 +725,64 @@ func (s *ErrorScreen) Layout(ctx *Context, ops op.Ctx, th *Colors, dims image.Po
 		}
 	}
 	s.w.Layout(ctx, ops, th, dims, s.Title, s.Body)
-	layoutNavigation(&s.inp, ops, th, dims, []NavButton{{Button: Button3, Style: StylePrimary, Icon: assets.IconCheckmark}}...)
+	layoutNavigation(ctx, &s.inp, ops, th, dims, []NavButton{{Button: Button3, Style: StylePrimary, Icon: assets.IconCheckmark}}...)
 	return false
 }
 
+// emergencyStopFlow blocks until the emergency stop switch is released
+// and the user has acknowledged the stop.
+func emergencyStopFlow(ctx *Context, ops op.Ctx) {
+	th := &engraveTheme
+	inp := new(InputTracker)
+	for {
+		for {
+			e, ok := inp.Next(ctx, Button3)
+			if !ok {
+				break
+			}
+			if e.Gesture == LongPress && !ctx.EmergencyStop {
+				return
+			}
+		}
+		var progress float32
+		if !ctx.EmergencyStop {
+			progress = inp.Progress(ctx, Button3)
+		}
+
+		dims := ctx.Platform.DisplaySize()
+		op.ColorOp(ops, th.Background)
+		layoutTitle(ctx, ops, dims.X, th.Text, "Emergency Stop")
+
+		r := layout.Rectangle{Max: dims}
+		btnw := assets.NavBtnPrimary.Bounds().Dx()
+		body := r.Shrink(leadingSize, btnw, 0, btnw)
+		txt := "The engraver was halted.\n\nRelease the emergency stop switch to continue."
+		if !ctx.EmergencyStop {
+			txt = "The engraver was halted.\n\nHold button to continue."
+		}
+		sz := widget.Labelwf(ops.Begin(), ctx.Styles.lead, body.Dx(), th.Text, txt)
+		op.Position(ops, ops.End(), body.Center(sz))
+
+		if !ctx.EmergencyStop {
+			layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
+				{Button: Button3, Style: StylePrimary, Icon: assets.IconCheckmark, Progress: progress},
+			}...)
+		}
+		ctx.Frame()
+	}
+}
+
 type ConfirmWarningScreen struct {
 	Title string
 	Body  string
 	Icon  image.RGBA64Image
 
 	warning Warning
-	confirm ConfirmDelay
 	inp     InputTracker
 }
 
 type Warning struct {
-	scroll  int
+	scroll  widget.ScrollArea
 	txtclip int
 	inp     InputTracker
 }
@@ -593,27 +795,7 @@ const (
 	ConfirmYes
 )
 
-type ConfirmDelay struct {
-	timeout time.Time
-}
-
-func (c *ConfirmDelay) Start(ctx *Context, delay time.Duration) {
-	c.timeout = ctx.Platform.Now().Add(delay)
-}
-
-func (c *ConfirmDelay) Progress(ctx *Context) float32 {
-	if c.timeout.IsZero() {
-		return 0.
-	}
-	now := ctx.Platform.Now()
-	d := c.timeout.Sub(now)
-	if d <= 0 {
-		return 1.
-	}
-	ctx.Platform.Wakeup()
-	return 1. - float32(d.Seconds()/confirmDelay.Seconds())
-}
-
+// confirmDelay is the duration of a long press.
 const confirmDelay = 1 * time.Second
 
 func (w *Warning) Layout(ctx *Context, ops op.Ctx, th *Colors, dims image.Point, title, txt string) image.Point {
@@ -625,11 +807,11 @@ func (w *Warning) Layout(ctx *Context, ops op.Ctx, th *Colors, dims image.Point,
 		switch e.Button {
 		case Up:
 			if e.Pressed {
-				w.scroll -= w.txtclip / 2
+				w.scroll.Scroll(-w.txtclip / 2)
 			}
 		case Down:
 			if e.Pressed {
-				w.scroll += w.txtclip / 2
+				w.scroll.Scroll(w.txtclip / 2)
 			}
 		}
 	}
@@ -638,16 +820,15 @@ func (w *Warning) Layout(ctx *Context, ops op.Ctx, th *Colors, dims image.Point,
 
 	op.ColorOp(ops, color.NRGBA{A: theme.overlayMask})
 
-	wbbg := assets.WarningBoxBg
-	wbout := assets.WarningBoxBorder
-	ptop, pend, pbottom, pstart := wbbg.Padding()
-	r := image.Rectangle{
-		Min: image.Pt(pstart+boxMargin, ptop+boxMargin),
-		Max: image.Pt(dims.X-pend-boxMargin, dims.Y-pbottom-boxMargin),
-	}
-	box := wbbg.Add(ops, r, true)
+	// boxRadius is the corner radius of the box, and padX, padY
+	// the padding of its content.
+	const boxRadius = 6
+	const padX, padY = 13, 2
+	box := image.Rect(boxMargin, boxMargin, dims.X-boxMargin, dims.Y-boxMargin)
+	r := image.Rect(box.Min.X+padX, box.Min.Y+padY, box.Max.X-padX, box.Max.Y-padY)
+	op.RoundRectOp(ops, box, boxRadius)
 	op.ColorOp(ops, th.Background)
-	wbout.Add(ops, r, true)
+	op.StrokeOp(ops, box, boxRadius, 1)
 	op.ColorOp(ops, th.Text)
 
 	btnOff := assets.NavBtnPrimary.Bounds().Dx() + btnMargin
@@ -656,33 +837,28 @@ func (w *Warning) Layout(ctx *Context, ops op.Ctx, th *Colors, dims image.Point,
 	op.Position(ops, titlew, image.Pt((dims.X-titlesz.X)/2, r.Min.Y))
 
 	bodyClip := image.Rectangle{
-		Min: image.Pt(pstart+boxMargin, ptop+titlesz.Y),
-		Max: image.Pt(dims.X-btnOff, dims.Y-pbottom-boxMargin),
+		Min: image.Pt(r.Min.X, padY+titlesz.Y),
+		Max: image.Pt(dims.X-btnOff, r.Max.Y),
 	}
 	bodysz := widget.Labelwf(ops.Begin(), ctx.Styles.body, bodyClip.Dx(), th.Text, txt)
 	body := ops.End()
 	innerCtx := ops.Begin()
 	w.txtclip = bodyClip.Dy()
-	maxScroll := bodysz.Y - (bodyClip.Dy() - 2*scrollFadeDist)
-	if w.scroll > maxScroll {
-		w.scroll = maxScroll
+	viewport := bodyClip.Dy() - 2*scrollFadeDist
+	scroll, moving := w.scroll.Update(bodysz.Y, viewport)
+	if moving {
+		ctx.Platform.Wakeup()
 	}
-	if w.scroll < 0 {
-		w.scroll = 0
-	}
-	op.Position(innerCtx, body, image.Pt(bodyClip.Min.X, bodyClip.Min.Y+scrollFadeDist-w.scroll))
+	op.Position(innerCtx, body, image.Pt(bodyClip.Min.X, bodyClip.Min.Y+scrollFadeDist-scroll))
 	fadeClip(ops, ops.End(), image.Rectangle(bodyClip))
+	view := image.Rect(bodyClip.Min.X, bodyClip.Min.Y+scrollFadeDist, bodyClip.Max.X+btnMargin, bodyClip.Max.Y-scrollFadeDist)
+	w.scroll.Layout(ops, th.Text, view, bodysz.Y)
 
-	return box.Bounds().Size()
+	return box.Size()
 }
 
 func (s *ConfirmWarningScreen) Layout(ctx *Context, ops op.Ctx, th *Colors, dims image.Point) ConfirmResult {
-	var progress float32
 	for {
-		progress = s.confirm.Progress(ctx)
-		if progress == 1 {
-			return ConfirmYes
-		}
 		e, ok := s.inp.Next(ctx, Button3, Button1)
 		if !ok {
 			break
@@ -693,15 +869,14 @@ func (s *ConfirmWarningScreen) Layout(ctx *Context, ops op.Ctx, th *Colors, dims
 				return ConfirmNo
 			}
 		case Button3:
-			if e.Pressed {
-				s.confirm.Start(ctx, confirmDelay)
-			} else {
-				s.confirm = ConfirmDelay{}
+			if e.Gesture == LongPress {
+				return ConfirmYes
 			}
 		}
 	}
+	progress := s.inp.Progress(ctx, Button3)
 	s.warning.Layout(ctx, ops, th, dims, s.Title, s.Body)
-	layoutNavigation(&s.inp, ops, th, dims, []NavButton{
+	layoutNavigation(ctx, &s.inp, ops, th, dims, []NavButton{
 		{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack},
 		{Button: Button3, Style: StylePrimary, Icon: s.Icon, Progress: progress},
 	}...)
@@ -755,7 +930,12 @@ func NewErrorScreen(err error) *ErrorScreen {
 	case errors.Is(err, backup.ErrDescriptorTooLarge):
 		return &ErrorScreen{
 			Title: "Too Large",
-			Body:  "The descriptor cannot fit any plate size.",
+			Body:  fmt.Sprintf("The descriptor cannot fit any plate size, even when split across %d plates.", backup.MaxParts),
+		}
+	case errors.Is(err, backup.ErrDataTooLarge), errors.Is(err, engrave.ErrQRTooLarge):
+		return &ErrorScreen{
+			Title: "Too Large",
+			Body:  "The data cannot fit any plate size.",
 		}
 	default:
 		return &ErrorScreen{
@@ -765,7 +945,7 @@ func NewErrorScreen(err error) *ErrorScreen {
 	}
 }
 
-func validateDescriptor(params engrave.Params, desc urtypes.OutputDescriptor) error {
+func validateDescriptor(params engrave.Params, settings Settings, desc urtypes.OutputDescriptor) error {
 	keys := make(map[string]bool)
 	for _, k := range desc.Keys {
 		xpub := k.String()
@@ -776,33 +956,31 @@ func validateDescriptor(params engrave.Params, desc urtypes.OutputDescriptor) er
 		}
 		keys[xpub] = true
 	}
-	// Do a dummy engrave to see whether the backup fits any plate.
+	// Do a dummy engrave to see whether the backup fits any plate,
+	// if need be split across several.
 	descPlate := backup.Descriptor{
 		Descriptor: desc,
 		KeyIdx:     0,
-		Font:       constant.Font,
+		Font:       settings.Font.face(),
 		Size:       backup.LargePlate,
+		QRLevel:    settings.QR.level(),
+		Addresses:  settings.Addresses == AddressesOn,
 	}
-	_, err := backup.EngraveDescriptor(params, descPlate)
-	if err != nil {
-		return err
-	}
-	// Verify that every permutation of desc.Threshold shares can recover the
-	// descriptor. Note that this is impossible by construction and by exhaustive
-	// tests, but it's good to be paranoid.
-	if !backup.Recoverable(desc) {
-		return errors.New("Descriptor is not recoverable. This is a bug in the program; please report it.")
-	}
-	return nil
+	_, err := backup.EngraveDescriptorParts(params, descPlate)
+	return err
 }
 
 type Plate struct {
 	Size              backup.PlateSize
 	MasterFingerprint uint32
 	Sides             []engrave.Plan
+	// SeedSide is the index of the side holding the seed, or -1
+	// for plates without a seed. The seed side is engraved in
+	// constant time and its strokes are never reordered.
+	SeedSide int
 }
 
-func engraveSeed(sizes []backup.PlateSize, params engrave.Params, m bip39.Mnemonic) (Plate, error) {
+func engraveSeed(sizes []backup.PlateSize, params engrave.Params, settings Settings, m bip39.Mnemonic) (Plate, error) {
 	mfp, err := masterFingerprintFor(m, &chaincfg.MainNetParams)
 	if err != nil {
 		return Plate{}, err
@@ -816,6 +994,8 @@ func engraveSeed(sizes []backup.PlateSize, params engrave.Params, m bip39.Mnemon
 			MasterFingerprint: mfp,
 			Font:              constant.Font,
 			Size:              sz,
+			QRLevel:           settings.QR.level(),
+			Template:          settings.Template.template(),
 		}
 		seedSide, err := backup.EngraveSeed(params, seedDesc)
 		if err != nil {
@@ -826,6 +1006,7 @@ func engraveSeed(sizes []backup.PlateSize, params engrave.Params, m bip39.Mnemon
 			Sides:             []engrave.Plan{seedSide},
 			Size:              sz,
 			MasterFingerprint: mfp,
+			SeedSide:          0,
 		}, nil
 	}
 	return Plate{}, lastErr
@@ -843,43 +1024,81 @@ func masterFingerprintFor(m bip39.Mnemonic, network *chaincfg.Params) (uint32, e
 	return mfp, nil
 }
 
-func engravePlate(sizes []backup.PlateSize, params engrave.Params, desc urtypes.OutputDescriptor, keyIdx int, m bip39.Mnemonic) (Plate, error) {
-	mfp, err := masterFingerprintFor(m, desc.Keys[keyIdx].Network)
-	if err != nil {
-		return Plate{}, err
-	}
-	var lastErr error
-	for _, sz := range sizes {
-		descPlate := backup.Descriptor{
-			Descriptor: desc,
-			KeyIdx:     keyIdx,
-			Font:       constant.Font,
-			Size:       sz,
-		}
-		descSide, err := backup.EngraveDescriptor(params, descPlate)
+// engravePlate lays out the plate of key keyIdx of desc. If m is
+// nil, the plate holds the descriptor only.
+func engravePlate(sizes []backup.PlateSize, params engrave.Params, settings Settings, desc urtypes.OutputDescriptor, keyIdx int, m bip39.Mnemonic) (Plate, error) {
+	mfp := desc.Keys[keyIdx].MasterFingerprint
+	if m != nil {
+		var err error
+		mfp, err = masterFingerprintFor(m, desc.Keys[keyIdx].Network)
 		if err != nil {
-			lastErr = err
-			continue
+			return Plate{}, err
 		}
-		seedDesc := backup.Seed{
-			Title:             desc.Title,
-			KeyIdx:            keyIdx,
-			Mnemonic:          m,
-			Keys:              len(desc.Keys),
-			MasterFingerprint: mfp,
-			Font:              constant.Font,
-			Size:              sz,
-		}
-		seedSide, err := backup.EngraveSeed(params, seedDesc)
-		if err != nil {
-			lastErr = err
-			continue
+	}
+	var lastErr error
+	// Split the descriptor only if it doesn't fit any plate whole.
+	for _, split := range []bool{false, true} {
+		for _, sz := range sizes {
+			descPlate := backup.Descriptor{
+				Descriptor: desc,
+				KeyIdx:     keyIdx,
+				Font:       settings.Font.face(),
+				Size:       sz,
+				QRLevel:    settings.QR.level(),
+				Addresses:  settings.Addresses == AddressesOn,
+			}
+			var descSides []engrave.Plan
+			var err error
+			if split {
+				descSides, err = backup.EngraveDescriptorParts(params, descPlate)
+			} else {
+				var side engrave.Plan
+				side, err = backup.EngraveDescriptor(params, descPlate)
+				descSides = []engrave.Plan{side}
+			}
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			if m == nil {
+				return Plate{
+					Size:              sz,
+					MasterFingerprint: mfp,
+					Sides:             descSides,
+					SeedSide:          -1,
+				}, nil
+			}
+			seedDesc := backup.Seed{
+				Title:             desc.Title,
+				KeyIdx:            keyIdx,
+				Mnemonic:          m,
+				Keys:              len(desc.Keys),
+				MasterFingerprint: mfp,
+				Label:             desc.Keys[keyIdx].Label,
+				Font:              constant.Font,
+				Size:              sz,
+				QRLevel:           settings.QR.level(),
+				FlipVertical:      settings.Flip == FlipVertical,
+				Template:          settings.Template.template(),
+			}
+			seedSide, err := backup.EngraveSeed(params, seedDesc)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			// The first plate holds the seed on its back side, the
+			// remaining plates the other parts of the descriptor.
+			sides := append([]engrave.Plan{descSides[0], seedSide}, descSides[1:]...)
+			return Plate{
+				Size:              sz,
+				MasterFingerprint: mfp,
+				Sides:             sides,
+				SeedSide:          1,
+			}, nil
+		}
+		if !errors.Is(lastErr, backup.ErrDescriptorTooLarge) {
+			break
 		}
-		return Plate{
-			Size:              sz,
-			MasterFingerprint: mfp,
-			Sides:             []engrave.Plan{descSide, seedSide},
-		}, nil
 	}
 	return Plate{}, lastErr
 }
@@ -922,6 +1141,9 @@ type Instruction struct {
 	Image image.RGBA64Image
 
 	resolvedBody string
+	// estimate is the estimated duration of the engraving
+	// started by a ConnectInstruction.
+	estimate time.Duration
 }
 
 var (
@@ -999,7 +1221,7 @@ var (
 
 	EngraveSideB = []Instruction{
 		{
-			Body: "Unscrew the 4 nuts and flip the top metal plate horizontally.",
+			Body: "Unscrew the 4 nuts and flip the top metal plate {{.Flip}}.",
 		},
 		{
 			Body: "Tighten the nuts firmly.",
@@ -1022,6 +1244,29 @@ var (
 	}
 )
 
+// engravePartInstructions returns the instructions for engraving side
+// of a plate, the descriptor part of a separate plate.
+func engravePartInstructions(side, parts int) []Instruction {
+	return []Instruction{
+		{
+			Body:  fmt.Sprintf("Remove the plate and place a new {{.Name}} on the machine for part %d of %d of the descriptor.", side, parts),
+			Image: assets.Sh02,
+		},
+		{
+			Body: "Tighten the nuts firmly.",
+		},
+		{
+			Body: "Hold button to start the engraving process. The process is loud, use hearing protection.",
+			Type: ConnectInstruction,
+		},
+		{
+			Lead: "Engraving plate",
+			Type: EngraveInstruction,
+			Side: side,
+		},
+	}
+}
+
 func isEmptyMnemonic(m bip39.Mnemonic) bool {
 	for _, w := range m {
 		if w != -1 {
@@ -1057,9 +1302,71 @@ var scrollMask = op.RegisterParameterizedImage(func(args op.ImageArguments, x, y
 	return color.RGBA64{A: a16}
 })
 
-func inputWordsFlow(ctx *Context, ops op.Ctx, th *Colors, mnemonic bip39.Mnemonic, selected int) {
-	kbd := NewKeyboard(ctx)
+// maxFinalWords is the longest pick list of final words offered
+// while entering the last word of a mnemonic.
+const maxFinalWords = 8
+
+// finalWords returns the checksum-valid final words of mnemonic,
+// or nil if other words than the last are missing.
+func finalWords(mnemonic bip39.Mnemonic) []bip39.Word {
+	if len(mnemonic) == 0 {
+		return nil
+	}
+	for _, w := range mnemonic[:len(mnemonic)-1] {
+		if w == -1 {
+			return nil
+		}
+	}
+	return mnemonic.FinalWords()
+}
+
+// matchFinalWords returns the words of finals that match the
+// partial input of kbd.
+func matchFinalWords(kbd *Keyboard, finals []bip39.Word) []bip39.Word {
+	var matches []bip39.Word
+	for _, w := range finals {
+		var match bool
+		if kbd.mode == KeyboardNumbers {
+			match = strings.HasPrefix(strconv.Itoa(int(w)+1), kbd.Word)
+		} else {
+			match = strings.HasPrefix(bip39.LabelFor(w), strings.ToLower(kbd.Word))
+		}
+		if match {
+			matches = append(matches, w)
+		}
+	}
+	return matches
+}
+
+// chooseFinalWord lets the user pick one of words for the last
+// word of a mnemonic.
+func chooseFinalWord(ctx *Context, ops op.Ctx, th *Colors, mode KeyboardMode, words []bip39.Word) (bip39.Word, bool) {
+	cs := &ChoiceScreen{
+		Title: "Final Word",
+		Lead:  "Words with a valid checksum",
+	}
+	for _, w := range words {
+		c := strings.ToUpper(bip39.LabelFor(w))
+		if mode == KeyboardNumbers {
+			c = strconv.Itoa(int(w)+1) + " " + c
+		}
+		cs.Choices = append(cs.Choices, c)
+	}
+	choice, ok := cs.Choose(ctx, ops, th)
+	if !ok {
+		return -1, false
+	}
+	return words[choice], true
+}
+
+func inputWordsFlow(ctx *Context, ops op.Ctx, th *Colors, mode KeyboardMode, mnemonic bip39.Mnemonic, selected int) {
+	kbd := newKeyboard(ctx, mode)
 	inp := new(InputTracker)
+	// The final words are offered when only the last word is missing.
+	var finals []bip39.Word
+	if selected == len(mnemonic)-1 {
+		finals = finalWords(mnemonic)
+	}
 	for {
 		for {
 			kbd.Update(ctx)
@@ -1078,6 +1385,15 @@ func inputWordsFlow(ctx *Context, ops op.Ctx, th *Colors, mnemonic bip39.Mnemoni
 				}
 				w, complete := kbd.Complete()
 				if !complete {
+					// Offer the final words that match the input.
+					matches := matchFinalWords(kbd, finals)
+					if len(matches) == 0 || len(matches) > maxFinalWords {
+						break
+					}
+					if w, ok := chooseFinalWord(ctx, ops, th, mode, matches); ok {
+						mnemonic[selected] = w
+						return
+					}
 					break
 				}
 				kbd.Clear()
@@ -1091,6 +1407,9 @@ func inputWordsFlow(ctx *Context, ops op.Ctx, th *Colors, mnemonic bip39.Mnemoni
 						break
 					}
 				}
+				if selected == len(mnemonic)-1 {
+					finals = finalWords(mnemonic)
+				}
 			}
 		}
 		dims := ctx.Platform.DisplaySize()
@@ -1115,6 +1434,13 @@ func inputWordsFlow(ctx *Context, ops op.Ctx, th *Colors, mnemonic bip39.Mnemoni
 		if complete {
 			hint = strings.ToUpper(bip39.LabelFor(completedWord))
 		}
+		if mode == KeyboardNumbers {
+			// Show the number along with its word.
+			longest = layoutWord(op.Ctx{}, 24, "2048 "+longestWord)
+			if complete {
+				hint = kbd.Word + " " + hint
+			}
+		}
 		layoutWord(ops.Begin(), selected+1, hint)
 		word := ops.End()
 		r := image.Rectangle{Max: longest}
@@ -1125,74 +1451,221 @@ func inputWordsFlow(ctx *Context, ops op.Ctx, th *Colors, mnemonic bip39.Mnemoni
 		top, _ := content.CutBottom(kbdsz.Y)
 		op.Position(ops, ops.End(), top.Center(longest))
 
-		layoutNavigation(inp, ops, th, dims, []NavButton{{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack}}...)
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack}}...)
 		if complete {
-			layoutNavigation(inp, ops, th, dims, []NavButton{{Button: Button2, Style: StylePrimary, Icon: assets.IconCheckmark}}...)
+			layoutNavigation(ctx, inp, ops, th, dims, []NavButton{{Button: Button2, Style: StylePrimary, Icon: assets.IconCheckmark}}...)
+		} else if matches := matchFinalWords(kbd, finals); len(matches) > 0 && len(matches) <= maxFinalWords {
+			layoutNavigation(ctx, inp, ops, th, dims, []NavButton{{Button: Button2, Style: StyleSecondary, Icon: assets.IconRight}}...)
 		}
 		ctx.Frame()
 	}
 }
 
-var kbdKeys = [...][]rune{
+// KeyboardMode selects the keys of a Keyboard and the runes it
+// accepts.
+type KeyboardMode int
+
+const (
+	// KeyboardWords is for BIP39 words; only letters that continue
+	// a word in the word list are valid.
+	KeyboardWords KeyboardMode = iota
+	// KeyboardText is for free text such as passphrases and labels,
+	// with switchable layers of letters, digits and symbols.
+	KeyboardText
+	// KeyboardNumbers is for BIP39 words by their number in the
+	// word list, from 1 to 2048.
+	KeyboardNumbers
+)
+
+const (
+	keyBackspace = '⌫'
+	// keyLayer switches a text keyboard to its next layer.
+	keyLayer = '\uE000'
+)
+
+var kbdKeys = [][]rune{
 	[]rune("QWERTYUIOP"),
 	[]rune("ASDFGHJKL"),
 	[]rune("ZXCVBNM⌫"),
 }
 
+var kbdNumbers = [][]rune{
+	[]rune("123"),
+	[]rune("456"),
+	[]rune("789"),
+	[]rune("0⌫"),
+}
+
+// kbdLayer is a layer of a text keyboard.
+type kbdLayer struct {
+	// Name labels the layer key of the layer before.
+	Name string
+	Keys [][]rune
+}
+
+var textLayers = []kbdLayer{
+	{"ABC", [][]rune{
+		[]rune("QWERTYUIOP"),
+		[]rune("ASDFGHJKL"),
+		[]rune("ZXCVBNM⌫"),
+		{keyLayer, ' '},
+	}},
+	{"abc", [][]rune{
+		[]rune("qwertyuiop"),
+		[]rune("asdfghjkl"),
+		[]rune("zxcvbnm⌫"),
+		{keyLayer, ' '},
+	}},
+	{"123", [][]rune{
+		[]rune("1234567890"),
+		[]rune("-/:;()$&@"),
+		[]rune(".,?!'\"⌫"),
+		{keyLayer, ' '},
+	}},
+	{"#+=", [][]rune{
+		[]rune("[]{}#%^*+="),
+		[]rune("_\\|~<>`"),
+		[]rune(".,?!'\"⌫"),
+		{keyLayer, ' '},
+	}},
+}
+
 type Keyboard struct {
 	Word string
+	// MaxLen limits the length of Word, if not zero.
+	MaxLen int
 
+	mode      KeyboardMode
+	layer     int
 	nvalid    int
-	positions [len(kbdKeys)][]image.Point
+	positions [][]image.Point
 	widest    image.Point
 	backspace image.Point
-	size      image.Point
+	layerKey  image.Point
+	space     image.Point
+	// bgbnds are the bounds of the background of the widest key.
+	bgbnds image.Rectangle
+	size   image.Point
 
 	mask     uint32
 	row, col int
 	inp      InputTracker
 }
 
+// kbdMargin is the space between keys.
+const kbdMargin = 2
+
+// NewKeyboard returns a keyboard for BIP39 words.
 func NewKeyboard(ctx *Context) *Keyboard {
-	k := new(Keyboard)
+	return newKeyboard(ctx, KeyboardWords)
+}
+
+// NewTextKeyboard returns a keyboard for free text.
+func NewTextKeyboard(ctx *Context) *Keyboard {
+	return newKeyboard(ctx, KeyboardText)
+}
+
+func newKeyboard(ctx *Context, mode KeyboardMode) *Keyboard {
+	k := &Keyboard{mode: mode}
 	k.widest = ctx.Styles.keyboard.Measure(math.MaxInt, "W")
 	bsb := assets.KeyBackspace.Bounds()
 	bsWidth := bsb.Min.X*2 + bsb.Dx()
 	k.backspace = image.Pt(bsWidth, k.widest.Y)
-	bgbnds := assets.Key.Bounds(image.Rectangle{Max: k.widest})
-	const margin = 2
-	bgsz := bgbnds.Size().Add(image.Pt(margin, margin))
-	longest := 0
-	for _, row := range kbdKeys {
-		if n := len(row); n > longest {
-			longest = n
-		}
-	}
-	maxw := longest*bgsz.X - margin
-	for i, row := range kbdKeys {
-		n := len(row)
-		if i == len(kbdKeys)-1 {
+	for _, l := range textLayers {
+		sz := ctx.Styles.keyboard.Measure(math.MaxInt, l.Name)
+		k.layerKey.X = max(k.layerKey.X, sz.X+bsb.Min.X*2)
+	}
+	k.layerKey.Y = k.widest.Y
+	k.bgbnds = assets.Key.Bounds(image.Rectangle{Max: k.widest})
+	// The space key is as wide as 4 regular keys.
+	const spaceKeys = 4
+	k.space = image.Pt(spaceKeys*k.keyStep(0)-k.keyStep(0)+k.widest.X, k.widest.Y)
+	k.Clear()
+	return k
+}
+
+// keys returns the rows of keys of the current layer.
+func (k *Keyboard) keys() [][]rune {
+	switch k.mode {
+	case KeyboardText:
+		return textLayers[k.layer].Keys
+	case KeyboardNumbers:
+		return kbdNumbers
+	}
+	return kbdKeys
+}
+
+func (k *Keyboard) keySize(key rune) image.Point {
+	switch key {
+	case keyBackspace:
+		return k.backspace
+	case keyLayer:
+		return k.layerKey
+	case ' ':
+		return k.space
+	}
+	return k.widest
+}
+
+// keyStep returns the horizontal distance from a key to the next.
+// A zero key is the widest regular key.
+func (k *Keyboard) keyStep(key rune) int {
+	bgw := k.bgbnds.Dx() - k.widest.X
+	if key == 0 {
+		return k.widest.X + bgw + kbdMargin
+	}
+	return k.keySize(key).X + bgw + kbdMargin
+}
+
+// setLayer switches to a layer of a text keyboard and lays out its keys.
+func (k *Keyboard) setLayer(layer int) {
+	k.layer = layer
+	keys := k.keys()
+	rowWidth := func(row []rune) int {
+		w := 0
+		for _, key := range row {
+			w += k.keyStep(key)
+		}
+		return w - kbdMargin
+	}
+	maxw := 0
+	for _, row := range keys {
+		maxw = max(maxw, rowWidth(row))
+	}
+	bgh := k.bgbnds.Dy() + kbdMargin
+	k.positions = make([][]image.Point, len(keys))
+	for i, row := range keys {
+		centered := row
+		if n := len(row); n > 0 && row[n-1] == keyBackspace {
 			// Center row without the backspace key.
-			n--
-		}
-		w := bgsz.X*n - margin
-		off := image.Pt((maxw-w)/2, 0)
-		for j := range row {
-			pos := image.Pt(j*bgsz.X, i*bgsz.Y)
-			pos = pos.Add(off)
-			pos = pos.Sub(bgbnds.Min)
+			centered = row[:n-1]
+		}
+		x := (maxw - rowWidth(centered)) / 2
+		for _, key := range row {
+			pos := image.Pt(x, i*bgh)
+			pos = pos.Sub(k.bgbnds.Min)
 			k.positions[i] = append(k.positions[i], pos)
+			x += k.keyStep(key)
 		}
 	}
 	k.size = image.Point{
 		X: maxw,
-		Y: len(kbdKeys)*bgsz.Y - margin,
+		Y: len(keys)*bgh - kbdMargin,
 	}
-	k.Clear()
-	return k
+	k.row = min(k.row, len(keys)-1)
+	k.col = min(k.col, len(keys[k.row])-1)
 }
 
+// Complete returns the BIP39 word entered on a words or numbers
+// keyboard and whether it is complete.
 func (k *Keyboard) Complete() (bip39.Word, bool) {
+	if k.mode == KeyboardNumbers {
+		n, err := strconv.Atoi(k.Word)
+		if err != nil || n < 1 || n > int(bip39.NumWords) {
+			return -1, false
+		}
+		return bip39.Word(n - 1), true
+	}
 	word := strings.ToLower(k.Word)
 	w, ok := bip39.ClosestWord(word)
 	if !ok {
@@ -1204,38 +1677,26 @@ func (k *Keyboard) Complete() (bip39.Word, bool) {
 
 func (k *Keyboard) Clear() {
 	k.Word = ""
+	k.setLayer(0)
 	k.updateMask()
-	k.row = len(kbdKeys) / 2
-	k.col = len(kbdKeys[k.row]) / 2
+	keys := k.keys()
+	k.row = len(keys) / 2
+	k.col = len(keys[k.row]) / 2
 	k.adjust(false)
 }
 
 func (k *Keyboard) updateMask() {
 	k.mask = ^uint32(0)
-	word := strings.ToLower(k.Word)
-	w, valid := bip39.ClosestWord(word)
-	if !valid {
+	if k.mode != KeyboardWords {
 		return
 	}
-	k.nvalid = 0
-	for ; w < bip39.NumWords; w++ {
-		bip39w := bip39.LabelFor(w)
-		if !strings.HasPrefix(bip39w, word) {
-			break
-		}
-		k.nvalid++
-		suffix := bip39w[len(word):]
-		if len(suffix) > 0 {
-			r := rune(strings.ToUpper(suffix)[0])
-			idx, valid := k.idxForRune(r)
-			if !valid {
-				panic("valid by construction")
-			}
-			k.mask &^= 1 << idx
-		}
-	}
-	if k.nvalid == 1 {
-		k.mask = ^uint32(0)
+	// The letters of the mask are ordered like the runes of
+	// idxForRune.
+	letters, n := bip39.NextLetters(strings.ToLower(k.Word))
+	k.nvalid = n
+	// A single match needs no more letters.
+	if n > 1 {
+		k.mask = ^letters
 	}
 }
 
@@ -1248,8 +1709,22 @@ func (k *Keyboard) idxForRune(r rune) (int, bool) {
 }
 
 func (k *Keyboard) Valid(r rune) bool {
-	if r == '⌫' {
+	switch {
+	case r == keyBackspace:
 		return len(k.Word) > 0
+	case k.MaxLen > 0 && len(k.Word) >= k.MaxLen:
+		return false
+	case k.mode == KeyboardText:
+		// Printable ASCII, including runes not on the current layer.
+		return r == keyLayer || ' ' <= r && r <= '~'
+	case k.mode == KeyboardNumbers:
+		// Digits that continue a word number without leading zeros.
+		if r < '0' || r > '9' {
+			return false
+		}
+		num := k.Word + string(r)
+		n, err := strconv.Atoi(num)
+		return err == nil && num[0] != '0' && n <= int(bip39.NumWords)
 	}
 	idx, valid := k.idxForRune(r)
 	return valid && k.mask&(1<<idx) == 0
@@ -1261,9 +1736,11 @@ func (k *Keyboard) Update(ctx *Context) {
 		if !ok {
 			break
 		}
-		if !e.Pressed {
+		// Long presses are not key presses.
+		if !e.Pressed || e.Gesture == LongPress {
 			continue
 		}
+		keys := k.keys()
 		switch e.Button {
 		case Left, CCW:
 			next := k.col
@@ -1271,12 +1748,12 @@ func (k *Keyboard) Update(ctx *Context) {
 				next--
 				if next == -1 {
 					if e.Button == CCW {
-						nrows := len(kbdKeys)
+						nrows := len(keys)
 						k.row = (k.row - 1 + nrows) % nrows
 					}
-					next = len(kbdKeys[k.row]) - 1
+					next = len(keys[k.row]) - 1
 				}
-				if !k.Valid(kbdKeys[k.row][next]) {
+				if !k.Valid(keys[k.row][next]) {
 					continue
 				}
 				k.col = next
@@ -1287,14 +1764,14 @@ func (k *Keyboard) Update(ctx *Context) {
 			next := k.col
 			for {
 				next++
-				if next == len(kbdKeys[k.row]) {
+				if next == len(keys[k.row]) {
 					if e.Button == CW {
-						nrows := len(kbdKeys)
+						nrows := len(keys)
 						k.row = (k.row + 1 + nrows) % nrows
 					}
 					next = 0
 				}
-				if !k.Valid(kbdKeys[k.row][next]) {
+				if !k.Valid(keys[k.row][next]) {
 					continue
 				}
 				k.col = next
@@ -1302,7 +1779,7 @@ func (k *Keyboard) Update(ctx *Context) {
 				break
 			}
 		case Up:
-			n := len(kbdKeys)
+			n := len(keys)
 			next := k.row
 			for {
 				next = (next - 1 + n) % n
@@ -1312,7 +1789,7 @@ func (k *Keyboard) Update(ctx *Context) {
 				}
 			}
 		case Down:
-			n := len(kbdKeys)
+			n := len(keys)
 			next := k.row
 			for {
 				next = (next + 1) % n
@@ -1322,9 +1799,17 @@ func (k *Keyboard) Update(ctx *Context) {
 				}
 			}
 		case Rune:
-			k.rune(e.Rune)
+			r := e.Rune
+			switch {
+			case r == '\b':
+				// Physical keyboards type backspace as a rune.
+				r = keyBackspace
+			case k.mode == KeyboardWords:
+				r = unicode.ToUpper(r)
+			}
+			k.rune(r)
 		case Center, Button3:
-			r := kbdKeys[k.row][k.col]
+			r := keys[k.row][k.col]
 			k.rune(r)
 		}
 	}
@@ -1334,14 +1819,18 @@ func (k *Keyboard) rune(r rune) {
 	if !k.Valid(r) {
 		return
 	}
-	if r == '⌫' {
+	switch r {
+	case keyLayer:
+		k.setLayer((k.layer + 1) % len(textLayers))
+		return
+	case keyBackspace:
 		_, n := utf8.DecodeLastRuneInString(k.Word)
 		k.Word = k.Word[:len(k.Word)-n]
-	} else {
+	default:
 		k.Word = k.Word + string(r)
 	}
 	k.updateMask()
-	k.adjust(r == '⌫')
+	k.adjust(r == keyBackspace)
 }
 
 // adjust resets the row and column to the nearest valid key, if any.
@@ -1349,10 +1838,10 @@ func (k *Keyboard) adjust(allowBackspace bool) {
 	dist := int(1e6)
 	current := k.positions[k.row][k.col]
 	found := false
-	for i, row := range kbdKeys {
+	for i, row := range k.keys() {
 		j := 0
 		for _, key := range row {
-			if !k.Valid(key) || key == '⌫' && !allowBackspace {
+			if !k.Valid(key) || key == keyBackspace && !allowBackspace {
 				j++
 				continue
 			}
@@ -1369,8 +1858,11 @@ func (k *Keyboard) adjust(allowBackspace bool) {
 	}
 	// Only if no other key was found, select backspace.
 	if !found {
-		k.row = len(k.positions) - 1
-		k.col = len(k.positions[k.row]) - 1
+		for i, row := range k.keys() {
+			if j := slices.Index(row, keyBackspace); j != -1 {
+				k.row, k.col = i, j
+			}
+		}
 	}
 }
 
@@ -1379,7 +1871,7 @@ func (k *Keyboard) adjustCol(row int) bool {
 	dist := int(1e6)
 	found := false
 	x := k.positions[k.row][k.col].X
-	for i, r := range kbdKeys[row] {
+	for i, r := range k.keys()[row] {
 		if !k.Valid(r) {
 			continue
 		}
@@ -1399,14 +1891,11 @@ func (k *Keyboard) adjustCol(row int) bool {
 }
 
 func (k *Keyboard) Layout(ctx *Context, ops op.Ctx, th *Colors) image.Point {
-	for i, row := range kbdKeys {
+	for i, row := range k.keys() {
 		for j, key := range row {
 			valid := k.Valid(key)
 			bg := assets.Key
-			bgsz := k.widest
-			if key == '⌫' {
-				bgsz = k.backspace
-			}
+			bgsz := k.keySize(key)
 			bgcol := th.Text
 			style := ctx.Styles.keyboard
 			col := th.Text
@@ -1419,13 +1908,19 @@ func (k *Keyboard) Layout(ctx *Context, ops op.Ctx, th *Colors) image.Point {
 				col = th.Background
 			}
 			var sz image.Point
-			if key == '⌫' {
+			switch key {
+			case keyBackspace:
 				icn := assets.KeyBackspace
 				sz = image.Pt(k.backspace.X, icn.Bounds().Dy())
 				op.ImageOp(ops.Begin(), icn, true)
 				op.ColorOp(ops, col)
-			} else {
-				sz = widget.Labelf(ops.Begin(), style, col, string(key))
+			case keyLayer:
+				next := textLayers[(k.layer+1)%len(textLayers)]
+				sz = widget.Labelf(ops.Begin(), style, col, "%s", next.Name)
+			case ' ':
+				sz = widget.Labelf(ops.Begin(), style, col, "space")
+			default:
+				sz = widget.Labelf(ops.Begin(), style, col, "%s", string(key))
 			}
 			key := ops.End()
 			bg.Add(ops.Begin(), image.Rectangle{Max: bgsz}, true)
@@ -1442,6 +1937,7 @@ type ChoiceScreen struct {
 	Lead    string
 	Choices []string
 	choice  int
+	scroll  widget.ScrollArea
 }
 
 func (s *ChoiceScreen) Choose(ctx *Context, ops op.Ctx, th *Colors) (int, bool) {
@@ -1479,7 +1975,7 @@ func (s *ChoiceScreen) Choose(ctx *Context, ops op.Ctx, th *Colors) (int, bool)
 		dims := ctx.Platform.DisplaySize()
 		s.Draw(ctx, ops, th, dims)
 
-		layoutNavigation(inp, ops, th, dims, []NavButton{
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
 			{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack},
 			{Button: Button3, Style: StylePrimary, Icon: assets.IconCheckmark},
 		}...)
@@ -1522,7 +2018,11 @@ func (s *ChoiceScreen) Draw(ctx *Context, ops op.Ctx, th *Colors, dims image.Poi
 
 	inner := ops.Begin()
 	h := 0
+	selected := 0
 	for i, c := range children {
+		if i == s.choice {
+			selected = h + c.Size.Y/2
+		}
 		xoff := (maxW - c.Size.X) / 2
 		pos := image.Pt(xoff, h)
 		txt := c.W
@@ -1538,7 +2038,21 @@ func (s *ChoiceScreen) Draw(ctx *Context, ops op.Ctx, th *Colors, dims image.Poi
 		op.Position(inner, txt, pos)
 		h += c.Size.Y
 	}
-	op.Position(ops, ops.End(), content.Center(image.Pt(maxW, h)))
+	list := ops.End()
+	if h <= content.Dy() {
+		op.Position(ops, list, content.Center(image.Pt(maxW, h)))
+		return
+	}
+	// Scroll the selected choice into view.
+	view := content.Shrink(scrollFadeDist, 0, scrollFadeDist, 0)
+	s.scroll.ScrollTo(selected - view.Dy()/2)
+	scroll, moving := s.scroll.Update(h, view.Dy())
+	if moving {
+		ctx.Platform.Wakeup()
+	}
+	pos := image.Pt(view.Center(image.Pt(maxW, 0)).X, view.Min.Y-scroll)
+	op.Position(ops.Begin(), list, pos)
+	fadeClip(ops, ops.End(), image.Rectangle(content))
 }
 
 func mainFlow(ctx *Context, ops op.Ctx) {
@@ -1563,8 +2077,17 @@ func mainFlow(ctx *Context, ops op.Ctx) {
 					Icon:  assets.IconRight,
 				}
 				th := mainScreenTheme(page)
+				// Unlock while the SD card is inserted to record
+				// the attempts. Diagnostics neither input seeds nor
+				// engrave.
+				if page != diagnostics && !unlockFlow(ctx, ops, th) {
+					continue events
+				}
+				// Plan files are read from, and settings stored
+				// to, the SD card.
+				usesSDCard := page == planFile || page == deviceSettings
 			loop:
-				for !ctx.EmptySDSlot {
+				for !ctx.EmptySDSlot && !usesSDCard {
 					res := ws.Layout(ctx, ops.Begin(), th, dims)
 					dialog := ops.End()
 					switch res {
@@ -1577,31 +2100,47 @@ func mainFlow(ctx *Context, ops op.Ctx) {
 					dialog.Add(ops)
 					ctx.Frame()
 				}
-				ctx.EmptySDSlot = true
-				switch page {
-				case backupWallet:
-					backupWalletFlow(ctx, ops, th)
-				}
+				if !usesSDCard {
+					ctx.EmptySDSlot = true
+				}
+				runProgram(ctx, func() {
+					switch page {
+					case backupWallet:
+						backupWalletFlow(ctx, ops, th)
+					case deviceSettings:
+						settingsFlow(ctx, ops, th)
+					case diagnostics:
+						diagnosticsFlow(ctx, ops, th)
+					case dataPlate:
+						dataPlateFlow(ctx, ops, th)
+					case verifyBackup:
+						verifyBackupFlow(ctx, ops, th)
+					case recoverWallet:
+						recoverWalletFlow(ctx, ops, th)
+					case planFile:
+						planFileFlow(ctx, ops, th)
+					}
+				})
 			case Left:
 				if !e.Pressed {
 					break
 				}
 				page--
 				if page < 0 {
-					page = backupWallet
+					page = planFile
 				}
 			case Right:
 				if !e.Pressed {
 					break
 				}
 				page++
-				if page > backupWallet {
+				if page > planFile {
 					page = 0
 				}
 			}
 		}
 		drawMainScreen(ctx, ops, dims, page)
-		layoutNavigation(inp, ops, mainScreenTheme(page), dims, []NavButton{
+		layoutNavigation(ctx, inp, ops, mainScreenTheme(page), dims, []NavButton{
 			{Button: Button3, Style: StylePrimary, Icon: assets.IconCheckmark},
 		}...)
 		ctx.Frame()
@@ -1612,6 +2151,18 @@ func mainScreenTheme(page program) *Colors {
 	switch page {
 	case backupWallet:
 		return &descriptorTheme
+	case deviceSettings:
+		return &engraveTheme
+	case diagnostics:
+		return &singleTheme
+	case dataPlate:
+		return &descriptorTheme
+	case verifyBackup:
+		return &singleTheme
+	case recoverWallet:
+		return &descriptorTheme
+	case planFile:
+		return &engraveTheme
 	default:
 		panic("invalid page")
 	}
@@ -1624,6 +2175,18 @@ func drawMainScreen(ctx *Context, ops op.Ctx, dims image.Point, page program) {
 	switch page {
 	case backupWallet:
 		title = "Backup Wallet"
+	case deviceSettings:
+		title = "Settings"
+	case diagnostics:
+		title = "Diagnostics"
+	case dataPlate:
+		title = "Data Plate"
+	case verifyBackup:
+		title = "Verify Backup"
+	case recoverWallet:
+		title = "Recover Wallet"
+	case planFile:
+		title = "Plan File"
 	}
 	op.ColorOp(ops, th.Background)
 
@@ -1643,6 +2206,21 @@ func drawMainScreen(ctx *Context, ops op.Ctx, dims image.Point, page program) {
 	op.Position(ops, ops.End(), r.SW(shsz).Add(image.Pt(3, 0)))
 }
 
+// layoutProgress draws the screen of a long running task that has
+// done of total steps completed.
+func layoutProgress(ctx *Context, ops op.Ctx, th *Colors, dims image.Point, title, body string, done, total int) {
+	op.ColorOp(ops, th.Background)
+	layoutTitle(ctx, ops, dims.X, th.Text, title)
+	r := layout.Rectangle{Max: dims}
+	_, content := r.CutTop(leadingSize)
+	content, lead := content.CutBottom(leadingSize)
+	const margin = 8
+	sz := widget.Labelwf(ops.Begin(), ctx.Styles.body, content.Dx()-2*margin, th.Text, "%s", body)
+	op.Position(ops, ops.End(), content.Center(sz))
+	leadsz := widget.Labelwf(ops.Begin(), ctx.Styles.lead, dims.X-2*margin, th.Text, "Checked %d of %d", done, total)
+	op.Position(ops, ops.End(), lead.Center(leadsz))
+}
+
 func layoutTitle(ctx *Context, ops op.Ctx, width int, col color.NRGBA, title string, args ...any) image.Rectangle {
 	const margin = 8
 	sz := widget.Labelwf(ops.Begin(), ctx.Styles.title, width-2*16, col, title, args...)
@@ -1669,29 +2247,32 @@ type NavButton struct {
 	Progress float32
 }
 
-func layoutNavigation(inp *InputTracker, ops op.Ctx, th *Colors, dims image.Point, btns ...NavButton) image.Rectangle {
-	navsz := assets.NavBtnPrimary.Bounds().Size()
+// layoutNavigation lays out the navigation buttons next to their
+// physical buttons, which are on the left side of a rotated display.
+func layoutNavigation(ctx *Context, inp *InputTracker, ops op.Ctx, th *Colors, dims image.Point, btns ...NavButton) image.Rectangle {
+	btnPrimary := navImage(ctx, assets.NavBtnPrimary)
+	navsz := btnPrimary.Bounds().Size()
 	button := func(ops op.Ctx, b NavButton, pressed bool) {
 		if b.Style == StyleNone {
 			return
 		}
 		switch b.Style {
 		case StyleSecondary:
-			op.ImageOp(ops, assets.NavBtnPrimary, true)
+			op.ImageOp(ops, btnPrimary, true)
 			op.ColorOp(ops, th.Background)
-			op.ImageOp(ops, assets.NavBtnSecondary, true)
+			op.ImageOp(ops, navImage(ctx, assets.NavBtnSecondary), true)
 			op.ColorOp(ops, th.Text)
 		case StylePrimary:
-			op.ImageOp(ops, assets.NavBtnPrimary, true)
+			op.ImageOp(ops, btnPrimary, true)
 			op.ColorOp(ops, th.Primary)
 		}
 		if b.Progress > 0 {
 			(&ProgressImage{
 				Progress: b.Progress,
-				Src:      assets.IconProgress,
+				Src:      navImage(ctx, assets.IconProgress),
 			}).Add(ops)
 		} else {
-			op.ImageOp(ops, b.Icon, true)
+			op.ImageOp(ops, navImage(ctx, b.Icon), true)
 		}
 		switch b.Style {
 		case StyleSecondary:
@@ -1700,22 +2281,25 @@ func layoutNavigation(inp *InputTracker, ops op.Ctx, th *Colors, dims image.Poin
 			op.ColorOp(ops, th.Text)
 		}
 		if b.Progress == 0 && pressed {
-			op.ImageOp(ops, assets.NavBtnPrimary, true)
+			op.ImageOp(ops, btnPrimary, true)
 			op.ColorOp(ops, color.NRGBA{A: theme.activeMask})
 		}
 	}
-	btnsz := assets.NavBtnPrimary.Bounds().Size()
 	ys := [3]int{
 		leadingSize,
-		(dims.Y - btnsz.Y) / 2,
-		dims.Y - leadingSize - btnsz.Y,
+		(dims.Y - navsz.Y) / 2,
+		dims.Y - leadingSize - navsz.Y,
+	}
+	x := dims.X - navsz.X
+	if ctx.Settings.Orientation == OrientRotated {
+		x = 0
 	}
 	var r image.Rectangle
 	for _, b := range btns {
 		idx := int(b.Button - Button1)
 		button(ops.Begin(), b, inp.Pressed[b.Button])
 		y := ys[idx]
-		pos := image.Pt(dims.X-btnsz.X, y)
+		pos := image.Pt(x, y)
 		op.Position(ops, ops.End(), pos)
 		r = r.Union(image.Rectangle{
 			Min: pos,
@@ -1744,7 +2328,7 @@ func layoutMainPage(ops op.Ctx, th *Colors, width int, page program) image.Point
 	const margin = 16
 
 	op.Position(ops, content, image.Pt((width-contentsz.X)/2, 8+h.Y(contentsz)))
-	const npage = int(backupWallet) + 1
+	const npage = int(planFile) + 1
 	if npage > 1 {
 		op.Position(ops, left, image.Pt(margin, h.Y(leftsz)))
 		op.Position(ops, right, image.Pt(width-margin-rightsz.X, h.Y(rightsz)))
@@ -1759,12 +2343,36 @@ func layoutMainPlates(ops op.Ctx, page program) image.Point {
 		img := assets.Hammer
 		op.ImageOp(ops, img, false)
 		return img.Bounds().Size()
+	case deviceSettings:
+		img := assets.Sh02
+		op.ImageOp(ops, img, false)
+		return img.Bounds().Size()
+	case diagnostics:
+		img := assets.LogoSmall
+		op.ImageOp(ops, img, false)
+		return img.Bounds().Size()
+	case dataPlate:
+		img := assets.Sh03
+		op.ImageOp(ops, img, false)
+		return img.Bounds().Size()
+	case verifyBackup:
+		img := assets.Sh02
+		op.ImageOp(ops, img, false)
+		return img.Bounds().Size()
+	case recoverWallet:
+		img := assets.Sh03
+		op.ImageOp(ops, img, false)
+		return img.Bounds().Size()
+	case planFile:
+		img := assets.Hammer
+		op.ImageOp(ops, img, false)
+		return img.Bounds().Size()
 	}
 	panic("invalid page")
 }
 
 func layoutMainPager(ops op.Ctx, th *Colors, page program) image.Point {
-	const npages = int(backupWallet) + 1
+	const npages = int(planFile) + 1
 	const space = 4
 	if npages <= 1 {
 		return image.Point{}
@@ -1783,10 +2391,14 @@ func layoutMainPager(ops op.Ctx, th *Colors, page program) image.Point {
 }
 
 func backupWalletFlow(ctx *Context, ops op.Ctx, th *Colors) {
-	mnemonic, ok := newMnemonicFlow(ctx, ops, th)
+	mnemonic, ok := inputMnemonicFlow(ctx, ops, th, true)
 	if !ok {
 		return
 	}
+	if mnemonic == nil {
+		watchOnlyFlow(ctx, ops, th)
+		return
+	}
 	ss := new(SeedScreen)
 	for {
 		if !ss.Confirm(ctx, ops, th, mnemonic) {
@@ -1797,7 +2409,7 @@ func backupWalletFlow(ctx *Context, ops op.Ctx, th *Colors) {
 			continue
 		}
 		if desc == nil {
-			plate, err := engraveSeed(ctx.Platform.PlateSizes(), ctx.Platform.EngraverParams(), mnemonic)
+			plate, err := engraveSeed(ctx.Platform.PlateSizes(), ctx.Platform.EngraverParams(), ctx.Settings, mnemonic)
 			if err != nil {
 				errScr := NewErrorScreen(err)
 				for {
@@ -1815,6 +2427,11 @@ func backupWalletFlow(ctx *Context, ops op.Ctx, th *Colors) {
 			}
 			completed := NewEngraveScreen(ctx, plate).Engrave(ctx, ops, &engraveTheme)
 			if completed {
+				recordBackup(ctx, Backup{
+					MasterFingerprint: plate.MasterFingerprint,
+					Keys:              1,
+					Size:              plate.Size,
+				})
 				return
 			}
 			continue
@@ -1829,7 +2446,7 @@ func backupWalletFlow(ctx *Context, ops op.Ctx, th *Colors) {
 			if !ok {
 				break
 			}
-			plate, err := engravePlate(ctx.Platform.PlateSizes(), ctx.Platform.EngraverParams(), *desc, keyIdx, mnemonic)
+			plate, err := engravePlate(ctx.Platform.PlateSizes(), ctx.Platform.EngraverParams(), ctx.Settings, ds.Descriptor, keyIdx, mnemonic)
 			if err != nil {
 				errScr := NewErrorScreen(err)
 				for {
@@ -1847,6 +2464,10 @@ func backupWalletFlow(ctx *Context, ops op.Ctx, th *Colors) {
 			}
 			completed := NewEngraveScreen(ctx, plate).Engrave(ctx, ops, &engraveTheme)
 			if completed {
+				recordBackup(ctx, descriptorBackup(ds.Descriptor, keyIdx, plate))
+				if len(desc.Keys) > 1 {
+					cosignersFlow(ctx, ops, th, ds.Descriptor, keyIdx)
+				}
 				return
 			}
 		}
@@ -1854,10 +2475,20 @@ func backupWalletFlow(ctx *Context, ops op.Ctx, th *Colors) {
 }
 
 func newMnemonicFlow(ctx *Context, ops op.Ctx, th *Colors) (bip39.Mnemonic, bool) {
+	return inputMnemonicFlow(ctx, ops, th, false)
+}
+
+// inputMnemonicFlow is like newMnemonicFlow, but if noSeed is
+// set it also offers to continue without a seed, in which case it
+// returns a nil mnemonic.
+func inputMnemonicFlow(ctx *Context, ops op.Ctx, th *Colors, noSeed bool) (bip39.Mnemonic, bool) {
 	cs := &ChoiceScreen{
 		Title:   "Input Seed",
 		Lead:    "Choose input method",
-		Choices: []string{"KEYBOARD", "CAMERA"},
+		Choices: []string{"KEYBOARD", "CAMERA", "NUMBERS"},
+	}
+	if noSeed {
+		cs.Choices = append(cs.Choices, "NO SEED")
 	}
 	showErr := func(errScreen *ErrorScreen) {
 		for {
@@ -1879,7 +2510,11 @@ outer:
 			return nil, false
 		}
 		switch choice {
-		case 0: // Keyboard.
+		case 0, 2: // Keyboard or word numbers.
+			mode := KeyboardWords
+			if choice == 2 {
+				mode = KeyboardNumbers
+			}
 			cs := &ChoiceScreen{
 				Title:   "Input Seed",
 				Lead:    "Choose number of words",
@@ -1890,8 +2525,8 @@ outer:
 				if !ok {
 					continue outer
 				}
-				mnemonic := emptyMnemonic([]int{12, 24}[choice])
-				inputWordsFlow(ctx, ops, th, mnemonic, 0)
+				mnemonic := ctx.secrets.track(emptyMnemonic([]int{12, 24}[choice]))
+				inputWordsFlow(ctx, ops, th, mode, mnemonic, 0)
 				if !isEmptyMnemonic(mnemonic) {
 					return mnemonic, true
 				}
@@ -1910,6 +2545,10 @@ outer:
 				} else if sqr, err := bip39.ParseMnemonic(strings.ToLower(string(b))); err == nil || errors.Is(err, bip39.ErrInvalidChecksum) {
 					res = sqr
 				}
+				if _, ok := res.(bip39.Mnemonic); ok {
+					// Wipe the encoded seed.
+					clear(b)
+				}
 			}
 			seed, ok := res.(bip39.Mnemonic)
 			if !ok {
@@ -1919,13 +2558,16 @@ outer:
 				})
 				continue
 			}
-			return seed, true
+			return ctx.secrets.track(seed), true
+		case 3: // No seed.
+			return nil, true
 		}
 	}
 }
 
 type SeedScreen struct {
 	selected int
+	scroll   widget.ScrollArea
 }
 
 func (s *SeedScreen) Confirm(ctx *Context, ops op.Ctx, th *Colors, mnemonic bip39.Mnemonic) bool {
@@ -1933,7 +2575,7 @@ func (s *SeedScreen) Confirm(ctx *Context, ops op.Ctx, th *Colors, mnemonic bip3
 	for {
 	events:
 		for {
-			e, ok := inp.Next(ctx, Button1, Button2, Center, Button3, Up, Down)
+			e, ok := inp.Next(ctx, Button1, Button2, Center, Button3, Up, Down, Left, Right)
 			if !ok {
 				break
 			}
@@ -1968,7 +2610,7 @@ func (s *SeedScreen) Confirm(ctx *Context, ops op.Ctx, th *Colors, mnemonic bip3
 				if !inp.Clicked(e.Button) {
 					break
 				}
-				inputWordsFlow(ctx, ops, th, mnemonic, s.selected)
+				inputWordsFlow(ctx, ops, th, KeyboardWords, mnemonic, s.selected)
 				continue
 			case Button3:
 				if !inp.Clicked(e.Button) || !isMnemonicComplete(mnemonic) {
@@ -1998,7 +2640,7 @@ func (s *SeedScreen) Confirm(ctx *Context, ops op.Ctx, th *Colors, mnemonic bip3
 					if nonstandard.ElectrumSeed(strings.Join(words, " ")) {
 						scr.Body = "Electrum seeds are not supported."
 					} else {
-						scr.Body = "The seed phrase is invalid.\n\nCheck the words and try again."
+						scr.Body = "The seed phrase is invalid.\n\nCheck the words and try again, or press left to search for corrections."
 					}
 					showErr(scr)
 					break
@@ -2012,6 +2654,42 @@ func (s *SeedScreen) Confirm(ctx *Context, ops op.Ctx, th *Colors, mnemonic bip3
 					break
 				}
 				return true
+			case Left:
+				if !inp.Clicked(e.Button) || !isMnemonicComplete(mnemonic) || mnemonic.Valid() {
+					break
+				}
+				// Prefer corrections that match the last scanned wallet.
+				var mfps []uint32
+				if ctx.LastDescriptor != nil {
+					for _, k := range ctx.LastDescriptor.Keys {
+						mfps = append(mfps, k.MasterFingerprint)
+					}
+				}
+				repairFlow(ctx, ops, th, mnemonic, mfps)
+			case Right:
+				if !inp.Clicked(e.Button) || !isMnemonicComplete(mnemonic) || !mnemonic.Valid() {
+					break
+				}
+				confirm := &ConfirmWarningScreen{
+					Title: "Show SeedQR?",
+					Body:  "The SeedQR reveals the seed to any camera that can see the screen.\n\nHold button to confirm.",
+					Icon:  assets.IconCheckmark,
+				}
+				for {
+					dims := ctx.Platform.DisplaySize()
+					res := confirm.Layout(ctx, ops.Begin(), th, dims)
+					d := ops.End()
+					switch res {
+					case ConfirmNo:
+						continue events
+					case ConfirmYes:
+						(&SeedQRScreen{Mnemonic: mnemonic}).Show(ctx, ops, th)
+						continue events
+					}
+					s.Draw(ctx, ops, th, dims, mnemonic)
+					d.Add(ops)
+					ctx.Frame()
+				}
 			case Down:
 				if e.Pressed && s.selected < len(mnemonic)-1 {
 					s.selected++
@@ -2026,12 +2704,12 @@ func (s *SeedScreen) Confirm(ctx *Context, ops op.Ctx, th *Colors, mnemonic bip3
 		dims := ctx.Platform.DisplaySize()
 		s.Draw(ctx, ops, th, dims, mnemonic)
 
-		layoutNavigation(inp, ops, th, dims, []NavButton{
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
 			{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack},
 			{Button: Button2, Style: StyleSecondary, Icon: assets.IconEdit},
 		}...)
 		if isMnemonicComplete(mnemonic) {
-			layoutNavigation(inp, ops, th, dims, []NavButton{
+			layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
 				{Button: Button3, Style: StylePrimary, Icon: assets.IconCheckmark},
 			}...)
 		}
@@ -2070,15 +2748,12 @@ func (s *SeedScreen) Draw(ctx *Context, ops op.Ctx, th *Colors, dims image.Point
 	content := list.Shrink(scrollFadeDist, navw, scrollFadeDist, navw)
 	lineHeight := longest.Y + 2
 	linesPerPage := content.Dy() / lineHeight
-	scroll := s.selected - linesPerPage/2
-	maxScroll := len(mnemonic) - linesPerPage
-	if scroll > maxScroll {
-		scroll = maxScroll
-	}
-	if scroll < 0 {
-		scroll = 0
+	s.scroll.ScrollTo((s.selected - linesPerPage/2) * lineHeight)
+	scroll, moving := s.scroll.Update(len(mnemonic)*lineHeight, linesPerPage*lineHeight)
+	if moving {
+		ctx.Platform.Wakeup()
 	}
-	off := content.Min.Add(image.Pt(0, -scroll*lineHeight))
+	off := content.Min.Add(image.Pt(0, -scroll))
 	{
 		ops := ops.Begin()
 		for i, w := range mnemonic {
@@ -2099,6 +2774,7 @@ func (s *SeedScreen) Draw(ctx *Context, ops op.Ctx, th *Colors, dims image.Point
 		}
 	}
 	fadeClip(ops, ops.End(), image.Rectangle(list))
+	s.scroll.Layout(ops, th.Text, image.Rectangle(content), len(mnemonic)*lineHeight)
 }
 
 func inputDescriptorFlow(ctx *Context, ops op.Ctx, th *Colors, mnemonic bip39.Mnemonic) (*urtypes.OutputDescriptor, bool) {
@@ -2108,7 +2784,8 @@ func inputDescriptorFlow(ctx *Context, ops op.Ctx, th *Colors, mnemonic bip39.Mn
 		Choices: []string{"SCAN", "SKIP"},
 	}
 	if ctx.LastDescriptor != nil {
-		if _, match := descriptorKeyIdx(*ctx.LastDescriptor, mnemonic, ""); match {
+		// Without a seed, any descriptor can be re-used.
+		if _, match := descriptorKeyIdx(*ctx.LastDescriptor, mnemonic, ""); match || mnemonic == nil {
 			cs.Choices = append(cs.Choices, "RE-USE")
 		}
 	}
@@ -2139,13 +2816,7 @@ func inputDescriptorFlow(ctx *Context, ops op.Ctx, th *Colors, mnemonic bip39.Mn
 			if !ok {
 				continue
 			}
-			desc, ok := res.(urtypes.OutputDescriptor)
-			if !ok {
-				if b, isbytes := res.([]byte); isbytes {
-					d, err := nonstandard.OutputDescriptor(b)
-					desc, ok = d, err == nil
-				}
-			}
+			desc, ok := scannedDescriptor(res)
 			if !ok {
 				showErr(&ErrorScreen{
 					Title: "Invalid Descriptor",
@@ -2161,8 +2832,11 @@ func inputDescriptorFlow(ctx *Context, ops op.Ctx, th *Colors, mnemonic bip39.Mn
 				continue
 			}
 			if len(desc.Keys) == 1 && desc.Keys[0].MasterFingerprint == 0 {
-				mfp, _ := masterFingerprintFor(mnemonic, &chaincfg.MainNetParams)
-				desc.Keys[0].MasterFingerprint = mfp
+				// A bare extended key lacks its origin.
+				desc, ok = originFlow(ctx, ops, th, desc, mnemonic)
+				if !ok {
+					continue
+				}
 			}
 			desc.Title = backup.TitleString(constant.Font, desc.Title)
 			ctx.LastDescriptor = &desc
@@ -2175,6 +2849,21 @@ func inputDescriptorFlow(ctx *Context, ops op.Ctx, th *Colors, mnemonic bip39.Mn
 	}
 }
 
+// scannedDescriptor converts a scanned descriptor, in any of the
+// supported formats, to an output descriptor.
+func scannedDescriptor(res any) (urtypes.OutputDescriptor, bool) {
+	switch res := res.(type) {
+	case urtypes.OutputDescriptor:
+		return res, true
+	case []byte:
+		d, err := nonstandard.OutputDescriptor(res)
+		return d, err == nil
+	case urtypes.KeyDescriptor:
+		return res.SinglesigDescriptor()
+	}
+	return urtypes.OutputDescriptor{}, false
+}
+
 type DescriptorScreen struct {
 	Descriptor urtypes.OutputDescriptor
 	Mnemonic   bip39.Mnemonic
@@ -2197,7 +2886,7 @@ func (s *DescriptorScreen) Confirm(ctx *Context, ops op.Ctx, th *Colors) (int, b
 	inp := new(InputTracker)
 	for {
 		for {
-			e, ok := inp.Next(ctx, Button1, Button2, Button3)
+			e, ok := inp.Next(ctx, Button1, Button2, Button3, Center, Right)
 			if !ok {
 				break
 			}
@@ -2211,11 +2900,35 @@ func (s *DescriptorScreen) Confirm(ctx *Context, ops op.Ctx, th *Colors) (int, b
 					break
 				}
 				ShowAddressesScreen(ctx, ops, th, s.Descriptor)
+			case Center:
+				if !inp.Clicked(e.Button) {
+					break
+				}
+				keyIdx, ok := descriptorKeyIdx(s.Descriptor, s.Mnemonic, "")
+				if !ok {
+					// As when confirming, a passphrase protected seed
+					// can only belong to a singlesig descriptor.
+					if len(s.Descriptor.Keys) > 1 {
+						showErr(&ErrorScreen{
+							Title: "Unknown Wallet",
+							Body:  "The wallet does not match the seed or is passphrase protected.",
+						})
+						break
+					}
+					keyIdx = 0
+				}
+				label, ok := inputLabelFlow(ctx, ops, th, s.Descriptor.Keys[keyIdx].Label)
+				if !ok {
+					break
+				}
+				// Don't modify the keys of the scanned descriptor.
+				s.Descriptor.Keys = slices.Clone(s.Descriptor.Keys)
+				s.Descriptor.Keys[keyIdx].Label = label
 			case Button3:
 				if !inp.Clicked(e.Button) {
 					break
 				}
-				if err := validateDescriptor(ctx.Platform.EngraverParams(), s.Descriptor); err != nil {
+				if err := validateDescriptor(ctx.Platform.EngraverParams(), ctx.Settings, s.Descriptor); err != nil {
 					showErr(NewErrorScreen(err))
 					continue
 				}
@@ -2226,41 +2939,31 @@ func (s *DescriptorScreen) Confirm(ctx *Context, ops op.Ctx, th *Colors) (int, b
 					// multisig descriptors where we can't know which key the seed
 					// belongs to.
 					if len(s.Descriptor.Keys) == 1 {
-						confirm := &ConfirmWarningScreen{
-							Title: "Unknown Wallet",
-							Body:  "The wallet does not match the seed.\n\nIf it is passphrase protected, long press to confirm.",
-							Icon:  assets.IconCheckmark,
-						}
-					loop:
-						for {
-							dims := ctx.Platform.DisplaySize()
-							res := confirm.Layout(ctx, ops.Begin(), th, dims)
-							d := ops.End()
-							switch res {
-							case ConfirmYes:
-								return 0, true
-							case ConfirmNo:
-								break loop
-							}
-							s.Draw(ctx, ops, th, dims)
-							d.Add(ops)
-							ctx.Frame()
+						if overrideMismatchFlow(ctx, ops, th, s) && recoveryTestFlow(ctx, ops, th, s.Descriptor) {
+							return 0, true
 						}
 					} else {
 						showErr(&ErrorScreen{
 							Title: "Unknown Wallet",
-							Body:  "The wallet does not match the seed or is passphrase protected.",
+							Body:  "The wallet does not match the seed or is passphrase protected.\n\n" + mismatchDetails(s.Descriptor, s.Mnemonic),
 						})
 					}
 					continue
 				}
+				if !recoveryTestFlow(ctx, ops, th, s.Descriptor) {
+					continue
+				}
 				return keyIdx, true
+			case Right:
+				if inp.Clicked(e.Button) {
+					recoveryReportFlow(ctx, ops, th, s.Descriptor)
+				}
 			}
 		}
 
 		dims := ctx.Platform.DisplaySize()
 		s.Draw(ctx, ops, th, dims)
-		layoutNavigation(inp, ops, th, dims, []NavButton{
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
 			{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack},
 			{Button: Button2, Style: StyleSecondary, Icon: assets.IconInfo},
 			{Button: Button3, Style: StylePrimary, Icon: assets.IconCheckmark},
@@ -2307,6 +3010,14 @@ func (s *DescriptorScreen) Draw(ctx *Context, ops op.Ctx, th *Colors, dims image
 		bodytxt.Y += infoSpacing
 		bodytxt.Add(ops, subst, body.Dx(), th.Text, "Script")
 		bodytxt.Add(ops, bodyst, body.Dx(), th.Text, desc.Script.String())
+		for i, k := range desc.Keys {
+			if k.Label == "" {
+				continue
+			}
+			bodytxt.Y += infoSpacing
+			bodytxt.Add(ops, subst, body.Dx(), th.Text, "Label %d/%d", i+1, len(desc.Keys))
+			bodytxt.Add(ops, bodyst, body.Dx(), th.Text, "%s", k.Label)
+		}
 	}
 
 	op.Position(ops, ops.End(), body.Min.Add(image.Pt(0, scrollFadeDist)))
@@ -2314,6 +3025,9 @@ func (s *DescriptorScreen) Draw(ctx *Context, ops op.Ctx, th *Colors, dims image
 
 func NewEngraveScreen(ctx *Context, plate Plate) *EngraveScreen {
 	var ins []Instruction
+	if needleDue(ctx.Settings) {
+		ins = append(ins, needleInstruction(ctx.Settings))
+	}
 	if !ctx.Calibrated {
 		ins = append(ins, EngraveFirstSideA...)
 	} else {
@@ -2322,7 +3036,22 @@ func NewEngraveScreen(ctx *Context, plate Plate) *EngraveScreen {
 	if len(plate.Sides) > 1 {
 		ins = append(ins, EngraveSideB...)
 	}
+	// Sides after the second are the parts of a split descriptor.
+	for side := 2; side < len(plate.Sides); side++ {
+		ins = append(ins, engravePartInstructions(side, len(plate.Sides)-1)...)
+	}
 	ins = append(ins, EngraveSuccess...)
+	params := ctx.Platform.EngraverParams()
+	if r := params.SpreadRegion; r > 0 {
+		sides := slices.Clone(plate.Sides)
+		for i, side := range sides {
+			// Spreading breaks the constant time of the seed.
+			if i != plate.SeedSide {
+				sides[i] = engrave.Spread(r, side)
+			}
+		}
+		plate.Sides = sides
+	}
 	s := &EngraveScreen{
 		plate:        plate,
 		instructions: ins,
@@ -2330,12 +3059,19 @@ func NewEngraveScreen(ctx *Context, plate Plate) *EngraveScreen {
 	for i, ins := range s.instructions {
 		repl := strings.NewReplacer(
 			"{{.Name}}", plateName(plate.Size),
+			"{{.Flip}}", ctx.Settings.Flip.String(),
 		)
 		s.instructions[i].resolvedBody = repl.Replace(ins.Body)
 		// As a special case, the Sh02 image is a placeholder for the plate-specific image.
 		if ins.Image == assets.Sh02 {
 			s.instructions[i].Image = plateImage(plate.Size)
 		}
+		// A connect instruction is followed by the engraving it starts.
+		if ins.Type == ConnectInstruction && i+1 < len(s.instructions) {
+			if next := s.instructions[i+1]; next.Type == EngraveInstruction {
+				s.instructions[i].estimate, _ = engrave.Estimate(plate.Sides[next.Side], params)
+			}
+		}
 	}
 	return s
 }
@@ -2346,18 +3082,160 @@ type EngraveScreen struct {
 
 	step   int
 	dryRun struct {
-		timeout time.Time
 		enabled bool
+		// trace replaces the dry run of every side with a
+		// trace of its outline.
+		trace bool
 	}
 	engrave engraveState
 }
 
 type engraveState struct {
 	dev          Engraver
-	cancel       chan struct{}
-	progress     chan float32
-	errs         chan error
-	lastProgress float32
+	job          *engraveJob
+	lastProgress EngraveProgress
+	paused       bool
+	// pointer is set while the pointer light is on.
+	pointer bool
+	// stroke is the stroke length of the engraving, in
+	// machine units.
+	stroke int
+}
+
+// EngraveProgress describes the progress of an engraving.
+type EngraveProgress struct {
+	// Completed is the number of executed commands
+	// out of Total.
+	Completed, Total int
+	// Distance is the total needle distance of the plan
+	// and Remaining the distance left, in machine units.
+	Distance, Remaining int
+	// ETA is the estimated time to completion, or zero
+	// if not yet known.
+	ETA time.Duration
+}
+
+// Fraction returns the completed fraction of the needle
+// distance.
+func (p EngraveProgress) Fraction() float32 {
+	if p.Distance == 0 {
+		return 0
+	}
+	return 1 - float32(p.Remaining)/float32(p.Distance)
+}
+
+// engraveJob is an engraving running in the background.
+type engraveJob struct {
+	cancel   chan struct{}
+	pause    chan bool
+	progress chan EngraveProgress
+	errs     chan error
+	now      func() time.Time
+	eta      etaClock
+}
+
+// etaClock estimates the remaining time of an engraving from the
+// rate of its progress, excluding the time spent paused.
+type etaClock struct {
+	mu        sync.Mutex
+	start     time.Time
+	startDist int
+	pausedAt  time.Time
+	paused    time.Duration
+}
+
+// Pause records the start or the end of a pause at now.
+func (c *etaClock) Pause(now time.Time, pause bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case pause && c.pausedAt.IsZero():
+		c.pausedAt = now
+	case !pause && !c.pausedAt.IsZero():
+		c.paused += now.Sub(c.pausedAt)
+		c.pausedAt = time.Time{}
+	}
+}
+
+// Update records the progress of done out of total needle distance
+// at now, and returns the estimated time remaining, or zero if
+// unknown. The first update starts the clock.
+func (c *etaClock) Update(now time.Time, done, total int) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.start.IsZero() {
+		c.start, c.startDist, c.paused = now, done, 0
+		return 0
+	}
+	d := done - c.startDist
+	if d <= 0 {
+		return 0
+	}
+	elapsed := now.Sub(c.start) - c.paused
+	return time.Duration(float64(elapsed) * float64(total-done) / float64(d))
+}
+
+// startEngrave runs plan on dev in the background and
+// closes dev when done.
+func startEngrave(ctx *Context, dev Engraver, sz backup.PlateSize, plan engrave.Plan) *engraveJob {
+	// Record the cumulative needle distance at every command.
+	var dists []int
+	dist := 0
+	pen := image.Point{}
+	for cmd := range plan {
+		dist += engrave.ManhattanDist(pen, cmd.Coord)
+		pen = cmd.Coord
+		dists = append(dists, dist)
+	}
+	j := &engraveJob{
+		cancel:   make(chan struct{}),
+		pause:    make(chan bool, 1),
+		progress: make(chan EngraveProgress, 1),
+		errs:     make(chan error, 1),
+		now:      ctx.Platform.Now,
+	}
+	wakeup := ctx.Platform.Wakeup
+	go func() {
+		defer wakeup()
+		defer dev.Close()
+		report := func(completed int) {
+			p := EngraveProgress{
+				Completed: completed,
+				Total:     len(dists),
+				Distance:  dist,
+				Remaining: dist,
+			}
+			if completed > 0 {
+				p.Remaining = dist - dists[completed-1]
+			}
+			// Measure from the first command to exclude the
+			// time spent homing the needle.
+			p.ETA = j.eta.Update(j.now(), dist-p.Remaining, dist)
+			// Don't spam the progress channel.
+			if completed%10 != 0 && completed < len(dists) {
+				return
+			}
+			select {
+			case <-j.progress:
+			default:
+			}
+			j.progress <- p
+			wakeup()
+		}
+		j.errs <- dev.Engrave(sz, plan, report, j.pause, j.cancel)
+	}()
+	return j
+}
+
+// Pause requests the engraving to pause or resume.
+func (j *engraveJob) Pause(pause bool) {
+	j.eta.Pause(j.now(), pause)
+	// Replace any request not yet received.
+	select {
+	case <-j.pause:
+	default:
+	}
+	j.pause <- pause
 }
 
 func (s *EngraveScreen) showError(ctx *Context, ops op.Ctx, th *Colors, errScr *ErrorScreen) {
@@ -2376,6 +3254,13 @@ func (s *EngraveScreen) showError(ctx *Context, ops op.Ctx, th *Colors, errScr *
 
 func (s *EngraveScreen) moveStep(ctx *Context, ops op.Ctx, th *Colors) bool {
 	ins := s.instructions[s.step]
+	if n := s.step + 1; n < len(s.instructions) {
+		next := s.instructions[n]
+		if next.Type == EngraveInstruction && next.Side == 1 && !s.dryRun.enabled &&
+			sideMismatch(ctx.Settings, s.plate) && !s.confirmSideB(ctx, ops, th) {
+			return false
+		}
+	}
 	if ins.Type == ConnectInstruction {
 		if s.engrave.dev != nil {
 			return false
@@ -2384,13 +3269,13 @@ func (s *EngraveScreen) moveStep(ctx *Context, ops op.Ctx, th *Colors) bool {
 		dev, err := ctx.Platform.Engraver()
 		if err != nil {
 			log.Printf("gui: failed to connect to engraver: %v", err)
-			s.showError(ctx, ops, th, &ErrorScreen{
-				Title: "Connection Error",
-				Body:  fmt.Sprintf("Ensure the engraver is turned on and verify that it is connected to the middle port of this device.\n\nError details: %v", err),
-			})
+			s.showError(ctx, ops, th, connectionError(err))
 			return false
 		}
 		s.engrave.dev = dev
+		if d, ok := dev.(EngraverDevice); ok {
+			ctx.engraver = d.Device()
+		}
 	}
 	s.step++
 	if s.step == len(s.instructions) {
@@ -2400,63 +3285,63 @@ func (s *EngraveScreen) moveStep(ctx *Context, ops op.Ctx, th *Colors) bool {
 	if ins.Type == EngraveInstruction {
 		plan := s.plate.Sides[ins.Side]
 		if s.dryRun.enabled {
-			plan = engrave.DryRun(plan)
-		}
-		totalDist := 0
-		pen := image.Point{}
-		for cmd := range plan {
-			totalDist += engrave.ManhattanDist(pen, cmd.Coord)
-			pen = cmd.Coord
-		}
-		cancel := make(chan struct{})
-		errs := make(chan error, 1)
-		progress := make(chan float32, 1)
-		s.engrave.cancel = cancel
-		s.engrave.errs = errs
-		s.engrave.progress = progress
-		dev := s.engrave.dev
-		wakeup := ctx.Platform.Wakeup
-		go func() {
-			defer wakeup()
-			defer dev.Close()
-			pplan := func(yield func(cmd engrave.Command) bool) {
-				dist := 0
-				completed := 0
-				pen := image.Point{}
-				for cmd := range plan {
-					if !yield(cmd) {
-						return
-					}
-					completed++
-					dist += engrave.ManhattanDist(pen, cmd.Coord)
-					pen = cmd.Coord
-					// Don't spam the progress channel.
-					if completed%10 != 0 && dist < totalDist {
-						continue
-					}
-					select {
-					case <-progress:
-					default:
-					}
-					p := float32(dist) / float32(totalDist)
-					progress <- p
-					wakeup()
-				}
+			if s.dryRun.trace {
+				plan = engrave.Outline(plan)
+				ctx.Platform.Pointer(true)
+				s.engrave.pointer = true
+			} else {
+				plan = engrave.DryRun(plan)
 			}
-			errs <- dev.Engrave(s.plate.Size, pplan, cancel)
-		}()
+		}
+		o := ctx.Settings.Origin
+		plan = engrave.Offset(o.X, o.Y, plan)
+		s.engrave.job = startEngrave(ctx, s.engrave.dev, s.plate.Size, plan)
+		s.engrave.lastProgress = EngraveProgress{}
+		_, s.engrave.stroke = engrave.Estimate(plan, ctx.Platform.EngraverParams())
 	}
 	return false
 }
 
+// connectionError describes an error from connecting to the
+// engraver.
+func connectionError(err error) *ErrorScreen {
+	switch {
+	case errors.Is(err, ErrEngraverNoResponse):
+		return &ErrorScreen{
+			Title: "No Response",
+			Body:  "The engraver didn't respond. Ensure it is turned on and connected to the middle port of this device.",
+		}
+	case errors.Is(err, ErrEngraverUnknown):
+		return &ErrorScreen{
+			Title: "Unknown Engraver",
+			Body:  "The device connected to the middle port is not a supported engraver.",
+		}
+	case errors.Is(err, ErrEngraverBusy):
+		return &ErrorScreen{
+			Title: "Engraver Busy",
+			Body:  "The engraver is still running a previous job. Turn it off and on again, then retry.",
+		}
+	}
+	return &ErrorScreen{
+		Title: "Connection Error",
+		Body:  fmt.Sprintf("Ensure the engraver is turned on and verify that it is connected to the middle port of this device.\n\nError details: %v", err),
+	}
+}
+
 func (s *EngraveScreen) canPrev() bool {
 	return s.step > 0 && s.instructions[s.step-1].Type == PrepareInstruction
 }
 
 func (s *EngraveScreen) Engrave(ctx *Context, ops op.Ctx, th *Colors) bool {
+	// Engravings outlast the idle timeout; keep the secrets.
+	ctx.secrets.holds++
 	defer func() {
-		if s.engrave.cancel != nil {
-			close(s.engrave.cancel)
+		ctx.secrets.holds--
+		if s.engrave.job != nil {
+			close(s.engrave.job.cancel)
+		}
+		if s.engrave.pointer {
+			ctx.Platform.Pointer(false)
 		}
 		s.engrave = engraveState{}
 	}()
@@ -2464,11 +3349,28 @@ func (s *EngraveScreen) Engrave(ctx *Context, ops op.Ctx, th *Colors) bool {
 	for {
 	loop:
 		for {
+			var progress <-chan EngraveProgress
+			var errs <-chan error
+			if j := s.engrave.job; j != nil {
+				progress, errs = j.progress, j.errs
+			}
 			select {
-			case p := <-s.engrave.progress:
+			case p := <-progress:
 				s.engrave.lastProgress = p
-			case err := <-s.engrave.errs:
+			case err := <-errs:
+				stroke := s.engrave.stroke
+				if s.engrave.pointer {
+					ctx.Platform.Pointer(false)
+				}
 				s.engrave = engraveState{}
+				if errors.Is(err, ErrEmergencyStop) {
+					s.step--
+					s.showError(ctx, ops, th, &ErrorScreen{
+						Title: "Engraving Stopped",
+						Body:  "The engraving was halted by the emergency stop.\n\nInspect the machine and the plate before engraving again.",
+					})
+					break
+				}
 				if err != nil {
 					log.Printf("gui: connection lost to engraver: %v", err)
 					s.step--
@@ -2479,6 +3381,10 @@ func (s *EngraveScreen) Engrave(ctx *Context, ops op.Ctx, th *Colors) bool {
 					break
 				}
 				ctx.Calibrated = true
+				recordWear(ctx, stroke)
+				if !s.dryRun.enabled && s.instructions[s.step].Side == 0 {
+					recordSideA(ctx, s.plate)
+				}
 				s.step++
 				if s.step == len(s.instructions) {
 					return true
@@ -2488,17 +3394,8 @@ func (s *EngraveScreen) Engrave(ctx *Context, ops op.Ctx, th *Colors) bool {
 			}
 		}
 
-	outer:
 		for {
 			ins := s.instructions[s.step]
-			if !s.dryRun.timeout.IsZero() {
-				now := ctx.Platform.Now()
-				d := s.dryRun.timeout.Sub(now)
-				if d <= 0 {
-					s.dryRun.timeout = time.Time{}
-					s.dryRun.enabled = !s.dryRun.enabled
-				}
-			}
 			e, ok := inp.Next(ctx, Button1, Button2, Button3)
 			if !ok {
 				break
@@ -2533,41 +3430,34 @@ func (s *EngraveScreen) Engrave(ctx *Context, ops op.Ctx, th *Colors) bool {
 					}
 				}
 			case Button2:
-				if e.Pressed {
-					t := ctx.Platform.Now().Add(confirmDelay)
-					s.dryRun.timeout = t
-					ctx.WakeupAt(t)
-				} else {
-					s.dryRun.timeout = time.Time{}
+				if ins.Type == EngraveInstruction {
+					// Pause on long presses as well, for users
+					// holding the button.
+					if s.engrave.job != nil && (inp.Clicked(e.Button) || e.Gesture == LongPress) {
+						s.engrave.paused = !s.engrave.paused
+						s.engrave.job.Pause(s.engrave.paused)
+					}
+					break
+				}
+				if e.Gesture == LongPress {
+					// Cycle through dry-run, outline trace and
+					// engraving.
+					d := &s.dryRun
+					switch {
+					case !d.enabled:
+						d.enabled = true
+					case !d.trace:
+						d.trace = true
+					default:
+						d.enabled, d.trace = false, false
+					}
 				}
 			case Button3:
 				switch ins.Type {
 				case ConnectInstruction:
-					if !e.Pressed {
+					if e.Gesture != LongPress {
 						continue
 					}
-					confirm := new(ConfirmDelay)
-					confirm.Start(ctx, confirmDelay)
-					inp.Pressed[e.Button] = false
-					for {
-						p := confirm.Progress(ctx)
-						if p == 1. {
-							break
-						}
-						for {
-							e, ok := inp.Next(ctx, Button3)
-							if !ok {
-								break
-							}
-							if e.Button == Button3 && !e.Pressed {
-								continue outer
-							}
-						}
-						dims := ctx.Platform.DisplaySize()
-						s.draw(ctx, ops, th, dims)
-						s.drawNav(inp, ops, th, dims, p)
-						ctx.Frame()
-					}
 				case EngraveInstruction:
 					continue
 				default:
@@ -2583,7 +3473,7 @@ func (s *EngraveScreen) Engrave(ctx *Context, ops op.Ctx, th *Colors) bool {
 
 		dims := ctx.Platform.DisplaySize()
 		s.draw(ctx, ops, th, dims)
-		s.drawNav(inp, ops, th, dims, 0)
+		s.drawNav(ctx, inp, ops, th, dims)
 
 		ctx.Frame()
 	}
@@ -2595,7 +3485,7 @@ func (s *EngraveScreen) draw(ctx *Context, ops op.Ctx, th *Colors, dims image.Po
 
 	r := layout.Rectangle{Max: dims}
 	_, subt := r.CutTop(leadingSize)
-	subtsz := widget.Labelf(ops.Begin(), ctx.Styles.body, th.Text, "%.8x", s.plate.MasterFingerprint)
+	subtsz := widget.Labelf(ops.Begin(), ctx.Styles.mono, th.Text, "%.8x", s.plate.MasterFingerprint)
 	op.Position(ops, ops.End(), subt.N(subtsz).Sub(image.Pt(0, 4)))
 
 	const margin = 8
@@ -2606,11 +3496,11 @@ func (s *EngraveScreen) draw(ctx *Context, ops op.Ctx, th *Colors, dims image.Po
 		middle, _ := content.CutBottom(leadingSize)
 		op.Offset(ops, middle.Center(assets.ProgressCircle.Bounds().Size()))
 		(&ProgressImage{
-			Progress: s.engrave.lastProgress,
+			Progress: s.engrave.lastProgress.Fraction(),
 			Src:      assets.ProgressCircle,
 		}).Add(ops)
 		op.ColorOp(ops, th.Text)
-		sz := widget.Labelf(ops.Begin(), ctx.Styles.progress, th.Text, "%d%%", int(s.engrave.lastProgress*100))
+		sz := widget.Labelf(ops.Begin(), ctx.Styles.progress, th.Text, "%d%%", int(s.engrave.lastProgress.Fraction()*100))
 		op.Position(ops, ops.End(), middle.Center(sz))
 	}
 	content = content.Shrink(0, margin, 0, margin)
@@ -2626,36 +3516,63 @@ func (s *EngraveScreen) draw(ctx *Context, ops op.Ctx, th *Colors, dims image.Po
 		bodysz.Y += sz.Y
 	}
 	op.Position(ops, ops.End(), content.Center(bodysz))
-	leadsz := widget.Labelwf(ops.Begin(), ctx.Styles.lead, dims.X-2*margin, th.Text, ins.Lead)
+	leadText := ins.Lead
+	if ins.Type == ConnectInstruction && ins.estimate > 0 {
+		leadText = formatEstimate(ins.estimate)
+		if ins.Lead != "" {
+			leadText = fmt.Sprintf("%s\n%s", ins.Lead, leadText)
+		}
+	}
+	if ins.Type == EngraveInstruction {
+		if s.engrave.paused {
+			leadText = "Engraving paused"
+		} else if eta := s.engrave.lastProgress.ETA; eta > 0 {
+			leadText = fmt.Sprintf("%s\n%s", ins.Lead, formatETA(eta))
+		}
+	}
+	leadsz := widget.Labelwf(ops.Begin(), ctx.Styles.lead, dims.X-2*margin, th.Text, leadText)
 	op.Position(ops, ops.End(), lead.Center(leadsz))
 
 	progressw := dims.X * (s.step + 1) / len(s.instructions)
 	op.ClipOp(image.Rectangle{Max: image.Pt(progressw, 2)}).Add(ops)
 	op.ColorOp(ops, th.Text)
 
+	if ins.Type == ConnectInstruction && ctx.engraver != "" {
+		sz := widget.Labelf(ops.Begin(), ctx.Styles.debug, th.Text, "%s", ctx.engraver)
+		op.Position(ops, ops.End(), r.SW(sz).Add(image.Pt(4, 0)))
+	}
 	if s.dryRun.enabled {
-		sz := widget.Labelf(ops.Begin(), ctx.Styles.debug, th.Text, "dry-run")
+		mode := "dry-run"
+		if s.dryRun.trace {
+			mode = "trace outline"
+		}
+		sz := widget.Labelf(ops.Begin(), ctx.Styles.debug, th.Text, mode)
 		op.Position(ops, ops.End(), r.SE(sz).Sub(image.Pt(4, 0)))
 	}
 }
 
-func (s *EngraveScreen) drawNav(inp *InputTracker, ops op.Ctx, th *Colors, dims image.Point, progress float32) {
+func (s *EngraveScreen) drawNav(ctx *Context, inp *InputTracker, ops op.Ctx, th *Colors, dims image.Point) {
 	icnBack := assets.IconBack
 	if s.canPrev() {
 		icnBack = assets.IconLeft
 	}
-	layoutNavigation(inp, ops, th, dims, []NavButton{{Button: Button1, Style: StyleSecondary, Icon: icnBack}}...)
+	layoutNavigation(ctx, inp, ops, th, dims, []NavButton{{Button: Button1, Style: StyleSecondary, Icon: icnBack}}...)
 	ins := s.instructions[s.step]
 	switch ins.Type {
 	case EngraveInstruction:
+		icn := assets.IconPause
+		if s.engrave.paused {
+			icn = assets.IconPlay
+		}
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{{Button: Button2, Style: StyleSecondary, Icon: icn}}...)
 	case ConnectInstruction:
-		layoutNavigation(inp, ops, th, dims, []NavButton{{Button: Button3, Style: StylePrimary, Icon: assets.IconHammer, Progress: progress}}...)
+		progress := inp.Progress(ctx, Button3)
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{{Button: Button3, Style: StylePrimary, Icon: assets.IconHammer, Progress: progress}}...)
 	default:
-		layoutNavigation(inp, ops, th, dims, []NavButton{{
-			Button:   Button3,
-			Style:    StylePrimary,
-			Icon:     assets.IconRight,
-			Progress: progress,
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{{
+			Button: Button3,
+			Style:  StylePrimary,
+			Icon:   assets.IconRight,
 		}}...)
 	}
 }
@@ -2666,7 +3583,9 @@ type Platform interface {
 	PlateSizes() []backup.PlateSize
 	Engraver() (Engraver, error)
 	EngraverParams() engrave.Params
-	CameraFrame(size image.Point)
+	// CameraFrame requests a camera frame of size with the
+	// controls applied.
+	CameraFrame(size image.Point, ctrls CameraControls)
 	Now() time.Time
 	DisplaySize() image.Point
 	// Dirty begins a refresh of the content
@@ -2676,18 +3595,103 @@ type Platform interface {
 	NextChunk() (draw.RGBA64Image, bool)
 	ScanQR(qr *image.Gray) ([][]byte, error)
 	Debug() bool
+	// LoadSettings loads the persisted settings.
+	LoadSettings() (Settings, error)
+	// StoreSettings persists settings.
+	StoreSettings(s Settings) error
+	// DeviceSecret returns a random secret bound to the device and
+	// not stored with the settings, for encrypting and signing data
+	// stored with the settings. It returns ErrNoDeviceSecret until
+	// ProvisionSecret is called.
+	DeviceSecret() ([]byte, error)
+	// ProvisionSecret creates the device secret. It may be
+	// irreversible, so it is only called when the user confirms.
+	ProvisionSecret() error
+	// ExportFile writes a file next to the settings, for reading on
+	// another computer.
+	ExportFile(name string, data []byte) error
+	// ImportFiles reads the files next to the settings with the
+	// extension ext, written on another computer. The files are
+	// keyed by name.
+	ImportFiles(ext string) (map[string][]byte, error)
+	// Beep sounds a short audible acknowledgment, if the device
+	// has a buzzer.
+	Beep()
+	// Pointer switches the light that marks the needle position
+	// during outline traces, if the device has one.
+	Pointer(on bool)
+}
+
+// formatETA formats the estimated time remaining of an
+// engraving.
+func formatETA(eta time.Duration) string {
+	if eta < time.Minute {
+		return "Less than a minute left"
+	}
+	return fmt.Sprintf("About %d min left", int((eta+time.Minute-1)/time.Minute))
+}
+
+// formatEstimate formats the estimated duration of an
+// engraving.
+func formatEstimate(d time.Duration) string {
+	if d < time.Minute {
+		return "Takes less than a minute"
+	}
+	return fmt.Sprintf("Takes about %d min", int((d+time.Minute-1)/time.Minute))
 }
 
 type Engraver interface {
-	Engrave(sz backup.PlateSize, plan engrave.Plan, quit <-chan struct{}) error
+	// Engrave engraves plan and reports the number of
+	// executed commands to progress. A true value received
+	// from pause pauses the engraving at the next move, false
+	// resumes it.
+	Engrave(sz backup.PlateSize, plan engrave.Plan, progress func(completed int), pause <-chan bool, quit <-chan struct{}) error
 	Close()
 }
 
+// EngraverDevice is implemented by engravers that know the path of
+// their device, such as an automatically detected serial port.
+type EngraverDevice interface {
+	Device() string
+}
+
+// ErrEmergencyStop is returned by Engraver.Engrave when the engraving
+// was halted by the emergency stop switch.
+var ErrEmergencyStop = errors.New("emergency stop")
+
+// Errors returned by Platform.Engraver when the engraver
+// fails its handshake.
+var (
+	// ErrEngraverNoResponse means that no engraver responded.
+	ErrEngraverNoResponse = errors.New("engraver not responding")
+	// ErrEngraverUnknown means that the connected device is not a
+	// supported engraver.
+	ErrEngraverUnknown = errors.New("unknown engraver")
+	// ErrEngraverBusy means that the engraver is executing a
+	// program, such as one left from an interrupted engraving.
+	ErrEngraverBusy = errors.New("engraver busy")
+)
+
 type FrameEvent struct {
 	Error error
 	Image image.Image
 }
 
+// CameraControls adjust the camera. The zero value is the automatic
+// camera configuration.
+type CameraControls struct {
+	// ZoomLevel selects a digital zoom factor of 2^ZoomLevel.
+	ZoomLevel int
+	// Exposure is the exposure compensation in half stops.
+	Exposure int
+}
+
+// Camera control limits.
+const (
+	maxZoomLevel = 2
+	maxExposure  = 4
+)
+
 type Event struct {
 	typ  int
 	data [4]uint32
@@ -2698,6 +3702,8 @@ const (
 	buttonEvent = 1 + iota
 	sdcardEvent
 	frameEvent
+	emergencyStopEvent
+	pointerEvent
 )
 
 type ButtonEvent struct {
@@ -2705,12 +3711,27 @@ type ButtonEvent struct {
 	Pressed bool
 	// Rune is only valid if Button is Rune.
 	Rune rune
+	// Gesture is set by InputTracker for recognized gestures.
+	Gesture Gesture
 }
 
 type SDCardEvent struct {
 	Inserted bool
 }
 
+// EmergencyStopEvent reports a change in the state of the
+// external emergency stop switch.
+type EmergencyStopEvent struct {
+	Triggered bool
+}
+
+// PointerEvent reports a press, drag or release of a touch or mouse
+// pointer at a position on the display.
+type PointerEvent struct {
+	Pos     image.Point
+	Pressed bool
+}
+
 type Button int
 
 const (
@@ -2757,8 +3778,6 @@ func (b Button) String() string {
 	}
 }
 
-const idleTimeout = 3 * time.Minute
-
 func Run(pl Platform, version string) func(yield func() bool) {
 	return func(yield func() bool) {
 		ctx := NewContext(pl)
@@ -2771,6 +3790,12 @@ func Run(pl Platform, version string) func(yield func() bool) {
 				start  time.Time
 				active bool
 				state  saver.State
+				// blanked is set when the privacy blank has
+				// cleared the screen.
+				blanked bool
+				// pressed is the start of the long press that
+				// ends the privacy blank.
+				pressed time.Time
 			}
 		}{
 			ctx: ctx,
@@ -2779,11 +3804,28 @@ func Run(pl Platform, version string) func(yield func() bool) {
 
 		it := func(yield func() bool) {
 			stop := new(int)
-			ctx.Frame = func() {
+			frame := func() {
 				if !yield() {
 					panic(stop)
 				}
 			}
+			var guarded func()
+			guarded = func() {
+				frame()
+				// Take over the screen until the emergency stop
+				// is released and acknowledged.
+				if ctx.EmergencyStop {
+					ctx.Frame = frame
+					emergencyStopFlow(ctx, a.root.Context())
+					ctx.Frame = guarded
+				}
+				// Abandon the flow whose secrets were wiped
+				// while idle.
+				if ctx.secrets.expired {
+					panic(expiredPanic{})
+				}
+			}
+			ctx.Frame = guarded
 			defer func() {
 				if err := recover(); err != stop {
 					panic(err)
@@ -2794,26 +3836,41 @@ func Run(pl Platform, version string) func(yield func() bool) {
 		startTime := time.Now()
 		var evts []Event
 		for range it {
-			dirty := a.root.Clip(image.Rectangle{Max: a.ctx.Platform.DisplaySize()})
+			dims := a.ctx.Platform.DisplaySize()
+			// Refresh only the changed areas, to reduce the transfers
+			// to the display.
+			damage := a.root.Damage(image.Rectangle{Max: dims})
 			layoutTime := time.Now()
-			if err := a.ctx.Platform.Dirty(dirty); err != nil {
-				panic(err)
-			}
-			for {
-				fb, ok := a.ctx.Platform.NextChunk()
-				if !ok {
-					break
+			rotated := a.ctx.Settings.Orientation == OrientRotated
+			for _, dirty := range damage {
+				if rotated {
+					dirty = rotateRect(dirty, dims)
+				}
+				if err := a.ctx.Platform.Dirty(dirty); err != nil {
+					panic(err)
 				}
-				fbdims := fb.Bounds().Size()
-				if a.mask == nil || fbdims != a.mask.Bounds().Size() {
-					a.mask = image.NewAlpha(image.Rectangle{Max: fbdims})
+				for {
+					fb, ok := a.ctx.Platform.NextChunk()
+					if !ok {
+						break
+					}
+					if rotated {
+						fb = &rotatedImage{fb: fb, dims: dims}
+					}
+					// Reuse the mask for chunks that fit.
+					fbr := image.Rectangle{Max: fb.Bounds().Size()}
+					if a.mask == nil {
+						a.mask = image.NewAlpha(fbr)
+					} else if !fbr.In(a.mask.Bounds()) {
+						a.mask = image.NewAlpha(fbr.Union(a.mask.Bounds()))
+					}
+					a.root.Draw(fb, a.mask)
 				}
-				a.root.Draw(fb, a.mask)
 			}
 			drawTime := time.Now()
 			if a.ctx.Platform.Debug() {
 				log.Printf("frame: %v layout: %v draw: %v %v",
-					drawTime.Sub(startTime), layoutTime.Sub(startTime), drawTime.Sub(layoutTime), dirty)
+					drawTime.Sub(startTime), layoutTime.Sub(startTime), drawTime.Sub(layoutTime), damage)
 			}
 			for {
 				if !yield() {
@@ -2821,28 +3878,64 @@ func Run(pl Platform, version string) func(yield func() bool) {
 				}
 				wakeup := a.ctx.Wakeup
 				a.ctx.Reset()
+				blank := a.idle.active && a.ctx.Settings.Saver == SaverBlank
 				for _, e := range a.ctx.Platform.AppendEvents(wakeup, evts[:0]) {
+					if be, ok := e.AsButton(); ok && blank {
+						// Only a long press ends the privacy blank.
+						a.idle.pressed = time.Time{}
+						if be.Pressed {
+							a.idle.pressed = a.ctx.Platform.Now()
+						}
+						continue
+					}
 					a.idle.start = a.ctx.Platform.Now()
 					if se, ok := e.AsSDCard(); ok {
-						a.ctx.EmptySDSlot = !se.Inserted
+						a.ctx.sdCard(se)
+					} else if ee, ok := e.AsEmergencyStop(); ok {
+						a.ctx.EmergencyStop = ee.Triggered
+					} else if be, ok := e.AsButton(); ok && a.ctx.Settings.Orientation == OrientRotated {
+						be.Button = rotateButton(be.Button)
+						a.ctx.Events(be.Event())
 					} else {
 						a.ctx.Events(e)
 					}
 					wakeup = time.Time{}
 				}
-				idleWakeup := a.idle.start.Add(idleTimeout)
 				now := a.ctx.Platform.Now()
+				if blank && !a.idle.pressed.IsZero() {
+					if resume := a.idle.pressed.Add(confirmDelay); now.Before(resume) {
+						a.ctx.WakeupAt(resume)
+					} else {
+						a.idle.start = now
+					}
+				}
+				idleWakeup := a.idle.start.Add(a.ctx.Settings.IdleTimeout.duration())
 				idle := now.Sub(idleWakeup) >= 0
 				if a.idle.active != idle {
 					a.idle.active = idle
+					a.idle.blanked = false
+					a.idle.pressed = time.Time{}
 					if idle {
 						a.idle.state = saver.State{}
+						// Don't leave seeds in memory, or on
+						// screen after the saver.
+						a.ctx.secrets.expire()
+						a.ctx.Unlocked = false
 					} else {
 						// The screen saver has invalidated the cached
 						// frame content.
 						a.root = op.Ops{}
 					}
 				}
+				if a.idle.active && a.ctx.Settings.Saver == SaverBlank {
+					if !a.idle.blanked {
+						a.idle.blanked = true
+						blankScreen(a.ctx.Platform)
+					}
+					// Nothing to animate; sleep until the next event.
+					a.ctx.WakeupAt(now.Add(time.Hour))
+					continue
+				}
 				if a.idle.active {
 					a.idle.state.Draw(a.ctx.Platform)
 					// Throttle screen saver speed.
@@ -2859,6 +3952,21 @@ func Run(pl Platform, version string) func(yield func() bool) {
 	}
 }
 
+// blankScreen clears the entire display.
+func blankScreen(p Platform) {
+	dims := p.DisplaySize()
+	if err := p.Dirty(image.Rectangle{Max: dims}); err != nil {
+		panic(err)
+	}
+	for {
+		fb, ok := p.NextChunk()
+		if !ok {
+			break
+		}
+		draw.Draw(fb, fb.Bounds(), image.Black, image.Point{}, draw.Src)
+	}
+}
+
 func rgb(c uint32) color.NRGBA {
 	return argb(0xff000000 | c)
 }
@@ -2919,6 +4027,43 @@ func (e Event) AsButton() (ButtonEvent, bool) {
 	}, true
 }
 
+func (s EmergencyStopEvent) Event() Event {
+	e := Event{typ: emergencyStopEvent}
+	if s.Triggered {
+		e.data[0] = 1
+	}
+	return e
+}
+
+func (e Event) AsEmergencyStop() (EmergencyStopEvent, bool) {
+	if e.typ != emergencyStopEvent {
+		return EmergencyStopEvent{}, false
+	}
+	return EmergencyStopEvent{
+		Triggered: e.data[0] != 0,
+	}, true
+}
+
+func (p PointerEvent) Event() Event {
+	e := Event{typ: pointerEvent}
+	e.data[0] = uint32(int32(p.Pos.X))
+	e.data[1] = uint32(int32(p.Pos.Y))
+	if p.Pressed {
+		e.data[2] = 1
+	}
+	return e
+}
+
+func (e Event) AsPointer() (PointerEvent, bool) {
+	if e.typ != pointerEvent {
+		return PointerEvent{}, false
+	}
+	return PointerEvent{
+		Pos:     image.Pt(int(int32(e.data[0])), int(int32(e.data[1]))),
+		Pressed: e.data[2] != 0,
+	}, true
+}
+
 func (e Event) AsSDCard() (SDCardEvent, bool) {
 	if e.typ != sdcardEvent {
 		return SDCardEvent{}, false
@@ -2,9 +2,11 @@ package gui
 
 import (
 	"bytes"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"image"
+	"image/color"
 	"image/draw"
 	"image/png"
 	"io"
@@ -12,20 +14,26 @@ import (
 	"math"
 	"os"
 	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/kortschak/qr"
+	"seedhammer.com/address"
 	"seedhammer.com/backup"
+	"seedhammer.com/bc/ur"
 	"seedhammer.com/bc/urtypes"
 	"seedhammer.com/bip32"
 	"seedhammer.com/bip39"
 	"seedhammer.com/driver/mjolnir"
 	"seedhammer.com/engrave"
 	"seedhammer.com/font/constant"
+	"seedhammer.com/font/poppins"
 	"seedhammer.com/gui/op"
 	"seedhammer.com/nonstandard"
 	"seedhammer.com/seedqr"
@@ -43,8 +51,8 @@ func TestDescriptorScreenError(t *testing.T) {
 	smallDesc := urtypes.OutputDescriptor{
 		Script:    urtypes.P2WSH,
 		Type:      urtypes.SortedMulti,
-		Threshold: 2,
-		Keys:      make([]urtypes.KeyDescriptor, 5),
+		Threshold: 1,
+		Keys:      make([]urtypes.KeyDescriptor, 20),
 	}
 	smallMnemonic := fillDescriptor(t, smallDesc, smallDesc.Script.DerivationPath(), 12, 0)
 	okDesc := urtypes.OutputDescriptor{
@@ -83,6 +91,268 @@ func TestDescriptorScreenError(t *testing.T) {
 	}
 }
 
+func TestDescriptorScreenOverride(t *testing.T) {
+	desc := urtypes.OutputDescriptor{
+		Script:    urtypes.P2WPKH,
+		Type:      urtypes.Singlesig,
+		Threshold: 1,
+		Keys:      make([]urtypes.KeyDescriptor, 1),
+	}
+	fillDescriptor(t, desc, desc.Script.DerivationPath(), 12, 0)
+	mfp := fmt.Sprintf("%.8X", desc.Keys[0].MasterFingerprint)
+	tests := []struct {
+		name  string
+		typed string
+		ok    bool
+	}{
+		{"matching fingerprint", mfp, true},
+		{"other fingerprint", "00000000", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newPlatform()
+			ctx := NewContext(p)
+			// A seed of another wallet.
+			scr := &DescriptorScreen{
+				Descriptor: desc,
+				Mnemonic:   twoOfThree.Mnemonic,
+			}
+			ops := new(op.Ops)
+			var ok, exited bool
+			frame, quit := iter.Pull(runUI(ctx, func() {
+				_, ok = scr.Confirm(ctx, ops.Context(), &descriptorTheme)
+				exited = true
+			}))
+			defer quit()
+			frame = resetOps(ops, frame)
+			ctxButton(ctx, Button3)
+			frame()
+			if !opsContains(ops, mfp) {
+				t.Error("wallet fingerprint not shown")
+			}
+			// Hold confirm.
+			ctxPress(ctx, Button3)
+			frame()
+			p.timeOffset += confirmDelay
+			frame()
+			ctxString(ctx, test.typed)
+			ctxButton(ctx, Button2)
+			frame()
+			if exited != test.ok || ok != test.ok {
+				t.Errorf("typing %s: confirmed %v, want %v", test.typed, ok, test.ok)
+			}
+		})
+	}
+}
+
+func TestDescriptorScreenRecoveryReport(t *testing.T) {
+	scr := &DescriptorScreen{
+		Descriptor: twoOfThree.Descriptor,
+		Mnemonic:   twoOfThree.Mnemonic,
+	}
+	p := newPlatform()
+	ctx := NewContext(p)
+	ops := new(op.Ops)
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		scr.Confirm(ctx, ops.Context(), &descriptorTheme)
+	}))
+	defer quit()
+	frame = resetOps(ops, frame)
+	ctxButton(ctx, Right)
+	frame()
+	if !opsContains(ops, "Recovery Verified") {
+		t.Fatal("recovery self-test not reported")
+	}
+	// Export the recovery sheet.
+	ctxButton(ctx, Button3)
+	frame()
+	name := fmt.Sprintf("recovery-%.8x.txt", walletID(twoOfThree.Descriptor))
+	if sheet := p.exported[name]; !bytes.Contains(sheet, []byte("2-of-3")) {
+		t.Errorf("recovery sheet %s not exported:\n%s", name, sheet)
+	}
+	if want := "1: 1\n2: 2\n3: 1 xor 2"; shardingTable(twoOfThree.Descriptor) != want {
+		t.Errorf("2-of-3 sharding table:\n%s\nwant\n%s", shardingTable(twoOfThree.Descriptor), want)
+	}
+}
+
+func TestRecoverWallet(t *testing.T) {
+	desc := twoOfThree.Descriptor
+	p := newPlatform()
+	ctx := NewContext(p)
+	ops := new(op.Ops)
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		recoverWalletFlow(ctx, ops.Context(), &descriptorTheme)
+	}))
+	defer quit()
+	frame = resetOps(ops, frame)
+	// Scan the descriptor parts of the first two plates.
+	data := desc.Encode()
+	for part := range 2 {
+		ctxQR(t, ctx, p, strings.ToUpper(ur.Encode("crypto-output", data, part+1, 2)))
+		for range 10 {
+			frame()
+		}
+	}
+	if !opsContains(ops, "Wallet Recovered") {
+		t.Fatal("wallet not recovered")
+	}
+	ctxButton(ctx, Button3)
+	frame()
+	want, err := nonstandard.FormatOutputDescriptor(desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := fmt.Sprintf("wallet-%.8x.txt", walletID(desc))
+	if got := string(p.exported[name]); got != want+"\n" {
+		t.Errorf("exported descriptor %s:\n%s\nwant\n%s", name, got, want)
+	}
+}
+
+func TestDescriptorUR(t *testing.T) {
+	desc := twoOfThree.Descriptor
+	enc := descriptorUR(desc)
+	if enc.SeqLen() < 2 {
+		t.Fatalf("descriptor UR of %d parts, want multiple", enc.SeqLen())
+	}
+	var d ur.Decoder
+	for range enc.SeqLen() {
+		if err := d.Add(enc.Next()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	typ, data, err := d.Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := urtypes.Parse(typ, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, desc) {
+		t.Errorf("decoded %v, want %v", got, desc)
+	}
+
+	p := newPlatform()
+	ctx := NewContext(p)
+	ops := new(op.Ops)
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		showUR(ctx, ops.Context(), &descriptorTheme, "Descriptor", "Import into a wallet", descriptorUR(desc))
+	}))
+	defer quit()
+	frame = resetOps(ops, frame)
+	frame()
+	first := renderUI(ops)
+	frame()
+	if !reflect.DeepEqual(renderUI(ops), first) {
+		t.Error("UR part changed before its delay")
+	}
+	p.timeOffset += urFrameDelay
+	frame()
+	if reflect.DeepEqual(renderUI(ops), first) {
+		t.Error("UR part didn't change after its delay")
+	}
+}
+
+func TestAddressesScreen(t *testing.T) {
+	desc := twoOfThree.Descriptor
+	ctx := NewContext(newPlatform())
+	ops := new(op.Ops)
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		ShowAddressesScreen(ctx, ops.Context(), &descriptorTheme, desc)
+	}))
+	defer quit()
+	frame = resetOps(ops, frame)
+	frame()
+	if opsContains(ops, fmt.Sprintf("%d:", address.GapLimit+1)) {
+		t.Fatal("addresses beyond the first batch derived before scrolling")
+	}
+	// Scroll past the first batch.
+	derived := false
+	for range address.GapLimit {
+		ctxButton(ctx, Down)
+		for range 5 {
+			frame()
+		}
+		if opsContains(ops, fmt.Sprintf("%d:", address.GapLimit+1)) {
+			derived = true
+			break
+		}
+	}
+	if !derived {
+		t.Error("no addresses derived when scrolling")
+	}
+	// Change addresses start from index 0.
+	ctxButton(ctx, Right)
+	frame()
+	addr, err := address.Change(desc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opsContains(ops, "1:"+addr[:4]) || !opsContains(ops, addr[len(addr)-4:]) {
+		t.Error("first change address missing")
+	}
+}
+
+func TestEngravePlateSplit(t *testing.T) {
+	// A 1-of-5 descriptor is too large for any plate.
+	desc := urtypes.OutputDescriptor{
+		Script:    urtypes.P2WSH,
+		Threshold: 1,
+		Type:      urtypes.SortedMulti,
+		Keys:      make([]urtypes.KeyDescriptor, 5),
+	}
+	m := fillDescriptor(t, desc, desc.Script.DerivationPath(), 24, 0)
+	p := newPlatform()
+	if err := validateDescriptor(p.EngraverParams(), Settings{}, desc); err != nil {
+		t.Fatal(err)
+	}
+	plate, err := engravePlate(p.PlateSizes(), p.EngraverParams(), Settings{}, desc, 0, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plate.Sides) < 3 {
+		t.Fatalf("descriptor not split across plates, %d sides", len(plate.Sides))
+	}
+	scr := NewEngraveScreen(NewContext(p), plate)
+	parts := len(plate.Sides) - 1
+	found := false
+	for _, ins := range scr.instructions {
+		if strings.Contains(ins.resolvedBody, fmt.Sprintf("part %d of %d", parts, parts)) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("no instructions for the last part")
+	}
+}
+
+func TestDescriptorScreenLabel(t *testing.T) {
+	desc := twoOfThree.Descriptor
+	scr := &DescriptorScreen{
+		Descriptor: desc,
+		Mnemonic:   twoOfThree.Mnemonic,
+	}
+	ctx := NewContext(newPlatform())
+	// Edit label, enter and confirm it, back.
+	ctxButton(ctx, Center)
+	ctxString(ctx, "Home safe")
+	ctxButton(ctx, Button2, Button1)
+	for range runUI(ctx, func() {
+		scr.Confirm(ctx, op.Ctx{}, &descriptorTheme)
+	}) {
+	}
+	keyIdx, ok := descriptorKeyIdx(desc, twoOfThree.Mnemonic, "")
+	if !ok {
+		t.Fatal("seed doesn't match descriptor")
+	}
+	if got, want := scr.Descriptor.Keys[keyIdx].Label, "HOME SAFE"; got != want {
+		t.Errorf("label is %q, want %q", got, want)
+	}
+	if desc.Keys[keyIdx].Label != "" {
+		t.Error("label modified the scanned descriptor")
+	}
+}
+
 func TestValidateDescriptor(t *testing.T) {
 	// Duplicate key.
 	dup := urtypes.OutputDescriptor{
@@ -94,12 +364,12 @@ func TestValidateDescriptor(t *testing.T) {
 	fillDescriptor(t, dup, dup.Script.DerivationPath(), 12, 0)
 	dup.Keys[1] = dup.Keys[0]
 
-	// Threshold too small.
+	// Too large even when split across plates.
 	smallDesc := urtypes.OutputDescriptor{
 		Script:    urtypes.P2WSH,
-		Threshold: 2,
+		Threshold: 1,
 		Type:      urtypes.SortedMulti,
-		Keys:      make([]urtypes.KeyDescriptor, 5),
+		Keys:      make([]urtypes.KeyDescriptor, 20),
 	}
 	fillDescriptor(t, smallDesc, smallDesc.Script.DerivationPath(), 12, 0)
 
@@ -113,7 +383,7 @@ func TestValidateDescriptor(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			err := validateDescriptor(mjolnir.Params, test.desc)
+			err := validateDescriptor(mjolnir.Params, Settings{}, test.desc)
 			if err == nil {
 				t.Fatal("validateDescriptor accepted an unsupported descriptor")
 			}
@@ -142,6 +412,11 @@ func runUILimit(ctx *Context, limit int, f func()) iter.Seq[struct{}] {
 			if frames > limit {
 				panic("UI is not making progress")
 			}
+			// Complete QR scans in progress, for deterministic
+			// results.
+			if ctx.scanner != nil {
+				ctx.scanner.Wait()
+			}
 			if !yield(struct{}{}) {
 				panic(token)
 			}
@@ -233,12 +508,70 @@ func TestMainScreen(t *testing.T) {
 	ctxButton(ctx, Down, Button3)
 	// Scan xpub as descriptor.
 	ctxQR(t, ctx, p, "xpub6F148LnjUhGrHfEN6Pa8VkwF8L6FJqYALxAkuHfacfVhMLVY4MRuUVMxr9pguAv67DHx1YFxqoKN8s4QfZtD9sR2xRCffTqi9E8FiFLAYk8")
-	frame()
+	for i := 0; i < 10 && !opsContains(ops, "Invalid Seed"); i++ {
+		frame()
+	}
 	if !opsContains(ops, "Invalid Seed") {
 		t.Fatal("MainScreen accepted invalid data for a Seed")
 	}
 }
 
+func TestMainScreenSettings(t *testing.T) {
+	ctx := NewContext(newPlatform())
+	ops := new(op.Ops)
+	next, quit := iter.Pull(runUI(ctx, func() {
+		mainFlow(ctx, ops.Context())
+	}))
+	defer quit()
+	frame := resetOps(ops, next)
+	// Settings are stored to the SD card, so don't ask to remove it.
+	ctxButton(ctx, Right, Button3)
+	frame()
+	if opsContains(ops, "Remove SD") {
+		t.Fatal("settings asked to remove the SD card")
+	}
+	if !opsContains(ops, "Choose setting") {
+		t.Fatal("settings didn't start")
+	}
+	if ctx.EmptySDSlot {
+		t.Error("settings ignored the SD card")
+	}
+}
+
+func TestMainScreenSwipe(t *testing.T) {
+	ctx := NewContext(newPlatform())
+	ops := new(op.Ops)
+	next, quit := iter.Pull(runUI(ctx, func() {
+		mainFlow(ctx, ops.Context())
+	}))
+	defer quit()
+	frame := resetOps(ops, next)
+	frame()
+	if !opsContains(ops, "Backup Wallet") {
+		t.Fatal("main screen didn't start at the backup program")
+	}
+	swipe := func(from, to image.Point) {
+		ctx.Events(
+			PointerEvent{Pos: from, Pressed: true}.Event(),
+			PointerEvent{Pos: to}.Event(),
+		)
+		frame()
+	}
+	swipe(image.Pt(200, 120), image.Pt(60, 130))
+	if !opsContains(ops, "Settings") || opsContains(ops, "Backup Wallet") {
+		t.Error("swipe left didn't show the next program")
+	}
+	swipe(image.Pt(60, 120), image.Pt(200, 110))
+	if !opsContains(ops, "Backup Wallet") {
+		t.Error("swipe right didn't show the previous program")
+	}
+	// Taps are not swipes.
+	swipe(image.Pt(120, 120), image.Pt(125, 120))
+	if !opsContains(ops, "Backup Wallet") {
+		t.Error("tap changed program")
+	}
+}
+
 func TestNonParticipatingSeed(t *testing.T) {
 	// Enter seed not part of the descriptor.
 	mnemonic := make(bip39.Mnemonic, 12)
@@ -268,12 +601,18 @@ func TestNonParticipatingSeed(t *testing.T) {
 	}
 }
 
-func dumpUI(t *testing.T, ops *op.Ops) {
+// renderUI draws the current frame of ops onto a screen sized image.
+func renderUI(ops *op.Ops) *image.NRGBA {
 	clip := image.Rectangle{Max: image.Pt(testDisplayDim, testDisplayDim)}
 	ops.Clip(clip)
 	fb := image.NewNRGBA(clip)
 	maskfb := image.NewAlpha(clip)
 	ops.Draw(fb, maskfb)
+	return fb
+}
+
+func dumpUI(t *testing.T, ops *op.Ops) {
+	fb := renderUI(ops)
 	buf := new(bytes.Buffer)
 	if err := png.Encode(buf, fb); err != nil {
 		t.Error(err)
@@ -286,7 +625,7 @@ func dumpUI(t *testing.T, ops *op.Ops) {
 func newTestEngraveScreen(t *testing.T, ctx *Context) *EngraveScreen {
 	desc := twoOfThree.Descriptor
 	const keyIdx = 0
-	plate, err := engravePlate(plateSizes, mjolnir.Params, desc, keyIdx, twoOfThree.Mnemonic)
+	plate, err := engravePlate(plateSizes, mjolnir.Params, Settings{}, desc, keyIdx, twoOfThree.Mnemonic)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -296,6 +635,75 @@ func newTestEngraveScreen(t *testing.T, ctx *Context) *EngraveScreen {
 	)
 }
 
+func TestEngraveWatchOnlyPlate(t *testing.T) {
+	desc := twoOfThree.Descriptor
+	for keyIdx, k := range desc.Keys {
+		plate, err := engravePlate(plateSizes, mjolnir.Params, Settings{}, desc, keyIdx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := len(plate.Sides); n != 1 {
+			t.Errorf("key %d: watch-only plate has %d sides, want 1", keyIdx, n)
+		}
+		if plate.MasterFingerprint != k.MasterFingerprint {
+			t.Errorf("key %d: plate fingerprint %.8x, want %.8x", keyIdx, plate.MasterFingerprint, k.MasterFingerprint)
+		}
+	}
+}
+
+func TestEngraveScreenEstimate(t *testing.T) {
+	ctx := NewContext(newPlatform())
+	scr := newTestEngraveScreen(t, ctx)
+	sides := 0
+	for _, ins := range scr.instructions {
+		if ins.Type != ConnectInstruction {
+			continue
+		}
+		sides++
+		if ins.estimate < time.Minute {
+			t.Errorf("side %d estimated to %v", sides, ins.estimate)
+		}
+	}
+	if sides != 2 {
+		t.Errorf("%d sides estimated, want 2", sides)
+	}
+}
+
+func TestEngraveScreenFlip(t *testing.T) {
+	ctx := NewContext(newPlatform())
+	ctx.Settings.Flip = FlipVertical
+	scr := newTestEngraveScreen(t, ctx)
+	found := false
+	for _, ins := range scr.instructions {
+		if strings.Contains(ins.resolvedBody, "flip the top metal plate vertically") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("no instruction for flipping the plate vertically")
+	}
+}
+
+func TestEngraveScreenSpread(t *testing.T) {
+	p := newPlatform()
+	normal := newTestEngraveScreen(t, NewContext(p))
+	p.settings.Order = OrderSpread
+	spread := newTestEngraveScreen(t, NewContext(p))
+	for i, ins := range spread.instructions {
+		if ins.Type != ConnectInstruction {
+			continue
+		}
+		n := normal.instructions[i].estimate
+		side := spread.instructions[i+1].Side
+		switch {
+		case side == spread.plate.SeedSide && ins.estimate != n:
+			t.Errorf("spread seed side estimated to %v, normal to %v", ins.estimate, n)
+		case side != spread.plate.SeedSide && ins.estimate <= n:
+			t.Errorf("spread engraving estimated to %v, normal to %v", ins.estimate, n)
+		}
+	}
+}
+
 func TestEngraveScreenCancel(t *testing.T) {
 	p := newPlatform()
 	ctx := NewContext(p)
@@ -334,7 +742,7 @@ func TestEngraveError(t *testing.T) {
 		path      []uint32
 		err       error
 	}{
-		{"threshold too small", 1, 5, nonstdPath, backup.ErrDescriptorTooLarge},
+		{"threshold too small", 1, 20, nonstdPath, backup.ErrDescriptorTooLarge},
 	}
 	for i, test := range tests {
 		name := fmt.Sprintf("%d-%d-of-%d", i, test.threshold, test.keys)
@@ -346,7 +754,7 @@ func TestEngraveError(t *testing.T) {
 				Keys:      make([]urtypes.KeyDescriptor, test.keys),
 			}
 			mnemonic := fillDescriptor(t, desc, test.path, 12, 0)
-			_, err := engravePlate(plateSizes, mjolnir.Params, desc, 0, mnemonic)
+			_, err := engravePlate(plateSizes, mjolnir.Params, Settings{}, desc, 0, mnemonic)
 			if err == nil {
 				t.Fatal("invalid descriptor succeeded")
 			}
@@ -414,34 +822,760 @@ func TestEngraveScreenConnectionError(t *testing.T) {
 	<-p.engrave.closed
 }
 
-func TestScanScreenConnectError(t *testing.T) {
+func TestEngraveScreenHandshakeErrors(t *testing.T) {
+	tests := []struct {
+		err   error
+		title string
+	}{
+		{ErrEngraverNoResponse, "No Response"},
+		{fmt.Errorf("%w: handshake reply 0x4f", ErrEngraverUnknown), "Unknown Engraver"},
+		{ErrEngraverBusy, "Engraver Busy"},
+	}
+	for _, test := range tests {
+		p := newPlatform()
+		p.engrave.connErr = test.err
+		ctx := NewContext(p)
+		scr := newTestEngraveScreen(t, ctx)
+		ops := new(op.Ops)
+		frame, quit := iter.Pull(runUI(ctx, func() {
+			scr.Engrave(ctx, ops.Context(), &engraveTheme)
+		}))
+		frame = resetOps(ops, frame)
+		for scr.instructions[scr.step].Type != ConnectInstruction {
+			ctxButton(ctx, Button3)
+			frame()
+		}
+		// Hold connect.
+		ctxPress(ctx, Button3)
+		frame()
+		p.timeOffset += confirmDelay
+		frame()
+		if !opsContains(ops, test.title) {
+			t.Errorf("%v: screen doesn't show %q", test.err, test.title)
+		}
+		quit()
+	}
+}
+
+func TestEmergencyStop(t *testing.T) {
 	p := newPlatform()
-	// Fail on connect.
 	ctx := NewContext(p)
-	scr := &ScanScreen{}
-	camErr := errors.New("failed to open camera")
-	ctx.events = append(ctx.events, FrameEvent{Error: camErr}.Event())
+	ctx.EmergencyStop = true
 	ops := new(op.Ops)
+	done := false
 	frame, quit := iter.Pull(runUI(ctx, func() {
-		scr.Scan(ctx, ops.Context())
+		emergencyStopFlow(ctx, ops.Context())
+		done = true
 	}))
 	defer quit()
 	frame = resetOps(ops, frame)
+	// Hold confirm while the switch is triggered.
+	ctxPress(ctx, Button3)
 	frame()
-	if !opsContains(ops, camErr.Error()) {
-		t.Fatal("initial camera error not reported")
+	p.timeOffset += confirmDelay
+	frame()
+	if done || !opsContains(ops, "Release") {
+		t.Fatal("emergency stop acknowledged while triggered")
+	}
+	// Release switch and acknowledge.
+	ctx.EmergencyStop = false
+	ctxPress(ctx, Button3)
+	frame()
+	p.timeOffset += confirmDelay
+	frame()
+	if !done {
+		t.Fatal("emergency stop not acknowledged after release")
 	}
 }
 
-func TestScanScreenStreamError(t *testing.T) {
-	p := newPlatform()
-	ctx := NewContext(p)
-	// Fail during streaming.
-	scr := &ScanScreen{}
-	// Connect.
-	camErr := errors.New("error during streaming")
-	ctx.events = append(ctx.events, FrameEvent{Error: camErr}.Event())
-	ops := new(op.Ops)
+func TestAddressesEncoder(t *testing.T) {
+	desc := twoOfThree.Descriptor
+	ctx := NewContext(newPlatform())
+	ops := new(op.Ops)
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		ShowAddressesScreen(ctx, ops.Context(), &descriptorTheme, desc)
+	}))
+	defer quit()
+	frame = resetOps(ops, frame)
+	frame()
+	// Scroll past the first batch, one line per detent.
+	for range address.GapLimit {
+		ctxButton(ctx, CW)
+		for range 5 {
+			frame()
+		}
+		if opsContains(ops, fmt.Sprintf("%d:", address.GapLimit+1)) {
+			return
+		}
+	}
+	t.Error("no addresses derived when scrolling with the encoder")
+}
+
+func TestCalibrate(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		calibrateFlow(ctx, op.Ctx{}, &engraveTheme)
+	}))
+	defer quit()
+	ctxButton(ctx, Right, Right, Down)
+	frame()
+	ctxButton(ctx, Button3)
+	frame()
+	step := mjolnir.Params.F(calibrationStep)
+	want := image.Pt(2*step, step)
+	if got := p.settings.Origin; got != want {
+		t.Errorf("stored origin %v, want %v", got, want)
+	}
+	if got := ctx.Settings.Origin; got != want {
+		t.Errorf("context origin %v, want %v", got, want)
+	}
+}
+
+func TestCalibrateEncoder(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		calibrateFlow(ctx, op.Ctx{}, &engraveTheme)
+	}))
+	defer quit()
+	// The encoder jogs X until the joystick moves Y.
+	ctxButton(ctx, CW, CW, CW, CCW, Down, CCW, CCW)
+	frame()
+	ctxButton(ctx, Button3)
+	frame()
+	step := mjolnir.Params.F(calibrationStep)
+	want := image.Pt(2*step, -step)
+	if got := p.settings.Origin; got != want {
+		t.Errorf("stored origin %v, want %v", got, want)
+	}
+}
+
+func TestGestures(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+	inp := new(InputTracker)
+
+	ctxPress(ctx, Button3)
+	if e, ok := inp.Next(ctx, Button3); !ok || e.Gesture != NoGesture {
+		t.Fatalf("press reported as %v, %v", e, ok)
+	}
+	if _, ok := inp.Next(ctx, Button3); ok {
+		t.Error("long press reported before the delay")
+	}
+	p.timeOffset += confirmDelay
+	if got := inp.Progress(ctx, Button3); got != 1 {
+		t.Errorf("long press progress %v, want 1", got)
+	}
+	if e, ok := inp.Next(ctx, Button3); !ok || e.Gesture != LongPress {
+		t.Errorf("held button reported as %v, %v, want a long press", e, ok)
+	}
+	ctx.Events(ButtonEvent{Button: Button3}.Event())
+	inp.Next(ctx, Button3)
+	if inp.Clicked(Button3) {
+		t.Error("long press released as a click")
+	}
+
+	ctxButton(ctx, Button1, Button1, Button1)
+	var taps []Gesture
+	for {
+		e, ok := inp.Next(ctx, Button1)
+		if !ok {
+			break
+		}
+		if inp.Clicked(e.Button) {
+			taps = append(taps, e.Gesture)
+		}
+	}
+	if want := []Gesture{NoGesture, DoubleTap, NoGesture}; !slices.Equal(taps, want) {
+		t.Errorf("clicks reported as %v, want %v", taps, want)
+	}
+
+	ctx.Events(
+		PointerEvent{Pos: image.Pt(100, 200), Pressed: true}.Event(),
+		PointerEvent{Pos: image.Pt(110, 50)}.Event(),
+	)
+	if e, ok := inp.Next(ctx, Button3); ok {
+		t.Errorf("swipe reported as %v without direction buttons", e)
+	}
+	if e, ok := inp.Next(ctx, Up, Down); !ok || e.Button != Down || e.Gesture != Swipe {
+		t.Errorf("swipe up reported as %v, %v, want a Down swipe", e, ok)
+	}
+}
+
+func TestSettings(t *testing.T) {
+	for _, s := range settingsMenu {
+		if s.values == 0 {
+			continue
+		}
+		t.Run(s.name, func(t *testing.T) {
+			p := newPlatform()
+			ctx := NewContext(p)
+			t.Cleanup(func() { applyTheme(ContrastNormal) })
+
+			// Select the last value.
+			for range s.values {
+				ctxButton(ctx, Down)
+			}
+			ctxButton(ctx, Button3)
+			for range runUI(ctx, func() {
+				s.flow(ctx, op.Ctx{}, &engraveTheme)
+			}) {
+			}
+			if got, want := s.index(p.settings), s.values-1; got != want {
+				t.Errorf("stored value %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestBackupRegistry(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+
+	plates := []Backup{
+		{Wallet: 1, Title: "Multisig", MasterFingerprint: 0x5a0804e3, KeyIdx: 0, Keys: 3, Size: backup.LargePlate},
+		{Wallet: 1, Title: "Multisig", MasterFingerprint: 0xdd4fadee, KeyIdx: 2, Keys: 3, Size: backup.SquarePlate},
+	}
+	for _, b := range plates {
+		recordBackup(ctx, b)
+	}
+	secret, err := p.DeviceSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := openRegistry(secret, p.settings.Registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(plates) {
+		t.Fatalf("registry contains %d backups, want %d", len(got), len(plates))
+	}
+	for i, b := range got {
+		if b.Time.IsZero() {
+			t.Errorf("backup %d has no time", i)
+		}
+		b.Time = time.Time{}
+		if b != plates[i] {
+			t.Errorf("backup %d is %+v, want %+v", i, b, plates[i])
+		}
+	}
+	if rem, want := remainingPlates(got, got[0]), []int{2}; !reflect.DeepEqual(rem, want) {
+		t.Errorf("remaining plates %v, want %v", rem, want)
+	}
+	if _, err := openRegistry([]byte("other device"), p.settings.Registry); err == nil {
+		t.Error("registry opened with the wrong secret")
+	}
+}
+
+func TestBackupRegistryNoSDCard(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+	p.storeErr = errors.New("SD card removed")
+	ctx.sdCard(SDCardEvent{Inserted: false})
+
+	b := Backup{Wallet: 1, MasterFingerprint: 0x5a0804e3, KeyIdx: 0, Keys: 2, Size: backup.LargePlate}
+	recordBackup(ctx, b)
+	if p.settings.Registry != nil {
+		t.Fatal("registry stored without SD card")
+	}
+	secret, err := p.DeviceSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := openRegistry(secret, ctx.Settings.Registry); err != nil || len(got) != 1 {
+		t.Fatalf("registry kept %d backups (%v), want 1", len(got), err)
+	}
+	p.storeErr = nil
+	ctx.sdCard(SDCardEvent{Inserted: true})
+	if got, err := openRegistry(secret, p.settings.Registry); err != nil || len(got) != 1 {
+		t.Fatalf("registry stored %d backups (%v) after SD card insertion, want 1", len(got), err)
+	}
+	if n := len(p.settings.AuditLog); n != 1 {
+		t.Errorf("audit log stored %d entries after SD card insertion, want 1", n)
+	}
+}
+
+func TestDeviceKey(t *testing.T) {
+	p := newPlatform()
+	p.noSecret = true
+	ctx := NewContext(p)
+
+	// Engraving never sets up the device key.
+	recordBackup(ctx, Backup{Wallet: 1, MasterFingerprint: 0x5a0804e3, Keys: 1, Size: backup.SquarePlate})
+	if !p.noSecret {
+		t.Fatal("device key set up while recording a backup")
+	}
+	if p.settings.Registry != nil {
+		t.Error("backup recorded without a device key")
+	}
+
+	ops := new(op.Ops)
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		deviceKeyFlow(ctx, ops.Context(), &descriptorTheme)
+	}))
+	defer quit()
+	frame = resetOps(ops, frame)
+	for range 5 {
+		frame()
+	}
+	if !opsContains(ops, "Set Up Device Key?") {
+		t.Fatal("device key set up without confirmation")
+	}
+	if !p.noSecret {
+		t.Fatal("device key set up before confirmation")
+	}
+	ctxPress(ctx, Button3)
+	frame()
+	p.timeOffset += confirmDelay
+	for range 5 {
+		frame()
+	}
+	if p.noSecret {
+		t.Error("device key not set up after confirmation")
+	}
+}
+
+func TestSDCardSwap(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+	p.storeErr = errors.New("SD card removed")
+	ctx.sdCard(SDCardEvent{Inserted: false})
+	recordBackup(ctx, Backup{Wallet: 1, MasterFingerprint: 0x5a0804e3, Keys: 1, Size: backup.SquarePlate})
+
+	// Insert the SD card of another device.
+	other := Settings{ID: []byte("other"), Registry: []byte("sealed"), Speed: SpeedFast}
+	p.storeErr = nil
+	p.settings = other
+	ctx.sdCard(SDCardEvent{Inserted: true})
+	if !reflect.DeepEqual(p.settings, other) {
+		t.Error("settings of another SD card overwritten")
+	}
+	if !reflect.DeepEqual(ctx.Settings, other) {
+		t.Error("settings of another SD card not loaded")
+	}
+
+	// Insert a blank SD card.
+	p = newPlatform()
+	ctx = NewContext(p)
+	p.storeErr = errors.New("SD card removed")
+	recordBackup(ctx, Backup{Wallet: 1, MasterFingerprint: 0x5a0804e3, Keys: 1, Size: backup.SquarePlate})
+	p.storeErr = nil
+	ctx.sdCard(SDCardEvent{Inserted: true})
+	if n := len(p.settings.AuditLog); n != 1 {
+		t.Errorf("blank SD card stored %d audit entries, want 1", n)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+	ctx.Version = "v1.2.3"
+
+	plates := []Backup{
+		{Wallet: 1, MasterFingerprint: 0x5a0804e3, KeyIdx: 0, Keys: 2, Size: backup.LargePlate},
+		{Wallet: 1, MasterFingerprint: 0xdd4fadee, KeyIdx: 1, Keys: 2, Size: backup.SquarePlate},
+	}
+	for _, b := range plates {
+		recordBackup(ctx, b)
+	}
+	entries := p.settings.AuditLog
+	if len(entries) != len(plates) {
+		t.Fatalf("audit log contains %d entries, want %d", len(entries), len(plates))
+	}
+	if err := verifyAudit(entries); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := p.DeviceSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	export, err := exportAudit(secret, entries, p.settings.AuditSignature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyAuditExport(export, entries); err != nil {
+		t.Error(err)
+	}
+	// Tamper with the first entry.
+	tampered := slices.Clone(entries)
+	tampered[0].Size = backup.SquarePlate
+	if err := verifyAudit(tampered); err == nil {
+		t.Error("tampered audit log verified")
+	}
+	if err := verifyAuditExport(export, entries[:1]); err == nil {
+		t.Error("export verified a truncated log")
+	}
+	// Rewrite and rechain the log.
+	var rewritten []AuditEntry
+	for _, e := range tampered {
+		rewritten = appendAudit(rewritten, e)
+	}
+	if _, err := exportAudit(secret, rewritten, p.settings.AuditSignature); err == nil {
+		t.Error("device exported a rewritten log")
+	}
+}
+
+// verifyAuditExport verifies the signature of an export made by
+// exportAudit, and that it covers log.
+func verifyAuditExport(export string, entries []AuditEntry) error {
+	fields := strings.Split(export, ":")
+	if len(fields) != 5 || fields[0] != "SHAUDIT" {
+		return errors.New("audit: invalid export")
+	}
+	if fields[1] != fmt.Sprint(len(entries)) {
+		return errors.New("audit: entry count mismatch")
+	}
+	if err := verifyAudit(entries); err != nil {
+		return err
+	}
+	head, err1 := hex.DecodeString(fields[2])
+	pubb, err2 := hex.DecodeString(fields[3])
+	sigb, err3 := hex.DecodeString(fields[4])
+	if err := errors.Join(err1, err2, err3); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	if len(entries) == 0 || !bytes.Equal(head, entries[len(entries)-1].Hash) {
+		return errors.New("audit: head hash mismatch")
+	}
+	pub, err := schnorr.ParsePubKey(pubb)
+	if err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	sig, err := schnorr.ParseSignature(sigb)
+	if err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	if !sig.Verify(head, pub) {
+		return errors.New("audit: invalid signature")
+	}
+	return nil
+}
+
+func TestContrastSetting(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+	t.Cleanup(func() { applyTheme(ContrastNormal) })
+
+	ctxButton(ctx, Down, Button3)
+	for range runUI(ctx, func() {
+		chooseSetting(ctx, op.Ctx{}, &engraveTheme, contrastSetting)
+	}) {
+	}
+	if got := p.settings.Contrast; got != ContrastHigh {
+		t.Errorf("stored contrast %v, want %v", got, ContrastHigh)
+	}
+	if got, want := engraveTheme, themes[ContrastHigh].Engrave; got != want {
+		t.Errorf("engrave theme %v, want %v", got, want)
+	}
+	if got, want := ctx.Styles.body.Face, poppins.Bold16; got != want {
+		t.Error("high contrast body style is not bold")
+	}
+}
+
+func TestAccessibilitySetting(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+
+	ctxButton(ctx, Down, Down, Button3)
+	for range runUI(ctx, func() {
+		chooseSetting(ctx, op.Ctx{}, &engraveTheme, accessibilitySetting)
+	}) {
+	}
+	if got := p.settings.Accessibility; got != AccessibilityBeep {
+		t.Errorf("stored accessibility %v, want %v", got, AccessibilityBeep)
+	}
+	if got, want := ctx.Styles.body.LineHeight(), 2*NewStyles(ContrastNormal, AccessibilityOff).body.LineHeight(); got != want {
+		t.Errorf("large print body line height %d, want %d", got, want)
+	}
+	ctxButton(ctx, Up)
+	inp := new(InputTracker)
+	for {
+		if _, ok := inp.Next(ctx, Up); !ok {
+			break
+		}
+	}
+	if p.beeps != 1 {
+		t.Errorf("%d beeps for a button press, want 1", p.beeps)
+	}
+}
+
+func TestSaverSetting(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+
+	ctxButton(ctx, Down, Button3, Down, Button3)
+	for range runUI(ctx, func() {
+		saverFlow(ctx, op.Ctx{}, &engraveTheme)
+	}) {
+	}
+	if got := p.settings.IdleTimeout; got != Idle10Min {
+		t.Errorf("stored idle timeout %v, want %v", got, Idle10Min)
+	}
+	if got := p.settings.Saver; got != SaverBlank {
+		t.Errorf("stored screen saver %v, want %v", got, SaverBlank)
+	}
+}
+
+func TestSecretsWipedOnCancel(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+	m, err := bip39.ParseMnemonic("attack pizza motion avocado network gather crop fresh patrol unusual wild holiday")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Scan seed.
+	ctxButton(ctx, Down, Button3)
+	ctxQR(t, ctx, p, string(seedqr.QR(m)))
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		runProgram(ctx, func() {
+			backupWalletFlow(ctx, op.Ctx{}, &singleTheme)
+		})
+	}))
+	defer quit()
+	for i := 0; i < 10 && len(ctx.secrets.mnemonics) == 0; i++ {
+		frame()
+	}
+	if n := len(ctx.secrets.mnemonics); n != 1 {
+		t.Fatalf("%d mnemonics tracked, want 1", n)
+	}
+	tracked := ctx.secrets.mnemonics[0]
+	// Back and hold discard.
+	ctxButton(ctx, Button1)
+	ctxPress(ctx, Button3)
+	frame()
+	p.timeOffset += confirmDelay
+	if _, running := frame(); running {
+		t.Fatal("flow didn't exit after discarding the seed")
+	}
+	if n := len(ctx.secrets.mnemonics); n > 0 {
+		t.Errorf("%d mnemonics reachable from context after cancel", n)
+	}
+	if !isEmptyMnemonic(tracked) {
+		t.Errorf("mnemonic %v not wiped after cancel", tracked)
+	}
+}
+
+func TestSecretsExpire(t *testing.T) {
+	var s secrets
+	m := s.track(emptyMnemonic(12))
+	m[0] = 1
+	s.holds++
+	s.expire()
+	if s.expired || m[0] != 1 {
+		t.Error("held secrets expired")
+	}
+	s.holds--
+	s.expire()
+	if !s.expired || !isEmptyMnemonic(m) {
+		t.Error("secrets didn't expire")
+	}
+}
+
+func TestPINLock(t *testing.T) {
+	p := newPlatform()
+	salt := []byte("salt")
+	secret, err := p.DeviceSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.settings.PINSalt = salt
+	p.settings.PINHash = hashPIN(secret, salt, "1234")
+	ctx := NewContext(p)
+
+	// Wrong PIN.
+	ctxString(ctx, "1235")
+	ctxButton(ctx, Button3)
+	// Correct PIN.
+	ctxString(ctx, "1234")
+	ctxButton(ctx, Button3)
+	var unlocked bool
+	for range runUI(ctx, func() {
+		unlocked = unlockFlow(ctx, op.Ctx{}, &singleTheme)
+	}) {
+	}
+	if !unlocked || !ctx.Unlocked {
+		t.Fatal("correct PIN didn't unlock device")
+	}
+	if n := p.settings.PINFailures; n != 0 {
+		t.Errorf("%d PIN failures after unlock, want 0", n)
+	}
+}
+
+func TestPINLockNoSDCard(t *testing.T) {
+	p := newPlatform()
+	salt := []byte("salt")
+	secret, err := p.DeviceSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.settings.PINSalt = salt
+	p.settings.PINHash = hashPIN(secret, salt, "1234")
+	ctx := NewContext(p)
+	p.storeErr = errors.New("SD card removed")
+
+	// Correct PIN, but the attempt can't be recorded.
+	ctxString(ctx, "1234")
+	ctxButton(ctx, Button3)
+	// Back.
+	ctxButton(ctx, Button1)
+	var unlocked bool
+	for range runUI(ctx, func() {
+		unlocked = unlockFlow(ctx, op.Ctx{}, &singleTheme)
+	}) {
+	}
+	if unlocked || ctx.Unlocked {
+		t.Error("unrecorded PIN attempt unlocked device")
+	}
+}
+
+func TestPINDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		delay    time.Duration
+	}{
+		{0, 0},
+		{pinFreeAttempts - 1, 0},
+		{pinFreeAttempts, pinBaseDelay},
+		{pinFreeAttempts + 1, 2 * pinBaseDelay},
+		{pinFreeAttempts + 100, pinMaxDelay},
+	}
+	for _, test := range tests {
+		if got := pinDelay(test.failures); got != test.delay {
+			t.Errorf("pinDelay(%d) = %v, want %v", test.failures, got, test.delay)
+		}
+	}
+}
+
+func TestRotatedImage(t *testing.T) {
+	dims := image.Pt(4, 3)
+	fb := image.NewRGBA64(image.Rectangle{Max: dims})
+	// The top left corner of the display.
+	r := rotateRect(image.Rect(0, 0, 1, 1), dims)
+	chunk := &rotatedImage{fb: fb.SubImage(r).(*image.RGBA64), dims: dims}
+	if got, want := chunk.Bounds(), image.Rect(0, 0, 1, 1); got != want {
+		t.Fatalf("rotated chunk bounds %v, want %v", got, want)
+	}
+	white := color.RGBA64{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff}
+	chunk.SetRGBA64(0, 0, white)
+	if got := fb.RGBA64At(dims.X-1, dims.Y-1); got != white {
+		t.Errorf("bottom right framebuffer pixel is %v, want %v", got, white)
+	}
+}
+
+func TestCondensedFont(t *testing.T) {
+	desc := urtypes.OutputDescriptor{
+		Script:    urtypes.P2WSH,
+		Threshold: 1,
+		Type:      urtypes.SortedMulti,
+		Keys:      make([]urtypes.KeyDescriptor, 3),
+	}
+	m := fillDescriptor(t, desc, desc.Script.DerivationPath(), 12, 0)
+	// The regular font splits the descriptor, the condensed font fits it
+	// on a single plate.
+	regular, err := engravePlate(plateSizes, mjolnir.Params, Settings{Font: FontRegular}, desc, 0, m)
+	if err != nil {
+		t.Fatalf("regular font: %v", err)
+	}
+	if len(regular.Sides) <= 2 {
+		t.Errorf("regular font: descriptor fits a single plate")
+	}
+	condensed, err := engravePlate(plateSizes, mjolnir.Params, Settings{Font: FontCondensed}, desc, 0, m)
+	if err != nil {
+		t.Fatalf("condensed font: %v", err)
+	}
+	if len(condensed.Sides) != 2 {
+		t.Errorf("condensed font: descriptor split into %d sides", len(condensed.Sides))
+	}
+}
+
+func TestDataPlate(t *testing.T) {
+	const url = "https://seedhammer.com"
+	plate, err := engraveData(plateSizes, mjolnir.Params, Settings{}, []byte(url))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plate.Size != backup.SquarePlate || len(plate.Sides) != 1 {
+		t.Errorf("engraved %d sides on plate %v", len(plate.Sides), plate.Size)
+	}
+	if _, err := engraveData(plateSizes, mjolnir.Params, Settings{}, make([]byte, 3000)); err == nil {
+		t.Error("oversized data plate succeeded")
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	p := newPlatform()
+	p.engrave.connErr = errors.New("no engraver")
+	ctx := NewContext(p)
+	scr := new(DiagnosticsScreen)
+
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		scr.Diagnose(ctx, op.Ctx{}, &singleTheme)
+	}))
+	defer quit()
+
+	// Camera.
+	ctxButton(ctx, Button3)
+	frame()
+	if got := scr.results[diagCamera].state; got != diagRunning {
+		t.Fatalf("camera diagnostic is %v, expected running", got)
+	}
+	ctx.Events(FrameEvent{Image: image.NewYCbCr(image.Rect(0, 0, 1, 1), image.YCbCrSubsampleRatio420)}.Event())
+	frame()
+	if got := scr.results[diagCamera].state; got != diagPassed {
+		t.Errorf("camera diagnostic is %v, expected pass", got)
+	}
+
+	// QR decoder. The test platform doesn't decode generated
+	// images.
+	ctxButton(ctx, Down, Button3)
+	frame()
+	if got := scr.results[diagDecoder].state; got != diagFailed {
+		t.Errorf("decoder diagnostic is %v, expected failure", got)
+	}
+
+	// Engraver.
+	ctxButton(ctx, Down, Button3)
+	ctxPress(ctx, Button3)
+	frame()
+	p.timeOffset += confirmDelay
+	frame()
+	frame()
+	if got := scr.results[diagEngraver].state; got != diagFailed {
+		t.Errorf("engraver diagnostic is %v, expected failure", got)
+	}
+}
+
+func TestScanScreenConnectError(t *testing.T) {
+	p := newPlatform()
+	// Fail on connect.
+	ctx := NewContext(p)
+	scr := &ScanScreen{}
+	camErr := errors.New("failed to open camera")
+	ctx.events = append(ctx.events, FrameEvent{Error: camErr}.Event())
+	ops := new(op.Ops)
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		scr.Scan(ctx, ops.Context())
+	}))
+	defer quit()
+	frame = resetOps(ops, frame)
+	frame()
+	if !opsContains(ops, camErr.Error()) {
+		t.Fatal("initial camera error not reported")
+	}
+}
+
+func TestScanScreenStreamError(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+	// Fail during streaming.
+	scr := &ScanScreen{}
+	// Connect.
+	camErr := errors.New("error during streaming")
+	ctx.events = append(ctx.events, FrameEvent{Error: camErr}.Event())
+	ops := new(op.Ops)
 	frame, quit := iter.Pull(runUI(ctx, func() {
 		scr.Scan(ctx, ops.Context())
 	}))
@@ -459,13 +1593,157 @@ func TestWordKeyboardScreen(t *testing.T) {
 		ctxString(ctx, strings.ToUpper(w))
 		ctxButton(ctx, Button2)
 		m := make(bip39.Mnemonic, 1)
-		inputWordsFlow(ctx, op.Ctx{}, &descriptorTheme, m, 0)
+		inputWordsFlow(ctx, op.Ctx{}, &descriptorTheme, KeyboardWords, m, 0)
 		if got := bip39.LabelFor(m[0]); got != w {
 			t.Errorf("keyboard mapped %q to %q", w, got)
 		}
 	}
 }
 
+func TestWordNumberKeyboard(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"1", "abandon"},
+		{"2048", "zoo"},
+		// Leading zeros and numbers above 2048 are ignored.
+		{"01", "abandon"},
+		{"2049", "bonus"},
+	}
+	for _, test := range tests {
+		ctx := NewContext(newPlatform())
+		ctxString(ctx, test.input)
+		ctxButton(ctx, Button2)
+		m := make(bip39.Mnemonic, 1)
+		inputWordsFlow(ctx, op.Ctx{}, &descriptorTheme, KeyboardNumbers, m, 0)
+		if got := bip39.LabelFor(m[0]); got != test.want {
+			t.Errorf("keyboard mapped %q to %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestFinalWordPicker(t *testing.T) {
+	for _, mode := range []KeyboardMode{KeyboardWords, KeyboardNumbers} {
+		ctx := NewContext(newPlatform())
+		ops := new(op.Ops)
+		m := make(bip39.Mnemonic, 24)
+		for i := range m {
+			m[i] = bip39.RandomWord()
+		}
+		finals := m.FinalWords()
+		m[len(m)-1] = -1
+		done := false
+		frame, quit := iter.Pull(runUI(ctx, func() {
+			inputWordsFlow(ctx, ops.Context(), &descriptorTheme, mode, m, len(m)-1)
+			done = true
+		}))
+		frame = resetOps(ops, frame)
+		frame()
+		ctxButton(ctx, Button2)
+		frame()
+		if !opsContains(ops, "Final Word") {
+			t.Errorf("mode %d: final words not offered", mode)
+		}
+		ctxButton(ctx, Down, Button3)
+		frame()
+		quit()
+		if !done {
+			t.Fatalf("mode %d: final word not picked", mode)
+		}
+		if got, want := m[len(m)-1], finals[1]; got != want {
+			t.Errorf("mode %d: picked %s, want %s", mode, bip39.LabelFor(got), bip39.LabelFor(want))
+		}
+		if !m.Valid() {
+			t.Errorf("mode %d: picked final word has an invalid checksum", mode)
+		}
+	}
+}
+
+func TestTextKeyboard(t *testing.T) {
+	ctx := NewContext(newPlatform())
+	kbd := NewTextKeyboard(ctx)
+	const text = "Pass phrase #1!"
+	ctxString(ctx, text)
+	kbd.Update(ctx)
+	if kbd.Word != text {
+		t.Fatalf("keyboard entered %q, want %q", kbd.Word, text)
+	}
+	// Select the layer key.
+	ctxButton(ctx, Down)
+	kbd.Update(ctx)
+	if kbd.keys()[kbd.row][kbd.col] != keyLayer {
+		ctxButton(ctx, Left)
+		kbd.Update(ctx)
+	}
+	for i, l := range textLayers {
+		if kbd.layer != i {
+			t.Fatalf("keyboard on layer %d, want %d (%s)", kbd.layer, i, l.Name)
+		}
+		kbd.Layout(ctx, op.Ctx{}, &descriptorTheme)
+		ctxButton(ctx, Center)
+		kbd.Update(ctx)
+	}
+	if kbd.layer != 0 {
+		t.Errorf("layer key didn't wrap around to the first layer")
+	}
+	// Switch to digits and enter the key above the layer key.
+	ctxButton(ctx, Center, Center, Up, Center)
+	kbd.Update(ctx)
+	if want := text + "."; kbd.Word != want {
+		t.Errorf("keyboard entered %q, want %q", kbd.Word, want)
+	}
+}
+
+func TestPhysicalKeyboard(t *testing.T) {
+	ctx := NewContext(newPlatform())
+	// Physical keyboards type lower case letters and backspace as
+	// runes.
+	words := NewKeyboard(ctx)
+	ctxString(ctx, "ac\bbo")
+	words.Update(ctx)
+	if want := "ABO"; words.Word != want {
+		t.Errorf("word keyboard entered %q, want %q", words.Word, want)
+	}
+	text := NewTextKeyboard(ctx)
+	ctxString(ctx, "Pass\bs")
+	text.Update(ctx)
+	if want := "Pass"; text.Word != want {
+		t.Errorf("text keyboard entered %q, want %q", text.Word, want)
+	}
+}
+
+func TestKeyboardSetting(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+	ops := new(op.Ops)
+	// Choose ON and hold to confirm the warning.
+	ctxButton(ctx, Down, Button3)
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		chooseSetting(ctx, ops.Context(), &descriptorTheme, keyboardSetting)
+	}))
+	defer quit()
+	frame = resetOps(ops, frame)
+	for range 5 {
+		frame()
+	}
+	if !opsContains(ops, "Keylogger") {
+		t.Fatal("no keylogger warning")
+	}
+	if ctx.Settings.Keyboard != KeyboardOff {
+		t.Fatal("keyboard enabled without confirmation")
+	}
+	ctxPress(ctx, Button3)
+	frame()
+	p.timeOffset += confirmDelay
+	for range 5 {
+		frame()
+	}
+	if ctx.Settings.Keyboard != KeyboardOn {
+		t.Error("keyboard not enabled after confirmation")
+	}
+}
+
 func ctxMnemonic(ctx *Context, m bip39.Mnemonic) {
 	for _, word := range m {
 		ctxString(ctx, strings.ToUpper(bip39.LabelFor(word)))
@@ -490,7 +1768,12 @@ func TestSeedScreenScan(t *testing.T) {
 		t.Fatal(err)
 	}
 	ctxQR(t, ctx, p, string(seedqr.QR(want)))
-	got, ok := newMnemonicFlow(ctx, op.Ctx{}, &descriptorTheme)
+	var got bip39.Mnemonic
+	var ok bool
+	for range runUI(ctx, func() {
+		got, ok = newMnemonicFlow(ctx, op.Ctx{}, &descriptorTheme)
+	}) {
+	}
 	if !ok {
 		t.Errorf("no mnemonic from scanned seed")
 	}
@@ -510,7 +1793,9 @@ func TestSeedScreenScanInvalid(t *testing.T) {
 		newMnemonicFlow(ctx, ops.Context(), &descriptorTheme)
 	}))
 	defer quit()
-	frame()
+	for i := 0; i < 10 && !opsContains(ops, "invalid seed"); i++ {
+		frame()
+	}
 	if !opsContains(ops, "invalid seed") {
 		t.Error("invalid seed accepted")
 	}
@@ -538,45 +1823,294 @@ func TestSeedScreenInvalidSeed(t *testing.T) {
 	if confirmed || !opsContains(ops, "invalid seed") {
 		t.Fatal("invalid seed accepted")
 	}
-	// Dismiss error.
-	ctxButton(ctx, Button3)
+	// Dismiss error.
+	ctxButton(ctx, Button3)
+
+	// Back.
+	ctxButton(ctx, Button1)
+	// Hold confirm.
+	ctxPress(ctx, Button3)
+	frame()
+	if exited {
+		t.Error("exited screen without confirmation")
+	}
+	p.timeOffset += confirmDelay
+	frame()
+	if !exited {
+		t.Error("failed to exit screen")
+	}
+}
+
+func TestSeedScreenRepair(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+	ctx.LastDescriptor = &twoOfThree.Descriptor
+	m := append(bip39.Mnemonic{}, twoOfThree.Mnemonic...)
+	// Transpose two words.
+	m[0], m[1] = m[1], m[0]
+	if m.Valid() {
+		t.Fatal("transposed seed is valid")
+	}
+	ctxButton(ctx, Left)
+	scr := new(SeedScreen)
+	ops := new(op.Ops)
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		scr.Confirm(ctx, ops.Context(), &singleTheme, m)
+	}))
+	defer quit()
+	frame = resetOps(ops, frame)
+	for i := 0; i < 1000 && !opsContains(ops, "Choose correction"); i++ {
+		frame()
+	}
+	if !opsContains(ops, "SWAP 1, 2") {
+		t.Fatal("matching correction not offered")
+	}
+	ctxButton(ctx, Button3)
+	frame()
+	if !reflect.DeepEqual(m, twoOfThree.Mnemonic) {
+		t.Errorf("repaired seed to %v, want %v", m, twoOfThree.Mnemonic)
+	}
+}
+
+func TestSeedScreenSeedQR(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+	m := append(bip39.Mnemonic{}, twoOfThree.Mnemonic...)
+	scr := new(SeedScreen)
+	ops := new(op.Ops)
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		scr.Confirm(ctx, ops.Context(), &singleTheme, m)
+	}))
+	defer quit()
+	frame = resetOps(ops, frame)
+	// Show SeedQR and hold confirm.
+	ctxButton(ctx, Right)
+	ctxPress(ctx, Button3)
+	frame()
+	if opsContains(ops, "closes in") {
+		t.Fatal("SeedQR shown without confirmation")
+	}
+	p.timeOffset += confirmDelay
+	frame()
+	if !opsContains(ops, "closes in") {
+		t.Fatal("SeedQR not shown")
+	}
+	ctxButton(ctx, Button2)
+	frame()
+	if !opsContains(ops, "compactseedqr") {
+		t.Error("CompactSeedQR not shown")
+	}
+	p.timeOffset += seedQRTimeout
+	frame()
+	if opsContains(ops, "closes in") || !opsContains(ops, "confirm seed") {
+		t.Error("SeedQR screen didn't time out")
+	}
+}
+
+func TestXpubMasterFingerprintSinglesig(t *testing.T) {
+	const mnemonic = "upset toe sheriff cotton vibrant shock torch waste congress innocent company review"
+	const descriptor = "zpub6qiC7jMrWkhNEu7YamFTWx8YHQaDFynLYQCUmxjCWpBiLQ4Qp6c6PEwpZpkN27XmUtBjX7hVLyyBKa7zhgaB5B2qvdckaP21ADwx7oYgYD6"
+
+	m, err := bip39.ParseMnemonic(mnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := newPlatform()
+	ctx := NewContext(p)
+	ops := new(op.Ops)
+	ctxQR(t, ctx, p, descriptor)
+	ctxButton(ctx, Button3)
+	var got *urtypes.OutputDescriptor
+	var parsed bool
+	for range runUI(ctx, func() {
+		got, parsed = inputDescriptorFlow(ctx, ops.Context(), &descriptorTheme, m)
+	}) {
+	}
+
+	if !parsed {
+		t.Error("failed to parse descriptor")
+	}
+
+	want, err := nonstandard.OutputDescriptor([]byte(descriptor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mfp, err := masterFingerprintFor(m, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Keys[0].MasterFingerprint = mfp
+	if !reflect.DeepEqual(want, *got) {
+		t.Error("descriptors don't match")
+	}
+}
+
+func TestKeyOrigin(t *testing.T) {
+	const (
+		zpub = "zpub6qiC7jMrWkhNEu7YamFTWx8YHQaDFynLYQCUmxjCWpBiLQ4Qp6c6PEwpZpkN27XmUtBjX7hVLyyBKa7zhgaB5B2qvdckaP21ADwx7oYgYD6"
+		xpub = "xpub6F148LnjUhGrHfEN6Pa8VkwF8L6FJqYALxAkuHfacfVhMLVY4MRuUVMxr9pguAv67DHx1YFxqoKN8s4QfZtD9sR2xRCffTqi9E8FiFLAYk8"
+	)
+	h := uint32(hdkeychain.HardenedKeyStart)
+	tests := []struct {
+		name   string
+		key    string
+		input  func(ctx *Context, waitFor func(string))
+		script urtypes.Script
+		path   urtypes.Path
+	}{
+		{
+			name: "preset",
+			key:  zpub,
+			input: func(ctx *Context, waitFor func(string)) {
+				// Accept the BIP84 preset implied by the key version.
+				ctxButton(ctx, Button3)
+			},
+			script: urtypes.P2WPKH,
+			path:   urtypes.Path{h + 84, h + 0, h + 0},
+		},
+		{
+			name: "custom",
+			key:  xpub,
+			input: func(ctx *Context, waitFor func(string)) {
+				ctxButton(ctx, Down, Down, Down, Down, Button3)
+				waitFor("Derivation Path")
+				ctxString(ctx, strings.Repeat(string(keyBackspace), len("m/44h/0h/0h")))
+				ctxString(ctx, "M/48H/0H/0H/2H")
+				ctxButton(ctx, Button2)
+			},
+			script: urtypes.P2PKH,
+			path:   urtypes.Path{h + 48, h + 0, h + 0, h + 2},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newPlatform()
+			ctx := NewContext(p)
+			ops := new(op.Ops)
+			var got *urtypes.OutputDescriptor
+			frame, quit := iter.Pull(runUI(ctx, func() {
+				got, _ = inputDescriptorFlow(ctx, ops.Context(), &descriptorTheme, nil)
+			}))
+			defer quit()
+			frame = resetOps(ops, frame)
+			waitFor := func(title string) {
+				t.Helper()
+				for range 20 {
+					frame()
+					if opsContains(ops, title) {
+						return
+					}
+				}
+				t.Fatalf("%q screen not shown", title)
+			}
+			ctxQR(t, ctx, p, test.key)
+			ctxButton(ctx, Button3)
+			waitFor("Key Origin")
+			test.input(ctx, waitFor)
+			waitFor("Fingerprint")
+			ctxString(ctx, "DC567276")
+			ctxButton(ctx, Button2)
+			for {
+				if _, ok := frame(); !ok {
+					break
+				}
+			}
+			if got == nil {
+				t.Fatal("no descriptor")
+			}
+			k := got.Keys[0]
+			if got.Script != test.script || !reflect.DeepEqual(k.DerivationPath, test.path) || k.MasterFingerprint != 0xdc567276 {
+				t.Errorf("key origin [%.8x/%v] with script %v, want [dc567276/%v] with script %v",
+					k.MasterFingerprint, k.DerivationPath, got.Script, test.path, test.script)
+			}
+		})
+	}
+}
 
-	// Back.
-	ctxButton(ctx, Button1)
-	// Hold confirm.
-	ctxPress(ctx, Button3)
-	frame()
-	if exited {
-		t.Error("exited screen without confirmation")
+func TestScanCameraControls(t *testing.T) {
+	ctx := NewContext(newPlatform())
+	ctxButton(ctx, Up, Up, Up, Left, Button1)
+	for range runUI(ctx, func() {
+		(&ScanScreen{}).Scan(ctx, op.Ctx{})
+	}) {
 	}
-	p.timeOffset += confirmDelay
-	frame()
-	if !exited {
-		t.Error("failed to exit screen")
+	want := CameraControls{ZoomLevel: maxZoomLevel, Exposure: -1}
+	if got := ctx.Camera; got != want {
+		t.Errorf("camera controls %+v, want %+v", got, want)
 	}
 }
 
-func TestXpubMasterFingerprintSinglesig(t *testing.T) {
+func TestFrameSharpness(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 40, 40))
+	if got := frameSharpness(img); got != 0 {
+		t.Errorf("uniform frame sharpness %d%%, want 0%%", got)
+	}
+	for y := range 40 {
+		for x := range 40 {
+			if x/2%2 == 0 {
+				img.SetGray(x, y, color.Gray{Y: 0xff})
+			}
+		}
+	}
+	if got := frameSharpness(img); got != 100 {
+		t.Errorf("striped frame sharpness %d%%, want 100%%", got)
+	}
+}
+
+func TestScaleRot(t *testing.T) {
+	// A checkerboard of single pixels averages to gray, at a
+	// non-integer scale.
+	src := image.NewGray(image.Rect(0, 0, 7, 7))
+	for y := range 7 {
+		for x := range 7 {
+			if (x+y)%2 == 0 {
+				src.SetGray(x, y, color.Gray{Y: 0xff})
+			}
+		}
+	}
+	dst := image.NewGray(image.Rect(0, 0, 2, 2))
+	scaleRot(dst, src, false)
+	for i, c := range dst.Pix {
+		if c < 0x60 || c > 0xa0 {
+			t.Errorf("checkerboard pixel %d scaled to %#x, want gray", i, c)
+		}
+	}
+	// The right half of the source is bright, and ends up at the bottom of
+	// the destination, or the top when rotated.
+	src = image.NewGray(image.Rect(0, 0, 6, 6))
+	for y := range 6 {
+		for x := 3; x < 6; x++ {
+			src.SetGray(x, y, color.Gray{Y: 0xff})
+		}
+	}
+	dst = image.NewGray(image.Rect(0, 0, 3, 3))
+	for _, rot180 := range []bool{false, true} {
+		scaleRot(dst, src, rot180)
+		bright, dark := 2, 0
+		if rot180 {
+			bright, dark = 0, 2
+		}
+		for x := range 3 {
+			if c := dst.GrayAt(x, bright).Y; c != 0xff {
+				t.Errorf("rot180=%v: pixel (%d,%d) is %#x, want bright", rot180, x, bright, c)
+			}
+			if c := dst.GrayAt(x, dark).Y; c != 0 {
+				t.Errorf("rot180=%v: pixel (%d,%d) is %#x, want dark", rot180, x, dark, c)
+			}
+		}
+	}
+}
+
+func TestScanHDKey(t *testing.T) {
 	const mnemonic = "upset toe sheriff cotton vibrant shock torch waste congress innocent company review"
-	const descriptor = "zpub6qiC7jMrWkhNEu7YamFTWx8YHQaDFynLYQCUmxjCWpBiLQ4Qp6c6PEwpZpkN27XmUtBjX7hVLyyBKa7zhgaB5B2qvdckaP21ADwx7oYgYD6"
+	const xpub = "zpub6qiC7jMrWkhNEu7YamFTWx8YHQaDFynLYQCUmxjCWpBiLQ4Qp6c6PEwpZpkN27XmUtBjX7hVLyyBKa7zhgaB5B2qvdckaP21ADwx7oYgYD6"
 
 	m, err := bip39.ParseMnemonic(mnemonic)
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	p := newPlatform()
-	ctx := NewContext(p)
-	ops := new(op.Ops)
-	ctxQR(t, ctx, p, descriptor)
-	ctxButton(ctx, Button3)
-	got, parsed := inputDescriptorFlow(ctx, ops.Context(), &descriptorTheme, m)
-
-	if !parsed {
-		t.Error("failed to parse descriptor")
-	}
-
-	want, err := nonstandard.OutputDescriptor([]byte(descriptor))
+	want, err := nonstandard.OutputDescriptor([]byte(xpub))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -585,8 +2119,24 @@ func TestXpubMasterFingerprintSinglesig(t *testing.T) {
 		t.Fatal(err)
 	}
 	want.Keys[0].MasterFingerprint = mfp
+	hdkey := strings.ToUpper(ur.Encode("crypto-hdkey", want.Keys[0].Encode(), 1, 1))
+
+	p := newPlatform()
+	ctx := NewContext(p)
+	ops := new(op.Ops)
+	ctxQR(t, ctx, p, hdkey)
+	ctxButton(ctx, Button3)
+	var got *urtypes.OutputDescriptor
+	var parsed bool
+	for range runUI(ctx, func() {
+		got, parsed = inputDescriptorFlow(ctx, ops.Context(), &descriptorTheme, m)
+	}) {
+	}
+	if !parsed || got == nil {
+		t.Fatal("failed to parse crypto-hdkey")
+	}
 	if !reflect.DeepEqual(want, *got) {
-		t.Error("descriptors don't match")
+		t.Errorf("crypto-hdkey parsed to\n%+v\nexpected\n%+v", *got, want)
 	}
 }
 
@@ -614,6 +2164,7 @@ func TestSeed(t *testing.T) {
 		MasterFingerprint: mfp,
 		Font:              constant.Font,
 		Size:              backup.SquarePlate,
+		QRLevel:           qr.M,
 	}
 	side, err := backup.EngraveSeed(p.EngraverParams(), seedDesc)
 	if err != nil {
@@ -637,6 +2188,267 @@ func TestSeed(t *testing.T) {
 		ctxButton(ctx, Button3)
 		frame()
 	}
+	if p.settings.NeedleWear == 0 {
+		t.Error("engraving didn't record needle wear")
+	}
+}
+
+func TestNeedleReminder(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+	plate := Plate{Sides: []engrave.Plan{func(yield func(engrave.Command) bool) {}}}
+	if ins := NewEngraveScreen(ctx, plate).instructions[0]; ins.Lead == "Needle maintenance" {
+		t.Error("reminder for a new needle")
+	}
+	ctx.Settings.NeedleWear = Service500m.millimeters()
+	if ins := NewEngraveScreen(ctx, plate).instructions[0]; ins.Lead != "Needle maintenance" {
+		t.Error("no reminder for a worn needle")
+	}
+	ctx.Settings.NeedleService = Service1000m
+	if ins := NewEngraveScreen(ctx, plate).instructions[0]; ins.Lead == "Needle maintenance" {
+		t.Error("reminder before the service interval")
+	}
+}
+
+func TestNeedleWearNoSDCard(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+	p.storeErr = errors.New("SD card removed")
+	mm := p.EngraverParams().Millimeter
+	recordWear(ctx, 1000*mm)
+	recordWear(ctx, 500*mm)
+	if got := ctx.Settings.NeedleWear; got != 1500 {
+		t.Fatalf("needle wear %d mm, want 1500 mm", got)
+	}
+	p.storeErr = nil
+	ctx.sdCard(SDCardEvent{Inserted: true})
+	if got := p.settings.NeedleWear; got != 1500 {
+		t.Errorf("stored needle wear %d mm after SD card insertion, want 1500 mm", got)
+	}
+}
+
+func TestPlateSideMismatch(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+	side := engrave.Plan(func(yield func(engrave.Command) bool) {})
+	plate := Plate{
+		Size:              backup.SquarePlate,
+		MasterFingerprint: 0x11111111,
+		Sides:             []engrave.Plan{side, side},
+	}
+	scr := NewEngraveScreen(ctx, plate)
+	ops := new(op.Ops)
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		scr.Engrave(ctx, ops.Context(), &engraveTheme)
+	}))
+	defer quit()
+	frame = resetOps(ops, frame)
+	// Side A is logged even without the SD card.
+	p.storeErr = errors.New("SD card removed")
+	testEngraving(t, p, ctx, scr, side, frame)
+	if a, ok := pendingSideA(ctx.Settings); !ok || a.MasterFingerprint != plate.MasterFingerprint {
+		t.Fatalf("side A logged as %+v, want fingerprint %.8x", a, plate.MasterFingerprint)
+	}
+	if err := verifyAudit(ctx.Settings.AuditLog); err != nil {
+		t.Fatal(err)
+	}
+	// Pretend the plate in the engraver is for another seed.
+	ctx.Settings.AuditLog = appendAudit(ctx.Settings.AuditLog, AuditEntry{MasterFingerprint: 0x22222222, SideA: true})
+	for scr.instructions[scr.step].Type != ConnectInstruction {
+		ctxButton(ctx, Button3)
+		frame()
+	}
+	// Hold connect.
+	ctxPress(ctx, Button3)
+	frame()
+	p.timeOffset += confirmDelay
+	frame()
+	if !opsContains(ops, "Plate Mismatch") {
+		t.Fatal("no warning before engraving a mismatched side B")
+	}
+	// Decline.
+	ctxButton(ctx, Button1)
+	frame()
+	if ins := scr.instructions[scr.step]; ins.Type != ConnectInstruction {
+		t.Fatalf("declined side B advanced to %v", ins.Type)
+	}
+	// Hold connect, then engrave anyway.
+	p.engrave.closed = make(chan []mjolnir.Cmd)
+	ctxPress(ctx, Button3)
+	frame()
+	p.timeOffset += confirmDelay
+	frame()
+	ctxPress(ctx, Button3)
+	frame()
+	p.timeOffset += confirmDelay
+	frame()
+	<-p.engrave.closed
+	for scr.instructions[scr.step].Type == EngraveInstruction {
+		frame()
+	}
+	recordBackup(ctx, Backup{MasterFingerprint: plate.MasterFingerprint, Keys: 1, Size: plate.Size})
+	if a, ok := pendingSideA(ctx.Settings); ok {
+		t.Errorf("side A %+v still pending after logging the plate", a)
+	}
+}
+
+func TestPlanFile(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+	params := p.EngraverParams()
+	side := engrave.Plan(func(yield func(engrave.Command) bool) {
+		_ = yield(engrave.Move(image.Pt(params.I(10), params.I(10)))) &&
+			yield(engrave.Line(image.Pt(params.I(20), params.I(100))))
+	})
+	p.imported = map[string][]byte{"plate.shp": engrave.MarshalPlan(side)}
+	plate, err := planPlate(plateSizes, params, p.imported["plate.shp"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plate.Size != backup.LargePlate {
+		t.Errorf("plan placed on %v, want %v", plate.Size, backup.LargePlate)
+	}
+
+	// Choose the plan.
+	ctxButton(ctx, Button3)
+	ops := new(op.Ops)
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		planFileFlow(ctx, ops.Context(), &singleTheme)
+	}))
+	defer quit()
+	frame = resetOps(ops, frame)
+	frame()
+	frame()
+	if !opsContains(ops, "Engrave Plate") {
+		t.Error("plan file not engraved")
+	}
+}
+
+func TestEngraveTraceOutline(t *testing.T) {
+	p := newPlatform()
+	ctx := NewContext(p)
+	side := engrave.Plan(func(yield func(engrave.Command) bool) {
+		_ = yield(engrave.Move(image.Pt(1000, 1000))) &&
+			yield(engrave.Line(image.Pt(2000, 3000)))
+	})
+	plate := Plate{
+		Size:  backup.SquarePlate,
+		Sides: []engrave.Plan{side},
+	}
+	scr := NewEngraveScreen(ctx, plate)
+	ops := new(op.Ops)
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		scr.Engrave(ctx, ops.Context(), &engraveTheme)
+	}))
+	defer quit()
+	frame = resetOps(ops, frame)
+	// Long press twice to select outline tracing.
+	for range 2 {
+		ctxPress(ctx, Button2)
+		frame()
+		p.timeOffset += confirmDelay
+		frame()
+		ctx.Events(ButtonEvent{Button: Button2}.Event())
+		frame()
+	}
+	if !opsContains(ops, "trace outline") {
+		t.Fatal("outline tracing not enabled")
+	}
+	testEngraving(t, p, ctx, scr, engrave.Outline(side), frame)
+	if want := []bool{true, false}; !reflect.DeepEqual(p.pointers, want) {
+		t.Errorf("pointer switched %v, want %v", p.pointers, want)
+	}
+}
+
+func TestEngraverDevice(t *testing.T) {
+	p := newPlatform()
+	p.engrave.device = "/dev/ttyUSB1"
+	ctx := NewContext(p)
+	side := engrave.Plan(func(yield func(engrave.Command) bool) {})
+	plate := Plate{
+		Size:  backup.SquarePlate,
+		Sides: []engrave.Plan{side, side},
+	}
+	scr := NewEngraveScreen(ctx, plate)
+	ops := new(op.Ops)
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		scr.Engrave(ctx, ops.Context(), &engraveTheme)
+	}))
+	defer quit()
+	frame = resetOps(ops, frame)
+	testEngraving(t, p, ctx, scr, side, frame)
+	for scr.instructions[scr.step].Type != ConnectInstruction {
+		ctxButton(ctx, Button3)
+		frame()
+	}
+	if !opsContains(ops, p.engrave.device) {
+		t.Error("connect screen doesn't show the engraver device")
+	}
+}
+
+func TestCosignersWrongSeed(t *testing.T) {
+	const oneOfTwoDesc = "wsh(sortedmulti(1,[94631f99/48h/0h/0h/2h]xpub6ENfRaMWq2UoFy5FrLRMwiEkdgFdMgjEoikR34RBGzhsx8JzAkn7fyQeR5odirEwERvmxhSEv7rsmV7nuzjSKKKJHBP2aQZVu3R2d5ERgcw,[4bbaa801/48h/0h/0h/2h]xpub6E8mpiqJiVKuJZqxtu5SbHQnwUWWPQpZEy9CVtvfU1gxXZnbb9DG2AvZyMHvyVRtUPAEmu6BuRCy4LK2rKMeNr7jQKXsCyFfr1osgFCMYpc))"
+	desc, err := nonstandard.OutputDescriptor([]byte(oneOfTwoDesc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The seed of the second key, which is already engraved.
+	m, err := bip39.ParseMnemonic("road lend lyrics shift rabbit amazing fetch impulse provide reopen sphere network")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := newPlatform()
+	ctx := NewContext(p)
+	ops := new(op.Ops)
+	frame, quit := iter.Pull(runUI(ctx, func() {
+		cosignersFlow(ctx, ops.Context(), &descriptorTheme, desc, 1)
+	}))
+	defer quit()
+	frame = resetOps(ops, frame)
+	// Hold confirm.
+	ctxPress(ctx, Button3)
+	frame()
+	p.timeOffset += confirmDelay
+	frame()
+	ctx.Events(ButtonEvent{Button: Button3}.Event())
+	// Scan the seed.
+	ctxButton(ctx, Down, Button3)
+	ctxQR(t, ctx, p, string(seedqr.QR(m)))
+	const want = "does not match cosigner 1 of 2"
+	for i := 0; i < 10 && !opsContains(ops, want); i++ {
+		frame()
+	}
+	if !opsContains(ops, want) {
+		t.Error("wrong cosigner seed accepted")
+	}
+}
+
+func TestVerifyBackup(t *testing.T) {
+	const oneOfTwoDesc = "wsh(sortedmulti(1,[94631f99/48h/0h/0h/2h]xpub6ENfRaMWq2UoFy5FrLRMwiEkdgFdMgjEoikR34RBGzhsx8JzAkn7fyQeR5odirEwERvmxhSEv7rsmV7nuzjSKKKJHBP2aQZVu3R2d5ERgcw,[4bbaa801/48h/0h/0h/2h]xpub6E8mpiqJiVKuJZqxtu5SbHQnwUWWPQpZEy9CVtvfU1gxXZnbb9DG2AvZyMHvyVRtUPAEmu6BuRCy4LK2rKMeNr7jQKXsCyFfr1osgFCMYpc))"
+	desc, err := nonstandard.OutputDescriptor([]byte(oneOfTwoDesc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		mnemonic string
+		desc     *urtypes.OutputDescriptor
+		title    string
+	}{
+		{"road lend lyrics shift rabbit amazing fetch impulse provide reopen sphere network", &desc, "Backup Verified"},
+		{"doll clerk nice coast caught valid shallow taxi buyer economy lunch roof", nil, "Seed Verified"},
+		{"attack pizza motion avocado network gather crop fresh patrol unusual wild holiday", &desc, "Backup Mismatch"},
+	}
+	for _, test := range tests {
+		m, err := bip39.ParseMnemonic(test.mnemonic)
+		if err != nil && !errors.Is(err, bip39.ErrInvalidChecksum) {
+			t.Fatal(err)
+		}
+		m = m.FixChecksum()
+		if got := verifyResult(m, test.desc).Title; got != test.title {
+			t.Errorf("%s: verified as %q, want %q", test.mnemonic, got, test.title)
+		}
+	}
 }
 
 func TestMulti(t *testing.T) {
@@ -665,6 +2477,7 @@ func TestMulti(t *testing.T) {
 			KeyIdx:     i,
 			Font:       constant.Font,
 			Size:       size,
+			QRLevel:    qr.M,
 		}
 		descSide, err := backup.EngraveDescriptor(p.EngraverParams(), descPlate)
 		if err != nil {
@@ -678,6 +2491,7 @@ func TestMulti(t *testing.T) {
 			MasterFingerprint: oneOfTwo.Keys[i].MasterFingerprint,
 			Font:              constant.Font,
 			Size:              size,
+			QRLevel:           qr.M,
 		}
 		seedSide, err := backup.EngraveSeed(p.EngraverParams(), seedDesc)
 		if err != nil {
@@ -749,14 +2563,80 @@ type testPlatform struct {
 		connErr        error
 		ioErr          error
 		ioErrDelivered chan<- struct{}
+		device         string
 	}
 
 	timeOffset time.Duration
-	qrImages   map[*uint8][]byte
+	// qrImages maps frame content to the QR code it contains.
+	// It is read by the scanner goroutine.
+	qrMu     sync.Mutex
+	qrImages map[string][]byte
+	settings Settings
+	// storeErr fails StoreSettings, as if the SD card were
+	// removed.
+	storeErr error
+	// noSecret is set until the device secret is provisioned.
+	noSecret bool
+	beeps    int
+	pointers []bool
+	exported map[string][]byte
+	imported map[string][]byte
+}
+
+func (t *testPlatform) LoadSettings() (Settings, error) {
+	return t.settings, nil
+}
+
+func (t *testPlatform) StoreSettings(s Settings) error {
+	if t.storeErr != nil {
+		return t.storeErr
+	}
+	t.settings = s
+	return nil
+}
+
+func (t *testPlatform) ExportFile(name string, data []byte) error {
+	if t.exported == nil {
+		t.exported = make(map[string][]byte)
+	}
+	t.exported[name] = data
+	return nil
+}
+
+func (t *testPlatform) ImportFiles(ext string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	for name, data := range t.imported {
+		if strings.HasSuffix(name, ext) {
+			files[name] = data
+		}
+	}
+	return files, nil
+}
+
+func (t *testPlatform) Pointer(on bool) {
+	t.pointers = append(t.pointers, on)
+}
+
+func (t *testPlatform) Beep() {
+	t.beeps++
+}
+
+func (t *testPlatform) DeviceSecret() ([]byte, error) {
+	if t.noSecret {
+		return nil, ErrNoDeviceSecret
+	}
+	return []byte("test device"), nil
+}
+
+func (t *testPlatform) ProvisionSecret() error {
+	t.noSecret = false
+	return nil
 }
 
 func (t *testPlatform) ScanQR(img *image.Gray) ([][]byte, error) {
-	if content, ok := t.qrImages[&img.Pix[0]]; ok {
+	t.qrMu.Lock()
+	defer t.qrMu.Unlock()
+	if content, ok := t.qrImages[string(img.Pix)]; ok {
 		return [][]byte{content}, nil
 	}
 	return nil, errors.New("no QR code")
@@ -824,10 +2704,9 @@ func ctxButton(ctx *Context, bs ...Button) {
 
 func (p *testPlatform) Wakeup() {
 	select {
-	case <-p.wakeups:
+	case p.wakeups <- struct{}{}:
 	default:
 	}
-	p.wakeups <- struct{}{}
 }
 
 func (p *testPlatform) AppendEvents(deadline time.Time, evts []Event) []Event {
@@ -871,7 +2750,11 @@ func (w *wrappedEngraver) Close() error {
 }
 
 func (p *testPlatform) EngraverParams() engrave.Params {
-	return mjolnir.Params
+	params := mjolnir.Params
+	if p.settings.Order == OrderSpread {
+		params.SpreadRegion = params.I(20)
+	}
+	return params
 }
 
 var plateSizes = []backup.PlateSize{backup.SquarePlate, backup.LargePlate}
@@ -886,23 +2769,29 @@ func (p *testPlatform) Engraver() (Engraver, error) {
 	}
 	sim := mjolnir.NewSimulator()
 	return &engraver{
-		dev: &wrappedEngraver{sim, p.engrave.closed, p.engrave.ioErr, p.engrave.ioErrDelivered},
+		dev:    &wrappedEngraver{sim, p.engrave.closed, p.engrave.ioErr, p.engrave.ioErrDelivered},
+		device: p.engrave.device,
 	}, nil
 }
 
 type engraver struct {
-	dev io.ReadWriteCloser
+	dev    io.ReadWriteCloser
+	device string
 }
 
-func (e *engraver) Engrave(sz backup.PlateSize, plan engrave.Plan, quit <-chan struct{}) error {
-	return mjolnir.Engrave(e.dev, mjolnir.Options{}, plan, quit)
+func (e *engraver) Device() string {
+	return e.device
+}
+
+func (e *engraver) Engrave(sz backup.PlateSize, plan engrave.Plan, progress func(completed int), pause <-chan bool, quit <-chan struct{}) error {
+	return mjolnir.Engrave(e.dev, mjolnir.Options{Progress: progress, Pause: pause}, plan, quit)
 }
 
 func (e *engraver) Close() {
 	e.dev.Close()
 }
 
-func (p *testPlatform) CameraFrame(dims image.Point) {
+func (p *testPlatform) CameraFrame(dims image.Point, ctrls CameraControls) {
 }
 
 func newPlatform() *testPlatform {
@@ -926,10 +2815,12 @@ func qrFrame(t *testing.T, p *testPlatform, content string) FrameEvent {
 			frameImg.Y[off] = uint8(r >> 8)
 		}
 	}
+	p.qrMu.Lock()
+	defer p.qrMu.Unlock()
 	if p.qrImages == nil {
-		p.qrImages = make(map[*byte][]byte)
+		p.qrImages = make(map[string][]byte)
 	}
-	p.qrImages[&frameImg.Y[0]] = []byte(content)
+	p.qrImages[string(frameImg.Y)] = []byte(content)
 	return FrameEvent{
 		Image: frameImg,
 	}
@@ -1018,3 +2909,41 @@ var twoOfThree = struct {
 		},
 	},
 }
+
+func TestEngraveProgress(t *testing.T) {
+	p := EngraveProgress{Distance: 200, Remaining: 50}
+	if got := p.Fraction(); got != 0.75 {
+		t.Errorf("fraction %v, want 0.75", got)
+	}
+	if got := (EngraveProgress{}).Fraction(); got != 0 {
+		t.Errorf("empty fraction %v, want 0", got)
+	}
+	tests := []struct {
+		eta  time.Duration
+		want string
+	}{
+		{30 * time.Second, "Less than a minute left"},
+		{time.Minute, "About 1 min left"},
+		{61 * time.Second, "About 2 min left"},
+	}
+	for _, test := range tests {
+		if got := formatETA(test.eta); got != test.want {
+			t.Errorf("formatETA(%v) = %q, want %q", test.eta, got, test.want)
+		}
+	}
+}
+
+func TestEngraveETA(t *testing.T) {
+	var c etaClock
+	t0 := time.Now()
+	c.Update(t0, 0, 100)
+	if got, want := c.Update(t0.Add(10*time.Second), 10, 100), 90*time.Second; got != want {
+		t.Errorf("ETA %v, want %v", got, want)
+	}
+	// A minute long pause doesn't count as engraving time.
+	c.Pause(t0.Add(10*time.Second), true)
+	c.Pause(t0.Add(70*time.Second), false)
+	if got, want := c.Update(t0.Add(80*time.Second), 20, 100), 80*time.Second; got != want {
+		t.Errorf("ETA %v after pause, want %v", got, want)
+	}
+}
@@ -0,0 +1,210 @@
+package gui
+
+import (
+	"image"
+	"slices"
+	"time"
+)
+
+const repeatStartDelay = 400 * time.Millisecond
+const repeatDelay = 100 * time.Millisecond
+
+// doubleTapDelay is the longest interval between the clicks of a
+// double tap.
+const doubleTapDelay = 300 * time.Millisecond
+
+// swipeDistance is the shortest pointer drag in pixels recognized as
+// a swipe.
+const swipeDistance = 40
+
+// Gesture is a gesture recognized by an InputTracker.
+type Gesture int
+
+const (
+	// NoGesture marks plain button events.
+	NoGesture Gesture = iota
+	// LongPress is reported while a button is held for
+	// confirmDelay.
+	LongPress
+	// DoubleTap is reported on the release of the second of two
+	// clicks within doubleTapDelay.
+	DoubleTap
+	// Swipe is reported for pointer drags, as a press of the
+	// direction button that moves the content along with the
+	// pointer.
+	Swipe
+)
+
+// InputTracker tracks the state of buttons and recognizes gestures
+// from buttons and pointer input.
+type InputTracker struct {
+	Pressed [nbuttons]bool
+	clicked [nbuttons]bool
+	repeats [nbuttons]time.Time
+	// presses records when held buttons were pressed. It is
+	// cleared when a long press is reported, to suppress the click
+	// of the release.
+	presses [nbuttons]time.Time
+	// taps records the latest click of every button.
+	taps    [nbuttons]time.Time
+	pointer struct {
+		down  bool
+		start image.Point
+	}
+}
+
+func isRepeatButton(b Button) bool {
+	switch b {
+	case Up, Down, Right, Left:
+		return true
+	}
+	return false
+}
+
+// isHoldButton reports whether b is reported as a long press when
+// held.
+func isHoldButton(b Button) bool {
+	switch b {
+	case Center, Button1, Button2, Button3:
+		return true
+	}
+	return false
+}
+
+// Next returns the next event of btns, including repeats of held
+// direction buttons and recognized gestures.
+func (t *InputTracker) Next(c *Context, btns ...Button) (ButtonEvent, bool) {
+	now := c.Platform.Now()
+	for _, b := range btns {
+		if !isRepeatButton(b) {
+			continue
+		}
+		if !t.Pressed[b] {
+			t.repeats[b] = time.Time{}
+			continue
+		}
+		wakeup := t.repeats[b]
+		if wakeup.IsZero() {
+			wakeup = now.Add(repeatStartDelay)
+		}
+		repeat := !now.Before(wakeup)
+		if repeat {
+			wakeup = now.Add(repeatDelay)
+		}
+		t.repeats[b] = wakeup
+		c.WakeupAt(wakeup)
+		if repeat {
+			return ButtonEvent{Button: b, Pressed: true}, true
+		}
+	}
+	for _, b := range btns {
+		if !isHoldButton(b) || !t.Pressed[b] || t.presses[b].IsZero() {
+			continue
+		}
+		deadline := t.presses[b].Add(confirmDelay)
+		if now.Before(deadline) {
+			c.WakeupAt(deadline)
+			continue
+		}
+		t.presses[b] = time.Time{}
+		return ButtonEvent{Button: b, Pressed: true, Gesture: LongPress}, true
+	}
+	if e, ok := t.swipe(c, btns); ok {
+		return e, true
+	}
+
+	e, ok := c.Next(btns...)
+	if !ok {
+		return ButtonEvent{}, false
+	}
+	if b := e.Button; int(b) < len(t.clicked) {
+		clicked := !e.Pressed && t.Pressed[b]
+		if isHoldButton(b) {
+			// Released long presses are not clicks.
+			clicked = clicked && !t.presses[b].IsZero()
+		}
+		t.clicked[b] = clicked
+		t.Pressed[b] = e.Pressed
+		t.presses[b] = time.Time{}
+		if e.Pressed {
+			t.presses[b] = now
+		}
+		if clicked {
+			tap := t.taps[b]
+			t.taps[b] = now
+			if !tap.IsZero() && now.Sub(tap) <= doubleTapDelay {
+				e.Gesture = DoubleTap
+				// Start over for the next double tap.
+				t.taps[b] = time.Time{}
+			}
+		}
+	}
+	if e.Pressed && c.Settings.Accessibility == AccessibilityBeep {
+		c.Platform.Beep()
+	}
+	return e, true
+}
+
+// swipe consumes pointer events and returns the direction button of
+// a completed swipe, if it is among btns. Pointer events are left
+// for other trackers if btns contains no direction buttons.
+func (t *InputTracker) swipe(c *Context, btns []Button) (ButtonEvent, bool) {
+	if !slices.ContainsFunc(btns, isRepeatButton) {
+		return ButtonEvent{}, false
+	}
+	for {
+		e, ok := c.NextPointer()
+		if !ok {
+			return ButtonEvent{}, false
+		}
+		if e.Pressed {
+			if !t.pointer.down {
+				t.pointer.down = true
+				t.pointer.start = e.Pos
+			}
+			continue
+		}
+		if !t.pointer.down {
+			continue
+		}
+		t.pointer.down = false
+		d := e.Pos.Sub(t.pointer.start)
+		dx, dy := max(d.X, -d.X), max(d.Y, -d.Y)
+		var b Button
+		switch {
+		case max(dx, dy) < swipeDistance:
+			continue
+		case dx >= dy && d.X < 0:
+			b = Right
+		case dx >= dy:
+			b = Left
+		case d.Y < 0:
+			b = Down
+		default:
+			b = Up
+		}
+		if slices.Contains(btns, b) {
+			return ButtonEvent{Button: b, Pressed: true, Gesture: Swipe}, true
+		}
+	}
+}
+
+func (t *InputTracker) Clicked(b Button) bool {
+	c := t.clicked[b]
+	t.clicked[b] = false
+	return c
+}
+
+// Progress returns the progress of the long press of b, and
+// schedules a frame for animating it.
+func (t *InputTracker) Progress(c *Context, b Button) float32 {
+	if !t.Pressed[b] || t.presses[b].IsZero() {
+		return 0.
+	}
+	d := c.Platform.Now().Sub(t.presses[b])
+	if d >= confirmDelay {
+		return 1.
+	}
+	c.Platform.Wakeup()
+	return float32(d.Seconds() / confirmDelay.Seconds())
+}
@@ -0,0 +1,79 @@
+package gui
+
+import (
+	"image"
+	"strings"
+
+	"seedhammer.com/backup"
+	"seedhammer.com/font/constant"
+	"seedhammer.com/gui/assets"
+	"seedhammer.com/gui/layout"
+	"seedhammer.com/gui/op"
+	"seedhammer.com/gui/widget"
+)
+
+// inputLabelFlow edits the label of a descriptor key. The label is
+// engraved next to the master fingerprint on the seed side of the
+// key's plate. An empty label removes it.
+func inputLabelFlow(ctx *Context, ops op.Ctx, th *Colors, label string) (string, bool) {
+	label, ok := inputTextFlow(ctx, ops, th, "Input Label", label, backup.MaxLabelLen)
+	if !ok {
+		return "", false
+	}
+	return backup.LabelString(constant.Font, strings.TrimSpace(label)), true
+}
+
+// inputTextFlow edits a short text of at most maxLen characters.
+// The text is shown in upper case.
+func inputTextFlow(ctx *Context, ops op.Ctx, th *Colors, title, txt string, maxLen int) (string, bool) {
+	kbd := NewTextKeyboard(ctx)
+	kbd.MaxLen = maxLen
+	kbd.Word = txt
+	inp := new(InputTracker)
+	for {
+		for {
+			kbd.Update(ctx)
+			e, ok := inp.Next(ctx, Button1, Button2)
+			if !ok {
+				break
+			}
+			if !inp.Clicked(e.Button) {
+				continue
+			}
+			switch e.Button {
+			case Button1:
+				return "", false
+			case Button2:
+				return kbd.Word, true
+			}
+		}
+		dims := ctx.Platform.DisplaySize()
+		op.ColorOp(ops, th.Background)
+		layoutTitle(ctx, ops, dims.X, th.Text, title)
+
+		screen := layout.Rectangle{Max: dims}
+		_, content := screen.CutTop(leadingSize)
+		content, _ = content.CutBottom(8)
+
+		kbdsz := kbd.Layout(ctx, ops.Begin(), th)
+		op.Position(ops, ops.End(), content.S(kbdsz))
+
+		style := ctx.Styles.word
+		longest := style.Measure(kbdsz.X, strings.Repeat("W", maxLen))
+		widget.Labelf(ops.Begin(), style, th.Background, "%s", strings.ToUpper(kbd.Word))
+		word := ops.End()
+		r := image.Rectangle{Max: longest}
+		r.Min.Y -= 3
+		assets.ButtonFocused.Add(ops.Begin(), r, true)
+		op.ColorOp(ops, th.Text)
+		word.Add(ops)
+		top, _ := content.CutBottom(kbdsz.Y)
+		op.Position(ops, ops.End(), top.Center(longest))
+
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
+			{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack},
+			{Button: Button2, Style: StylePrimary, Icon: assets.IconCheckmark},
+		}...)
+		ctx.Frame()
+	}
+}
@@ -0,0 +1,79 @@
+package gui
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"seedhammer.com/bc/urtypes"
+	"seedhammer.com/bip39"
+	"seedhammer.com/gui/assets"
+	"seedhammer.com/gui/op"
+)
+
+// mismatchDetails explains why mnemonic doesn't match desc, by
+// listing the master fingerprint of the seed and the fingerprints
+// and derivation paths of the keys it was matched against.
+func mismatchDetails(desc urtypes.OutputDescriptor, mnemonic bip39.Mnemonic) string {
+	var b strings.Builder
+	if len(desc.Keys) > 0 {
+		if mfp, err := masterFingerprintFor(mnemonic, desc.Keys[0].Network); err == nil {
+			fmt.Fprintf(&b, "Seed fingerprint: %.8X\n\n", mfp)
+		}
+	}
+	b.WriteString("Wallet keys tried:")
+	for i, k := range desc.Keys {
+		fmt.Fprintf(&b, "\n%d: %.8X %s", i+1, k.MasterFingerprint, k.DerivationPath)
+	}
+	return b.String()
+}
+
+// overrideMismatchFlow lets the user engrave a singlesig
+// descriptor that doesn't match the seed, as is the case for
+// passphrase protected seeds. To avoid engraving the wrong wallet by
+// mistake, the user must type the master fingerprint of the
+// descriptor key. It reports whether the user overrode the mismatch.
+func overrideMismatchFlow(ctx *Context, ops op.Ctx, th *Colors, s *DescriptorScreen) bool {
+	confirm := &ConfirmWarningScreen{
+		Title: "Unknown Wallet",
+		Body:  "The wallet does not match the seed.\n\n" + mismatchDetails(s.Descriptor, s.Mnemonic) + "\n\nIf the seed is passphrase protected, long press to confirm by typing the wallet fingerprint.",
+		Icon:  assets.IconCheckmark,
+	}
+	for {
+		dims := ctx.Platform.DisplaySize()
+		res := confirm.Layout(ctx, ops.Begin(), th, dims)
+		d := ops.End()
+		switch res {
+		case ConfirmYes:
+			want := fmt.Sprintf("%.8X", s.Descriptor.Keys[0].MasterFingerprint)
+			typed, ok := inputTextFlow(ctx, ops, th, "Fingerprint", "", len(want))
+			if !ok {
+				return false
+			}
+			if strings.ToUpper(strings.TrimSpace(typed)) != want {
+				errScr := &ErrorScreen{
+					Title: "Wrong Fingerprint",
+					Body:  fmt.Sprintf("The typed fingerprint %s does not match the wallet fingerprint %s.", strings.ToUpper(typed), want),
+				}
+				for {
+					dims := ctx.Platform.DisplaySize()
+					dismissed := errScr.Layout(ctx, ops.Begin(), th, dims)
+					d := ops.End()
+					if dismissed {
+						return false
+					}
+					s.Draw(ctx, ops, th, dims)
+					d.Add(ops)
+					ctx.Frame()
+				}
+			}
+			log.Printf("gui: wallet %s engraved despite seed mismatch", want)
+			return true
+		case ConfirmNo:
+			return false
+		}
+		s.Draw(ctx, ops, th, dims)
+		d.Add(ops)
+		ctx.Frame()
+	}
+}
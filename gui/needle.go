@@ -0,0 +1,103 @@
+package gui
+
+import (
+	"fmt"
+
+	"seedhammer.com/gui/assets"
+	"seedhammer.com/gui/op"
+)
+
+// ServiceInterval is the engraved stroke length after which the
+// needle is due for rotation or replacement.
+type ServiceInterval int
+
+const (
+	Service500m ServiceInterval = iota
+	Service250m
+	Service1000m
+)
+
+// millimeters returns the length of the interval.
+func (s ServiceInterval) millimeters() int {
+	switch s {
+	case Service250m:
+		return 250_000
+	case Service1000m:
+		return 1_000_000
+	default:
+		return 500_000
+	}
+}
+
+// needleDue reports whether the needle is due for service.
+func needleDue(settings Settings) bool {
+	return settings.NeedleWear >= settings.NeedleService.millimeters()
+}
+
+// recordWear adds the length of engraved strokes, in machine
+// units, to the needle wear counter.
+func recordWear(ctx *Context, stroke int) {
+	mm := ctx.Platform.EngraverParams().Millimeter
+	if stroke <= 0 || mm == 0 {
+		return
+	}
+	settings := ctx.Settings
+	settings.NeedleWear += stroke / mm
+	recordSettings(ctx, settings)
+}
+
+// needleInstruction reminds the user to service a worn needle
+// before engraving.
+func needleInstruction(settings Settings) Instruction {
+	return Instruction{
+		Body: fmt.Sprintf("The needle has engraved %d m since its last service. Rotate or replace it, then reset the counter in the Needle setting.", settings.NeedleWear/1000),
+		Lead: "Needle maintenance",
+	}
+}
+
+func needleFlow(ctx *Context, ops op.Ctx, th *Colors) {
+	intervals := []ServiceInterval{Service250m, Service500m, Service1000m}
+	cs := &ChoiceScreen{
+		Title:   "Needle",
+		Lead:    fmt.Sprintf("%d m engraved since service", ctx.Settings.NeedleWear/1000),
+		Choices: []string{"RESET", "250 M", "500 M", "1000 M"},
+	}
+	for i, s := range intervals {
+		if s == ctx.Settings.NeedleService {
+			cs.choice = i + 1
+		}
+	}
+	for {
+		choice, ok := cs.Choose(ctx, ops, th)
+		if !ok {
+			return
+		}
+		settings := ctx.Settings
+		if choice == 0 {
+			confirm := &ConfirmWarningScreen{
+				Title: "Reset Counter?",
+				Body:  "Reset the counter only after rotating or replacing the needle.\n\nHold button to confirm.",
+				Icon:  assets.IconCheckmark,
+			}
+			res := ConfirmNone
+			for res == ConfirmNone {
+				dims := ctx.Platform.DisplaySize()
+				res = confirm.Layout(ctx, ops.Begin(), th, dims)
+				d := ops.End()
+				cs.Draw(ctx, ops, th, dims)
+				d.Add(ops)
+				ctx.Frame()
+			}
+			if res == ConfirmNo {
+				continue
+			}
+			settings.NeedleWear = 0
+		} else {
+			settings.NeedleService = intervals[choice-1]
+		}
+		if err := storeSettings(ctx, ops, th, settings); err != nil {
+			continue
+		}
+		return
+	}
+}
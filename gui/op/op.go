@@ -3,6 +3,7 @@ package op
 import (
 	"image"
 	"image/color"
+	"math"
 	"strings"
 
 	"golang.org/x/image/draw"
@@ -14,6 +15,7 @@ type Ops struct {
 	maskStack []frameOp
 	frame     frame
 	prevFrame frame
+	damage    []image.Rectangle
 
 	scratchMask genImage
 	scratchImg  genImage
@@ -156,9 +158,26 @@ func (o *Ops) ExtractText(dst image.Rectangle) string {
 	return b.String()
 }
 
+// Clip returns the bounds of the damage of the frame, as computed by
+// Damage.
 func (o *Ops) Clip(dst image.Rectangle) image.Rectangle {
-	o.serialize(drawState{clip: dst}, opCursor{})
 	clip := image.Rectangle{}
+	for _, r := range o.Damage(dst) {
+		clip = clip.Union(r)
+	}
+	return clip
+}
+
+// maxDamage is the maximum number of damage rectangles per frame.
+const maxDamage = 4
+
+// Damage compares the frame with the previous frame and returns the
+// rectangles of dst whose content changed. Nearby and overlapping
+// rectangles are merged. The result is valid until the next call to
+// Damage.
+func (o *Ops) Damage(dst image.Rectangle) []image.Rectangle {
+	o.serialize(drawState{clip: dst}, opCursor{})
+	o.damage = o.damage[:0]
 	prevDrawOps := o.prevFrame.drawOps
 loop:
 	for _, op := range o.frame.drawOps {
@@ -168,8 +187,6 @@ loop:
 		firstOp := o.frame.ops[op.start]
 		scanned := 0
 		nops := op.end - op.start
-		// prevClip collects unmatched clip rectangles.
-		prevClip := image.Rectangle{}
 		for i, prevOp := range prevDrawOps {
 			prevFirstOp := o.prevFrame.ops[prevOp.start]
 			prevNOps := prevOp.end - prevOp.start
@@ -178,16 +195,17 @@ loop:
 				ops := o.frame.ops[op.start+1 : op.end]
 				prevOps := o.prevFrame.ops[prevOp.start+1 : prevOp.end]
 				if opsEqual(ops, prevOps) {
-					// Match found; add interim unmatched clip areas and
+					// Match found; add interim unmatched areas and
 					// advance the previous frame.
-					clip = clip.Union(prevClip)
+					for _, prevOp := range prevDrawOps[:i] {
+						o.addDamage(o.prevFrame.ops[prevOp.end-1].clip)
+					}
 					prevDrawOps = prevDrawOps[i+1:]
 					continue loop
 				}
 				// Count the ops matched by opsEqual.
 				scanned += len(ops)
 			}
-			prevClip = prevClip.Union(o.prevFrame.ops[prevOp.end-1].clip)
 			scanned++
 			if scanned >= scanMax {
 				break
@@ -195,18 +213,60 @@ loop:
 		}
 		// No match found.
 		lastOp := o.frame.ops[op.end-1]
-		oclip := lastOp.clip
-		clip = clip.Union(oclip)
-		if clip == dst {
-			return clip
+		if o.addDamage(lastOp.clip) == dst {
+			o.damage = append(o.damage[:0], dst)
+			return o.damage
 		}
 	}
 	// Add remaining ops from the previous frame.
 	for _, prevOp := range prevDrawOps {
-		oclip := o.prevFrame.ops[prevOp.end-1].clip
-		clip = clip.Union(oclip)
+		o.addDamage(o.prevFrame.ops[prevOp.end-1].clip)
 	}
-	return clip
+	return o.damage
+}
+
+// addDamage adds r to the damage rectangles, merging it with the
+// rectangles that waste little area when combined. It returns the
+// rectangle r ended up in.
+func (o *Ops) addDamage(r image.Rectangle) image.Rectangle {
+	if r.Empty() {
+		return r
+	}
+	for {
+		merged := false
+		for i, d := range o.damage {
+			u := d.Union(r)
+			if area(u)*2 > (area(d)+area(r))*3 {
+				continue
+			}
+			// Merge and re-check the others against the union.
+			o.damage = append(o.damage[:i], o.damage[i+1:]...)
+			r = u
+			merged = true
+			break
+		}
+		if !merged {
+			break
+		}
+	}
+	if len(o.damage) < maxDamage {
+		o.damage = append(o.damage, r)
+		return r
+	}
+	// Merge with the rectangle that grows the least.
+	best := 0
+	for i, d := range o.damage {
+		if area(d.Union(r))-area(d) < area(o.damage[best].Union(r))-area(o.damage[best]) {
+			best = i
+		}
+	}
+	o.damage[best] = o.damage[best].Union(r)
+	return o.damage[best]
+}
+
+func area(r image.Rectangle) int {
+	sz := r.Size()
+	return sz.X * sz.Y
 }
 
 func opsEqual(ops1, ops2 []frameOp) bool {
@@ -445,9 +505,19 @@ var uniformImage = RegisterParameterizedImage(func(args ImageArguments, x, y int
 var glyphImage = RegisterParameterizedImage(func(args ImageArguments, x, y int) color.RGBA64 {
 	face, r := decodeGlyphImage(args)
 	glyph, _, _ := face.Glyph(r)
-	return glyph.RGBA64At(x, y)
+	s := face.Scale()
+	return glyph.RGBA64At(floorDiv(x, s), floorDiv(y, s))
 })
 
+// floorDiv is x/d rounded towards negative infinity.
+func floorDiv(x, d int) int {
+	q := x / d
+	if x%d != 0 && x < 0 {
+		q--
+	}
+	return q
+}
+
 func decodeGlyphImage(args ImageArguments) (*bitmap.Face, rune) {
 	return args.Refs[0].(*bitmap.Face), rune(args.Args[0])
 }
@@ -468,6 +538,44 @@ func ColorOp(ops Ctx, col color.NRGBA) {
 	addImageOp(ops, nil, uniformImage, imageMask, image.Rect(-1e9, -1e9, 1e9, 1e9), nil, []uint32{nrgba})
 }
 
+// RoundRectOp adds a mask of r with its corners rounded to radius.
+// Like other masks, it clips the following operations, including
+// calls.
+func RoundRectOp(ops Ctx, r image.Rectangle, radius int) {
+	StrokeOp(ops, r, radius, 0)
+}
+
+// StrokeOp adds a mask of the outline of width inside the edges of
+// the rounded rectangle r. A zero width fills the rectangle.
+func StrokeOp(ops Ctx, r image.Rectangle, radius, width int) {
+	radius = max(0, min(radius, r.Dx()/2, r.Dy()/2))
+	addImageOp(ops, nil, roundRectImage, intersectMask, r, nil, []uint32{uint32(radius), uint32(width)})
+}
+
+// CircleOp adds a mask of the circle around center.
+func CircleOp(ops Ctx, center image.Point, radius int) {
+	d := image.Pt(radius, radius)
+	RoundRectOp(ops, image.Rectangle{Min: center.Sub(d), Max: center.Add(d)}, radius)
+}
+
+var roundRectImage = RegisterParameterizedImage(func(args ImageArguments, x, y int) color.RGBA64 {
+	radius, width := float64(args.Args[0]), float64(args.Args[1])
+	b := args.Bounds
+	// Signed distance from the center of (x, y) to the edge,
+	// negative inside.
+	hw, hh := float64(b.Dx())/2, float64(b.Dy())/2
+	px := math.Abs(float64(x-b.Min.X)+.5-hw) - (hw - radius)
+	py := math.Abs(float64(y-b.Min.Y)+.5-hh) - (hh - radius)
+	d := math.Hypot(max(px, 0), max(py, 0)) + min(max(px, py), 0) - radius
+	// Approximate the pixel coverage with a 1 pixel wide ramp.
+	cov := .5 - d
+	if width > 0 {
+		cov = min(cov, .5+d+width)
+	}
+	a := uint16(0xffff * max(0, min(cov, 1)))
+	return color.RGBA64{A: a}
+})
+
 func ImageOp(ops Ctx, img image.Image, mask bool) {
 	m := imageMask
 	if mask {
@@ -482,11 +590,13 @@ func GlyphOp(ops Ctx, face *bitmap.Face, r rune) {
 		ClipOp{}.Add(ops)
 		return
 	}
+	b := m.Bounds()
+	s := face.Scale()
 	addImageOp(
 		ops, nil,
 		glyphImage,
 		intersectMask,
-		m.Bounds(),
+		image.Rectangle{Min: b.Min.Mul(s), Max: b.Max.Mul(s)},
 		[]any{face},
 		[]uint32{uint32(r)},
 	)
@@ -3,6 +3,7 @@ package op
 import (
 	"image"
 	"image/color"
+	"reflect"
 	"testing"
 
 	"seedhammer.com/image/rgb565"
@@ -27,3 +28,110 @@ func TestAllocs(t *testing.T) {
 		t.Errorf("got %d allocs, expected %d", a, 0)
 	}
 }
+
+func TestDamage(t *testing.T) {
+	bounds := image.Rect(0, 0, 100, 100)
+	ops := new(Ops)
+	corners := func(col color.NRGBA) {
+		ctx := ops.Context()
+		ColorOp(ctx, color.NRGBA{A: 0xff})
+		rect := func(r image.Rectangle, col color.NRGBA) {
+			ClipOp(r).Add(ctx.Begin())
+			ColorOp(ctx, col)
+			ctx.End().Add(ctx)
+		}
+		rect(image.Rect(0, 0, 10, 10), col)
+		rect(image.Rect(90, 90, 100, 100), col)
+	}
+	corners(color.NRGBA{R: 0xff, A: 0xff})
+	if got := ops.Damage(bounds); len(got) != 1 || got[0] != bounds {
+		t.Fatalf("first frame damaged %v, want %v", got, bounds)
+	}
+	ops.Reset()
+	corners(color.NRGBA{R: 0xff, A: 0xff})
+	if got := ops.Damage(bounds); len(got) != 0 {
+		t.Errorf("unchanged frame damaged %v", got)
+	}
+	ops.Reset()
+	corners(color.NRGBA{G: 0xff, A: 0xff})
+	want := []image.Rectangle{image.Rect(0, 0, 10, 10), image.Rect(90, 90, 100, 100)}
+	if got := ops.Damage(bounds); !reflect.DeepEqual(got, want) {
+		t.Errorf("changed corners damaged %v, want %v", got, want)
+	}
+	if got, want := ops.Clip(bounds), image.Rect(0, 0, 100, 100); got != want {
+		t.Errorf("changed corners clipped to %v, want %v", got, want)
+	}
+}
+
+func TestRoundRect(t *testing.T) {
+	bounds := image.Rect(0, 0, 20, 20)
+	draw := func(f func(ctx Ctx)) *image.RGBA {
+		ops := new(Ops)
+		f(ops.Context())
+		fb := image.NewRGBA(bounds)
+		ops.Clip(bounds)
+		ops.Draw(fb, image.NewAlpha(bounds))
+		return fb
+	}
+	white := color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	tests := []struct {
+		name    string
+		draw    func(ctx Ctx)
+		painted []image.Point
+		clear   []image.Point
+	}{
+		{
+			"filled",
+			func(ctx Ctx) {
+				RoundRectOp(ctx, bounds, 6)
+				ColorOp(ctx, white)
+			},
+			[]image.Point{{10, 10}, {0, 10}, {10, 19}},
+			[]image.Point{{0, 0}, {19, 19}},
+		},
+		{
+			"stroke",
+			func(ctx Ctx) {
+				StrokeOp(ctx, bounds, 6, 2)
+				ColorOp(ctx, white)
+			},
+			[]image.Point{{0, 10}, {1, 10}, {10, 19}},
+			[]image.Point{{0, 0}, {2, 10}, {10, 10}},
+		},
+		{
+			"circle",
+			func(ctx Ctx) {
+				CircleOp(ctx, image.Pt(10, 10), 10)
+				ColorOp(ctx, white)
+			},
+			[]image.Point{{10, 10}, {1, 10}, {10, 1}},
+			[]image.Point{{1, 1}, {18, 18}},
+		},
+		{
+			"nested clips",
+			func(ctx Ctx) {
+				inner := ctx.Begin()
+				ClipOp(image.Rect(0, 0, 20, 10)).Add(inner)
+				ColorOp(inner, white)
+				call := ctx.End()
+				RoundRectOp(ctx, bounds, 6)
+				call.Add(ctx)
+			},
+			[]image.Point{{10, 5}, {0, 9}},
+			[]image.Point{{0, 0}, {10, 10}},
+		},
+	}
+	for _, test := range tests {
+		fb := draw(test.draw)
+		for _, p := range test.painted {
+			if a := fb.RGBAAt(p.X, p.Y).A; a != 0xff {
+				t.Errorf("%s: pixel %v alpha %#x, want painted", test.name, p, a)
+			}
+		}
+		for _, p := range test.clear {
+			if a := fb.RGBAAt(p.X, p.Y).A; a != 0 {
+				t.Errorf("%s: pixel %v alpha %#x, want clear", test.name, p, a)
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package gui
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"seedhammer.com/bc/urtypes"
+	"seedhammer.com/bip32"
+	"seedhammer.com/bip39"
+	"seedhammer.com/gui/op"
+	"seedhammer.com/nonstandard"
+)
+
+// originPresets are the standard single-sig accounts offered for
+// keys scanned without origin.
+var originPresets = []struct {
+	Name   string
+	Script urtypes.Script
+}{
+	{"BIP44", urtypes.P2PKH},
+	{"BIP49", urtypes.P2SH_P2WPKH},
+	{"BIP84", urtypes.P2WPKH},
+	{"BIP86", urtypes.P2TR},
+}
+
+// originFlow completes the origin of the key of a single-sig
+// descriptor scanned as a bare extended key. If the key belongs to
+// mnemonic at the implied path or a preset, the origin is filled in
+// from the seed. Otherwise, the derivation path and master
+// fingerprint are entered by the user.
+func originFlow(ctx *Context, ops op.Ctx, th *Colors, desc urtypes.OutputDescriptor, mnemonic bip39.Mnemonic) (urtypes.OutputDescriptor, bool) {
+	if mnemonic != nil {
+		if d, ok := matchOrigin(desc, mnemonic); ok {
+			return d, true
+		}
+	}
+	cs := &ChoiceScreen{
+		Title: "Key Origin",
+		Lead:  "Choose derivation path",
+	}
+	cs.choice = len(originPresets)
+	for i, p := range originPresets {
+		cs.Choices = append(cs.Choices, p.Name)
+		if p.Script == desc.Script {
+			cs.choice = i
+		}
+	}
+	cs.Choices = append(cs.Choices, "CUSTOM")
+	showErr := func(errScreen *ErrorScreen) {
+		for {
+			dims := ctx.Platform.DisplaySize()
+			dismissed := errScreen.Layout(ctx, ops.Begin(), th, dims)
+			d := ops.End()
+			if dismissed {
+				break
+			}
+			cs.Draw(ctx, ops, th, dims)
+			d.Add(ops)
+			ctx.Frame()
+		}
+	}
+	for {
+		choice, ok := cs.Choose(ctx, ops, th)
+		if !ok {
+			return desc, false
+		}
+		d := desc
+		d.Keys = slices.Clone(desc.Keys)
+		k := &d.Keys[0]
+		if choice < len(originPresets) {
+			d.Script = originPresets[choice].Script
+			k.DerivationPath = presetPath(d.Script, k.Network)
+		} else {
+			txt, ok := inputTextFlow(ctx, ops, th, "Derivation Path", strings.ToLower(k.DerivationPath.String()), 24)
+			if !ok {
+				continue
+			}
+			path, err := nonstandard.ParseDerivationPath(strings.ToLower(txt))
+			if err != nil {
+				showErr(&ErrorScreen{
+					Title: "Invalid Path",
+					Body:  "Enter a derivation path such as M/84H/0H/0H.",
+				})
+				continue
+			}
+			k.DerivationPath = path
+		}
+		txt, ok := inputTextFlow(ctx, ops, th, "Fingerprint", "", 8)
+		if !ok {
+			continue
+		}
+		mfp, err := parseFingerprint(txt)
+		if err != nil {
+			showErr(&ErrorScreen{
+				Title: "Invalid Fingerprint",
+				Body:  "Enter the 8 hexadecimal digits of the master fingerprint.",
+			})
+			continue
+		}
+		k.MasterFingerprint = mfp
+		return d, true
+	}
+}
+
+// matchOrigin fills in the origin of the single key of desc if it
+// is derived from m at its implied path or at one of the presets.
+func matchOrigin(desc urtypes.OutputDescriptor, m bip39.Mnemonic) (urtypes.OutputDescriptor, bool) {
+	k := desc.Keys[0]
+	mk, ok := deriveMasterKey(m, k.Network)
+	if !ok {
+		return desc, false
+	}
+	scripts := []urtypes.Script{desc.Script}
+	for _, p := range originPresets {
+		scripts = append(scripts, p.Script)
+	}
+	for i, s := range scripts {
+		path := k.DerivationPath
+		if i > 0 {
+			path = presetPath(s, k.Network)
+		}
+		mfp, xpub, err := bip32.Derive(mk, path)
+		if err != nil || !sameKey(k, xpub) {
+			continue
+		}
+		desc.Script = s
+		desc.Keys = slices.Clone(desc.Keys)
+		desc.Keys[0].DerivationPath = path
+		desc.Keys[0].MasterFingerprint = mfp
+		return desc, true
+	}
+	return desc, false
+}
+
+// presetPath returns the standard account path of script with the
+// coin type of the network.
+func presetPath(script urtypes.Script, network *chaincfg.Params) urtypes.Path {
+	path := slices.Clone(script.DerivationPath())
+	path[1] = hdkeychain.HardenedKeyStart + network.HDCoinType
+	return path
+}
+
+// sameKey reports whether k and xpub share their public key and
+// chain code, regardless of their depth and child number.
+func sameKey(k urtypes.KeyDescriptor, xpub *hdkeychain.ExtendedKey) bool {
+	pub, err := xpub.ECPubKey()
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(pub.SerializeCompressed(), k.KeyData) && bytes.Equal(xpub.ChainCode(), k.ChainCode)
+}
+
+// parseFingerprint parses a master fingerprint in hexadecimal.
+func parseFingerprint(txt string) (uint32, error) {
+	fp, err := hex.DecodeString(txt)
+	if err != nil || len(fp) != 4 {
+		return 0, errors.New("invalid fingerprint")
+	}
+	return binary.BigEndian.Uint32(fp), nil
+}
@@ -0,0 +1,73 @@
+package gui
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"seedhammer.com/gui/assets"
+	"seedhammer.com/gui/op"
+)
+
+// recordSideA logs side A of a two sided plate in the audit log, so
+// a side B of a different plate can be caught before it is engraved
+// on the back of side A. The plate itself is logged when its side B
+// is engraved.
+func recordSideA(ctx *Context, plate Plate) {
+	if len(plate.Sides) < 2 {
+		return
+	}
+	logAudit(ctx, AuditEntry{
+		Time:              ctx.Platform.Now(),
+		MasterFingerprint: plate.MasterFingerprint,
+		Size:              plate.Size,
+		Version:           ctx.Version,
+		SideA:             true,
+	})
+}
+
+// pendingSideA returns the audit entry of the side A of the plate
+// in the engraver, if its side B is not yet engraved.
+func pendingSideA(settings Settings) (AuditEntry, bool) {
+	entries := settings.AuditLog
+	if len(entries) == 0 {
+		return AuditEntry{}, false
+	}
+	e := entries[len(entries)-1]
+	return e, e.SideA
+}
+
+// sideMismatch reports whether side A of the plate in the engraver
+// was engraved for a different seed than plate.
+func sideMismatch(settings Settings, plate Plate) bool {
+	a, ok := pendingSideA(settings)
+	return ok && a.MasterFingerprint != plate.MasterFingerprint
+}
+
+// confirmSideB warns the user before engraving side B of plate on
+// the back of a side A of a different seed. It reports whether the
+// user chose to engrave anyway.
+func (s *EngraveScreen) confirmSideB(ctx *Context, ops op.Ctx, th *Colors) bool {
+	a, _ := pendingSideA(ctx.Settings)
+	confirm := &ConfirmWarningScreen{
+		Title: "Plate Mismatch",
+		Body: fmt.Sprintf("Side A of the plate in the engraver was engraved for seed %.8X on %s, but this side B is for seed %.8X.\n\nHold button to engrave anyway.",
+			a.MasterFingerprint, a.Time.Format(time.DateOnly), s.plate.MasterFingerprint),
+		Icon: assets.IconHammer,
+	}
+	for {
+		dims := ctx.Platform.DisplaySize()
+		res := confirm.Layout(ctx, ops.Begin(), th, dims)
+		d := ops.End()
+		switch res {
+		case ConfirmNo:
+			return false
+		case ConfirmYes:
+			log.Printf("gui: side B of %.8X engraved on side A of %.8X", s.plate.MasterFingerprint, a.MasterFingerprint)
+			return true
+		}
+		s.draw(ctx, ops, th, dims)
+		d.Add(ops)
+		ctx.Frame()
+	}
+}
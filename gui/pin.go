@@ -0,0 +1,282 @@
+package gui
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"image"
+	"log"
+	"slices"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+	"seedhammer.com/gui/assets"
+	"seedhammer.com/gui/layout"
+	"seedhammer.com/gui/op"
+	"seedhammer.com/gui/widget"
+)
+
+// pinLength is the number of digits in a PIN.
+const pinLength = 4
+
+// pinIterations is the PBKDF2 iteration count of PIN hashes.
+const pinIterations = 10000
+
+// Failed PIN attempts beyond pinFreeAttempts delay the next attempt
+// by pinBaseDelay, doubling for each failure up to pinMaxDelay.
+const (
+	pinFreeAttempts = 3
+	pinBaseDelay    = 30 * time.Second
+	pinMaxDelay     = time.Hour
+)
+
+// hashPIN hashes pin with salt and the device secret, so the hash
+// can't be brute-forced without the device.
+func hashPIN(secret, salt []byte, pin string) []byte {
+	return pbkdf2.Key([]byte(pin), slices.Concat(salt, secret), pinIterations, sha256.Size, sha256.New)
+}
+
+// pinDelay returns the wait before the next attempt after failures
+// failed attempts.
+func pinDelay(failures int) time.Duration {
+	if failures < pinFreeAttempts {
+		return 0
+	}
+	d := pinBaseDelay
+	for range failures - pinFreeAttempts {
+		d *= 2
+		if d >= pinMaxDelay {
+			return pinMaxDelay
+		}
+	}
+	return d
+}
+
+// unlockFlow asks for the device PIN, if one is set, and reports
+// whether the device is unlocked.
+func unlockFlow(ctx *Context, ops op.Ctx, th *Colors) bool {
+	if ctx.Unlocked || len(ctx.Settings.PINHash) == 0 {
+		return true
+	}
+	scr := &PINScreen{Title: "Unlock", Lead: "Enter PIN"}
+	for {
+		if ctx.pinRetry.IsZero() {
+			ctx.pinRetry = ctx.Platform.Now().Add(pinDelay(ctx.Settings.PINFailures))
+		}
+		scr.Retry = ctx.pinRetry
+		pin, ok := scr.Enter(ctx, ops, th)
+		if !ok {
+			return false
+		}
+		// Count the attempt as failed before checking it, so
+		// neither restarting nor removing the SD card resets the
+		// delay.
+		settings := ctx.Settings
+		settings.PINFailures++
+		secret, err := ctx.Platform.DeviceSecret()
+		if err == nil {
+			err = ctx.Platform.StoreSettings(settings)
+		}
+		if err != nil {
+			log.Printf("gui: PIN attempt not recorded: %v", err)
+			scr.Lead = "Insert SD card"
+			continue
+		}
+		ctx.Settings = settings
+		ctx.unsaved = false
+		ctx.pinRetry = time.Time{}
+		if !hmac.Equal(hashPIN(secret, settings.PINSalt, pin), settings.PINHash) {
+			scr.Lead = "Wrong PIN"
+			continue
+		}
+		settings.PINFailures = 0
+		recordSettings(ctx, settings)
+		ctx.Unlocked = true
+		return true
+	}
+}
+
+// pinFlow sets or removes the device PIN.
+func pinFlow(ctx *Context, ops op.Ctx, th *Colors) {
+	if !unlockFlow(ctx, ops, th) {
+		return
+	}
+	cs := &ChoiceScreen{
+		Title:   "PIN",
+		Lead:    "Choose PIN action",
+		Choices: []string{"SET", "REMOVE"},
+	}
+	showErr := func(errScr *ErrorScreen) {
+		for {
+			dims := ctx.Platform.DisplaySize()
+			dismissed := errScr.Layout(ctx, ops.Begin(), th, dims)
+			d := ops.End()
+			if dismissed {
+				break
+			}
+			cs.Draw(ctx, ops, th, dims)
+			d.Add(ops)
+			ctx.Frame()
+		}
+	}
+	for {
+		choice, ok := cs.Choose(ctx, ops, th)
+		if !ok {
+			return
+		}
+		settings := ctx.Settings
+		switch choice {
+		case 0:
+			pin, ok := (&PINScreen{Title: "New PIN", Lead: "Enter new PIN"}).Enter(ctx, ops, th)
+			if !ok {
+				continue
+			}
+			again, ok := (&PINScreen{Title: "New PIN", Lead: "Repeat new PIN"}).Enter(ctx, ops, th)
+			if !ok {
+				continue
+			}
+			if pin != again {
+				showErr(&ErrorScreen{
+					Title: "PIN Mismatch",
+					Body:  "The PINs don't match. The PIN is unchanged.",
+				})
+				continue
+			}
+			secret, ok := deviceSecret(ctx, ops, th)
+			if !ok {
+				continue
+			}
+			salt := make([]byte, 16)
+			if _, err := rand.Read(salt); err != nil {
+				showErr(NewErrorScreen(err))
+				continue
+			}
+			settings.PINSalt = salt
+			settings.PINHash = hashPIN(secret, salt, pin)
+		case 1:
+			settings.PINSalt = nil
+			settings.PINHash = nil
+		}
+		settings.PINFailures = 0
+		if err := storeSettings(ctx, ops, th, settings); err != nil {
+			continue
+		}
+		return
+	}
+}
+
+// PINScreen inputs a PIN with the arrow keys or digit runes.
+type PINScreen struct {
+	Title string
+	Lead  string
+	// Retry is the earliest time a PIN may be entered.
+	Retry time.Time
+
+	digits [pinLength]int
+	pos    int
+}
+
+// Enter runs the screen until the user confirms a PIN or goes
+// back.
+func (s *PINScreen) Enter(ctx *Context, ops op.Ctx, th *Colors) (string, bool) {
+	inp := new(InputTracker)
+	for {
+		wait := s.Retry.Sub(ctx.Platform.Now())
+		for {
+			e, ok := inp.Next(ctx, Button1, Button3, Up, Down, Left, Right, Rune)
+			if !ok {
+				break
+			}
+			switch e.Button {
+			case Button1:
+				if inp.Clicked(e.Button) {
+					return "", false
+				}
+			case Button3:
+				if inp.Clicked(e.Button) && wait <= 0 {
+					pin := make([]byte, pinLength)
+					for i, d := range s.digits {
+						pin[i] = byte('0' + d)
+					}
+					s.digits = [pinLength]int{}
+					s.pos = 0
+					return string(pin), true
+				}
+			case Up:
+				if e.Pressed {
+					s.digits[s.pos] = (s.digits[s.pos] + 1) % 10
+				}
+			case Down:
+				if e.Pressed {
+					s.digits[s.pos] = (s.digits[s.pos] + 9) % 10
+				}
+			case Left:
+				if e.Pressed && s.pos > 0 {
+					s.pos--
+				}
+			case Right:
+				if e.Pressed && s.pos < pinLength-1 {
+					s.pos++
+				}
+			case Rune:
+				if e.Rune >= '0' && e.Rune <= '9' {
+					s.digits[s.pos] = int(e.Rune - '0')
+					s.pos = min(s.pos+1, pinLength-1)
+				}
+			}
+		}
+		dims := ctx.Platform.DisplaySize()
+		s.draw(ctx, ops, th, dims, wait)
+		if wait > 0 {
+			ctx.WakeupAt(s.Retry)
+			layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
+				{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack},
+			}...)
+		} else {
+			layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
+				{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack},
+				{Button: Button3, Style: StylePrimary, Icon: assets.IconCheckmark},
+			}...)
+		}
+		ctx.Frame()
+	}
+}
+
+func (s *PINScreen) draw(ctx *Context, ops op.Ctx, th *Colors, dims image.Point, wait time.Duration) {
+	op.ColorOp(ops, th.Background)
+	layoutTitle(ctx, ops, dims.X, th.Text, s.Title)
+
+	r := layout.Rectangle{Max: dims}
+	_, content := r.CutTop(leadingSize)
+	content, lead := content.CutBottom(leadingSize)
+
+	style := ctx.Styles.title
+	digitsz := style.Measure(dims.X, "0")
+	const margin = 8
+	box := image.Pt(digitsz.X+2*margin, digitsz.Y)
+	w := pinLength*box.X + (pinLength-1)*margin
+	off := content.Center(image.Pt(w, box.Y))
+	for i, d := range s.digits {
+		pos := off.Add(image.Pt(i*(box.X+margin), 0))
+		col := th.Text
+		// Show only the digit being edited.
+		txt := "*"
+		if i == s.pos {
+			col = th.Background
+			txt = fmt.Sprint(d)
+			assets.ButtonFocused.Add(ops.Begin(), image.Rectangle{Max: box}, true)
+			op.ColorOp(ops, th.Text)
+			op.Position(ops, ops.End(), pos)
+		}
+		sz := widget.Labelf(ops.Begin(), style, col, txt)
+		op.Position(ops, ops.End(), pos.Add(image.Pt((box.X-sz.X)/2, 0)))
+	}
+
+	leadTxt := s.Lead
+	if wait > 0 {
+		leadTxt = fmt.Sprintf("Try again in %d s", int(wait.Round(time.Second)/time.Second))
+	}
+	leadsz := widget.Labelwf(ops.Begin(), ctx.Styles.lead, dims.X-2*margin, th.Text, leadTxt)
+	op.Position(ops, ops.End(), lead.Center(leadsz))
+}
@@ -0,0 +1,92 @@
+package gui
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	"seedhammer.com/backup"
+	"seedhammer.com/engrave"
+	"seedhammer.com/gui/op"
+)
+
+// planFileExt is the extension of the plan files written by the
+// -shp flag of cmd/cli.
+const planFileExt = ".shp"
+
+// planFileFlow engraves a plan file from the SD card.
+func planFileFlow(ctx *Context, ops op.Ctx, th *Colors) {
+	showErr := func(errScr *ErrorScreen) {
+		for {
+			dims := ctx.Platform.DisplaySize()
+			dismissed := errScr.Layout(ctx, ops.Begin(), th, dims)
+			d := ops.End()
+			if dismissed {
+				return
+			}
+			op.ColorOp(ops, th.Background)
+			d.Add(ops)
+			ctx.Frame()
+		}
+	}
+	files, err := ctx.Platform.ImportFiles(planFileExt)
+	if err == nil && len(files) == 0 {
+		err = errors.New("no plan files found")
+	}
+	if err != nil {
+		log.Printf("gui: %v", err)
+		showErr(&ErrorScreen{
+			Title: "No Plan Files",
+			Body:  fmt.Sprintf("Copy %s plan files to the SD card and try again.\n\nError details: %v", planFileExt, err),
+		})
+		return
+	}
+	names := slices.Sorted(maps.Keys(files))
+	cs := &ChoiceScreen{
+		Title: "Plan File",
+		Lead:  "Choose plan to engrave",
+	}
+	for _, name := range names {
+		name = strings.ToUpper(name)
+		cs.Choices = append(cs.Choices, strings.TrimSuffix(name, strings.ToUpper(planFileExt)))
+	}
+	for {
+		choice, ok := cs.Choose(ctx, ops, th)
+		if !ok {
+			return
+		}
+		plate, err := planPlate(ctx.Platform.PlateSizes(), ctx.Platform.EngraverParams(), files[names[choice]])
+		if err != nil {
+			showErr(NewErrorScreen(err))
+			continue
+		}
+		if NewEngraveScreen(ctx, plate).Engrave(ctx, ops, &engraveTheme) {
+			return
+		}
+	}
+}
+
+// planPlate decodes a plan file and places it on the first plate
+// size that fits it.
+func planPlate(sizes []backup.PlateSize, params engrave.Params, data []byte) (Plate, error) {
+	plan, err := engrave.UnmarshalPlan(data)
+	if err != nil {
+		return Plate{}, err
+	}
+	b := engrave.Measure(plan)
+	for _, sz := range sizes {
+		if b.In(image.Rectangle{Max: sz.Dims().Mul(params.Millimeter)}) {
+			return Plate{
+				Size:  sz,
+				Sides: []engrave.Plan{plan},
+				// The plan may be a seed side.
+				SeedSide: 0,
+			}, nil
+		}
+	}
+	return Plate{}, errors.New("plan doesn't fit any plate")
+}
@@ -0,0 +1,159 @@
+package gui
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/kortschak/qr"
+	"seedhammer.com/address"
+	"seedhammer.com/backup"
+	"seedhammer.com/bc/ur"
+	"seedhammer.com/bc/urtypes"
+	"seedhammer.com/font/constant"
+	"seedhammer.com/gui/assets"
+	"seedhammer.com/gui/op"
+	"seedhammer.com/nonstandard"
+)
+
+// recoverWalletFlow reassembles a wallet output descriptor from
+// the descriptor QR codes of its plates, the inverse of
+// backupWalletFlow. The parts of a descriptor split across plates
+// are collected by scanning the plates in any order until enough
+// are scanned. It never engraves.
+func recoverWalletFlow(ctx *Context, ops op.Ctx, th *Colors) {
+	showErr := func(errScreen *ErrorScreen) {
+		for {
+			dims := ctx.Platform.DisplaySize()
+			dismissed := errScreen.Layout(ctx, ops.Begin(), th, dims)
+			d := ops.End()
+			if dismissed {
+				break
+			}
+			op.ColorOp(ops, th.Background)
+			d.Add(ops)
+			ctx.Frame()
+		}
+	}
+	for {
+		res, ok := (&ScanScreen{
+			Title: "Recover",
+			Lead:  "Descriptor QR codes of the plates",
+		}).Scan(ctx, ops)
+		if !ok {
+			return
+		}
+		desc, ok := scannedDescriptor(res)
+		if !ok || !address.Supported(desc) {
+			showErr(&ErrorScreen{
+				Title: "Invalid Descriptor",
+				Body:  "The scanned data does not represent a supported wallet output descriptor.",
+			})
+			continue
+		}
+		txt, err := nonstandard.FormatOutputDescriptor(desc)
+		if err != nil {
+			showErr(NewErrorScreen(err))
+			continue
+		}
+		desc.Title = backup.TitleString(constant.Font, desc.Title)
+		// Allow the recovered descriptor to be re-used for verifying
+		// the seeds of the plates.
+		ctx.LastDescriptor = &desc
+		recoveredWalletFlow(ctx, ops, th, desc, txt)
+		return
+	}
+}
+
+// recoveredWalletFlow shows a summary of a recovered descriptor.
+// Its addresses can be shown, and its textual form, txt, shown as
+// a QR code or exported to the SD card. The descriptor can also be
+// shown as an animated UR.
+func recoveredWalletFlow(ctx *Context, ops op.Ctx, th *Colors, desc urtypes.OutputDescriptor, txt string) {
+	showErr := func(errScreen *ErrorScreen) {
+		for {
+			dims := ctx.Platform.DisplaySize()
+			dismissed := errScreen.Layout(ctx, ops.Begin(), th, dims)
+			d := ops.End()
+			if dismissed {
+				break
+			}
+			op.ColorOp(ops, th.Background)
+			d.Add(ops)
+			ctx.Frame()
+		}
+	}
+	body := recoverySummary(desc) + "\n\nPress right to show the descriptor QR code, or left to show it as an animated UR."
+	var w Warning
+	inp := new(InputTracker)
+	for {
+		for {
+			e, ok := inp.Next(ctx, Button1, Button2, Button3, Left, Right)
+			if !ok {
+				break
+			}
+			if !inp.Clicked(e.Button) {
+				continue
+			}
+			switch e.Button {
+			case Button1:
+				return
+			case Button2:
+				ShowAddressesScreen(ctx, ops, th, desc)
+			case Right:
+				code, err := qr.Encode(txt, qr.L)
+				if err != nil {
+					showErr(NewErrorScreen(err))
+					break
+				}
+				showQR(ctx, ops, th, "Descriptor", "Import into a wallet", code)
+			case Left:
+				showUR(ctx, ops, th, "Descriptor", "Import into a wallet", descriptorUR(desc))
+			case Button3:
+				name := fmt.Sprintf("wallet-%.8x.txt", walletID(desc))
+				if err := ctx.Platform.ExportFile(name, []byte(txt+"\n")); err != nil {
+					log.Printf("gui: descriptor not exported: %v", err)
+					showErr(&ErrorScreen{
+						Title: "Descriptor Not Saved",
+						Body:  fmt.Sprintf("Insert the SD card and try again.\n\nError details: %v", err),
+					})
+					break
+				}
+				showErr(&ErrorScreen{
+					Title: "Descriptor Saved",
+					Body:  fmt.Sprintf("The descriptor is saved as %s on the SD card.", name),
+				})
+			}
+		}
+		dims := ctx.Platform.DisplaySize()
+		op.ColorOp(ops, th.Background)
+		w.Layout(ctx, ops, th, dims, "Wallet Recovered", body)
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
+			{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack},
+			{Button: Button2, Style: StyleSecondary, Icon: assets.IconInfo},
+			{Button: Button3, Style: StylePrimary, Icon: assets.IconCheckmark},
+		}...)
+		ctx.Frame()
+	}
+}
+
+// descriptorUR returns an encoder of the parts of the animated UR
+// of desc.
+func descriptorUR(desc urtypes.OutputDescriptor) *ur.Encoder {
+	return ur.NewEncoder("crypto-output", desc.Encode(), urFragmentLen)
+}
+
+// recoverySummary describes the script, threshold and keys of a
+// recovered descriptor.
+func recoverySummary(desc urtypes.OutputDescriptor) string {
+	var b strings.Builder
+	b.WriteString(desc.Script.String())
+	if desc.Type != urtypes.Singlesig {
+		fmt.Fprintf(&b, "\nAny %d of %d keys spend.", desc.Threshold, len(desc.Keys))
+	}
+	b.WriteString("\n\nKeys:")
+	for i, k := range desc.Keys {
+		fmt.Fprintf(&b, "\n%d: %.8X %s", i+1, k.MasterFingerprint, k.DerivationPath)
+	}
+	return b.String()
+}
@@ -0,0 +1,235 @@
+package gui
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/kortschak/qr"
+	"seedhammer.com/backup"
+	"seedhammer.com/bc/ur"
+	"seedhammer.com/bc/urtypes"
+	"seedhammer.com/gui/assets"
+	"seedhammer.com/gui/layout"
+	"seedhammer.com/gui/op"
+	"seedhammer.com/gui/widget"
+)
+
+// recoveryBatch is the number of share combinations tested for
+// recovery per frame.
+const recoveryBatch = 16
+
+// recoveryTestFlow verifies that every combination of threshold
+// plates recovers desc, showing the progress. Note that a failure
+// is impossible by construction and by exhaustive tests, but it's
+// good to be paranoid. It reports whether the test completed and
+// succeeded.
+func recoveryTestFlow(ctx *Context, ops op.Ctx, th *Colors, desc urtypes.OutputDescriptor) bool {
+	rt := backup.NewRecoveryTest(desc)
+	inp := new(InputTracker)
+	for rt.Done < rt.Total {
+		for {
+			e, ok := inp.Next(ctx, Button1)
+			if !ok {
+				break
+			}
+			if inp.Clicked(e.Button) {
+				return false
+			}
+		}
+		for end := min(rt.Done+recoveryBatch, rt.Total); rt.Done < end; {
+			if !rt.Step() {
+				errScr := &ErrorScreen{
+					Title: "Self-test Failed",
+					Body:  "Descriptor is not recoverable. This is a bug in the program; please report it.",
+				}
+				for {
+					dims := ctx.Platform.DisplaySize()
+					dismissed := errScr.Layout(ctx, ops.Begin(), th, dims)
+					d := ops.End()
+					if dismissed {
+						return false
+					}
+					op.ColorOp(ops, th.Background)
+					d.Add(ops)
+					ctx.Frame()
+				}
+			}
+		}
+		if rt.Done == rt.Total {
+			break
+		}
+		ctx.Platform.Wakeup()
+		dims := ctx.Platform.DisplaySize()
+		body := fmt.Sprintf("Recovering the descriptor from every %d of %d plates.", desc.Threshold, len(desc.Keys))
+		layoutProgress(ctx, ops, th, dims, "Self-test Recovery", body, rt.Done, rt.Total)
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
+			{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack},
+		}...)
+		ctx.Frame()
+	}
+	return true
+}
+
+// recoveryReportFlow runs the recovery self-test of desc and shows
+// its result along with the parts of the descriptor held by every
+// plate. The recovery sheet of desc can be shown as a QR code or
+// exported to the SD card.
+func recoveryReportFlow(ctx *Context, ops op.Ctx, th *Colors, desc urtypes.OutputDescriptor) {
+	if !recoveryTestFlow(ctx, ops, th, desc) {
+		return
+	}
+	_, parts := backup.ShareParts(desc, 0)
+	body := fmt.Sprintf("Any %d of the %d plates recover the descriptor, split into %d parts.\n\nParts per plate:\n%s",
+		desc.Threshold, len(desc.Keys), parts, shardingTable(desc))
+	showErr := func(errScreen *ErrorScreen) {
+		for {
+			dims := ctx.Platform.DisplaySize()
+			dismissed := errScreen.Layout(ctx, ops.Begin(), th, dims)
+			d := ops.End()
+			if dismissed {
+				break
+			}
+			op.ColorOp(ops, th.Background)
+			d.Add(ops)
+			ctx.Frame()
+		}
+	}
+	sheet := backup.RecoverySheet(desc)
+	var w Warning
+	inp := new(InputTracker)
+	for {
+		for {
+			e, ok := inp.Next(ctx, Button1, Button2, Button3)
+			if !ok {
+				break
+			}
+			if !inp.Clicked(e.Button) {
+				continue
+			}
+			switch e.Button {
+			case Button1:
+				return
+			case Button2:
+				code, err := qr.Encode(sheet, qr.L)
+				if err != nil {
+					showErr(NewErrorScreen(err))
+					break
+				}
+				showQR(ctx, ops, th, "Recovery Sheet", "Recovery instructions", code)
+			case Button3:
+				name := fmt.Sprintf("recovery-%.8x.txt", walletID(desc))
+				if err := ctx.Platform.ExportFile(name, []byte(sheet)); err != nil {
+					log.Printf("gui: recovery sheet not exported: %v", err)
+					showErr(&ErrorScreen{
+						Title: "Sheet Not Saved",
+						Body:  fmt.Sprintf("Insert the SD card and try again.\n\nError details: %v", err),
+					})
+					break
+				}
+				showErr(&ErrorScreen{
+					Title: "Sheet Saved",
+					Body:  fmt.Sprintf("The recovery sheet is saved as %s on the SD card.", name),
+				})
+			}
+		}
+		dims := ctx.Platform.DisplaySize()
+		op.ColorOp(ops, th.Background)
+		w.Layout(ctx, ops, th, dims, "Recovery Verified", body)
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
+			{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack},
+			{Button: Button2, Style: StyleSecondary, Icon: assets.IconInfo},
+			{Button: Button3, Style: StylePrimary, Icon: assets.IconCheckmark},
+		}...)
+		ctx.Frame()
+	}
+}
+
+const (
+	// urFragmentLen is the maximum length of the message fragments
+	// of animated URs, in bytes. It keeps the QR codes of the parts
+	// legible on the display.
+	urFragmentLen = 60
+	// urFrameDelay is the time every part of an animated UR is
+	// shown.
+	urFrameDelay = 250 * time.Millisecond
+)
+
+// showQR shows a QR code with a title and lead until the user
+// exits.
+func showQR(ctx *Context, ops op.Ctx, th *Colors, title, lead string, code *qr.Code) {
+	showQRFrames(ctx, ops, th, title, lead, 0, func() *qr.Code {
+		return code
+	})
+}
+
+// showUR is like showQR, but shows the parts generated by enc in
+// turn, as an animated QR code.
+func showUR(ctx *Context, ops op.Ctx, th *Colors, title, lead string, enc *ur.Encoder) {
+	showQRFrames(ctx, ops, th, title, lead, urFrameDelay, func() *qr.Code {
+		// Upper case parts encode in the denser alphanumeric mode.
+		code, err := qr.Encode(strings.ToUpper(enc.Next()), qr.L)
+		if err != nil {
+			// Parts are bounded by urFragmentLen.
+			panic(err)
+		}
+		return code
+	})
+}
+
+// showQRFrames shows the QR codes returned by next, a new code
+// every delay. A zero delay shows the first code only.
+func showQRFrames(ctx *Context, ops op.Ctx, th *Colors, title, lead string, delay time.Duration, next func() *qr.Code) {
+	var img image.Image
+	var deadline time.Time
+	inp := new(InputTracker)
+	for {
+		for {
+			e, ok := inp.Next(ctx, Button1)
+			if !ok {
+				break
+			}
+			if inp.Clicked(e.Button) {
+				return
+			}
+		}
+		dims := ctx.Platform.DisplaySize()
+		now := ctx.Platform.Now()
+		if img == nil || delay > 0 && !now.Before(deadline) {
+			code := next()
+			// Leave room for the title, lead and navigation buttons.
+			code.Scale = max(1, (dims.Y-2*leadingSize)/(code.Size+8))
+			img = code.Image()
+			deadline = now.Add(delay)
+		}
+		if delay > 0 {
+			ctx.WakeupAt(deadline)
+		}
+		op.ColorOp(ops, th.Background)
+		layoutTitle(ctx, ops, dims.X, th.Text, title)
+		r := layout.Rectangle{Max: dims}
+		_, content := r.CutTop(leadingSize)
+		content, leadr := content.CutBottom(leadingSize)
+		op.ImageOp(ops.Begin(), img, false)
+		op.Position(ops, ops.End(), content.Center(img.Bounds().Size()))
+		const margin = 8
+		leadsz := widget.Labelwf(ops.Begin(), ctx.Styles.lead, dims.X-2*margin, th.Text, "%s", lead)
+		op.Position(ops, ops.End(), leadr.Center(leadsz))
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
+			{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack},
+		}...)
+		ctx.Frame()
+	}
+}
+
+// shardingTable lists the parts of the descriptor QR codes of
+// every plate, one plate per line.
+func shardingTable(desc urtypes.OutputDescriptor) string {
+	var lines []string
+	for k := range desc.Keys {
+		lines = append(lines, fmt.Sprintf("%d: %s", k+1, backup.FormatShareParts(desc, k)))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,280 @@
+package gui
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"seedhammer.com/backup"
+	"seedhammer.com/bc/urtypes"
+	"seedhammer.com/gui/assets"
+	"seedhammer.com/gui/layout"
+	"seedhammer.com/gui/op"
+	"seedhammer.com/gui/widget"
+)
+
+// Backup records an engraved plate in the backup registry.
+type Backup struct {
+	// Wallet identifies the descriptor of the plate, or is zero
+	// for plates without a descriptor.
+	Wallet uint32
+	// Title is the descriptor title.
+	Title string
+	// MasterFingerprint identifies the seed of the plate.
+	MasterFingerprint uint32
+	// KeyIdx is the index of the plate among the Keys plates
+	// of the wallet.
+	KeyIdx int
+	Keys   int
+	Size   backup.PlateSize
+	Time   time.Time
+}
+
+// registryKeyInfo separates the registry key from other uses of
+// the device secret.
+const registryKeyInfo = "seedhammer backup registry"
+
+func registryCipher(secret []byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(registryKeyInfo))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealRegistry encrypts the registry with a key derived from
+// the device secret.
+func sealRegistry(secret []byte, backups []Backup) ([]byte, error) {
+	aead, err := registryCipher(secret)
+	if err != nil {
+		return nil, fmt.Errorf("registry: %w", err)
+	}
+	data, err := json.Marshal(backups)
+	if err != nil {
+		return nil, fmt.Errorf("registry: %w", err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("registry: %w", err)
+	}
+	return aead.Seal(nonce, nonce, data, nil), nil
+}
+
+// openRegistry decrypts a registry sealed by sealRegistry. An
+// empty registry decrypts to no backups.
+func openRegistry(secret, sealed []byte) ([]Backup, error) {
+	if len(sealed) == 0 {
+		return nil, nil
+	}
+	aead, err := registryCipher(secret)
+	if err != nil {
+		return nil, fmt.Errorf("registry: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("registry: truncated")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	data, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("registry: %w", err)
+	}
+	var backups []Backup
+	if err := json.Unmarshal(data, &backups); err != nil {
+		return nil, fmt.Errorf("registry: %w", err)
+	}
+	return backups, nil
+}
+
+// walletID identifies a descriptor in the backup registry.
+func walletID(desc urtypes.OutputDescriptor) uint32 {
+	h := sha256.Sum256(desc.Encode())
+	return binary.BigEndian.Uint32(h[:])
+}
+
+// descriptorBackup returns the registry entry of the plate for
+// key keyIdx of desc.
+func descriptorBackup(desc urtypes.OutputDescriptor, keyIdx int, plate Plate) Backup {
+	return Backup{
+		Wallet:            walletID(desc),
+		Title:             desc.Title,
+		MasterFingerprint: plate.MasterFingerprint,
+		KeyIdx:            keyIdx,
+		Keys:              len(desc.Keys),
+		Size:              plate.Size,
+	}
+}
+
+// recordBackup adds an engraved plate to the backup registry.
+// Failures are logged and otherwise ignored, because the plate
+// is engraved regardless.
+func recordBackup(ctx *Context, b Backup) {
+	recordAudit(ctx, b)
+	secret, err := ctx.Platform.DeviceSecret()
+	if err != nil {
+		log.Printf("gui: backup not recorded: %v", err)
+		return
+	}
+	backups, err := openRegistry(secret, ctx.Settings.Registry)
+	if err != nil {
+		// Don't overwrite a registry sealed by another device.
+		log.Printf("gui: backup not recorded: %v", err)
+		return
+	}
+	b.Time = ctx.Platform.Now()
+	backups = append(backups, b)
+	sealed, err := sealRegistry(secret, backups)
+	if err != nil {
+		log.Printf("gui: backup not recorded: %v", err)
+		return
+	}
+	settings := ctx.Settings
+	settings.Registry = sealed
+	recordSettings(ctx, settings)
+}
+
+// remainingPlates returns the 1-based indices of the plates of
+// b's wallet not in the registry.
+func remainingPlates(backups []Backup, b Backup) []int {
+	engraved := make(map[int]bool)
+	for _, o := range backups {
+		if o.Wallet == b.Wallet && o.Keys == b.Keys {
+			engraved[o.KeyIdx] = true
+		}
+	}
+	var remaining []int
+	for i := 0; i < b.Keys; i++ {
+		if !engraved[i] {
+			remaining = append(remaining, i+1)
+		}
+	}
+	return remaining
+}
+
+// BackupsScreen lists the plates of the backup registry, most
+// recent first.
+type BackupsScreen struct {
+	Backups []Backup
+
+	selected int
+}
+
+func backupsFlow(ctx *Context, ops op.Ctx, th *Colors) {
+	secret, ok := deviceSecret(ctx, ops, th)
+	if !ok {
+		return
+	}
+	backups, err := openRegistry(secret, ctx.Settings.Registry)
+	if err != nil {
+		log.Printf("gui: %v", err)
+		errScr := &ErrorScreen{
+			Title: "Backups Unavailable",
+			Body:  fmt.Sprintf("The backup registry can't be read.\n\nError details: %v", err),
+		}
+		for {
+			dims := ctx.Platform.DisplaySize()
+			dismissed := errScr.Layout(ctx, ops.Begin(), th, dims)
+			d := ops.End()
+			if dismissed {
+				return
+			}
+			op.ColorOp(ops, th.Background)
+			d.Add(ops)
+			ctx.Frame()
+		}
+	}
+	slices.Reverse(backups)
+	(&BackupsScreen{Backups: backups}).Show(ctx, ops, th)
+}
+
+// Show runs the screen until the user exits.
+func (s *BackupsScreen) Show(ctx *Context, ops op.Ctx, th *Colors) {
+	inp := new(InputTracker)
+	for {
+		for {
+			e, ok := inp.Next(ctx, Button1, Button3, Up, Down)
+			if !ok {
+				break
+			}
+			switch e.Button {
+			case Button1:
+				if inp.Clicked(e.Button) {
+					return
+				}
+			case Button3:
+				if inp.Clicked(e.Button) {
+					auditFlow(ctx, ops, th)
+				}
+			case Up:
+				if e.Pressed && s.selected > 0 {
+					s.selected--
+				}
+			case Down:
+				if e.Pressed && s.selected < len(s.Backups)-1 {
+					s.selected++
+				}
+			}
+		}
+		dims := ctx.Platform.DisplaySize()
+		s.draw(ctx, ops, th, dims)
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
+			{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack},
+			{Button: Button3, Style: StylePrimary, Icon: assets.IconRight},
+		}...)
+		ctx.Frame()
+	}
+}
+
+func (s *BackupsScreen) draw(ctx *Context, ops op.Ctx, th *Colors, dims image.Point) {
+	op.ColorOp(ops, th.Background)
+	layoutTitle(ctx, ops, dims.X, th.Text, "Backups")
+
+	const margin = 8
+	r := layout.Rectangle{Max: dims}
+	_, content := r.CutTop(leadingSize)
+	content, lead := content.CutBottom(leadingSize)
+	content = content.Shrink(0, margin, 0, margin)
+	rows := content.Dy() / (ctx.Styles.subtitle.LineHeight() + margin)
+	first := max(0, s.selected-rows+1)
+	y := 0
+	for i := first; i < len(s.Backups) && i < first+rows; i++ {
+		b := s.Backups[i]
+		style := ctx.Styles.subtitle
+		if i != s.selected {
+			style = ctx.Styles.body
+		}
+		name := b.Title
+		if name == "" {
+			name = fmt.Sprintf("%.8X", b.MasterFingerprint)
+		}
+		sz := widget.Labelwf(ops.Begin(), style, content.Dx(), th.Text, "%s %d/%d %s", name, b.KeyIdx+1, b.Keys, b.Time.Format(time.DateOnly))
+		op.Position(ops, ops.End(), content.Min.Add(image.Pt(0, y)))
+		y += sz.Y + margin
+	}
+	leadTxt := "No plates engraved."
+	if len(s.Backups) > 0 {
+		b := s.Backups[s.selected]
+		leadTxt = fmt.Sprintf("Seed %.8X. All plates engraved.", b.MasterFingerprint)
+		if rem := remainingPlates(s.Backups, b); len(rem) > 0 {
+			idx := make([]string, len(rem))
+			for i, r := range rem {
+				idx[i] = fmt.Sprint(r)
+			}
+			leadTxt = fmt.Sprintf("Seed %.8X. Remaining: %s.", b.MasterFingerprint, strings.Join(idx, ", "))
+		}
+	}
+	leadsz := widget.Labelwf(ops.Begin(), ctx.Styles.lead, dims.X-2*margin, th.Text, leadTxt)
+	op.Position(ops, ops.End(), lead.Center(leadsz))
+}
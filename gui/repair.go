@@ -0,0 +1,128 @@
+package gui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"seedhammer.com/bip39"
+	"seedhammer.com/gui/assets"
+	"seedhammer.com/gui/op"
+)
+
+const (
+	// maxRepairs limits the corrections offered for an invalid
+	// seed.
+	maxRepairs = 10
+	// repairBatch is the number of corrections checked against
+	// the master fingerprints per frame.
+	repairBatch = 4
+)
+
+// repairFlow searches for corrections of the invalid mnemonic and
+// lets the user choose one, which replaces mnemonic. If mfps is
+// not empty, only corrections with one of the master fingerprints
+// are offered. It reports whether mnemonic was replaced.
+func repairFlow(ctx *Context, ops op.Ctx, th *Colors, mnemonic bip39.Mnemonic, mfps []uint32) bool {
+	repairs := bip39.Repairs(mnemonic)
+	// Wipe the corrections, even if the screen saver cancels the flow.
+	defer func() {
+		for _, r := range repairs {
+			clear(r)
+		}
+	}()
+	matches := repairs
+	if len(mfps) > 0 {
+		var ok bool
+		matches, ok = matchRepairs(ctx, ops, th, repairs, mfps)
+		if !ok {
+			return false
+		}
+	}
+	matches = matches[:min(len(matches), maxRepairs)]
+	if len(matches) == 0 {
+		body := "No swap of two words or change of a single word makes the seed valid."
+		if len(mfps) > 0 {
+			body = "No swap of two words or change of a single word makes the seed match the wallet."
+		}
+		errScr := &ErrorScreen{
+			Title: "No Corrections",
+			Body:  body,
+		}
+		for {
+			dims := ctx.Platform.DisplaySize()
+			dismissed := errScr.Layout(ctx, ops.Begin(), th, dims)
+			d := ops.End()
+			if dismissed {
+				return false
+			}
+			op.ColorOp(ops, th.Background)
+			d.Add(ops)
+			ctx.Frame()
+		}
+	}
+	cs := &ChoiceScreen{
+		Title: "Repair Seed",
+		Lead:  "Choose correction",
+	}
+	for _, r := range matches {
+		cs.Choices = append(cs.Choices, describeRepair(mnemonic, r))
+	}
+	choice, ok := cs.Choose(ctx, ops, th)
+	if !ok {
+		return false
+	}
+	copy(mnemonic, matches[choice])
+	return true
+}
+
+// matchRepairs returns the repairs whose master fingerprint is among
+// mfps, at most maxRepairs. Deriving the fingerprints is slow, so
+// the progress is shown while searching. It reports false if the
+// user cancels the search.
+func matchRepairs(ctx *Context, ops op.Ctx, th *Colors, repairs []bip39.Mnemonic, mfps []uint32) ([]bip39.Mnemonic, bool) {
+	var matches []bip39.Mnemonic
+	inp := new(InputTracker)
+	for i := 0; i < len(repairs) && len(matches) < maxRepairs; {
+		for {
+			e, ok := inp.Next(ctx, Button1)
+			if !ok {
+				break
+			}
+			if inp.Clicked(e.Button) {
+				return nil, false
+			}
+		}
+		for end := min(i+repairBatch, len(repairs)); i < end; i++ {
+			mfp, err := masterFingerprintFor(repairs[i], &chaincfg.MainNetParams)
+			if err == nil && slices.Contains(mfps, mfp) {
+				matches = append(matches, repairs[i])
+			}
+		}
+		ctx.Platform.Wakeup()
+
+		dims := ctx.Platform.DisplaySize()
+		layoutProgress(ctx, ops, th, dims, "Repair Seed", "Matching corrections with the fingerprints of the wallet.", i, len(repairs))
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
+			{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack},
+		}...)
+		ctx.Frame()
+	}
+	return matches, true
+}
+
+// describeRepair describes the correction of m to r.
+func describeRepair(m, r bip39.Mnemonic) string {
+	var diff []int
+	for i := range m {
+		if m[i] != r[i] {
+			diff = append(diff, i)
+		}
+	}
+	if len(diff) == 2 {
+		return fmt.Sprintf("SWAP %d, %d", diff[0]+1, diff[1]+1)
+	}
+	i := diff[0]
+	return fmt.Sprintf("%d: %s", i+1, strings.ToUpper(bip39.LabelFor(r[i])))
+}
@@ -0,0 +1,63 @@
+package gui
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// rotatedImage is a framebuffer chunk of a display of size dims,
+// rotated 180 degrees.
+type rotatedImage struct {
+	fb   draw.RGBA64Image
+	dims image.Point
+}
+
+// rotateRect rotates r 180 degrees within a display of size dims.
+func rotateRect(r image.Rectangle, dims image.Point) image.Rectangle {
+	return image.Rectangle{Min: dims.Sub(r.Max), Max: dims.Sub(r.Min)}
+}
+
+func (r *rotatedImage) ColorModel() color.Model {
+	return r.fb.ColorModel()
+}
+
+func (r *rotatedImage) Bounds() image.Rectangle {
+	return rotateRect(r.fb.Bounds(), r.dims)
+}
+
+func (r *rotatedImage) At(x, y int) color.Color {
+	return r.fb.At(r.dims.X-1-x, r.dims.Y-1-y)
+}
+
+func (r *rotatedImage) RGBA64At(x, y int) color.RGBA64 {
+	return r.fb.RGBA64At(r.dims.X-1-x, r.dims.Y-1-y)
+}
+
+func (r *rotatedImage) Set(x, y int, c color.Color) {
+	r.fb.Set(r.dims.X-1-x, r.dims.Y-1-y, c)
+}
+
+func (r *rotatedImage) SetRGBA64(x, y int, c color.RGBA64) {
+	r.fb.SetRGBA64(r.dims.X-1-x, r.dims.Y-1-y, c)
+}
+
+// rotateButton maps a button of a controller mounted upside-down
+// to the button at its place in the rotated display.
+func rotateButton(b Button) Button {
+	switch b {
+	case Up:
+		return Down
+	case Down:
+		return Up
+	case Left:
+		return Right
+	case Right:
+		return Left
+	case Button1:
+		return Button3
+	case Button3:
+		return Button1
+	}
+	return b
+}
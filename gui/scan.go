@@ -0,0 +1,122 @@
+package gui
+
+import (
+	"image"
+
+	"seedhammer.com/image/qrprep"
+)
+
+// qrScanner decodes QR codes in camera frames on a separate
+// goroutine, to keep the camera feed responsive while decoding.
+// Frames that arrive while a scan is in progress are skipped.
+type qrScanner struct {
+	platform Platform
+	frames   chan *image.Gray
+	results  chan [][]byte
+	// frame is the copy of the frame being scanned. The
+	// camera reclaims its frames, so the worker needs its own.
+	frame *image.Gray
+	// prep holds the binarized and deskewed frame.
+	prep qrprep.Preparer
+	// busy is set while the worker owns frame and prep.
+	busy bool
+	// done is set when the results of the scan have arrived,
+	// but not yet been consumed.
+	done bool
+	res  [][]byte
+}
+
+func newQRScanner(p Platform) *qrScanner {
+	s := &qrScanner{
+		platform: p,
+		frames:   make(chan *image.Gray, 1),
+		results:  make(chan [][]byte, 1),
+		frame:    new(image.Gray),
+	}
+	go s.run()
+	return s
+}
+
+func (s *qrScanner) run() {
+	for img := range s.frames {
+		s.results <- s.scan(img)
+		s.platform.Wakeup()
+	}
+}
+
+// scan scans the center of img, and falls back to the full image if
+// no QR codes were found there. Scanning the smaller region is faster
+// for codes aimed at by the camera corners. As a last resort, the
+// image is binarized and deskewed, for codes of low contrast such as
+// engraved plates.
+func (s *qrScanner) scan(img *image.Gray) [][]byte {
+	roi := scanRegion(img.Bounds())
+	if res, err := s.platform.ScanQR(img.SubImage(roi).(*image.Gray)); err == nil && len(res) > 0 {
+		return res
+	}
+	if res, err := s.platform.ScanQR(img); err == nil && len(res) > 0 {
+		return res
+	}
+	res, _ := s.platform.ScanQR(s.prep.Prepare(img))
+	return res
+}
+
+// scanRegion returns the center region of b to scan first.
+func scanRegion(b image.Rectangle) image.Rectangle {
+	sz := b.Size().Mul(3).Div(5)
+	off := b.Min.Add(b.Size().Sub(sz).Div(2))
+	return image.Rectangle{Min: off, Max: off.Add(sz)}
+}
+
+// Submit queues a copy of img for scanning, unless the previous
+// frame is still being scanned.
+func (s *qrScanner) Submit(img *image.Gray) bool {
+	if s.busy {
+		return false
+	}
+	f := s.frame
+	f.Pix = append(f.Pix[:0], img.Pix...)
+	f.Stride = img.Stride
+	f.Rect = img.Rect
+	s.busy = true
+	s.frames <- f
+	return true
+}
+
+// Results returns the results of the submitted frame, if its scan
+// has completed.
+func (s *qrScanner) Results() ([][]byte, bool) {
+	if !s.busy {
+		return nil, false
+	}
+	if !s.done {
+		select {
+		case s.res = <-s.results:
+			s.done = true
+		default:
+			return nil, false
+		}
+	}
+	res := s.res
+	s.res = nil
+	s.done = false
+	s.busy = false
+	return res, true
+}
+
+// Wait blocks until the scan in progress, if any, completes.
+func (s *qrScanner) Wait() {
+	if s.busy && !s.done {
+		s.res = <-s.results
+		s.done = true
+	}
+}
+
+// Discard waits for the scan in progress, drops its results and
+// clears the frame copies, which may contain a seed.
+func (s *qrScanner) Discard() {
+	s.Wait()
+	s.Results()
+	clear(s.frame.Pix)
+	s.prep.Clear()
+}
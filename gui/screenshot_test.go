@@ -0,0 +1,140 @@
+package gui
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"iter"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"seedhammer.com/bip39"
+	"seedhammer.com/gui/op"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// Screenshot comparison tolerances. Small differences are expected from
+// changes to anti-aliasing and image scaling.
+const (
+	// pixelTolerance is the largest difference of a color channel
+	// for matching pixels.
+	pixelTolerance = 0x10
+	// mismatchTolerance is the maximum fraction of mismatched
+	// pixels, in parts per thousand.
+	mismatchTolerance = 2
+)
+
+func TestScreenshots(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx *Context, ops op.Ctx)
+	}{
+		{"main", func(t *testing.T, ctx *Context, ops op.Ctx) {
+			ctx.EmptySDSlot = true
+			mainFlow(ctx, ops)
+		}},
+		{"sd-warning", func(t *testing.T, ctx *Context, ops op.Ctx) {
+			ctxButton(ctx, Button3)
+			mainFlow(ctx, ops)
+		}},
+		{"settings", func(t *testing.T, ctx *Context, ops op.Ctx) {
+			settingsFlow(ctx, ops, &singleTheme)
+		}},
+		{"words", func(t *testing.T, ctx *Context, ops op.Ctx) {
+			inputWordsFlow(ctx, ops, &singleTheme, KeyboardWords, make(bip39.Mnemonic, 12), 0)
+		}},
+		{"seed", func(t *testing.T, ctx *Context, ops op.Ctx) {
+			new(SeedScreen).Confirm(ctx, ops, &singleTheme, twoOfThree.Mnemonic)
+		}},
+		{"descriptor", func(t *testing.T, ctx *Context, ops op.Ctx) {
+			scr := &DescriptorScreen{
+				Descriptor: twoOfThree.Descriptor,
+				Mnemonic:   twoOfThree.Mnemonic,
+			}
+			scr.Confirm(ctx, ops, &descriptorTheme)
+		}},
+		{"engrave", func(t *testing.T, ctx *Context, ops op.Ctx) {
+			newTestEngraveScreen(t, ctx).Engrave(ctx, ops, &engraveTheme)
+		}},
+		{"addresses", func(t *testing.T, ctx *Context, ops op.Ctx) {
+			ShowAddressesScreen(ctx, ops, &descriptorTheme, twoOfThree.Descriptor)
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := NewContext(newPlatform())
+			ops := new(op.Ops)
+			next, quit := iter.Pull(runUI(ctx, func() {
+				test.run(t, ctx, ops.Context())
+			}))
+			defer quit()
+			frame := resetOps(ops, next)
+			// Let the screen process its events and settle.
+			for range 3 {
+				frame()
+			}
+			got := renderUI(ops)
+			golden := filepath.Join("testdata", "screen-"+test.name+".png")
+			if *update {
+				if err := os.MkdirAll("testdata", 0o750); err != nil {
+					t.Fatal(err)
+				}
+				writePNG(t, golden, got)
+				return
+			}
+			f, err := os.Open(golden)
+			if err != nil {
+				t.Fatalf("%v; run with -update to create %s", err, golden)
+			}
+			defer f.Close()
+			want, err := png.Decode(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w, g := want.Bounds(), got.Bounds(); w != g {
+				t.Fatalf("golden image bounds mismatch: got %v, want %v", g, w)
+			}
+			mismatches := 0
+			b := got.Bounds()
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					if !colorsMatch(got.At(x, y), want.At(x, y)) {
+						mismatches++
+					}
+				}
+			}
+			if mismatches*1000 > b.Dx()*b.Dy()*mismatchTolerance {
+				out := filepath.Join(os.TempDir(), "screen-"+test.name+".png")
+				writePNG(t, out, got)
+				t.Errorf("%d pixels differ from %s; screenshot written to %s", mismatches, golden, out)
+			}
+		})
+	}
+}
+
+func colorsMatch(c1, c2 color.Color) bool {
+	r1, g1, b1, a1 := c1.RGBA()
+	r2, g2, b2, a2 := c2.RGBA()
+	for _, d := range [...][2]uint32{{r1, r2}, {g1, g2}, {b1, b2}, {a1, a2}} {
+		// Compare 8-bit channels.
+		v1, v2 := int(d[0]>>8), int(d[1]>>8)
+		if v1-v2 > pixelTolerance || v2-v1 > pixelTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+func writePNG(t *testing.T, name string, img image.Image) {
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, buf.Bytes(), 0o640); err != nil {
+		t.Fatal(err)
+	}
+}
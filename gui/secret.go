@@ -0,0 +1,87 @@
+package gui
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"seedhammer.com/gui/assets"
+	"seedhammer.com/gui/op"
+)
+
+// ErrNoDeviceSecret is returned by Platform.DeviceSecret until the
+// secret is created by Platform.ProvisionSecret.
+var ErrNoDeviceSecret = errors.New("device key not set up")
+
+// deviceSecret returns the device secret. A device without one
+// offers to set it up, which is permanent and must be confirmed.
+// It reports false if no secret is available, after showing why.
+func deviceSecret(ctx *Context, ops op.Ctx, th *Colors) ([]byte, bool) {
+	secret, err := ctx.Platform.DeviceSecret()
+	if errors.Is(err, ErrNoDeviceSecret) {
+		if !confirmProvision(ctx, ops, th) {
+			return nil, false
+		}
+		err = ctx.Platform.ProvisionSecret()
+		if err == nil {
+			secret, err = ctx.Platform.DeviceSecret()
+		}
+	}
+	if err != nil {
+		log.Printf("gui: device key unavailable: %v", err)
+		showError(ctx, ops, th, &ErrorScreen{
+			Title: "Device Key Unavailable",
+			Body:  fmt.Sprintf("The device key can't be read or set up.\n\nError details: %v", err),
+		})
+		return nil, false
+	}
+	return secret, true
+}
+
+func confirmProvision(ctx *Context, ops op.Ctx, th *Colors) bool {
+	confirm := &ConfirmWarningScreen{
+		Title: "Set Up Device Key?",
+		Body:  "The device key protects the backup registry, audit log and PIN. It is permanently programmed into the controller and can't be changed or erased.\n\nHold button to confirm.",
+		Icon:  assets.IconCheckmark,
+	}
+	for {
+		dims := ctx.Platform.DisplaySize()
+		res := confirm.Layout(ctx, ops.Begin(), th, dims)
+		d := ops.End()
+		switch res {
+		case ConfirmNo:
+			return false
+		case ConfirmYes:
+			return true
+		}
+		op.ColorOp(ops, th.Background)
+		d.Add(ops)
+		ctx.Frame()
+	}
+}
+
+// deviceKeyFlow sets up the device secret, if the device has none.
+func deviceKeyFlow(ctx *Context, ops op.Ctx, th *Colors) {
+	if _, ok := deviceSecret(ctx, ops, th); !ok {
+		return
+	}
+	showError(ctx, ops, th, &ErrorScreen{
+		Title: "Device Key",
+		Body:  "The device key is set up.",
+	})
+}
+
+// showError shows scr until it is dismissed.
+func showError(ctx *Context, ops op.Ctx, th *Colors, scr *ErrorScreen) {
+	for {
+		dims := ctx.Platform.DisplaySize()
+		dismissed := scr.Layout(ctx, ops.Begin(), th, dims)
+		d := ops.End()
+		if dismissed {
+			return
+		}
+		op.ColorOp(ops, th.Background)
+		d.Add(ops)
+		ctx.Frame()
+	}
+}
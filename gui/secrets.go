@@ -0,0 +1,63 @@
+package gui
+
+import (
+	"seedhammer.com/bip39"
+)
+
+// secrets tracks the mnemonics held by flows, so they can be wiped
+// when the flows exit or the device is left idle.
+type secrets struct {
+	mnemonics []bip39.Mnemonic
+	// holds counts the operations, such as engravings, that must
+	// complete before the secrets can expire.
+	holds int
+	// expired is set when the secrets were wiped while in use, and
+	// the flow using them must be cancelled.
+	expired bool
+}
+
+// expiredPanic cancels the flow whose secrets expired.
+type expiredPanic struct{}
+
+// track adds m to the secrets and returns it.
+func (s *secrets) track(m bip39.Mnemonic) bip39.Mnemonic {
+	s.mnemonics = append(s.mnemonics, m)
+	return m
+}
+
+// wipe overwrites every tracked mnemonic with empty words and
+// forgets them.
+func (s *secrets) wipe() {
+	for _, m := range s.mnemonics {
+		for i := range m {
+			m[i] = -1
+		}
+	}
+	clear(s.mnemonics)
+	s.mnemonics = s.mnemonics[:0]
+}
+
+// expire wipes the secrets unless an operation holds them, and
+// marks them expired if any were wiped.
+func (s *secrets) expire() {
+	if s.holds > 0 || len(s.mnemonics) == 0 {
+		return
+	}
+	s.wipe()
+	s.expired = true
+}
+
+// runProgram runs the flow of a program and wipes its secrets when
+// it exits. A flow whose secrets expire is cancelled.
+func runProgram(ctx *Context, flow func()) {
+	defer func() {
+		err := recover()
+		expired := ctx.secrets.expired
+		ctx.secrets.wipe()
+		ctx.secrets.expired = false
+		if err != nil && (err != expiredPanic{} || !expired) {
+			panic(err)
+		}
+	}()
+	flow()
+}
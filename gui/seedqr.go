@@ -0,0 +1,116 @@
+package gui
+
+import (
+	"image"
+	"log"
+	"time"
+
+	"github.com/kortschak/qr"
+	"seedhammer.com/bip39"
+	"seedhammer.com/gui/assets"
+	"seedhammer.com/gui/layout"
+	"seedhammer.com/gui/op"
+	"seedhammer.com/gui/widget"
+	"seedhammer.com/seedqr"
+)
+
+// seedQRTimeout is the time a SeedQR is shown before its screen
+// closes by itself.
+const seedQRTimeout = 60 * time.Second
+
+// SeedQRScreen shows the SeedQR of a seed, for transcribing it to
+// another device with a camera.
+type SeedQRScreen struct {
+	Mnemonic bip39.Mnemonic
+
+	compact bool
+	code    *qr.Code
+}
+
+// Show runs the screen until the user exits or it times out.
+func (s *SeedQRScreen) Show(ctx *Context, ops op.Ctx, th *Colors) {
+	// Wipe the encoded seed, even if the screen saver cancels the flow.
+	defer s.wipe()
+	deadline := ctx.Platform.Now().Add(seedQRTimeout)
+	inp := new(InputTracker)
+	for {
+		for {
+			e, ok := inp.Next(ctx, Button1, Button2)
+			if !ok {
+				break
+			}
+			if !inp.Clicked(e.Button) {
+				continue
+			}
+			switch e.Button {
+			case Button1:
+				return
+			case Button2:
+				s.compact = !s.compact
+				s.wipe()
+			}
+		}
+		now := ctx.Platform.Now()
+		if !now.Before(deadline) {
+			return
+		}
+		dims := ctx.Platform.DisplaySize()
+		if s.code == nil {
+			if err := s.encode(dims); err != nil {
+				log.Printf("gui: %v", err)
+				return
+			}
+		}
+		// Update the countdown every second.
+		secs := int((deadline.Sub(now) + time.Second - 1) / time.Second)
+		ctx.WakeupAt(deadline.Add(-time.Duration(secs-1) * time.Second))
+
+		op.ColorOp(ops, th.Background)
+		title := "SeedQR"
+		if s.compact {
+			title = "CompactSeedQR"
+		}
+		layoutTitle(ctx, ops, dims.X, th.Text, title)
+		r := layout.Rectangle{Max: dims}
+		_, content := r.CutTop(leadingSize)
+		content, lead := content.CutBottom(leadingSize)
+		img := s.code.Image()
+		op.ImageOp(ops.Begin(), img, false)
+		op.Position(ops, ops.End(), content.Center(img.Bounds().Size()))
+		const margin = 8
+		leadsz := widget.Labelwf(ops.Begin(), ctx.Styles.lead, dims.X-2*margin, th.Text, "Closes in %d seconds.", secs)
+		op.Position(ops, ops.End(), lead.Center(leadsz))
+		layoutNavigation(ctx, inp, ops, th, dims, []NavButton{
+			{Button: Button1, Style: StyleSecondary, Icon: assets.IconBack},
+			{Button: Button2, Style: StyleSecondary, Icon: assets.IconFlip},
+		}...)
+		ctx.Frame()
+	}
+}
+
+// encode the seed in its SeedQR or CompactSeedQR form, scaled to
+// the display.
+func (s *SeedQRScreen) encode(dims image.Point) error {
+	var content []byte
+	if s.compact {
+		content = seedqr.CompactQR(s.Mnemonic)
+	} else {
+		content = seedqr.QR(s.Mnemonic)
+	}
+	code, err := qr.Encode(string(content), qr.L)
+	clear(content)
+	if err != nil {
+		return err
+	}
+	// Leave room for the title, lead and navigation buttons.
+	code.Scale = max(1, (dims.Y-2*leadingSize)/(code.Size+8))
+	s.code = code
+	return nil
+}
+
+func (s *SeedQRScreen) wipe() {
+	if s.code != nil {
+		clear(s.code.Bitmap)
+		s.code = nil
+	}
+}
@@ -0,0 +1,553 @@
+package gui
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/kortschak/qr"
+	"seedhammer.com/backup"
+	"seedhammer.com/font/condensed"
+	"seedhammer.com/font/constant"
+	"seedhammer.com/font/vector"
+	"seedhammer.com/gui/assets"
+	"seedhammer.com/gui/op"
+)
+
+// Settings are the device settings persisted by the Platform.
+type Settings struct {
+	// ID is a random identifier of the settings, for telling the
+	// SD card they are stored on apart from other cards.
+	ID []byte
+	// Origin is added to the coordinates of every engraving
+	// to correct for the tolerances of the engraver. It is
+	// measured in machine units.
+	Origin image.Point
+	// Speed is the engraving speed profile.
+	Speed SpeedProfile
+	// Font is the font of descriptor sides.
+	Font PlateFont
+	// QR is the error correction of engraved QR codes.
+	QR QRCorrection
+	// Registry is the encrypted backup registry, sealed by
+	// sealRegistry.
+	Registry []byte
+	// Orientation is the mounting orientation of the controller.
+	Orientation Orientation
+	// Contrast selects the display theme and text styles.
+	Contrast Contrast
+	// Accessibility enables large print and audible feedback.
+	Accessibility Accessibility
+	// IdleTimeout is the inactivity before the screen saver starts.
+	IdleTimeout IdleTimeout
+	// Saver selects the screen saver.
+	Saver SaverMode
+	// PINHash is the salted hash of the device PIN, or empty
+	// if no PIN is set.
+	PINHash []byte
+	PINSalt []byte
+	// PINFailures counts the failed PIN attempts since the
+	// last successful attempt.
+	PINFailures int
+	// AuditLog is the hash chained log of engraving jobs.
+	AuditLog []AuditEntry
+	// AuditSignature is the signature of the head of AuditLog,
+	// made by the device when it appended the latest entry.
+	AuditSignature []byte
+	// NeedleWear is the length of the strokes engraved since
+	// the needle was last serviced, in millimeters.
+	NeedleWear int
+	// NeedleService is the wear after which the needle is due
+	// for service.
+	NeedleService ServiceInterval
+	// Flip is the direction plates are flipped between their
+	// sides.
+	Flip FlipDirection
+	// Order is the order strokes are engraved in.
+	Order StrokeOrder
+	// Addresses selects whether descriptor sides list the first
+	// addresses of their wallet.
+	Addresses PlateAddresses
+	// Keyboard enables typing on a USB keyboard, on platforms that
+	// support one.
+	Keyboard KeyboardInput
+	// Template is the design of seed sides.
+	Template PlateTemplate
+}
+
+// SpeedProfile trades engraving quality for speed.
+type SpeedProfile int
+
+const (
+	SpeedNormal SpeedProfile = iota
+	SpeedFine
+	SpeedFast
+)
+
+// StrokeOrder selects the order strokes are engraved in.
+type StrokeOrder int
+
+const (
+	OrderNormal StrokeOrder = iota
+	// OrderSpread alternates strokes between distant regions of
+	// the plate, for thin plates.
+	OrderSpread
+)
+
+// PlateAddresses selects whether the first receive and change
+// addresses are engraved below the descriptor, for confirming a
+// recovered wallet without software.
+type PlateAddresses int
+
+const (
+	AddressesOff PlateAddresses = iota
+	AddressesOn
+)
+
+// PlateTemplate selects the design of seed sides. The minimal
+// designs engrave the seed words or the SeedQR code only.
+type PlateTemplate int
+
+const (
+	TemplateStandard PlateTemplate = iota
+	TemplateWords
+	TemplateQR
+)
+
+func (t PlateTemplate) template() backup.Template {
+	switch t {
+	case TemplateWords:
+		return backup.WordsTemplate
+	case TemplateQR:
+		return backup.QRTemplate
+	default:
+		return backup.StandardTemplate
+	}
+}
+
+// KeyboardInput selects whether a USB keyboard may be used for
+// typing seeds, passphrases and labels. A keyboard could record
+// what is typed, so it is disabled unless enabled by the user.
+type KeyboardInput int
+
+const (
+	KeyboardOff KeyboardInput = iota
+	KeyboardOn
+)
+
+// FlipDirection is the direction a plate is flipped after engraving
+// its first side. Flipping vertically keeps the bolt holes of the
+// sides in the same orientation.
+type FlipDirection int
+
+const (
+	FlipHorizontal FlipDirection = iota
+	FlipVertical
+)
+
+func (f FlipDirection) String() string {
+	if f == FlipVertical {
+		return "vertically"
+	}
+	return "horizontally"
+}
+
+// PlateFont trades the legibility of descriptor text for fit.
+type PlateFont int
+
+const (
+	FontRegular PlateFont = iota
+	FontCondensed
+)
+
+func (f PlateFont) face() *vector.Face {
+	switch f {
+	case FontCondensed:
+		return condensed.Font
+	default:
+		return constant.Font
+	}
+}
+
+// QRCorrection trades the density of engraved QR codes for
+// their resilience to damage.
+type QRCorrection int
+
+const (
+	QRMedium QRCorrection = iota
+	QRQuartile
+	QRHigh
+)
+
+func (c QRCorrection) level() qr.Level {
+	switch c {
+	case QRQuartile:
+		return qr.Q
+	case QRHigh:
+		return qr.H
+	default:
+		return qr.M
+	}
+}
+
+// Orientation is the mounting orientation of the controller. A
+// rotated controller displays the screen upside-down and places
+// the navigation buttons on the left.
+type Orientation int
+
+const (
+	OrientNormal Orientation = iota
+	OrientRotated
+)
+
+// Contrast selects the display palette. High contrast uses dark
+// backgrounds and heavier text for readability in bright or
+// uneven light.
+type Contrast int
+
+const (
+	ContrastNormal Contrast = iota
+	ContrastHigh
+)
+
+// Accessibility selects the large print mode, with doubled body
+// text and enlarged navigation buttons, optionally with a beep
+// acknowledging every button press.
+type Accessibility int
+
+const (
+	AccessibilityOff Accessibility = iota
+	AccessibilityLarge
+	AccessibilityBeep
+)
+
+// IdleTimeout is the inactivity before the screen saver starts.
+type IdleTimeout int
+
+const (
+	Idle3Min IdleTimeout = iota
+	Idle1Min
+	Idle10Min
+)
+
+func (t IdleTimeout) duration() time.Duration {
+	switch t {
+	case Idle1Min:
+		return 1 * time.Minute
+	case Idle10Min:
+		return 10 * time.Minute
+	default:
+		return 3 * time.Minute
+	}
+}
+
+// SaverMode selects the screen saver. The privacy blank clears
+// the screen and ignores buttons until a long press, so that no
+// seed is left visible.
+type SaverMode int
+
+const (
+	SaverAnimation SaverMode = iota
+	SaverBlank
+)
+
+// setting is an entry of the settings menu.
+type setting struct {
+	name string
+	flow func(ctx *Context, ops op.Ctx, th *Colors)
+	// values is the number of values of a setting chosen by
+	// chooseSetting without confirmation, and index returns the
+	// index of its value in Settings.
+	values int
+	index  func(s Settings) int
+}
+
+// settingsMenu lists the entries of the settings menu.
+var settingsMenu = []setting{
+	{name: "CALIBRATE", flow: calibrateFlow},
+	speedSetting.entry("SPEED"),
+	fontSetting.entry("FONT"),
+	qrSetting.entry("QR"),
+	{name: "BACKUPS", flow: backupsFlow},
+	orientationSetting.entry("DISPLAY"),
+	contrastSetting.entry("THEME"),
+	accessibilitySetting.entry("ACCESS"),
+	{name: "SAVER", flow: saverFlow},
+	{name: "PIN", flow: pinFlow},
+	{name: "NEEDLE", flow: needleFlow},
+	flipSetting.entry("FLIP"),
+	orderSetting.entry("ORDER"),
+	addressesSetting.entry("ADDRESS"),
+	keyboardSetting.entry("KEYBOARD"),
+	templateSetting.entry("TEMPLATE"),
+	{name: "KEY", flow: deviceKeyFlow},
+}
+
+func settingsFlow(ctx *Context, ops op.Ctx, th *Colors) {
+	cs := &ChoiceScreen{
+		Title: "Settings",
+		Lead:  "Choose setting",
+	}
+	for _, s := range settingsMenu {
+		cs.Choices = append(cs.Choices, s.name)
+	}
+	for {
+		choice, ok := cs.Choose(ctx, ops, th)
+		if !ok {
+			return
+		}
+		settingsMenu[choice].flow(ctx, ops, th)
+	}
+}
+
+// choiceSetting is a setting chosen from a list of values.
+type choiceSetting[T comparable] struct {
+	title  string
+	lead   string
+	labels []string
+	values []T
+	// field returns the setting in s.
+	field func(s *Settings) *T
+	// confirm, if not nil, confirms changing the setting to v.
+	confirm func(ctx *Context, ops op.Ctx, th *Colors, v T) bool
+}
+
+// entry returns the settings menu entry of the setting.
+func (c *choiceSetting[T]) entry(name string) setting {
+	e := setting{
+		name: name,
+		flow: func(ctx *Context, ops op.Ctx, th *Colors) {
+			chooseSetting(ctx, ops, th, c)
+		},
+	}
+	if c.confirm == nil {
+		e.values = len(c.values)
+		e.index = func(s Settings) int {
+			return slices.Index(c.values, *c.field(&s))
+		}
+	}
+	return e
+}
+
+// chooseSetting lets the user choose a value of a setting and
+// stores it. It reports whether a value was stored.
+func chooseSetting[T comparable](ctx *Context, ops op.Ctx, th *Colors, c *choiceSetting[T]) bool {
+	cs := &ChoiceScreen{
+		Title:   c.title,
+		Lead:    c.lead,
+		Choices: c.labels,
+	}
+	current := *c.field(&ctx.Settings)
+	if i := slices.Index(c.values, current); i != -1 {
+		cs.choice = i
+	}
+	for {
+		choice, ok := cs.Choose(ctx, ops, th)
+		if !ok {
+			return false
+		}
+		v := c.values[choice]
+		if c.confirm != nil && v != current && !c.confirm(ctx, ops, th, v) {
+			continue
+		}
+		settings := ctx.Settings
+		*c.field(&settings) = v
+		if err := storeSettings(ctx, ops, th, settings); err != nil {
+			continue
+		}
+		// The contrast and accessibility settings change the styles.
+		ctx.applyStyles()
+		return true
+	}
+}
+
+var speedSetting = &choiceSetting[SpeedProfile]{
+	title:  "Speed",
+	lead:   "Choose engraving speed",
+	labels: []string{"FINE", "NORMAL", "FAST"},
+	values: []SpeedProfile{SpeedFine, SpeedNormal, SpeedFast},
+	field:  func(s *Settings) *SpeedProfile { return &s.Speed },
+}
+
+var fontSetting = &choiceSetting[PlateFont]{
+	title:  "Font",
+	lead:   "Choose descriptor font",
+	labels: []string{"REGULAR", "CONDENSED"},
+	values: []PlateFont{FontRegular, FontCondensed},
+	field:  func(s *Settings) *PlateFont { return &s.Font },
+}
+
+var qrSetting = &choiceSetting[QRCorrection]{
+	title:  "QR",
+	lead:   "Choose error correction",
+	labels: []string{"MEDIUM", "QUARTILE", "HIGH"},
+	values: []QRCorrection{QRMedium, QRQuartile, QRHigh},
+	field:  func(s *Settings) *QRCorrection { return &s.QR },
+}
+
+var flipSetting = &choiceSetting[FlipDirection]{
+	title:  "Flip",
+	lead:   "Choose plate flip direction",
+	labels: []string{"HORIZONTAL", "VERTICAL"},
+	values: []FlipDirection{FlipHorizontal, FlipVertical},
+	field:  func(s *Settings) *FlipDirection { return &s.Flip },
+}
+
+var orderSetting = &choiceSetting[StrokeOrder]{
+	title:  "Order",
+	lead:   "Choose stroke order",
+	labels: []string{"NORMAL", "SPREAD"},
+	values: []StrokeOrder{OrderNormal, OrderSpread},
+	field:  func(s *Settings) *StrokeOrder { return &s.Order },
+}
+
+var addressesSetting = &choiceSetting[PlateAddresses]{
+	title:  "Address",
+	lead:   "Engrave first addresses",
+	labels: []string{"OFF", "ON"},
+	values: []PlateAddresses{AddressesOff, AddressesOn},
+	field:  func(s *Settings) *PlateAddresses { return &s.Addresses },
+}
+
+var templateSetting = &choiceSetting[PlateTemplate]{
+	title:  "Template",
+	lead:   "Choose seed side design",
+	labels: []string{"STANDARD", "WORDS", "QR"},
+	values: []PlateTemplate{TemplateStandard, TemplateWords, TemplateQR},
+	field:  func(s *Settings) *PlateTemplate { return &s.Template },
+}
+
+// keyboardSetting enables or disables USB keyboard input. Enabling
+// it requires confirming a warning about keyloggers.
+var keyboardSetting = &choiceSetting[KeyboardInput]{
+	title:   "Keyboard",
+	lead:    "Allow USB keyboard input",
+	labels:  []string{"OFF", "ON"},
+	values:  []KeyboardInput{KeyboardOff, KeyboardOn},
+	field:   func(s *Settings) *KeyboardInput { return &s.Keyboard },
+	confirm: confirmKeyboard,
+}
+
+func confirmKeyboard(ctx *Context, ops op.Ctx, th *Colors, k KeyboardInput) bool {
+	if k != KeyboardOn {
+		return true
+	}
+	confirm := &ConfirmWarningScreen{
+		Title: "Keylogger Risk",
+		Body:  "A keyboard can record or transmit everything typed on it, including seed words and passphrases. Only connect a keyboard you trust.\n\nHold button to confirm.",
+		Icon:  assets.IconCheckmark,
+	}
+	for {
+		dims := ctx.Platform.DisplaySize()
+		res := confirm.Layout(ctx, ops.Begin(), th, dims)
+		d := ops.End()
+		switch res {
+		case ConfirmNo:
+			return false
+		case ConfirmYes:
+			return true
+		}
+		op.ColorOp(ops, th.Background)
+		d.Add(ops)
+		ctx.Frame()
+	}
+}
+
+var orientationSetting = &choiceSetting[Orientation]{
+	title:  "Display",
+	lead:   "Choose orientation",
+	labels: []string{"NORMAL", "ROTATED"},
+	values: []Orientation{OrientNormal, OrientRotated},
+	field:  func(s *Settings) *Orientation { return &s.Orientation },
+}
+
+var contrastSetting = &choiceSetting[Contrast]{
+	title:  "Theme",
+	lead:   "Choose display contrast",
+	labels: []string{"NORMAL", "HIGH"},
+	values: []Contrast{ContrastNormal, ContrastHigh},
+	field:  func(s *Settings) *Contrast { return &s.Contrast },
+}
+
+var accessibilitySetting = &choiceSetting[Accessibility]{
+	title:  "Access",
+	lead:   "Choose accessibility mode",
+	labels: []string{"OFF", "LARGE", "LARGE+BEEP"},
+	values: []Accessibility{AccessibilityOff, AccessibilityLarge, AccessibilityBeep},
+	field:  func(s *Settings) *Accessibility { return &s.Accessibility },
+}
+
+var idleSetting = &choiceSetting[IdleTimeout]{
+	title:  "Saver",
+	lead:   "Choose timeout",
+	labels: []string{"1 MIN", "3 MIN", "10 MIN"},
+	values: []IdleTimeout{Idle1Min, Idle3Min, Idle10Min},
+	field:  func(s *Settings) *IdleTimeout { return &s.IdleTimeout },
+}
+
+var saverSetting = &choiceSetting[SaverMode]{
+	title:  "Saver",
+	lead:   "Choose screen saver",
+	labels: []string{"ANIMATION", "BLANK"},
+	values: []SaverMode{SaverAnimation, SaverBlank},
+	field:  func(s *Settings) *SaverMode { return &s.Saver },
+}
+
+// saverFlow chooses the idle timeout followed by the screen saver.
+func saverFlow(ctx *Context, ops op.Ctx, th *Colors) {
+	for chooseSetting(ctx, ops, th, idleSetting) {
+		if chooseSetting(ctx, ops, th, saverSetting) {
+			return
+		}
+	}
+}
+
+// storeSettings persists settings and updates the context. Errors
+// are shown to the user.
+func storeSettings(ctx *Context, ops op.Ctx, th *Colors, settings Settings) error {
+	err := ctx.Platform.StoreSettings(settings)
+	if err == nil {
+		ctx.Settings = settings
+		ctx.unsaved = false
+		return nil
+	}
+	log.Printf("gui: failed to store settings: %v", err)
+	errScr := &ErrorScreen{
+		Title: "Settings Not Saved",
+		Body:  fmt.Sprintf("Insert the SD card and try again.\n\nError details: %v", err),
+	}
+	for {
+		dims := ctx.Platform.DisplaySize()
+		dismissed := errScr.Layout(ctx, ops.Begin(), th, dims)
+		d := ops.End()
+		if dismissed {
+			return err
+		}
+		op.ColorOp(ops, th.Background)
+		d.Add(ops)
+		ctx.Frame()
+	}
+}
+
+// recordSettings updates the context with settings recorded by
+// the device itself, such as engraving jobs. Programs run with the
+// SD card removed, so settings that can't be stored are kept and
+// stored by flushSettings when the card is inserted.
+func recordSettings(ctx *Context, settings Settings) {
+	ctx.Settings = settings
+	if err := ctx.Platform.StoreSettings(settings); err != nil {
+		log.Printf("gui: settings not stored until the SD card is inserted: %v", err)
+		ctx.unsaved = true
+		return
+	}
+	ctx.unsaved = false
+}
+
+// flushSettings stores settings kept by recordSettings.
+func flushSettings(ctx *Context) {
+	if ctx.unsaved {
+		recordSettings(ctx, ctx.Settings)
+	}
+}
@@ -22,8 +22,22 @@ type Style struct {
 	Alignment       Alignment
 	LineHeightScale float32
 	LetterSpacing   int
+	// TabStop is the distance in pixels between tab stops. Tabs
+	// advance to the next stop of the line and are not drawn.
+	TabStop int
+	// MiddleEllipsis truncates lines wider than the maximum width
+	// by replacing their middle with an ellipsis, instead of
+	// wrapping them.
+	MiddleEllipsis bool
 }
 
+// SoftHyphen marks a break opportunity inside a word. It is invisible
+// unless a line breaks at it, in which case a hyphen ends the line.
+const SoftHyphen = '\u00ad'
+
+// ellipsisDots is the number of periods in an ellipsis.
+const ellipsisDots = 3
+
 type Alignment int
 
 const (
@@ -243,18 +257,30 @@ type Layout struct {
 	MaxWidth int
 	Style    Style
 
-	state      layoutState
-	cursor     state
-	prevCur    state
-	checkpoint state
-	width      fixed.Int26_6
-	runes      int
-	spaceBreak bool
-	breakWidth fixed.Int26_6
-	eof        bool
-	dot        fixed.Int26_6
-	lineRunes  int
-	lineWidth  fixed.Int26_6
+	state       layoutState
+	cursor      state
+	prevCur     state
+	checkpoint  state
+	width       fixed.Int26_6
+	runes       int
+	spaceBreak  bool
+	hyphenBreak bool
+	breakWidth  fixed.Int26_6
+	eof         bool
+	dot         fixed.Int26_6
+	lineStart   fixed.Int26_6
+	lineRunes   int
+	lineWidth   fixed.Int26_6
+	// ellipsis describes the truncation of the current line.
+	ellipsis struct {
+		// at is the number of runes before the ellipsis, or -1
+		// for lines without.
+		at int
+		// skip is the number of runes replaced by the ellipsis.
+		skip int
+		// dots is the number of periods left to yield.
+		dots int
+	}
 }
 
 type layoutState int
@@ -276,12 +302,33 @@ func (l *Layout) Next(format string, args ...any) (Glyph, bool) {
 		case layoutInit:
 			l.init(format, args)
 		case layoutRunes:
+			if e := &l.ellipsis; e.at == 0 {
+				if e.dots > 0 {
+					e.dots--
+					a := l.Style.advance('.')
+					g := Glyph{
+						Rune:    '.',
+						Dot:     l.dot,
+						Advance: a,
+					}
+					l.dot += a
+					return g, true
+				}
+				for ; e.skip > 0; e.skip-- {
+					l.cursor.next(l.Style, 0, format, args)
+					l.lineRunes--
+				}
+				e.at = -1
+			}
 			if l.lineRunes == 0 {
 				l.state = layoutEOL
 				break
 			}
 			l.lineRunes--
-			r, a, ok := l.cursor.next(l.Style, format, args)
+			if l.ellipsis.at > 0 {
+				l.ellipsis.at--
+			}
+			r, a, ok := l.cursor.next(l.Style, l.dot-l.lineStart, format, args)
 			if !ok {
 				panic("underflow")
 			}
@@ -291,11 +338,25 @@ func (l *Layout) Next(format string, args ...any) (Glyph, bool) {
 				Advance: a,
 			}
 			l.dot += a
+			if r == SoftHyphen || r == '\t' && l.Style.TabStop > 0 {
+				continue
+			}
 			return g, true
 		case layoutEOL:
 			if l.eof {
 				return Glyph{}, false
 			}
+			if l.hyphenBreak {
+				l.hyphenBreak = false
+				a := l.Style.advance('-')
+				g := Glyph{
+					Rune:    '-',
+					Dot:     l.dot,
+					Advance: a,
+				}
+				l.dot += a
+				return g, true
+			}
 			g := Glyph{
 				Rune: '\n',
 				Dot:  l.dot,
@@ -304,7 +365,7 @@ func (l *Layout) Next(format string, args ...any) (Glyph, bool) {
 			if l.spaceBreak {
 				l.runes--
 				l.width -= l.breakWidth
-				l.cursor.next(l.Style, format, args)
+				l.cursor.next(l.Style, 0, format, args)
 			}
 			l.prevCur = l.cursor
 			l.state = layoutInit
@@ -314,29 +375,43 @@ func (l *Layout) Next(format string, args ...any) (Glyph, bool) {
 }
 
 func (l *Layout) init(format string, args []any) {
+	if l.Style.MiddleEllipsis {
+		l.initEllipsis(format, args)
+		return
+	}
 	// Compute line extent in runes and width.
 	l.lineRunes = 0
 	l.lineWidth = fixed.I(0)
 	l.cursor = l.checkpoint
 	l.spaceBreak = false
+	l.hyphenBreak = false
 	l.breakWidth = fixed.I(0)
+	l.ellipsis.at = -1
+	hyphen := l.Style.advance('-')
 	for {
 		if l.runes > 0 && l.width.Ceil() > l.MaxWidth {
 			break
 		}
-		r, a, ok := l.cursor.next(l.Style, format, args)
+		r, a, ok := l.cursor.next(l.Style, l.width, format, args)
 		if !ok {
 			l.eof = true
+			l.hyphenBreak = false
 			l.lineRunes = l.runes
 			l.lineWidth = l.width
 			break
 		}
 		space := unicode.IsSpace(r)
-		if space || (l.lineRunes == 0 && (l.width+a).Ceil() > l.MaxWidth) {
-			l.spaceBreak = space
+		// Break at soft hyphens only if the hyphen fits.
+		soft := r == SoftHyphen && (l.width+hyphen).Ceil() <= l.MaxWidth
+		if space || soft || (l.lineRunes == 0 && (l.width+a).Ceil() > l.MaxWidth) {
+			l.spaceBreak = space || soft
+			l.hyphenBreak = soft
 			l.breakWidth = a
 			l.lineRunes = l.runes
 			l.lineWidth = l.width
+			if soft {
+				l.lineWidth += hyphen
+			}
 		}
 		l.runes++
 		l.width += a
@@ -346,7 +421,86 @@ func (l *Layout) init(format string, args []any) {
 	}
 	l.runes -= l.lineRunes
 	l.width -= l.lineWidth
-	// Rewind and yield glyphs.
+	if l.hyphenBreak {
+		l.width += hyphen
+	}
+	l.rewind()
+}
+
+// initEllipsis is like init, but truncates the line instead of
+// breaking it.
+func (l *Layout) initEllipsis(format string, args []any) {
+	start := l.checkpoint
+	l.cursor = start
+	l.spaceBreak = false
+	l.hyphenBreak = false
+	l.breakWidth = fixed.I(0)
+	n, w := 0, fixed.I(0)
+	for {
+		r, a, ok := l.cursor.next(l.Style, w, format, args)
+		if !ok {
+			l.eof = true
+			break
+		}
+		if r == '\n' {
+			l.spaceBreak = true
+			l.breakWidth = a
+			break
+		}
+		n++
+		w += a
+	}
+	l.lineRunes = n
+	l.lineWidth = w
+	l.ellipsis.at = -1
+	if w.Ceil() > l.MaxWidth {
+		dots := ellipsisDots * l.Style.advance('.')
+		avail := fixed.I(l.MaxWidth) - dots
+		// Binary search for the most runes kept at both ends.
+		// Keeping as many at the start as the end makes the
+		// result stable when laid out again at its own width.
+		lo, hi := 0, n/2
+		for lo < hi {
+			m := (lo + hi + 1) / 2
+			if l.ends(start, m, n, w, format, args) <= avail {
+				lo = m
+			} else {
+				hi = m - 1
+			}
+		}
+		l.ellipsis.at = lo
+		l.ellipsis.skip = n - 2*lo
+		l.ellipsis.dots = ellipsisDots
+		l.lineWidth = l.ends(start, lo, n, w, format, args) + dots
+	}
+	// The line break, if any, is left for layoutEOL to skip.
+	l.runes, l.width = 0, fixed.I(0)
+	if l.spaceBreak {
+		l.runes, l.width = 1, l.breakWidth
+	}
+	l.rewind()
+}
+
+// ends returns the width of the first and last m of the n runes of
+// the line starting at start and of width w.
+func (l *Layout) ends(start state, m, n int, w fixed.Int26_6, format string, args []any) fixed.Int26_6 {
+	var head, prefix fixed.Int26_6
+	for i := range n - m {
+		if i == m {
+			head = prefix
+		}
+		_, a, _ := start.next(l.Style, prefix, format, args)
+		prefix += a
+	}
+	if m == n-m {
+		head = prefix
+	}
+	return head + w - prefix
+}
+
+// rewind the cursor to the start of the line measured by init, and
+// prepare for yielding its glyphs.
+func (l *Layout) rewind() {
 	l.checkpoint = l.cursor
 	l.cursor = l.prevCur
 	l.dot = fixed.I(0)
@@ -356,6 +510,7 @@ func (l *Layout) init(format string, args []any) {
 	case AlignEnd:
 		l.dot = fixed.I(l.MaxWidth) - l.lineWidth
 	}
+	l.lineStart = l.dot
 	l.state = layoutRunes
 }
 
@@ -364,11 +519,18 @@ type state struct {
 	formatter formatter
 }
 
-func (s *state) next(l Style, format string, args []any) (rune, fixed.Int26_6, bool) {
+// next returns the next rune and its advance, at x from the start
+// of its line.
+func (s *state) next(l Style, x fixed.Int26_6, format string, args []any) (rune, fixed.Int26_6, bool) {
 	r, ok := s.formatter.Next(format, args...)
 	if !ok {
 		return 0, 0, false
 	}
+	if r == '\t' && l.TabStop > 0 {
+		s.prevR = -1
+		stop := fixed.I(l.TabStop)
+		return r, stop - x%stop, true
+	}
 	a, ok := l.Face.GlyphAdvance(r)
 	if !ok {
 		s.prevR = -1
@@ -380,3 +542,9 @@ func (s *state) next(l Style, format string, args []any) (rune, fixed.Int26_6, b
 	a += fixed.I(l.LetterSpacing)
 	return r, a, true
 }
+
+// advance returns the advance of r without kerning.
+func (l Style) advance(r rune) fixed.Int26_6 {
+	a, _ := l.Face.GlyphAdvance(r)
+	return a + fixed.I(l.LetterSpacing)
+}
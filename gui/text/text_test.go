@@ -125,3 +125,83 @@ func TestLayout(t *testing.T) {
 		}
 	}
 }
+
+// layoutLines returns the lines of a layout.
+func layoutLines(l *Layout, format string, args ...any) []string {
+	var lines []string
+	var buf strings.Builder
+	for {
+		g, ok := l.Next(format, args...)
+		if !ok {
+			break
+		}
+		if g.Rune == '\n' {
+			lines = append(lines, buf.String())
+			buf.Reset()
+			continue
+		}
+		buf.WriteRune(g.Rune)
+	}
+	return append(lines, buf.String())
+}
+
+func TestSoftHyphen(t *testing.T) {
+	st := Style{Face: poppins.Regular16}
+	txt := "Hello Seed\u00adHammer"
+	narrow := st.Measure(1000, "Hello Seed-")
+	got := layoutLines(&Layout{MaxWidth: narrow.X, Style: st}, txt)
+	if want := []string{"Hello Seed-", "Hammer"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("broken at soft hyphen: got %q, want %q", got, want)
+	}
+	got = layoutLines(&Layout{MaxWidth: 1000, Style: st}, txt)
+	if want := []string{"Hello SeedHammer"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unbroken: got %q, want %q", got, want)
+	}
+}
+
+func TestMiddleEllipsis(t *testing.T) {
+	st := Style{Face: poppins.Regular16, MiddleEllipsis: true}
+	const addr = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+	got := layoutLines(&Layout{MaxWidth: 1000, Style: st}, "Short\n%s", addr)
+	if want := []string{"Short", addr}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wide layout: got %q, want %q", got, want)
+	}
+	const width = 120
+	got = layoutLines(&Layout{MaxWidth: width, Style: st}, "%s\nShort", addr)
+	if len(got) != 2 || got[1] != "Short" {
+		t.Fatalf("narrow layout: got %q", got)
+	}
+	line := got[0]
+	head, tail, ok := strings.Cut(line, "...")
+	if !ok || len(head) != len(tail) || len(head) == 0 ||
+		!strings.HasPrefix(addr, head) || !strings.HasSuffix(addr, tail) {
+		t.Errorf("truncated %q to %q", addr, line)
+	}
+	sz := st.Measure(width, "%s", addr)
+	if sz.X > width {
+		t.Errorf("truncated width %d exceeds %d", sz.X, width)
+	}
+	// Laying out at the truncated width must not truncate further.
+	if again := layoutLines(&Layout{MaxWidth: sz.X, Style: st}, "%s", addr); again[0] != line {
+		t.Errorf("truncated to %q at width %d, want %q", again[0], sz.X, line)
+	}
+}
+
+func TestTabStops(t *testing.T) {
+	st := Style{Face: poppins.Regular16, TabStop: 40}
+	for _, n := range []int{1, 100} {
+		l := &Layout{MaxWidth: 1000, Style: st}
+		for {
+			g, ok := l.Next("%d:\tA", n)
+			if !ok {
+				t.Fatalf("%d: tab stop glyph missing", n)
+			}
+			if g.Rune == 'A' {
+				if g.Dot != fixed.I(40) {
+					t.Errorf("%d: glyph after tab at %v, want %v", n, g.Dot, fixed.I(40))
+				}
+				break
+			}
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"image/color"
 
 	"seedhammer.com/font/comfortaa"
+	"seedhammer.com/font/gomono"
 	"seedhammer.com/font/poppins"
 	"seedhammer.com/gui/text"
 )
@@ -18,6 +19,7 @@ type Styles struct {
 	title    text.Style
 	subtitle text.Style
 	body     text.Style
+	mono     text.Style
 	lead     text.Style
 	button   text.Style
 	word     text.Style
@@ -34,6 +36,8 @@ type Colors struct {
 	Primary    color.NRGBA
 }
 
+// The program themes in use, selected from the themes registry by
+// applyTheme.
 var (
 	descriptorTheme Colors
 	singleTheme     Colors
@@ -43,33 +47,97 @@ var (
 
 const leadingSize = 44
 
+// Theme is a set of program themes and overlay masks.
+type Theme struct {
+	Descriptor Colors
+	Single     Colors
+	Engrave    Colors
+	Camera     Colors
+
+	OverlayMask  uint8
+	ActiveMask   uint8
+	InactiveMask uint8
+}
+
+// themes maps each contrast setting to its theme.
+var themes = map[Contrast]Theme{
+	ContrastNormal: {
+		Descriptor: Colors{
+			Background: rgb(0x267f26),
+			Text:       rgb(0xe9f2ea),
+			Primary:    rgb(0x02427d),
+		},
+		Single: Colors{
+			Background: rgb(0xdd9700),
+			Text:       rgb(0xfbf4e8),
+			Primary:    rgb(0x02427d),
+		},
+		Engrave: Colors{
+			Background: rgb(0xd1e83cb),
+			Text:       rgb(0xdffffff),
+			Primary:    rgb(0x02427d),
+		},
+		Camera: Colors{
+			Text: rgb(0xfbf4e8),
+		},
+		OverlayMask:  0x55,
+		ActiveMask:   0x55,
+		InactiveMask: 0x55,
+	},
+	// ContrastHigh keeps the program hues as dark backgrounds
+	// behind white text.
+	ContrastHigh: {
+		Descriptor: Colors{
+			Background: rgb(0x0b2e0b),
+			Text:       rgb(0xffffff),
+			Primary:    rgb(0xffd200),
+		},
+		Single: Colors{
+			Background: rgb(0x3d2900),
+			Text:       rgb(0xffffff),
+			Primary:    rgb(0xffd200),
+		},
+		Engrave: Colors{
+			Background: rgb(0x000000),
+			Text:       rgb(0xffffff),
+			Primary:    rgb(0xffd200),
+		},
+		Camera: Colors{
+			Text: rgb(0xffffff),
+		},
+		OverlayMask:  0xaa,
+		ActiveMask:   0x88,
+		InactiveMask: 0x88,
+	},
+}
+
 func init() {
-	prim := rgb(0x02427d)
-	descriptorTheme = Colors{
-		Background: rgb(0x267f26),
-		Text:       rgb(0xe9f2ea),
-		Primary:    prim,
-	}
-	singleTheme = Colors{
-		Background: rgb(0xdd9700),
-		Text:       rgb(0xfbf4e8),
-		Primary:    prim,
-	}
-	engraveTheme = Colors{
-		Background: rgb(0xd1e83cb),
-		Text:       rgb(0xdffffff),
-		Primary:    prim,
-	}
-	cameraTheme = Colors{
-		Text: rgb(0xfbf4e8),
+	applyTheme(ContrastNormal)
+}
+
+// applyTheme replaces the program themes with the theme for c.
+// Screens keep pointers to the program themes, so the change
+// applies to screens already running.
+func applyTheme(c Contrast) {
+	t, ok := themes[c]
+	if !ok {
+		t = themes[ContrastNormal]
 	}
-	theme.overlayMask = 0x55
-	theme.activeMask = 0x55
-	theme.inactiveMask = 0x55
+	descriptorTheme = t.Descriptor
+	singleTheme = t.Single
+	engraveTheme = t.Engrave
+	cameraTheme = t.Camera
+	theme.overlayMask = t.OverlayMask
+	theme.activeMask = t.ActiveMask
+	theme.inactiveMask = t.InactiveMask
 }
 
-func NewStyles() Styles {
-	return Styles{
+// NewStyles returns the text styles for the contrast setting c
+// and accessibility mode a. High contrast uses bold faces for body
+// text and larger subtitles. Large print doubles body and lead
+// text.
+func NewStyles(c Contrast, a Accessibility) Styles {
+	s := Styles{
 		title: text.Style{
 			Face:            poppins.Bold23,
 			Alignment:       text.AlignCenter,
@@ -80,6 +148,9 @@ func NewStyles() Styles {
 			Face:            poppins.Regular16,
 			LineHeightScale: 0.75,
 		},
+		mono: text.Style{
+			Face: gomono.Regular14,
+		},
 		debug: text.Style{
 			Face: poppins.Bold10,
 		},
@@ -117,4 +188,16 @@ func NewStyles() Styles {
 			LetterSpacing: -1,
 		},
 	}
+	if c == ContrastHigh {
+		s.body.Face = poppins.Bold16
+		s.mono.Face = gomono.Bold14
+		s.lead.Face = poppins.Bold16
+		s.subtitle.Face = poppins.Bold20
+	}
+	if a != AccessibilityOff {
+		s.body.Face = s.body.Face.Scaled(2)
+		s.mono.Face = s.mono.Face.Scaled(2)
+		s.lead.Face = s.lead.Face.Scaled(2)
+	}
+	return s
 }
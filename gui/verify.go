@@ -0,0 +1,73 @@
+package gui
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"seedhammer.com/bc/urtypes"
+	"seedhammer.com/bip39"
+	"seedhammer.com/gui/op"
+)
+
+// verifyBackupFlow compares the seed of an engraved plate with a
+// wallet descriptor. It never engraves.
+func verifyBackupFlow(ctx *Context, ops op.Ctx, th *Colors) {
+	for {
+		mnemonic, ok := newMnemonicFlow(ctx, ops, th)
+		if !ok {
+			return
+		}
+		desc, ok := inputDescriptorFlow(ctx, ops, th, mnemonic)
+		if !ok {
+			continue
+		}
+		res := verifyResult(mnemonic, desc)
+		for {
+			dims := ctx.Platform.DisplaySize()
+			dismissed := res.Layout(ctx, ops.Begin(), th, dims)
+			d := ops.End()
+			if dismissed {
+				return
+			}
+			op.ColorOp(ops, th.Background)
+			d.Add(ops)
+			ctx.Frame()
+		}
+	}
+}
+
+// verifyResult returns the screen that reports whether mnemonic
+// is a seed of desc. A nil desc verifies the seed only.
+func verifyResult(mnemonic bip39.Mnemonic, desc *urtypes.OutputDescriptor) *ErrorScreen {
+	if !mnemonic.Valid() {
+		return &ErrorScreen{
+			Title: "Invalid Seed",
+			Body:  "The seed checksum is invalid. The plate may be damaged or incorrectly engraved.",
+		}
+	}
+	network := &chaincfg.MainNetParams
+	if desc != nil && len(desc.Keys) > 0 {
+		network = desc.Keys[0].Network
+	}
+	mfp, err := masterFingerprintFor(mnemonic, network)
+	if err != nil {
+		return NewErrorScreen(err)
+	}
+	if desc == nil {
+		return &ErrorScreen{
+			Title: "Seed Verified",
+			Body:  fmt.Sprintf("The seed is valid.\n\nFingerprint: %.8X", mfp),
+		}
+	}
+	keyIdx, ok := descriptorKeyIdx(*desc, mnemonic, "")
+	if !ok {
+		return &ErrorScreen{
+			Title: "Backup Mismatch",
+			Body:  fmt.Sprintf("The seed with fingerprint %.8X does not match the wallet or is passphrase protected.", mfp),
+		}
+	}
+	return &ErrorScreen{
+		Title: "Backup Verified",
+		Body:  fmt.Sprintf("The seed matches key %d of %d of the wallet.\n\nFingerprint: %.8X", keyIdx+1, len(desc.Keys), mfp),
+	}
+}
@@ -0,0 +1,127 @@
+package gui
+
+import (
+	"fmt"
+
+	"seedhammer.com/engrave"
+	"seedhammer.com/gui/assets"
+	"seedhammer.com/gui/op"
+)
+
+// watchOnlyFlow engraves the descriptor sides of the plates of a
+// wallet, without any seed. The keys of the descriptor can't be
+// checked against a seed, so the user must confirm that first.
+func watchOnlyFlow(ctx *Context, ops op.Ctx, th *Colors) {
+	confirm := &ConfirmWarningScreen{
+		Title: "No Seed?",
+		Body:  "The plates will hold the wallet descriptor only. Its keys are not checked against any seed, so make sure the descriptor is correct.\n\nHold button to confirm.",
+		Icon:  assets.IconCheckmark,
+	}
+	for {
+		dims := ctx.Platform.DisplaySize()
+		res := confirm.Layout(ctx, ops.Begin(), th, dims)
+		d := ops.End()
+		if res == ConfirmNo {
+			return
+		}
+		if res == ConfirmYes {
+			break
+		}
+		op.ColorOp(ops, th.Background)
+		d.Add(ops)
+		ctx.Frame()
+	}
+	showErr := func(errScreen *ErrorScreen) {
+		for {
+			dims := ctx.Platform.DisplaySize()
+			dismissed := errScreen.Layout(ctx, ops.Begin(), th, dims)
+			d := ops.End()
+			if dismissed {
+				break
+			}
+			op.ColorOp(ops, th.Background)
+			d.Add(ops)
+			ctx.Frame()
+		}
+	}
+	for {
+		desc, ok := inputDescriptorFlow(ctx, ops, th, nil)
+		if !ok {
+			return
+		}
+		if desc == nil {
+			// There is nothing to engrave without a descriptor.
+			continue
+		}
+		if err := validateDescriptor(ctx.Platform.EngraverParams(), ctx.Settings, *desc); err != nil {
+			showErr(NewErrorScreen(err))
+			continue
+		}
+		if !recoveryTestFlow(ctx, ops, th, *desc) {
+			continue
+		}
+		// Lay out every plate before engraving any of them. The
+		// parts of a split descriptor go on plates of their own,
+		// because there is no seed side to pair them with.
+		type job struct {
+			keyIdx int
+			plate  Plate
+		}
+		var jobs []job
+		var err error
+		for keyIdx := range desc.Keys {
+			var plate Plate
+			plate, err = engravePlate(ctx.Platform.PlateSizes(), ctx.Platform.EngraverParams(), ctx.Settings, *desc, keyIdx, nil)
+			if err != nil {
+				break
+			}
+			for _, side := range plate.Sides {
+				p := plate
+				p.Sides = []engrave.Plan{side}
+				jobs = append(jobs, job{keyIdx: keyIdx, plate: p})
+			}
+		}
+		if err != nil {
+			showErr(NewErrorScreen(err))
+			continue
+		}
+		for i, j := range jobs {
+			for {
+				if !watchOnlyPrompt(ctx, ops, th, len(jobs), i) {
+					return
+				}
+				if NewEngraveScreen(ctx, j.plate).Engrave(ctx, ops, &engraveTheme) {
+					break
+				}
+			}
+			if i+1 == len(jobs) || jobs[i+1].keyIdx != j.keyIdx {
+				recordBackup(ctx, descriptorBackup(*desc, j.keyIdx, j.plate))
+			}
+		}
+		return
+	}
+}
+
+// watchOnlyPrompt asks the user to continue with plate idx of
+// n. It reports whether the user confirmed.
+func watchOnlyPrompt(ctx *Context, ops op.Ctx, th *Colors, n, idx int) bool {
+	confirm := &ConfirmWarningScreen{
+		Title: "Descriptor Plate",
+		Body:  fmt.Sprintf("Engrave the descriptor on plate %d of %d. The back side is left blank.\n\nHold button to continue.", idx+1, n),
+		Icon:  assets.IconCheckmark,
+	}
+	for {
+		dims := ctx.Platform.DisplaySize()
+		res := confirm.Layout(ctx, ops.Begin(), th, dims)
+		d := ops.End()
+		switch res {
+		case ConfirmYes:
+			return true
+		case ConfirmNo:
+			return false
+		}
+		op.ColorOp(ops, th.Background)
+		d.Add(ops)
+		ctx.Frame()
+	}
+}
@@ -0,0 +1,93 @@
+package widget
+
+import (
+	"image"
+	"image/color"
+
+	"seedhammer.com/gui/op"
+)
+
+// ScrollArea tracks the scroll offset of content taller than its
+// viewport. The offset moves towards its target over a few frames
+// and is clamped to the content.
+type ScrollArea struct {
+	offset int
+	target int
+}
+
+// thumbWidth is the width of the scrollbar thumb.
+const thumbWidth = 2
+
+// Scroll moves the scroll target by delta pixels.
+func (s *ScrollArea) Scroll(delta int) {
+	s.target += delta
+}
+
+// ScrollTo sets the scroll target.
+func (s *ScrollArea) ScrollTo(target int) {
+	s.target = target
+}
+
+// Reset scrolls to the top without animation.
+func (s *ScrollArea) Reset() {
+	*s = ScrollArea{}
+}
+
+// Update clamps the scroll target to content in a viewport and
+// advances the offset towards it. It returns the offset and
+// whether it is still moving, in which case the caller should
+// schedule another frame.
+func (s *ScrollArea) Update(content, viewport int) (int, bool) {
+	maxScroll := max(0, content-viewport)
+	s.target = min(max(0, s.target), maxScroll)
+	s.offset = min(max(0, s.offset), maxScroll)
+	d := s.target - s.offset
+	// Cover half the remaining distance per frame.
+	step := d / 2
+	if step == 0 {
+		step = d
+	}
+	s.offset += step
+	return s.offset, s.offset != s.target
+}
+
+// Layout draws a scrollbar thumb along the right edge of the
+// viewport r, sized and positioned to indicate the visible part
+// of content. Nothing is drawn if the content fits.
+func (s *ScrollArea) Layout(ops op.Ctx, col color.NRGBA, r image.Rectangle, content int) {
+	viewport := r.Dy()
+	if content <= viewport || viewport <= 0 {
+		return
+	}
+	h := max(thumbWidth, viewport*viewport/content)
+	y := r.Min.Y + (viewport-h)*s.offset/(content-viewport)
+	ops.Begin()
+	op.ClipOp(image.Rect(r.Max.X-thumbWidth, y, r.Max.X, y+h)).Add(ops)
+	op.ColorOp(ops, col)
+	ops.End().Add(ops)
+}
+
+// PageIndicator draws a row of n dots centered at center, with the
+// dot of the current page opaque and the others faded.
+func PageIndicator(ops op.Ctx, col color.NRGBA, center image.Point, n, page int) {
+	const dot, gap = 4, 4
+	if n < 2 {
+		return
+	}
+	w := n*dot + (n-1)*gap
+	x := center.X - w/2
+	y := center.Y - dot/2
+	faded := col
+	faded.A /= 3
+	for i := range n {
+		c := faded
+		if i == page {
+			c = col
+		}
+		ops.Begin()
+		op.CircleOp(ops, image.Pt(x+dot/2, y+dot/2), dot/2)
+		op.ColorOp(ops, c)
+		ops.End().Add(ops)
+		x += dot + gap
+	}
+}
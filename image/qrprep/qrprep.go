@@ -0,0 +1,421 @@
+// Package qrprep prepares camera images of QR codes for decoding. It
+// binarizes images with an adaptive threshold, which recovers codes of
+// low contrast such as engraved plates, and corrects the perspective of
+// codes whose finder patterns it can locate.
+package qrprep
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// Preparer prepares images, reusing its buffers between calls.
+type Preparer struct {
+	sums     []uint32
+	bin, out image.Gray
+}
+
+const (
+	// thresholdBias is the percentage below the local mean a pixel must
+	// be to be considered dark.
+	thresholdBias = 8
+	// moduleSize is the size in pixels of a module in deskewed images.
+	moduleSize = 4
+	// quietZone is the number of blank modules surrounding deskewed
+	// codes.
+	quietZone = 4
+)
+
+// Patterns of the alternating dark and light runs through the center
+// of QR code features, in modules. Runs of length zero match runs of
+// any length, because the outer ring of an alignment pattern may touch
+// dark modules.
+var (
+	finderPattern    = [5]int{1, 1, 3, 1, 1}
+	alignmentPattern = [5]int{0, 1, 1, 1, 0}
+)
+
+// Prepare returns a binarized copy of img, with its perspective
+// corrected if the finder patterns of a QR code were located. The
+// result is valid until the next call to Prepare.
+func (p *Preparer) Prepare(img *image.Gray) *image.Gray {
+	p.binarize(img)
+	if p.deskew() {
+		return &p.out
+	}
+	return &p.bin
+}
+
+// Clear zeroes the buffers of p.
+func (p *Preparer) Clear() {
+	clear(p.bin.Pix)
+	clear(p.out.Pix)
+}
+
+// binarize thresholds every pixel of img against the mean of its
+// surroundings, using a summed-area table of img.
+func (p *Preparer) binarize(img *image.Gray) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	p.bin.Rect = image.Rectangle{Max: b.Size()}
+	p.bin.Stride = w
+	p.bin.Pix = resize(p.bin.Pix, w*h)
+	stride := w + 1
+	p.sums = resize(p.sums, stride*(h+1))
+	sums := p.sums
+	clear(sums[:stride])
+	for y := 0; y < h; y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+		sums[(y+1)*stride] = 0
+		var acc uint32
+		for x := 0; x < w; x++ {
+			acc += uint32(row[x])
+			sums[(y+1)*stride+x+1] = sums[y*stride+x+1] + acc
+		}
+	}
+	r := max(8, min(w, h)/8)
+	for y := 0; y < h; y++ {
+		y0, y1 := max(y-r, 0), min(y+r+1, h)
+		row := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+		for x := 0; x < w; x++ {
+			x0, x1 := max(x-r, 0), min(x+r+1, w)
+			area := uint64((x1 - x0) * (y1 - y0))
+			sum := uint64(sums[y1*stride+x1] - sums[y0*stride+x1] - sums[y1*stride+x0] + sums[y0*stride+x0])
+			v := uint8(0xff)
+			if uint64(row[x])*area*100 < sum*(100-thresholdBias) {
+				v = 0
+			}
+			p.bin.Pix[y*w+x] = v
+		}
+	}
+}
+
+// deskew locates the finder patterns of a QR code in the binarized
+// image and samples the code into an upright square image.
+func (p *Preparer) deskew() bool {
+	finders := search(&p.bin, p.bin.Rect, finderPattern)
+	sort.SliceStable(finders, func(i, j int) bool {
+		return finders[i].hits > finders[j].hits
+	})
+	if len(finders) < 3 || finders[2].hits < 2 {
+		return false
+	}
+	tl, tr, bl := orient([3]feature(finders[:3]))
+	module := (tl.module + tr.module + bl.module) / 3
+	n := (dist(tl, tr) + dist(tl, bl)) / 2 / module
+	// Round the dimension to a valid QR code size.
+	dim := int(math.Round(n)) + 7
+	switch dim % 4 {
+	case 0:
+		dim++
+	case 2:
+		dim--
+	case 3:
+		return false
+	}
+	if dim < 21 || dim > 177 {
+		return false
+	}
+	d := float64(dim)
+	src := [4][2]float64{
+		{tl.x, tl.y},
+		{tr.x, tr.y},
+		{tr.x + bl.x - tl.x, tr.y + bl.y - tl.y},
+		{bl.x, bl.y},
+	}
+	dst := [4][2]float64{
+		{3.5, 3.5},
+		{d - 3.5, 3.5},
+		{d - 3.5, d - 3.5},
+		{3.5, d - 3.5},
+	}
+	// Codes of version 2 and up have an alignment pattern near the
+	// bottom-right corner. Locate it to correct for perspective;
+	// otherwise assume a parallelogram.
+	if dim >= 25 {
+		k := 1 - 3/(d-7)
+		ex := tl.x + (tr.x-tl.x+bl.x-tl.x)*k
+		ey := tl.y + (tr.y-tl.y+bl.y-tl.y)*k
+		u := [2]float64{(tr.x - tl.x) / (d - 7), (tr.y - tl.y) / (d - 7)}
+		v := [2]float64{(bl.x - tl.x) / (d - 7), (bl.y - tl.y) / (d - 7)}
+		if a, ok := alignment(&p.bin, ex, ey, u, v); ok {
+			src[2] = [2]float64{a.x, a.y}
+			dst[2] = [2]float64{d - 6.5, d - 6.5}
+		}
+	}
+	t := squareToQuad(src).mul(squareToQuad(dst).inverse())
+	sz := (dim + 2*quietZone) * moduleSize
+	p.out.Rect = image.Rect(0, 0, sz, sz)
+	p.out.Stride = sz
+	p.out.Pix = resize(p.out.Pix, sz*sz)
+	for y := 0; y < sz; y++ {
+		v := (float64(y)+.5)/moduleSize - quietZone
+		for x := 0; x < sz; x++ {
+			u := (float64(x)+.5)/moduleSize - quietZone
+			sx, sy := t.apply(u, v)
+			c := uint8(0xff)
+			if pt := image.Pt(int(math.Floor(sx)), int(math.Floor(sy))); pt.In(p.bin.Rect) {
+				c = p.bin.Pix[p.bin.PixOffset(pt.X, pt.Y)]
+			}
+			p.out.Pix[y*sz+x] = c
+		}
+	}
+	return true
+}
+
+// alignment returns the alignment pattern near (x, y), searching
+// within a few modules. Candidates are verified by sampling the 5x5
+// modules of the pattern along the module vectors u and v, because
+// data modules may resemble rows of the pattern.
+func alignment(img *image.Gray, x, y float64, u, v [2]float64) (feature, bool) {
+	module := math.Hypot(u[0], u[1])
+	r := int(10 * module)
+	c := image.Pt(int(x), int(y))
+	area := image.Rectangle{Min: c.Sub(image.Pt(r, r)), Max: c.Add(image.Pt(r, r))}
+	var (
+		best      feature
+		bestScore int
+		bestd     float64
+	)
+	for _, a := range search(img, area, alignmentPattern) {
+		if a.module < module/2 || a.module > module*2 {
+			continue
+		}
+		score := 0
+		for j := -2; j <= 2; j++ {
+			for i := -2; i <= 2; i++ {
+				sx := a.x + float64(i)*u[0] + float64(j)*v[0]
+				sy := a.y + float64(i)*u[1] + float64(j)*v[1]
+				pt := image.Pt(int(math.Floor(sx)), int(math.Floor(sy)))
+				if !pt.In(img.Rect) {
+					continue
+				}
+				if ring := max(abs(i), abs(j)); dark(img, pt.X, pt.Y) == (ring != 1) {
+					score++
+				}
+			}
+		}
+		d := math.Hypot(a.x-x, a.y-y)
+		if score > bestScore || score == bestScore && d < bestd {
+			best, bestScore, bestd = a, score, d
+		}
+	}
+	// Allow for a few misread modules.
+	return best, bestScore >= 5*5-3
+}
+
+// feature is the center of a located finder or alignment pattern.
+type feature struct {
+	x, y   float64
+	module float64
+	// hits is the number of rows the feature was found in.
+	hits int
+}
+
+func dist(a, b feature) float64 {
+	return math.Hypot(a.x-b.x, a.y-b.y)
+}
+
+// orient orders the finder patterns of a QR code as its top-left,
+// top-right and bottom-left corners.
+func orient(f [3]feature) (tl, tr, bl feature) {
+	d01, d12, d02 := dist(f[0], f[1]), dist(f[1], f[2]), dist(f[0], f[2])
+	// The top-left finder is opposite the longest side.
+	switch {
+	case d12 >= d01 && d12 >= d02:
+		tl, tr, bl = f[0], f[1], f[2]
+	case d02 >= d01:
+		tl, tr, bl = f[1], f[0], f[2]
+	default:
+		tl, tr, bl = f[2], f[0], f[1]
+	}
+	if (tr.x-tl.x)*(bl.y-tl.y)-(tr.y-tl.y)*(bl.x-tl.x) < 0 {
+		tr, bl = bl, tr
+	}
+	return tl, tr, bl
+}
+
+// search scans the rows of area in the binarized img for features
+// matching pattern, and merges matches of the same feature.
+func search(img *image.Gray, area image.Rectangle, pattern [5]int) []feature {
+	area = area.Intersect(img.Rect)
+	var (
+		found []feature
+		runs  []int
+	)
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		runs = runs[:0]
+		start := area.Min.X
+		for x := area.Min.X + 1; x <= area.Max.X; x++ {
+			if x == area.Max.X || dark(img, x, y) != dark(img, x-1, y) {
+				runs = append(runs, x-start)
+				start = x
+			}
+		}
+		firstDark := dark(img, area.Min.X, y)
+		x := area.Min.X
+		for i := 0; i+5 <= len(runs); i++ {
+			if isDark := (i%2 == 0) == firstDark; isDark {
+				if _, ok := match([5]int(runs[i:i+5]), pattern); ok {
+					cx := x + runs[i] + runs[i+1] + runs[i+2]/2
+					if f, ok := crossCheck(img, cx, y, pattern); ok {
+						found = merge(found, f)
+					}
+				}
+			}
+			x += runs[i]
+		}
+	}
+	return found
+}
+
+// crossCheck verifies a horizontal match centered at (x, y) by
+// matching the column through it.
+func crossCheck(img *image.Gray, x, y int, pattern [5]int) (feature, bool) {
+	b := img.Rect
+	count := func(y, dy int, isDark bool) (int, int) {
+		n := 0
+		for y >= b.Min.Y && y < b.Max.Y && dark(img, x, y) == isDark {
+			n++
+			y += dy
+		}
+		return n, y
+	}
+	var runs [5]int
+	up, top := count(y, -1, true)
+	down, bottom := count(y+1, 1, true)
+	runs[2] = up + down
+	center := float64(top+1) + float64(runs[2])/2
+	runs[1], top = count(top, -1, false)
+	runs[0], _ = count(top, -1, true)
+	runs[3], bottom = count(bottom, 1, false)
+	runs[4], _ = count(bottom, 1, true)
+	module, ok := match(runs, pattern)
+	if !ok {
+		return feature{}, false
+	}
+	return feature{x: float64(x) + .5, y: center, module: module}, true
+}
+
+// match reports whether the lengths of runs match pattern, and
+// returns the module size.
+func match(runs [5]int, pattern [5]int) (float64, bool) {
+	total, units := 0, 0
+	for i, r := range runs {
+		if r == 0 {
+			return 0, false
+		}
+		if pattern[i] > 0 {
+			total += r
+			units += pattern[i]
+		}
+	}
+	module := float64(total) / float64(units)
+	for i, r := range runs {
+		if pattern[i] == 0 {
+			continue
+		}
+		want := float64(pattern[i]) * module
+		if math.Abs(float64(r)-want) >= want/2+.5 {
+			return 0, false
+		}
+	}
+	return module, true
+}
+
+// merge adds f to the features, averaging it with a nearby match of
+// the same feature.
+func merge(found []feature, f feature) []feature {
+	for i := range found {
+		g := &found[i]
+		if math.Abs(g.x-f.x) > 2*g.module || math.Abs(g.y-f.y) > 2*g.module ||
+			math.Abs(g.module-f.module) > g.module/3 {
+			continue
+		}
+		n := float64(g.hits)
+		g.x = (g.x*n + f.x) / (n + 1)
+		g.y = (g.y*n + f.y) / (n + 1)
+		g.module = (g.module*n + f.module) / (n + 1)
+		g.hits++
+		return found
+	}
+	f.hits = 1
+	return append(found, f)
+}
+
+func dark(img *image.Gray, x, y int) bool {
+	return img.Pix[img.PixOffset(x, y)] < 0x80
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func resize[T any](s []T, n int) []T {
+	if cap(s) < n {
+		return make([]T, n)
+	}
+	return s[:n]
+}
+
+// transform is a projective transform in row-major order.
+type transform [9]float64
+
+func (t transform) apply(x, y float64) (float64, float64) {
+	w := t[6]*x + t[7]*y + t[8]
+	return (t[0]*x + t[1]*y + t[2]) / w, (t[3]*x + t[4]*y + t[5]) / w
+}
+
+// mul returns the transform that applies u and then t.
+func (t transform) mul(u transform) transform {
+	var r transform
+	for i := range 3 {
+		for j := range 3 {
+			for k := range 3 {
+				r[i*3+j] += t[i*3+k] * u[k*3+j]
+			}
+		}
+	}
+	return r
+}
+
+// inverse returns the inverse of t, up to scale.
+func (t transform) inverse() transform {
+	a, b, c, d, e, f, g, h, i := t[0], t[1], t[2], t[3], t[4], t[5], t[6], t[7], t[8]
+	return transform{
+		e*i - f*h, c*h - b*i, b*f - c*e,
+		f*g - d*i, a*i - c*g, c*d - a*f,
+		d*h - e*g, b*g - a*h, a*e - b*d,
+	}
+}
+
+// squareToQuad returns the transform that maps the corners (0, 0),
+// (1, 0), (1, 1) and (0, 1) of the unit square to the corners of q.
+func squareToQuad(q [4][2]float64) transform {
+	x0, y0 := q[0][0], q[0][1]
+	x1, y1 := q[1][0], q[1][1]
+	x2, y2 := q[2][0], q[2][1]
+	x3, y3 := q[3][0], q[3][1]
+	dx3, dy3 := x0-x1+x2-x3, y0-y1+y2-y3
+	if dx3 == 0 && dy3 == 0 {
+		return transform{
+			x1 - x0, x2 - x1, x0,
+			y1 - y0, y2 - y1, y0,
+			0, 0, 1,
+		}
+	}
+	dx1, dx2 := x1-x2, x3-x2
+	dy1, dy2 := y1-y2, y3-y2
+	den := dx1*dy2 - dx2*dy1
+	g := (dx3*dy2 - dx2*dy3) / den
+	h := (dx1*dy3 - dx3*dy1) / den
+	return transform{
+		x1 - x0 + g*x1, x3 - x0 + h*x3, x0,
+		y1 - y0 + g*y1, y3 - y0 + h*y3, y0,
+		g, h, 1,
+	}
+}
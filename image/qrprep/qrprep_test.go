@@ -0,0 +1,132 @@
+package qrprep
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDeskew(t *testing.T) {
+	tests := []struct {
+		name string
+		quad [4][2]float64
+		// affine is set if the quad is a parallelogram.
+		affine bool
+	}{
+		{"upright", [4][2]float64{{100, 100}, {400, 100}, {400, 400}, {100, 400}}, true},
+		{"rotated", [4][2]float64{{120, 60}, {430, 140}, {360, 450}, {50, 370}}, true},
+		{"perspective", [4][2]float64{{150, 90}, {420, 120}, {440, 430}, {110, 400}}, false},
+	}
+	for _, dim := range []int{21, 29, 41} {
+		c := newTestCode(dim)
+		for _, test := range tests {
+			if !test.affine && dim < 25 {
+				// Perspective correction requires an alignment pattern.
+				continue
+			}
+			// Dark modules are only slightly darker than the light modules
+			// of the opposite corner.
+			img := c.render(500, test.quad, 110, 160)
+			p := new(Preparer)
+			out := p.Prepare(img)
+			if out != &p.out {
+				t.Errorf("%s %dx%d: code not located", test.name, dim, dim)
+				continue
+			}
+			if got, want := out.Bounds().Dx(), (dim+2*quietZone)*moduleSize; got != want {
+				t.Errorf("%s %dx%d: deskewed to width %d, want %d", test.name, dim, dim, got, want)
+				continue
+			}
+			errs := 0
+			for y := range dim {
+				for x := range dim {
+					px := (x+quietZone)*moduleSize + moduleSize/2
+					py := (y+quietZone)*moduleSize + moduleSize/2
+					if dark(out, px, py) != c.dark(x, y) {
+						errs++
+					}
+				}
+			}
+			if errs > 0 {
+				t.Errorf("%s %dx%d: %d modules misread", test.name, dim, dim, errs)
+			}
+		}
+	}
+}
+
+func TestBinarizeBlank(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 100, 100))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i % 100)
+	}
+	p := new(Preparer)
+	if out := p.Prepare(img); out != &p.bin {
+		t.Error("code located in a gradient")
+	}
+}
+
+// testCode is a square of random modules with the finder and
+// alignment patterns of a QR code.
+type testCode struct {
+	dim     int
+	modules []bool
+}
+
+func newTestCode(dim int) testCode {
+	c := testCode{dim: dim, modules: make([]bool, dim*dim)}
+	r := rand.New(rand.NewSource(1))
+	for i := range c.modules {
+		c.modules[i] = r.Intn(2) == 0
+	}
+	// pattern draws concentric square rings around (cx, cy) with
+	// the dark rings given.
+	pattern := func(cx, cy, rings int, dark ...int) {
+		for y := cy - rings; y <= cy+rings; y++ {
+			for x := cx - rings; x <= cx+rings; x++ {
+				if x < 0 || y < 0 || x >= dim || y >= dim {
+					continue
+				}
+				ring := max(abs(x-cx), abs(y-cy))
+				isDark := false
+				for _, d := range dark {
+					isDark = isDark || ring == d
+				}
+				c.modules[y*dim+x] = isDark
+			}
+		}
+	}
+	// Finder patterns with their separators.
+	pattern(3, 3, 4, 0, 1, 3)
+	pattern(dim-4, 3, 4, 0, 1, 3)
+	pattern(3, dim-4, 4, 0, 1, 3)
+	if dim >= 25 {
+		pattern(dim-7, dim-7, 2, 0, 2)
+	}
+	return c
+}
+
+func (c testCode) dark(x, y int) bool {
+	return c.modules[y*c.dim+x]
+}
+
+// render draws the code into the quad of an image of size sz, with a
+// shading gradient.
+func (c testCode) render(sz int, quad [4][2]float64, dark, light uint8) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, sz, sz))
+	t := squareToQuad(quad).inverse()
+	for y := range sz {
+		for x := range sz {
+			u, v := t.apply(float64(x)+.5, float64(y)+.5)
+			mx := int(math.Floor(u * float64(c.dim)))
+			my := int(math.Floor(v * float64(c.dim)))
+			col := light
+			if mx >= 0 && my >= 0 && mx < c.dim && my < c.dim && c.dark(mx, my) {
+				col = dark
+			}
+			shade := uint8((x + y) * 40 / (2 * sz))
+			img.Pix[y*sz+x] = col - shade
+		}
+	}
+	return img
+}
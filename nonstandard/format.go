@@ -0,0 +1,140 @@
+package nonstandard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"seedhammer.com/bc/urtypes"
+)
+
+// FormatOutputDescriptor formats desc in the textual descriptor
+// language of [BIP380], including its checksum. The output is
+// understood by [OutputDescriptor] and by Bitcoin Core.
+//
+// [BIP380]: https://github.com/bitcoin/bips/blob/master/bip-0380.mediawiki
+func FormatOutputDescriptor(desc urtypes.OutputDescriptor) (string, error) {
+	var keys []string
+	for _, k := range desc.Keys {
+		keys = append(keys, formatKey(k))
+	}
+	var inner string
+	switch desc.Type {
+	case urtypes.Singlesig:
+		if len(keys) != 1 {
+			return "", fmt.Errorf("descriptor: singlesig descriptor with %d keys", len(keys))
+		}
+		inner = keys[0]
+	case urtypes.SortedMulti, urtypes.Multi:
+		if len(keys) == 0 {
+			return "", errors.New("descriptor: multisig descriptor without keys")
+		}
+		fn := "sortedmulti"
+		if desc.Type == urtypes.Multi {
+			fn = "multi"
+		}
+		inner = fmt.Sprintf("%s(%d,%s)", fn, desc.Threshold, strings.Join(keys, ","))
+	default:
+		return "", fmt.Errorf("descriptor: unknown type: %v", desc.Type)
+	}
+	var txt string
+	switch s, multi := desc.Script, desc.Type != urtypes.Singlesig; {
+	case s == urtypes.P2SH && multi:
+		txt = "sh(" + inner + ")"
+	case s == urtypes.P2SH_P2WSH && multi:
+		txt = "sh(wsh(" + inner + "))"
+	case s == urtypes.P2WSH && multi:
+		txt = "wsh(" + inner + ")"
+	case s == urtypes.P2MS && multi:
+		txt = inner
+	case s == urtypes.P2SH_P2WPKH && !multi:
+		txt = "sh(wpkh(" + inner + "))"
+	case s == urtypes.P2PKH && !multi:
+		txt = "pkh(" + inner + ")"
+	case s == urtypes.P2WPKH && !multi:
+		txt = "wpkh(" + inner + ")"
+	case s == urtypes.P2TR && !multi:
+		txt = "tr(" + inner + ")"
+	case s == urtypes.P2PK && !multi:
+		txt = "pk(" + inner + ")"
+	default:
+		return "", fmt.Errorf("descriptor: unsupported script: %v", s)
+	}
+	sum, ok := descriptorChecksum(txt)
+	if !ok {
+		return "", errors.New("descriptor: invalid character")
+	}
+	return txt + "#" + sum, nil
+}
+
+// formatKey formats a key expression with its origin and children.
+func formatKey(k urtypes.KeyDescriptor) string {
+	var b strings.Builder
+	if k.MasterFingerprint != 0 || len(k.DerivationPath) > 0 {
+		fmt.Fprintf(&b, "[%.8x%s]", k.MasterFingerprint, strings.TrimPrefix(k.DerivationPath.String(), "m"))
+	}
+	b.WriteString(k.String())
+	for _, c := range k.Children {
+		b.WriteByte('/')
+		switch c.Type {
+		case urtypes.ChildDerivation:
+			fmt.Fprintf(&b, "%d", c.Index)
+		case urtypes.WildcardDerivation:
+			b.WriteByte('*')
+		case urtypes.RangeDerivation:
+			fmt.Fprintf(&b, "<%d;%d>", c.Index, c.End)
+		}
+		if c.Hardened {
+			b.WriteByte('h')
+		}
+	}
+	return b.String()
+}
+
+const (
+	checksumInputCharset = "0123456789()[],'/*abcdefgh@:$%{}IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	checksumCharset      = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+// descriptorChecksum computes the BIP380 checksum of desc. It
+// reports false if desc contains characters outside the descriptor
+// character set.
+func descriptorChecksum(desc string) (string, bool) {
+	polymod := func(c, val uint64) uint64 {
+		c0 := c >> 35
+		c = (c&0x7ffffffff)<<5 ^ val
+		for i, g := range []uint64{0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd} {
+			if c0>>i&1 == 1 {
+				c ^= g
+			}
+		}
+		return c
+	}
+	c := uint64(1)
+	cls, clsCount := uint64(0), 0
+	for _, r := range desc {
+		pos := strings.IndexRune(checksumInputCharset, r)
+		if pos == -1 {
+			return "", false
+		}
+		c = polymod(c, uint64(pos&31))
+		cls = cls*3 + uint64(pos>>5)
+		clsCount++
+		if clsCount == 3 {
+			c = polymod(c, cls)
+			cls, clsCount = 0, 0
+		}
+	}
+	if clsCount > 0 {
+		c = polymod(c, cls)
+	}
+	for range 8 {
+		c = polymod(c, 0)
+	}
+	c ^= 1
+	var sum [8]byte
+	for j := range sum {
+		sum[j] = checksumCharset[c>>(5*(7-j))&31]
+	}
+	return string(sum[:]), true
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
 	"seedhammer.com/bc/urtypes"
+	"seedhammer.com/bip32"
 )
 
 // ElectrumSeed reports whether the seed phrase is a valid Electrum
@@ -42,6 +43,9 @@ func OutputDescriptor(enc []byte) (urtypes.OutputDescriptor, error) {
 	if err == nil {
 		return desc, nil
 	}
+	if desc, err := parseDescriptorFile(string(enc)); err == nil {
+		return desc, nil
+	}
 	var jsonDesc struct {
 		Label      string `json:"label"`
 		Descriptor string `json:"descriptor"`
@@ -54,18 +58,24 @@ func OutputDescriptor(enc []byte) (urtypes.OutputDescriptor, error) {
 		desc.Title = jsonDesc.Label
 		return desc, err
 	}
-	// If the derivation path of a cosigner key expression matches
-	// a single-sig script, convert it to an output descriptor.
-	if k, err := parseHDKeyExpr(nil, enc); err == nil {
-		for _, s := range []urtypes.Script{urtypes.P2PKH, urtypes.P2WPKH, urtypes.P2SH_P2WPKH} {
-			path := s.DerivationPath()
-			if !reflect.DeepEqual(path, k.DerivationPath) {
-				continue
+	// Convert a single key expression to a single-sig output descriptor.
+	// The SLIP-132 version of the key implies the script, except for plain
+	// xpubs whose derivation path must match a single-sig script.
+	if script, k, err := parseHDKeyExpr(nil, enc); err == nil {
+		if script == urtypes.P2PKH {
+			script = urtypes.UnknownScript
+			for _, s := range []urtypes.Script{urtypes.P2PKH, urtypes.P2WPKH, urtypes.P2SH_P2WPKH} {
+				if reflect.DeepEqual(s.DerivationPath(), k.DerivationPath) {
+					script = s
+					break
+				}
 			}
+		}
+		if script.Singlesig() {
 			return urtypes.OutputDescriptor{
 				Type:      urtypes.Singlesig,
 				Threshold: 1,
-				Script:    s,
+				Script:    script,
 				Keys: []urtypes.KeyDescriptor{
 					k,
 				},
@@ -129,7 +139,7 @@ func parseBlueWalletDescriptor(txt string) (urtypes.OutputDescriptor, error) {
 				return urtypes.OutputDescriptor{}, fmt.Errorf("bluewallet: unknown format %q", val)
 			}
 		default:
-			_, xpub, err := parseHDKey(val)
+			_, xpub, err := bip32.ParseKey(val)
 			if err != nil {
 				return urtypes.OutputDescriptor{}, fmt.Errorf("bluewallet: invalid xpub: %q", val)
 			}
@@ -195,6 +205,17 @@ func parsePathElement(p string) (uint32, error) {
 	return iu32 + offset, nil
 }
 
+// ParseDerivationPath parses a derivation path such as m/84h/0h/0h.
+// The leading "m/" is optional, and hardened elements are marked by
+// either h or '.
+func ParseDerivationPath(path string) (urtypes.Path, error) {
+	path = strings.TrimPrefix(path, "m/")
+	if path == "" || path == "m" {
+		return nil, errors.New("empty derivation path")
+	}
+	return parseDerivationPath(path)
+}
+
 func parseDerivationPath(path string) (urtypes.Path, error) {
 	var res urtypes.Path
 	parts := strings.Split(path, "/")
@@ -258,9 +279,25 @@ func parsePath(path string) ([]urtypes.Derivation, error) {
 	return res, nil
 }
 
+// parseDescriptorFile parses the first descriptor of a file with
+// descriptors separated by comment lines, such as those exported
+// by Sparrow. The first descriptor is the multipath (BIP389)
+// descriptor covering both receive and change addresses.
+func parseDescriptorFile(txt string) (urtypes.OutputDescriptor, error) {
+	for _, l := range strings.Split(txt, "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		return parseTextOutputDescriptor(l)
+	}
+	return urtypes.OutputDescriptor{}, errors.New("descriptor: no descriptor in file")
+}
+
 // parseTextOutputDescriptor parses descriptors in textual form, as described in
 // https://github.com/bitcoin/bitcoin/blob/master/doc/descriptors.md.
 func parseTextOutputDescriptor(desc string) (urtypes.OutputDescriptor, error) {
+	desc = strings.TrimSpace(desc)
 	// Chop off checksum, if any.
 	if start := len(desc) - 9; start >= 0 && desc[start] == '#' {
 		desc = desc[:start]
@@ -296,6 +333,14 @@ func parseTextOutputDescriptor(desc string) (urtypes.OutputDescriptor, error) {
 		r.Script = urtypes.P2WPKH
 	case "tr":
 		r.Script = urtypes.P2TR
+	case "pk":
+		r.Script = urtypes.P2PK
+	case "multi":
+		r.Script = urtypes.P2MS
+		r.Type = urtypes.Multi
+	case "sortedmulti":
+		r.Script = urtypes.P2MS
+		r.Type = urtypes.SortedMulti
 	default:
 		return urtypes.OutputDescriptor{}, fmt.Errorf("descriptor: unknown script type: %q", script)
 	}
@@ -322,6 +367,8 @@ func parseTextOutputDescriptor(desc string) (urtypes.OutputDescriptor, error) {
 			switch script2 {
 			case "sortedmulti":
 				r.Type = urtypes.SortedMulti
+			case "multi":
+				r.Type = urtypes.Multi
 			default:
 				return urtypes.OutputDescriptor{}, fmt.Errorf("descriptor: unknown script type: %q", script2)
 			}
@@ -331,7 +378,10 @@ func parseTextOutputDescriptor(desc string) (urtypes.OutputDescriptor, error) {
 	switch r.Type {
 	case urtypes.Singlesig:
 		keys = []string{desc}
-	case urtypes.SortedMulti:
+	case urtypes.SortedMulti, urtypes.Multi:
+		if r.Script.Singlesig() {
+			return urtypes.OutputDescriptor{}, fmt.Errorf("descriptor: multisig in single-sig script: %s", r.Script)
+		}
 		args := strings.Split(desc, ",")
 		threshold, err := strconv.Atoi(args[0])
 		if err != nil {
@@ -341,7 +391,7 @@ func parseTextOutputDescriptor(desc string) (urtypes.OutputDescriptor, error) {
 		keys = args[1:]
 	}
 	for _, k := range keys {
-		key, err := parseHDKeyExpr(r.Script.DerivationPath(), []byte(k))
+		_, key, err := parseHDKeyExpr(r.Script.DerivationPath(), []byte(k))
 		if err != nil {
 			return urtypes.OutputDescriptor{}, fmt.Errorf("hdkey: %w", err)
 		}
@@ -350,8 +400,9 @@ func parseTextOutputDescriptor(desc string) (urtypes.OutputDescriptor, error) {
 	return r, nil
 }
 
-// parseHDKeyExpr parses an extended key on the form [mfp/path]key.
-func parseHDKeyExpr(impliedPath urtypes.Path, enc []byte) (urtypes.KeyDescriptor, error) {
+// parseHDKeyExpr parses an extended key on the form [mfp/path]key, along
+// with the script implied by its SLIP-132 version.
+func parseHDKeyExpr(impliedPath urtypes.Path, enc []byte) (urtypes.Script, urtypes.KeyDescriptor, error) {
 	k := string(enc)
 	key := urtypes.KeyDescriptor{
 		DerivationPath: impliedPath,
@@ -359,21 +410,21 @@ func parseHDKeyExpr(impliedPath urtypes.Path, enc []byte) (urtypes.KeyDescriptor
 	if len(k) > 0 && k[0] == '[' {
 		end := strings.Index(k, "]")
 		if end == -1 {
-			return urtypes.KeyDescriptor{}, fmt.Errorf("hdkey: missing ']': %q", k)
+			return 0, urtypes.KeyDescriptor{}, fmt.Errorf("hdkey: missing ']': %q", k)
 		}
 		originAndPath := k[1:end]
 		k = k[end+1:]
 		if len(originAndPath) < 9 || originAndPath[8] != '/' {
-			return urtypes.KeyDescriptor{}, fmt.Errorf("hdkey: missing or invalid fingerprint: %q", k)
+			return 0, urtypes.KeyDescriptor{}, fmt.Errorf("hdkey: missing or invalid fingerprint: %q", k)
 		}
 		fp, err := hex.DecodeString(originAndPath[:8])
 		if err != nil {
-			return urtypes.KeyDescriptor{}, fmt.Errorf("hdkey: invalid fingerprint: %q", k)
+			return 0, urtypes.KeyDescriptor{}, fmt.Errorf("hdkey: invalid fingerprint: %q", k)
 		}
 		key.MasterFingerprint = binary.BigEndian.Uint32(fp)
 		path, err := parseDerivationPath(originAndPath[9:])
 		if err != nil {
-			return urtypes.KeyDescriptor{}, fmt.Errorf("hdkey: invalid derivation path: %q", k)
+			return 0, urtypes.KeyDescriptor{}, fmt.Errorf("hdkey: invalid derivation path: %q", k)
 		}
 		key.DerivationPath = path
 	}
@@ -382,13 +433,13 @@ func parseHDKeyExpr(impliedPath urtypes.Path, enc []byte) (urtypes.KeyDescriptor
 		k = k[:xpubEnd]
 		childPath, err := parsePath(children)
 		if err != nil {
-			return urtypes.KeyDescriptor{}, fmt.Errorf("hdkey: invalid children path: %q", k)
+			return 0, urtypes.KeyDescriptor{}, fmt.Errorf("hdkey: invalid children path: %q", k)
 		}
 		key.Children = childPath
 	}
-	script, xpub, err := parseHDKey(k)
+	script, xpub, err := bip32.ParseKey(k)
 	if err != nil {
-		return urtypes.KeyDescriptor{}, err
+		return 0, urtypes.KeyDescriptor{}, err
 	}
 	if key.DerivationPath == nil {
 		// This is a key with no implicit or explicit derivation path, fall back
@@ -398,57 +449,17 @@ func parseHDKeyExpr(impliedPath urtypes.Path, enc []byte) (urtypes.KeyDescriptor
 	}
 	pub, err := xpub.ECPubKey()
 	if err != nil {
-		return urtypes.KeyDescriptor{}, fmt.Errorf("hdkey: invalid public key: %q", k)
+		return 0, urtypes.KeyDescriptor{}, fmt.Errorf("hdkey: invalid public key: %q", k)
 	}
 	network, err := networkFor(xpub)
 	if err != nil {
-		return urtypes.KeyDescriptor{}, fmt.Errorf("hdkey: invalid network: %q", k)
+		return 0, urtypes.KeyDescriptor{}, fmt.Errorf("hdkey: invalid network: %q", k)
 	}
 	key.Network = network
 	key.ChainCode = xpub.ChainCode()
 	key.KeyData = pub.SerializeCompressed()
 	key.ParentFingerprint = xpub.ParentFingerprint()
-	return key, nil
-}
-
-// parseHDKey parses an extended key, along with its implied script type. It returns
-// normalized xpubs where the version bytes matches a network.
-func parseHDKey(k string) (urtypes.Script, *hdkeychain.ExtendedKey, error) {
-	xpub, err := hdkeychain.NewKeyFromString(k)
-	if err != nil {
-		return 0, nil, fmt.Errorf("hdkey: invalid extended key: %q", k)
-	}
-	const (
-		xpubVer = "0488b21e"
-		zpubVer = "04b24746"
-		ypubVer = "049d7cb2"
-		YpubVer = "0295b43f"
-		ZpubVer = "02aa7ed3"
-
-		tpubVer = "043587cf"
-	)
-	version := hex.EncodeToString(xpub.Version())
-	var script urtypes.Script
-	switch version {
-	case xpubVer, tpubVer:
-		script = urtypes.P2PKH
-	case zpubVer:
-		script = urtypes.P2WPKH
-	case YpubVer:
-		script = urtypes.P2SH_P2WSH
-	case ZpubVer:
-		script = urtypes.P2WSH
-	default:
-		return 0, nil, fmt.Errorf("hdkey: unsupported version: %s", version)
-	}
-	// Now we have a derivation path, normalize the version bytes to xpub.
-	switch version {
-	case zpubVer, ypubVer, YpubVer, ZpubVer:
-		xpub.SetNet(&chaincfg.MainNetParams)
-	case tpubVer:
-		xpub.SetNet(&chaincfg.TestNet3Params)
-	}
-	return script, xpub, nil
+	return script, key, nil
 }
 
 type Decoder struct {
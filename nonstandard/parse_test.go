@@ -140,6 +140,36 @@ func TestOutputDescriptors(t *testing.T) {
 	}
 }
 
+func TestMultipathDescriptor(t *testing.T) {
+	// Descriptor file as exported by Sparrow.
+	const sparrow = `# Receive and change descriptor (BIP389):
+wsh(sortedmulti(2,[dc567276/48h/0h/0h/2h]xpub6DiYrfRwNnjeX4vHsWMajJVFKrbEEnu8gAW9vDuQzgTWEsEHE16sGWeXXUV1LBWQE1yCTmeprSNcqZ3W74hqVdgDbtYHUv3eM4W2TEUhpan/<0;1>/*,[f245ae38/48h/0h/0h/2h]xpub6DnT4E1fT8VxuAZW29avMjr5i99aYTHBp9d7fiLnpL5t4JEprQqPMbTw7k7rh5tZZ2F5g8PJpssqrZoebzBChaiJrmEvWwUTEMAbHsY39Ge/<0;1>/*,[c5d87297/48h/0h/0h/2h]xpub6DjrnfAyuonMaboEb3ZQZzhQ2ZEgaKV2r64BFmqymZqJqviLTe1JzMr2X2RfQF892RH7MyYUbcy77R7pPu1P71xoj8cDUMNhAMGYzKR4noZ/<0;1>/*))#ud8uyjz3
+
+# Receive descriptor (Bitcoin Core):
+wsh(sortedmulti(2,[dc567276/48h/0h/0h/2h]xpub6DiYrfRwNnjeX4vHsWMajJVFKrbEEnu8gAW9vDuQzgTWEsEHE16sGWeXXUV1LBWQE1yCTmeprSNcqZ3W74hqVdgDbtYHUv3eM4W2TEUhpan/0/*,[f245ae38/48h/0h/0h/2h]xpub6DnT4E1fT8VxuAZW29avMjr5i99aYTHBp9d7fiLnpL5t4JEprQqPMbTw7k7rh5tZZ2F5g8PJpssqrZoebzBChaiJrmEvWwUTEMAbHsY39Ge/0/*,[c5d87297/48h/0h/0h/2h]xpub6DjrnfAyuonMaboEb3ZQZzhQ2ZEgaKV2r64BFmqymZqJqviLTe1JzMr2X2RfQF892RH7MyYUbcy77R7pPu1P71xoj8cDUMNhAMGYzKR4noZ/0/*))#hfwurrvt
+
+# Change descriptor (Bitcoin Core):
+wsh(sortedmulti(2,[dc567276/48h/0h/0h/2h]xpub6DiYrfRwNnjeX4vHsWMajJVFKrbEEnu8gAW9vDuQzgTWEsEHE16sGWeXXUV1LBWQE1yCTmeprSNcqZ3W74hqVdgDbtYHUv3eM4W2TEUhpan/1/*,[f245ae38/48h/0h/0h/2h]xpub6DnT4E1fT8VxuAZW29avMjr5i99aYTHBp9d7fiLnpL5t4JEprQqPMbTw7k7rh5tZZ2F5g8PJpssqrZoebzBChaiJrmEvWwUTEMAbHsY39Ge/1/*,[c5d87297/48h/0h/0h/2h]xpub6DjrnfAyuonMaboEb3ZQZzhQ2ZEgaKV2r64BFmqymZqJqviLTe1JzMr2X2RfQF892RH7MyYUbcy77R7pPu1P71xoj8cDUMNhAMGYzKR4noZ/1/*))#ju9xa9ur
+`
+	got, err := OutputDescriptor([]byte(sparrow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := OutputDescriptor([]byte("wsh(sortedmulti(2,[dc567276/48h/0h/0h/2h]xpub6DiYrfRwNnjeX4vHsWMajJVFKrbEEnu8gAW9vDuQzgTWEsEHE16sGWeXXUV1LBWQE1yCTmeprSNcqZ3W74hqVdgDbtYHUv3eM4W2TEUhpan/0/*,[f245ae38/48h/0h/0h/2h]xpub6DnT4E1fT8VxuAZW29avMjr5i99aYTHBp9d7fiLnpL5t4JEprQqPMbTw7k7rh5tZZ2F5g8PJpssqrZoebzBChaiJrmEvWwUTEMAbHsY39Ge/0/*,[c5d87297/48h/0h/0h/2h]xpub6DjrnfAyuonMaboEb3ZQZzhQ2ZEgaKV2r64BFmqymZqJqviLTe1JzMr2X2RfQF892RH7MyYUbcy77R7pPu1P71xoj8cDUMNhAMGYzKR4noZ/0/*))#hfwurrvt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range want.Keys {
+		want.Keys[i].Children = []urtypes.Derivation{
+			{Type: urtypes.RangeDerivation, Index: 0, End: 1},
+			{Type: urtypes.WildcardDerivation},
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("multipath descriptor decoded to\n%#v\nexpected\n%#v\n", got, want)
+	}
+}
+
 func TestProprietaryDescriptors(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -209,6 +239,22 @@ c5d87297: xpub6DjrnfAyuonMaboEb3ZQZzhQ2ZEgaKV2r64BFmqymZqJqviLTe1JzMr2X2RfQF892R
 			"zpub6qpFgGWoG7bKmDDMvmwHBvg6inZAb2KF2Vg8h4fKJ2ickSZ71PsMmRg1FyRWAS6PqPCSzd5CB6PHixx64k6q5svZNZd9bEoCWJuMSkSRzJx",
 			"wpkh([00000000/84'/0'/0']xpub6C9j4wAxxkWN4cq8G4N2mkV6NrGGhnLFCGdh8GsYY1xreEveW5YEXJMjDZWLAcnZ26xqVft5FmgBxPixdMGoVQZMdtEJRRADxrn4facoGnx)",
 		},
+		{
+			"",
+			"ypub6WyzNbqt7S3quv2F6R9eyqabYpQieQKk7P9uufmRv2LjhLjskjho9N1sEmTvAXSURk5eF9UdiS2jqgLXM3gpHeExWDvj1KyiEaqi47h3Ef1",
+			"sh(wpkh([00000000/49'/0'/0']xpub6C9j4wAxxkWN4cq8G4N2mkV6NrGGhnLFCGdh8GsYY1xreEveW5YEXJMjDZWLAcnZ26xqVft5FmgBxPixdMGoVQZMdtEJRRADxrn4facoGnx))",
+		},
+		{
+			// The SLIP-132 version takes precedence over the derivation path.
+			"",
+			"[4bbaa801/44'/0'/0']zpub6qpFgGWoG7bKmDDMvmwHBvg6inZAb2KF2Vg8h4fKJ2ickSZ71PsMmRg1FyRWAS6PqPCSzd5CB6PHixx64k6q5svZNZd9bEoCWJuMSkSRzJx",
+			"wpkh([4bbaa801/44'/0'/0']xpub6C9j4wAxxkWN4cq8G4N2mkV6NrGGhnLFCGdh8GsYY1xreEveW5YEXJMjDZWLAcnZ26xqVft5FmgBxPixdMGoVQZMdtEJRRADxrn4facoGnx)",
+		},
+		{
+			"",
+			"vpub5YVCTbq8fPRQN2StbLnnMaJ62uyNpYMFN3bFZV5mn1D6Y3JBzmD7HB3TB9bAAoUiCpjDzigxLSy6BpVqBxSmtwC9uCqTFbXFRQemtQXQnHC",
+			"wpkh([00000000/84'/0'/0']tpubDCXMbAzeg2TpLR1yiFM7yfpThyMvhAqJjuDzUpvgsvikPXbMaJPKfk2ZTbb7h7jnp1Vk7FPwnsWEeaDa2D83Nr1ehUyc6wpTYpNURb6Qt26)",
+		},
 		{
 			"",
 			"xpub6C9j4wAxxkWN4cq8G4N2mkV6NrGGhnLFCGdh8GsYY1xreEveW5YEXJMjDZWLAcnZ26xqVft5FmgBxPixdMGoVQZMdtEJRRADxrn4facoGnx",
@@ -258,3 +304,30 @@ func TestElectrumSeed(t *testing.T) {
 		t.Fatal("failed to detect Electrum seed")
 	}
 }
+
+func TestParseDerivationPath(t *testing.T) {
+	h := uint32(hdkeychain.HardenedKeyStart)
+	tests := []struct {
+		path string
+		want urtypes.Path
+	}{
+		{"m/84h/0h/0h", urtypes.Path{h + 84, h + 0, h + 0}},
+		{"48'/1'/0'/2'", urtypes.Path{h + 48, h + 1, h + 0, h + 2}},
+		{"m/0/1", urtypes.Path{0, 1}},
+	}
+	for _, test := range tests {
+		got, err := ParseDerivationPath(test.path)
+		if err != nil {
+			t.Errorf("%q: %v", test.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q parsed to %v, want %v", test.path, got, test.want)
+		}
+	}
+	for _, invalid := range []string{"", "m", "m/", "m/84h//0h", "m/-1", "m/4294967296", "m/2147483648h"} {
+		if _, err := ParseDerivationPath(invalid); err == nil {
+			t.Errorf("%q parsed without error", invalid)
+		}
+	}
+}